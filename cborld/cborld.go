@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package cborld encodes JSON-LD documents as CBOR-LD
+// (https://json-ld.github.io/cbor-ld-spec/): CBOR in which JSON-LD
+// keywords, the terms defined by the document's contexts, registered
+// context URLs and IRIs are replaced by compact integer codes. The
+// codes of terms are derived from the contexts when encoding and
+// decoding, so both sides must resolve them to the same content
+package cborld
+
+import (
+	"reflect"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/piprate/json-gold/ld"
+)
+
+// CBOR tags of CBOR-LD documents
+const (
+	TagUncompressed = 0x0500 // plain CBOR of the JSON-LD document
+	TagCompressed   = 0x0501 // compressed with the tables of this package
+)
+
+// ids of JSON-LD keywords. A term's id plus one marks an array value
+var keywords = map[string]uint64{
+	"@context": 0, "@type": 2, "@id": 4, "@value": 6, "@direction": 8, "@graph": 10, "@included": 12,
+	"@index": 14, "@json": 16, "@language": 18, "@list": 20, "@nest": 22, "@reverse": 24, "@base": 26,
+	"@container": 28, "@default": 30, "@embed": 32, "@explicit": 34, "@none": 36, "@omitDefault": 38,
+	"@prefix": 40, "@preserve": 42, "@protected": 44, "@requireAll": 46, "@set": 48, "@version": 50,
+	"@vocab": 52,
+}
+
+// id of the first term defined by a context
+const firstTermID = 100
+
+// registered contexts: the contexts bundled with dogg3rz. Ids are
+// assigned from 0x8000 up, outside the range of the CBOR-LD registry,
+// and must never change
+var contextTable = map[string]uint64{
+	"https://schema.org/":                          0x8000,
+	"http://schema.org/":                           0x8001,
+	"https://schema.org":                           0x8002,
+	"http://schema.org":                            0x8003,
+	"https://schema.org/docs/jsonldcontext.jsonld": 0x8004,
+	"http://www.w3.org/ns/dcat":                    0x8005,
+	"https://www.w3.org/ns/dcat":                   0x8006,
+	"http://www.w3.org/ns/prov":                    0x8007,
+	"http://purl.org/dc/terms/":                    0x8008,
+	"http://www.w3.org/2004/02/skos/core":          0x8009,
+	"http://www.w3.org/ns/shacl":                   0x800a,
+	"https://www.w3.org/ns/activitystreams":        0x800b,
+	"https://www.w3.org/ns/did/v1":                 0x800c,
+	"https://w3id.org/did/v1":                      0x800d,
+	"https://w3id.org/security/multikey/v1":        0x800e,
+}
+
+// RegisteredContext returns true if the context URL u is encoded by an id
+func RegisteredContext(u string) bool {
+
+	_, ok := contextTable[u]
+
+	return ok
+}
+
+// IsCBORLD returns true if data starts with a CBOR-LD tag
+func IsCBORLD(data []byte) bool {
+
+	// TAGS 0x0500 AND 0x0501 ARE ENCODED AS 0xD9 0x05 0x0X
+	return len(data) > 3 && data[0] == 0xd9 && data[1] == 0x05 && (data[2] == 0x00 || data[2] == 0x01)
+}
+
+var encMode, _ = cbor.EncOptions{Sort: cbor.SortCanonical}.EncMode()
+
+var decMode, _ = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[interface{}]interface{}{})}.DecMode()
+
+// Encode returns the compressed CBOR-LD encoding of the JSON-LD
+// document doc. Context URLs are resolved with loader
+func Encode(doc map[string]interface{}, loader ld.DocumentLoader) ([]byte, error) {
+
+	table, err := newTermTable(doc["@context"], loader)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := table.encodeObject(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return encMode.Marshal(cbor.Tag{Number: TagCompressed, Content: payload})
+}
+
+// Decode returns the JSON-LD document encoded as CBOR-LD in data.
+// Context URLs are resolved with loader
+func Decode(data []byte, loader ld.DocumentLoader) (map[string]interface{}, error) {
+
+	var v interface{}
+	if err := decMode.Unmarshal(data, &v); err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "invalid CBOR-LD")
+	}
+
+	tag, ok := v.(cbor.Tag)
+	if !ok || (tag.Number != TagCompressed && tag.Number != TagUncompressed) {
+		return nil, errors.InvalidValue.New("invalid CBOR-LD: missing CBOR-LD tag")
+	}
+
+	payload, ok := tag.Content.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.InvalidValue.New("invalid CBOR-LD: document is not a map")
+	}
+
+	if tag.Number == TagUncompressed {
+		doc, ok := plainValue(payload).(map[string]interface{})
+		if !ok {
+			return nil, errors.InvalidValue.New("invalid CBOR-LD: uncompressed document has non-string keys")
+		}
+		return doc, nil
+	}
+
+	// THE CONTEXT IS DECODED FIRST TO BUILD THE TERM TABLE
+	documentContext, err := decodeContext(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := newTermTable(documentContext, loader)
+	if err != nil {
+		return nil, err
+	}
+
+	return table.decodeObject(payload)
+}
+
+// plainValue converts the decoded CBOR value v to its JSON form.
+// Maps with non-string keys are returned as is
+func plainValue(v interface{}) interface{} {
+
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			s, ok := key.(string)
+			if !ok {
+				return v
+			}
+			m[s] = plainValue(item)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = plainValue(item)
+		}
+		return items
+	case uint64:
+		return float64(value)
+	case int64:
+		return float64(value)
+	}
+
+	return v
+}
@@ -0,0 +1,158 @@
+package cborld
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/piprate/json-gold/ld"
+)
+
+// testLoader serves context documents from memory
+type testLoader map[string]string
+
+func (l testLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+
+	data, ok := l[u]
+	if !ok {
+		return nil, errors.NotFound.Newf("%s: not found", u)
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		return nil, err
+	}
+
+	return &ld.RemoteDocument{DocumentURL: u, Document: doc}, nil
+}
+
+var testContexts = testLoader{
+	"https://schema.org/": `{"@context": {"name": "https://schema.org/name", "Person": "https://schema.org/Person",
+		"knows": {"@id": "https://schema.org/knows", "@type": "@id"}}}`,
+	"http://example.org/context": `{"@context": {"@import": "http://example.org/imported",
+		"ex": "http://example.org/", "xsd": "http://www.w3.org/2001/XMLSchema#",
+		"created": {"@id": "ex:created", "@type": "xsd:dateTime"},
+		"kind": {"@id": "ex:kind", "@type": "@vocab"},
+		"address": {"@id": "ex:address", "@context": {"city": "ex:city"}}}}`,
+	"http://example.org/imported": `{"@context": {"age": "http://example.org/age"}}`,
+}
+
+func decodeJSON(t *testing.T, s string) map[string]interface{} {
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	docs := []string{
+		`{"@context": ["https://schema.org/", "http://example.org/context", {"local": "http://example.org/local"}],
+		  "@graph": [
+		    {"@id": "https://example.org/jane", "@type": "Person", "name": "Jane", "age": 42.5,
+		     "knows": ["http://example.org/joe", "_:b0", "urn:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6"],
+		     "created": ["2020-01-02T03:04:05Z", "2020-01-02T03:04:05.5Z"], "kind": ["Person", "ex:Other", "https://example.org/T"],
+		     "address": {"city": "Paris", "@context": {"zip": "http://example.org/zip"}, "zip": "75001"},
+		     "local": [true, null, 1, [1, "x"]], "ex:unknown": {"name": "nested"}},
+		    {"@id": "_:b0", "@type": ["Person", "http://example.org/Robot"], "name": {"@value": "R2", "@language": "en"},
+		     "created": {"@value": "2020", "@type": "xsd:gYear"}, "knows": [["http://example.org/list"]], "kind": 7}
+		  ]}`,
+		`{"@id": "http://example.org/no-context", "http://example.org/p": "URN:UUID:F81D4FAE-7DEC-11D0-A765-00A0C91E6BF6"}`,
+	}
+
+	for _, s := range docs {
+		doc := decodeJSON(t, s)
+
+		encoded, err := Encode(doc, testContexts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsCBORLD(encoded) {
+			t.Errorf("expected CBOR-LD tag, got % x", encoded[:4])
+		}
+
+		decoded, err := Decode(encoded, testContexts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(decoded, doc) {
+			expected, _ := json.Marshal(doc)
+			actual, _ := json.Marshal(decoded)
+			t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+		}
+
+		if plain, _ := json.Marshal(doc); len(encoded) >= len(plain) {
+			t.Errorf("expected CBOR-LD (%d bytes) to be smaller than JSON (%d bytes)", len(encoded), len(plain))
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+
+	doc := decodeJSON(t, `{"@context": ["https://schema.org/", "http://example.org/context"], "@id": "https://example.org/jane",
+	  "@type": "Person", "knows": "http://example.org/joe", "created": "2020-01-02T03:04:05Z", "kind": ["Person"]}`)
+
+	encoded, err := Encode(doc, testContexts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tag cbor.Tag
+	if err := cbor.Unmarshal(encoded, &tag); err != nil {
+		t.Fatal(err)
+	}
+	if tag.Number != TagCompressed {
+		t.Errorf("expected tag %#x, got %#x", TagCompressed, tag.Number)
+	}
+
+	// TERMS OF THE FIRST CONTEXT ARE NUMBERED FROM 100 IN SORTED ORDER
+	// (Person 100, knows 102, name 104), THEN THOSE OF THE IMPORTED AND
+	// THE SECOND CONTEXT (age 106, address 108, created 110, ex 112,
+	// kind 114, xsd 116) FOLLOWED BY THE SCOPED CONTEXT (city 118)
+	expected := map[interface{}]interface{}{
+		uint64(1):   []interface{}{uint64(0x8000), "http://example.org/context"},
+		uint64(2):   uint64(100),
+		uint64(4):   []interface{}{uint64(2), "example.org/jane"},
+		uint64(102): []interface{}{uint64(1), "example.org/joe"},
+		uint64(110): uint64(1577934245),
+		uint64(115): []interface{}{uint64(100)},
+	}
+	if !reflect.DeepEqual(tag.Content, expected) {
+		t.Errorf("expected\n%v\ngot\n%v", expected, tag.Content)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+
+	unknownTerm, _ := cbor.Marshal(cbor.Tag{Number: TagCompressed, Content: map[uint64]interface{}{500: "x"}})
+	wrongPlurality, _ := cbor.Marshal(cbor.Tag{Number: TagCompressed, Content: map[uint64]interface{}{5: "x"}})
+	unknownContext, _ := cbor.Marshal(cbor.Tag{Number: TagCompressed, Content: map[uint64]interface{}{0: 7}})
+	plain, _ := cbor.Marshal(map[string]interface{}{"@id": "x"})
+
+	for name, data := range map[string][]byte{"unknown term": unknownTerm, "plurality": wrongPlurality,
+		"unknown context": unknownContext, "plain CBOR": plain} {
+		if _, err := Decode(data, testContexts); errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("%s: expected InvalidValue error, got %v", name, err)
+		}
+	}
+
+	if IsCBORLD(plain) {
+		t.Errorf("expected plain CBOR not to be recognized as CBOR-LD")
+	}
+
+	// UNCOMPRESSED CBOR-LD IS PLAIN CBOR UNDER ITS TAG
+	uncompressed, _ := cbor.Marshal(cbor.Tag{Number: TagUncompressed, Content: map[string]interface{}{"@id": "x", "n": 1}})
+	doc, err := Decode(uncompressed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]interface{}{"@id": "x", "n": float64(1)}; !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected %v, got %v", expected, doc)
+	}
+}
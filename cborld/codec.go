@@ -0,0 +1,371 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cborld
+
+import (
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// valueCodec compresses the string values of a key
+type valueCodec interface {
+	// encode returns the compressed form of s or s if it cannot be compressed
+	encode(s string) interface{}
+	// decode returns the string compressed to v
+	decode(v interface{}) (string, error)
+}
+
+// encodeObject returns the compressed form of the JSON-LD object m
+func (t *termTable) encodeObject(m map[string]interface{}) (map[interface{}]interface{}, error) {
+
+	encoded := make(map[interface{}]interface{}, len(m))
+
+	for key, value := range m {
+
+		if id, ok := t.ids[key]; ok {
+			e, ok, err := t.encodeValue(value, t.codecOf(key))
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				if _, plural := value.([]interface{}); plural {
+					id++
+				}
+				encoded[id] = e
+				continue
+			}
+		}
+
+		// UNKNOWN TERMS AND VALUES THE KEY'S CODEC CANNOT HOLD
+		e, _, err := t.encodeValue(value, nil)
+		if err != nil {
+			return nil, err
+		}
+		encoded[key] = e
+	}
+
+	return encoded, nil
+}
+
+// encodeValue returns the compressed form of value v or false if
+// codec cannot encode it
+func (t *termTable) encodeValue(v interface{}, codec valueCodec) (interface{}, bool, error) {
+
+	items, ok := v.([]interface{})
+	if !ok {
+		return t.encodeItem(v, codec)
+	}
+
+	encoded := make([]interface{}, len(items))
+	for i, item := range items {
+		if _, nested := item.([]interface{}); nested && codec != nil {
+			// COMPRESSED VALUES ARE ARRAYS TOO
+			return nil, false, nil
+		}
+		e, ok, err := t.encodeItem(item, codec)
+		if err != nil || !ok {
+			return nil, ok, err
+		}
+		encoded[i] = e
+	}
+
+	return encoded, true, nil
+}
+
+func (t *termTable) encodeItem(v interface{}, codec valueCodec) (interface{}, bool, error) {
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if _, ok := codec.(contextCodec); ok {
+			// INLINE CONTEXTS ARE NOT COMPRESSED
+			return value, true, nil
+		}
+		e, err := t.encodeObject(value)
+		return e, err == nil, err
+	case string:
+		if codec != nil {
+			return codec.encode(value), true, nil
+		}
+		return value, true, nil
+	case []interface{}:
+		return t.encodeValue(value, nil)
+	}
+
+	// ONLY STRINGS AND OBJECTS CAN BE TOLD FROM COMPRESSED VALUES
+	return v, codec == nil, nil
+}
+
+// decodeObject returns the JSON-LD object compressed to m
+func (t *termTable) decodeObject(m map[interface{}]interface{}) (map[string]interface{}, error) {
+
+	decoded := make(map[string]interface{}, len(m))
+
+	for key, value := range m {
+
+		var term string
+		var v interface{}
+		var err error
+
+		switch k := key.(type) {
+		case string:
+			term = k
+			v, err = t.decodeValue(value, nil)
+		case uint64:
+			var ok bool
+			if term, ok = t.terms[k-k%2]; !ok {
+				return nil, errors.InvalidValue.Newf("invalid CBOR-LD: unknown term id %d", k)
+			}
+			// COMPRESSED VALUES MAY BE ARRAYS. ONLY THE ID TELLS AN ARRAY VALUE
+			if k%2 == 0 {
+				v, err = t.decodeItem(value, t.codecOf(term))
+			} else if items, ok := value.([]interface{}); ok {
+				v, err = t.decodeValue(items, t.codecOf(term))
+			} else {
+				return nil, errors.InvalidValue.Newf("invalid CBOR-LD: value of term id %d is not an array", k)
+			}
+		default:
+			return nil, errors.InvalidValue.Newf("invalid CBOR-LD: unexpected key %v", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := decoded[term]; exists {
+			return nil, errors.InvalidValue.Newf("invalid CBOR-LD: duplicate key %s", term)
+		}
+		decoded[term] = v
+	}
+
+	return decoded, nil
+}
+
+// decodeValue returns the value compressed to v. Arrays are decoded
+// item by item
+func (t *termTable) decodeValue(v interface{}, codec valueCodec) (interface{}, error) {
+
+	items, ok := v.([]interface{})
+	if !ok {
+		return t.decodeItem(v, codec)
+	}
+
+	decoded := make([]interface{}, len(items))
+	for i, item := range items {
+		d, err := t.decodeItem(item, codec)
+		if err != nil {
+			return nil, err
+		}
+		decoded[i] = d
+	}
+
+	return decoded, nil
+}
+
+func (t *termTable) decodeItem(v interface{}, codec valueCodec) (interface{}, error) {
+
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		if _, ok := codec.(contextCodec); ok {
+			if c, ok := plainValue(value).(map[string]interface{}); ok {
+				return c, nil
+			}
+			return nil, errors.InvalidValue.New("invalid CBOR-LD: inline context has non-string keys")
+		}
+		return t.decodeObject(value)
+	case string:
+		return value, nil
+	}
+
+	if codec != nil {
+		return codec.decode(v)
+	}
+
+	if items, ok := v.([]interface{}); ok {
+		return t.decodeValue(items, nil)
+	}
+
+	return plainValue(v), nil
+}
+
+// decodeContext returns the top-level context of the compressed document m
+func decodeContext(m map[interface{}]interface{}) (interface{}, error) {
+
+	t := &termTable{}
+
+	if v, ok := m[keywords["@context"]]; ok {
+		return t.decodeItem(v, contextCodec{})
+	}
+	if v, ok := m[keywords["@context"]+1]; ok {
+		return t.decodeValue(v, contextCodec{})
+	}
+
+	return plainValue(m["@context"]), nil
+}
+
+// contextCodec compresses registered context URLs
+type contextCodec struct{}
+
+var contextURLs = func() map[uint64]string {
+	urls := make(map[uint64]string, len(contextTable))
+	for u, id := range contextTable {
+		urls[id] = u
+	}
+	return urls
+}()
+
+func (contextCodec) encode(s string) interface{} {
+
+	if id, ok := contextTable[s]; ok {
+		return id
+	}
+
+	return s
+}
+
+func (contextCodec) decode(v interface{}) (string, error) {
+
+	if id, ok := v.(uint64); ok {
+		if u, ok := contextURLs[id]; ok {
+			return u, nil
+		}
+	}
+
+	return "", errors.InvalidValue.Newf("invalid CBOR-LD: unknown context %v", v)
+}
+
+// uriCodec compresses the scheme of IRIs
+type uriCodec struct{}
+
+// scheme ids of uriCodec
+const (
+	schemeHTTP    = 1
+	schemeHTTPS   = 2
+	schemeURNUUID = 3
+)
+
+func (uriCodec) encode(s string) interface{} {
+
+	switch {
+	case strings.HasPrefix(s, "https://"):
+		return []interface{}{uint64(schemeHTTPS), strings.TrimPrefix(s, "https://")}
+	case strings.HasPrefix(s, "http://"):
+		return []interface{}{uint64(schemeHTTP), strings.TrimPrefix(s, "http://")}
+	case strings.HasPrefix(s, "urn:uuid:"):
+		if uuid, ok := parseUUID(strings.TrimPrefix(s, "urn:uuid:")); ok {
+			return []interface{}{uint64(schemeURNUUID), uuid}
+		}
+	}
+
+	return s
+}
+
+func (uriCodec) decode(v interface{}) (string, error) {
+
+	if items, ok := v.([]interface{}); ok && len(items) == 2 {
+		scheme, _ := items[0].(uint64)
+		switch rest := items[1].(type) {
+		case string:
+			switch scheme {
+			case schemeHTTP:
+				return "http://" + rest, nil
+			case schemeHTTPS:
+				return "https://" + rest, nil
+			}
+		case []byte:
+			if scheme == schemeURNUUID && len(rest) == 16 {
+				return "urn:uuid:" + formatUUID(rest), nil
+			}
+		}
+	}
+
+	return "", errors.InvalidValue.Newf("invalid CBOR-LD: invalid compressed IRI %v", v)
+}
+
+// parseUUID returns the bytes of the lower case UUID s
+func parseUUID(s string) ([]byte, bool) {
+
+	if len(s) != 36 || strings.ToLower(s) != s {
+		return nil, false
+	}
+
+	uuid, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(uuid) != 16 || formatUUID(uuid) != s {
+		return nil, false
+	}
+
+	return uuid, true
+}
+
+func formatUUID(b []byte) string {
+
+	h := hex.EncodeToString(b)
+
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// vocabCodec compresses terms (and keywords) to their ids and other
+// IRIs like uriCodec
+type vocabCodec struct {
+	table *termTable
+}
+
+func (c vocabCodec) encode(s string) interface{} {
+
+	if id, ok := c.table.ids[s]; ok {
+		return id
+	}
+
+	return uriCodec{}.encode(s)
+}
+
+func (c vocabCodec) decode(v interface{}) (string, error) {
+
+	if id, ok := v.(uint64); ok {
+		if term, ok := c.table.terms[id]; ok {
+			return term, nil
+		}
+		return "", errors.InvalidValue.Newf("invalid CBOR-LD: unknown term id %d", id)
+	}
+
+	return uriCodec{}.decode(v)
+}
+
+// dateTimeCodec compresses xsd:dateTime values in UTC with second
+// precision to seconds since the epoch
+type dateTimeCodec struct{}
+
+const dateTimeLayout = "2006-01-02T15:04:05Z"
+
+func (dateTimeCodec) encode(s string) interface{} {
+
+	if t, err := time.Parse(dateTimeLayout, s); err == nil && t.Format(dateTimeLayout) == s {
+		return t.Unix()
+	}
+
+	return s
+}
+
+func (dateTimeCodec) decode(v interface{}) (string, error) {
+
+	switch seconds := v.(type) {
+	case uint64:
+		return time.Unix(int64(seconds), 0).UTC().Format(dateTimeLayout), nil
+	case int64:
+		return time.Unix(seconds, 0).UTC().Format(dateTimeLayout), nil
+	}
+
+	return "", errors.InvalidValue.Newf("invalid CBOR-LD: invalid compressed date-time %v", v)
+}
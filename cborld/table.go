@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cborld
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+// maximum nesting of contexts (remote, imported and scoped)
+const maxContextDepth = 32
+
+// termDefinition holds what the codecs need to know about a term
+type termDefinition struct {
+	id  string // IRI (possibly compact) the term maps to
+	typ string // type coercion: @id, @vocab or a (possibly compact) datatype IRI
+}
+
+// termTable maps the terms defined by a document's contexts to ids.
+// Terms are numbered context by context in the order the contexts
+// are processed, each context's new terms in sorted order. Terms
+// introduced by contexts embedded below the top level of the document
+// are not numbered and encoded as strings
+type termTable struct {
+	ids         map[string]uint64
+	terms       map[uint64]string
+	definitions map[string]termDefinition
+	next        uint64
+	loader      ld.DocumentLoader
+	loaded      map[string]bool
+}
+
+func newTermTable(documentContext interface{}, loader ld.DocumentLoader) (*termTable, error) {
+
+	t := &termTable{
+		ids:         make(map[string]uint64),
+		terms:       make(map[uint64]string),
+		definitions: make(map[string]termDefinition),
+		next:        firstTermID,
+		loader:      loader,
+		loaded:      make(map[string]bool),
+	}
+	for keyword, id := range keywords {
+		t.ids[keyword] = id
+		t.terms[id] = keyword
+	}
+
+	if err := t.addContext(documentContext, 0); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// addContext numbers the terms defined by context c and the contexts
+// it references
+func (t *termTable) addContext(c interface{}, depth int) error {
+
+	if depth > maxContextDepth {
+		return errors.OutOfRange.Newf("maximum context depth %d exceeded", maxContextDepth)
+	}
+
+	switch value := c.(type) {
+	case nil:
+	case string:
+		if t.loaded[value] {
+			return nil
+		}
+		t.loaded[value] = true
+		if t.loader == nil {
+			return errors.InvalidState.Newf("%s: no loader to resolve context", value)
+		}
+		doc, err := t.loader.LoadDocument(value)
+		if err != nil {
+			return errors.Wrapf(err, "%s", value)
+		}
+		m, ok := doc.Document.(map[string]interface{})
+		if !ok {
+			return errors.InvalidValue.Newf("%s: context document is not an object", value)
+		}
+		return t.addContext(m["@context"], depth+1)
+	case []interface{}:
+		for _, item := range value {
+			if err := t.addContext(item, depth+1); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if imported, ok := value["@import"].(string); ok {
+			if err := t.addContext(imported, depth+1); err != nil {
+				return err
+			}
+		}
+		terms := make([]string, 0, len(value))
+		for term := range value {
+			if !strings.HasPrefix(term, "@") {
+				terms = append(terms, term)
+			}
+		}
+		sort.Strings(terms)
+		for _, term := range terms {
+			t.define(term, value[term])
+		}
+		// SCOPED CONTEXTS FOLLOW IN THE ORDER OF THEIR TERMS
+		for _, term := range terms {
+			if definition, ok := value[term].(map[string]interface{}); ok {
+				if scoped, ok := definition["@context"]; ok {
+					if err := t.addContext(scoped, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	default:
+		return errors.InvalidValue.Newf("invalid context %v", c)
+	}
+
+	return nil
+}
+
+// define records the definition of term, numbering it if it is new
+func (t *termTable) define(term string, definition interface{}) {
+
+	if _, ok := t.ids[term]; !ok {
+		t.ids[term] = t.next
+		t.terms[t.next] = term
+		t.next += 2
+	}
+
+	switch value := definition.(type) {
+	case string:
+		t.definitions[term] = termDefinition{id: value}
+	case map[string]interface{}:
+		d := termDefinition{}
+		d.id, _ = value["@id"].(string)
+		d.typ, _ = value["@type"].(string)
+		t.definitions[term] = d
+	default:
+		delete(t.definitions, term)
+	}
+}
+
+// expandIRI expands a term or compact IRI using the term definitions
+func (t *termTable) expandIRI(s string) string {
+
+	for i := 0; i < maxContextDepth; i++ {
+		if d, ok := t.definitions[s]; ok && len(d.id) > 0 && d.id != s {
+			s = d.id
+			continue
+		}
+		prefix, suffix, found := strings.Cut(s, ":")
+		if !found || strings.HasPrefix(suffix, "//") {
+			break
+		}
+		d, ok := t.definitions[prefix]
+		if !ok || len(d.id) < 1 || d.id == prefix {
+			break
+		}
+		s = d.id + suffix
+	}
+
+	return s
+}
+
+// codecOf returns the codec of the values of key or nil if they are
+// not compressed
+func (t *termTable) codecOf(key string) valueCodec {
+
+	switch key {
+	case "@context":
+		return contextCodec{}
+	case "@id":
+		return uriCodec{}
+	case "@type":
+		return vocabCodec{t}
+	}
+
+	d, ok := t.definitions[key]
+	if !ok {
+		return nil
+	}
+
+	switch d.typ {
+	case "@id":
+		return uriCodec{}
+	case "@vocab":
+		return vocabCodec{t}
+	}
+
+	if t.expandIRI(d.typ) == ld.XSDNS+"dateTime" {
+		return dateTimeCodec{}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/datacequia/go-dogg3rz/resource"
+)
+
+type dgrzCanonicalizeCmd struct {
+	Output  string `short:"o" long:"output" description:"file to write (default: standard output)"`
+	Verbose []bool `short:"v" long:"verbose" description:"Show verbose canonicalization information"`
+
+	Positional struct {
+		File string `positional-arg-name:"FILE" description:"RDF, JSON-LD or YAML-LD file to canonicalize" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'canonicalize' COMMAND
+	register(&dgrzCanonicalizeCmd{})
+}
+
+func (x *dgrzCanonicalizeCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	var verboseWriter io.Writer
+
+	// STANDARD OUTPUT MAY CARRY THE CANONICAL N-QUADS
+	if len(x.Verbose) > 0 && x.Verbose[0] {
+		verboseWriter = os.Stderr
+	}
+
+	var out io.Writer = os.Stdout
+	if len(x.Output) > 0 {
+		f, err := os.Create(x.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return resource.GetGrapplicationResource(ctxt).Canonicalize(ctxt, x.Positional.File, out, verboseWriter)
+}
+
+func (o *dgrzCanonicalizeCmd) CommandName() string {
+	return "canonicalize"
+}
+
+func (o *dgrzCanonicalizeCmd) ShortDescription() string {
+	return "output the canonical N-Quads of a file"
+}
+
+func (o *dgrzCanonicalizeCmd) LongDescription() string {
+	return "write the statements of an RDF, JSON-LD or YAML-LD file as canonical N-Quads " +
+		"(W3C RDF Dataset Canonicalization, RDFC-1.0). the output does not depend on " +
+		"formatting or blank node labels; its SHA-256 hash identifies the statements in the object store " +
+		"and in snapshot ids. JSON-LD and YAML-LD files are processed with the grapp's contexts and settings"
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/yamlld"
+)
+
+type dgrzConvertCmd struct {
+	To     string `short:"t" long:"to" choice:"jsonld" choice:"yamlld" description:"target format (default: YAML-LD for JSON-LD files, JSON-LD for YAML-LD files)"`
+	Output string `short:"o" long:"output" description:"file to write (default: standard output)"`
+
+	Positional struct {
+		File string `positional-arg-name:"FILE" description:"JSON-LD or YAML-LD file to convert" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'convert' COMMAND
+	register(&dgrzConvertCmd{})
+}
+
+func (x *dgrzConvertCmd) Execute(args []string) error {
+
+	src := x.Positional.File
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	to := x.To
+	if len(to) < 1 {
+		to = "yamlld"
+		if yamlld.IsYAMLLD(src) {
+			to = "jsonld"
+		}
+	}
+
+	var converted []byte
+
+	switch {
+	case to == "yamlld" && yamlld.IsYAMLLD(src), to == "jsonld" && !yamlld.IsYAMLLD(src):
+		return errors.InvalidValue.Newf("%s: already in the target format", src)
+	case to == "yamlld":
+		converted, err = yamlld.FromJSON(data, src)
+	default:
+		converted, err = yamlld.ToJSON(data, src)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(x.Output) > 0 {
+		return os.WriteFile(x.Output, converted, 0644)
+	}
+
+	_, err = os.Stdout.Write(converted)
+
+	return err
+}
+
+func (o *dgrzConvertCmd) CommandName() string {
+	return "convert"
+}
+
+func (o *dgrzConvertCmd) ShortDescription() string {
+	return "convert between JSON-LD and YAML-LD"
+}
+
+func (o *dgrzConvertCmd) LongDescription() string {
+	return "translate a JSON-LD document to YAML-LD or a YAML-LD document to JSON-LD. " +
+		"the order of members is preserved and YAML anchors, aliases and merge keys are expanded"
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/datacequia/go-dogg3rz/resource"
+)
+
+type dgrzDIDCmd struct {
+	Resolve dgrzDIDResolveCmd `command:"resolve" description:"resolve a DID to its DID document"`
+}
+
+type dgrzDIDResolveCmd struct {
+	Positional struct {
+		DID string `positional-arg-name:"DID" description:"did:key or did:web identifier (or DID URL)" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'did' COMMAND
+	register(&dgrzDIDCmd{})
+}
+
+// DID CMD
+func (o *dgrzDIDCmd) CommandName() string {
+	return "did"
+}
+
+func (o *dgrzDIDCmd) ShortDescription() string {
+	return "decentralized identifier commands"
+}
+
+func (o *dgrzDIDCmd) LongDescription() string {
+	return "resolve the decentralized identifiers (DIDs) of users and data products"
+}
+
+// DID RESOLVE CMD
+func (x *dgrzDIDResolveCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	doc, err := resource.GetGrapplicationResource(ctxt).ResolveDID(ctxt, x.Positional.DID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(doc))
+
+	return nil
+}
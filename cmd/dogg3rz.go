@@ -67,10 +67,14 @@ func Run() {
 
 	// ADD ALL REGISTERED COMMANDS TO COMMAND PARSER
 	for _, c := range dgrzCmds {
-		_, err := parser.AddCommand(c.CommandName(), c.ShortDescription(), c.LongDescription(), c)
+		command, err := parser.AddCommand(c.CommandName(), c.ShortDescription(), c.LongDescription(), c)
 		if err != nil {
 			log.Fatalf("failed to add command to command parser: { Command Name = '%s'}: %s", c.CommandName(), err)
 		}
+		// COMMANDS THAT RUN WITH OR WITHOUT ONE OF THEIR SUBCOMMANDS
+		if o, ok := c.(interface{ SubcommandsOptional() bool }); ok {
+			command.SubcommandsOptional = o.SubcommandsOptional()
+		}
 	}
 
 	// PARSE COMMAND LINE ARGS
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/datacequia/go-dogg3rz/resource"
+	"github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+type dgrzExportCmd struct {
+	Format  string `short:"f" long:"format" default:"nquads" choice:"nquads" choice:"ntriples" choice:"turtle" choice:"trig" choice:"jsonld-expanded" choice:"jsonld-compacted" choice:"jsonld-flattened" choice:"parquet" choice:"hdt" description:"output serialization"`
+	Graph   string `short:"g" long:"graph" description:"export only this named graph ('default' for the default graph)"`
+	File    string `long:"file" description:"export only this project file"`
+	Context string `short:"c" long:"context" description:"context IRI or grapp relative path to compact with (default: prefixes declared in the project files)"`
+	Output  string `short:"o" long:"output" description:"file to write (default: standard output)"`
+	Verbose []bool `short:"v" long:"verbose" description:"Show verbose export information"`
+
+	Positional struct {
+		Snapshot string `positional-arg-name:"SNAPSHOT" description:"snapshot to export: branch, tag, snapshot id or id prefix with an optional ~n ancestor suffix (default: working tree)"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'export' COMMAND
+	register(&dgrzExportCmd{})
+}
+
+func (x *dgrzExportCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	var verboseWriter io.Writer
+
+	// STANDARD OUTPUT MAY CARRY THE EXPORTED DATA
+	if len(x.Verbose) > 0 && x.Verbose[0] {
+		verboseWriter = os.Stderr
+	}
+
+	options := grapp.ExportOptions{
+		Snapshot: x.Positional.Snapshot,
+		Format:   x.Format,
+		Graph:    x.Graph,
+		File:     x.File,
+		Context:  x.Context,
+	}
+
+	var out io.Writer = os.Stdout
+	if len(x.Output) > 0 {
+		f, err := os.Create(x.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return resource.GetGrapplicationResource(ctxt).Export(ctxt, out, options, verboseWriter)
+}
+
+func (o *dgrzExportCmd) CommandName() string {
+	return "export"
+}
+
+func (o *dgrzExportCmd) ShortDescription() string {
+	return "export grapplication data as RDF"
+}
+
+func (o *dgrzExportCmd) LongDescription() string {
+	return "write the union of the statements in the grapplication's project files, or in those of a snapshot, " +
+		"as N-Quads, N-Triples, Turtle, TriG, JSON-LD, Parquet or HDT. output can be restricted to one named graph or project file"
+}
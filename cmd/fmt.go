@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/datacequia/go-dogg3rz/resource"
+	"github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+type dgrzFmtCmd struct {
+	Compact bool   `long:"compact" description:"compact full IRIs against each document's own context"`
+	Check   bool   `long:"check" description:"list unformatted files and fail instead of rewriting them"`
+	Verbose []bool `short:"v" long:"verbose" description:"Show verbose formatting information"`
+
+	Positional struct {
+		Files []string `positional-arg-name:"FILE" description:"JSON-LD files to format (default: the grapplication's .jsonld project files)"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'fmt' COMMAND
+	register(&dgrzFmtCmd{})
+}
+
+func (x *dgrzFmtCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	var verboseWriter io.Writer
+
+	// STANDARD OUTPUT CARRIES THE FILES LISTED BY --check
+	if len(x.Verbose) > 0 && x.Verbose[0] {
+		verboseWriter = os.Stderr
+	}
+
+	options := grapp.FormatOptions{
+		Compact: x.Compact,
+		Check:   x.Check,
+	}
+
+	return resource.GetGrapplicationResource(ctxt).Format(ctxt, x.Positional.Files, options, os.Stdout, verboseWriter)
+}
+
+func (o *dgrzFmtCmd) CommandName() string {
+	return "fmt"
+}
+
+func (o *dgrzFmtCmd) ShortDescription() string {
+	return "format JSON-LD files"
+}
+
+func (o *dgrzFmtCmd) LongDescription() string {
+	return "rewrite JSON-LD files in place indented by two spaces with @context, @id and @type first " +
+		"and the remaining keys sorted. files are only rewritten if their expanded statements remain the same. " +
+		"with --check files are listed instead and the command fails if any is not formatted"
+}
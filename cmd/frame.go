@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/datacequia/go-dogg3rz/resource"
+	"github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+type dgrzFrameCmd struct {
+	Frame   string `short:"f" long:"frame" description:"JSON-LD or YAML-LD frame document" required:"yes"`
+	Output  string `short:"o" long:"output" description:"file to write (default: standard output)"`
+	Verbose []bool `short:"v" long:"verbose" description:"Show verbose framing information"`
+
+	Positional struct {
+		Snapshot string `positional-arg-name:"SNAPSHOT" description:"snapshot to frame: branch, tag, snapshot id or id prefix with an optional ~n ancestor suffix (default: working tree)"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'frame' COMMAND
+	register(&dgrzFrameCmd{})
+}
+
+func (x *dgrzFrameCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	var verboseWriter io.Writer
+
+	// STANDARD OUTPUT MAY CARRY THE FRAMED DOCUMENT
+	if len(x.Verbose) > 0 && x.Verbose[0] {
+		verboseWriter = os.Stderr
+	}
+
+	options := grapp.FrameOptions{
+		Snapshot: x.Positional.Snapshot,
+		Frame:    x.Frame,
+	}
+
+	var out io.Writer = os.Stdout
+	if len(x.Output) > 0 {
+		f, err := os.Create(x.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return resource.GetGrapplicationResource(ctxt).Frame(ctxt, out, options, verboseWriter)
+}
+
+func (o *dgrzFrameCmd) CommandName() string {
+	return "frame"
+}
+
+func (o *dgrzFrameCmd) ShortDescription() string {
+	return "shape grapplication data with a JSON-LD frame"
+}
+
+func (o *dgrzFrameCmd) LongDescription() string {
+	return "apply a JSON-LD 1.1 frame to the union of the statements in the grapplication's project files " +
+		"(or in those of a snapshot) and write the resulting tree-shaped JSON-LD document. " +
+		"contexts referenced by the frame are resolved relative to the frame and like those of project files"
+}
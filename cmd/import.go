@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/datacequia/go-dogg3rz/resource"
+	"github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+type dgrzImportCmd struct {
+	Format  string `short:"f" long:"format" choice:"ttl" choice:"nt" choice:"nq" choice:"trig" choice:"parquet" choice:"hdt" description:"RDF serialization of the file (default: by file extension)"`
+	Context string `short:"c" long:"context" description:"context IRI or grapp relative path to compact with (default: prefixes declared in the file)"`
+	Output  string `short:"o" long:"output" description:"name of the .jsonld or .yamlld project file to write (default: file name with .jsonld extension)"`
+	Force   bool   `long:"force" description:"overwrite an existing project file"`
+	Source  string `long:"source" description:"IRI to record as the location of the file (default: its grapp relative path)"`
+	Verbose []bool `short:"v" long:"verbose" description:"Show verbose import information"`
+
+	// FILE IS TAKEN FROM THE REMAINING ARGS: GO-FLAGS DOES NOT ALLOW
+	// POSITIONAL ARGS ON A COMMAND WITH SUBCOMMANDS
+	Csv dgrzImportCSVCmd `command:"csv" description:"import a CSV file described by CSVW metadata"`
+}
+
+type dgrzImportCSVCmd struct {
+	Metadata string `short:"m" long:"metadata" description:"CSVW metadata file (default: FILE-metadata.json or csv-metadata.json next to FILE)"`
+	Mode     string `long:"mode" choice:"standard" choice:"minimal" default:"standard" description:"CSV to RDF conversion mode"`
+	Context  string `short:"c" long:"context" description:"context IRI or grapp relative path to compact with (default: prefixes used by the metadata)"`
+	Output   string `short:"o" long:"output" description:"name of the .jsonld or .yamlld project file to write (default: file name with .jsonld extension)"`
+	Force    bool   `long:"force" description:"overwrite an existing project file"`
+	Source   string `long:"source" description:"IRI to record as the location of FILE (default: its grapp relative path)"`
+	Verbose  []bool `short:"v" long:"verbose" description:"Show verbose import information"`
+
+	Positional struct {
+		File string `positional-arg-name:"FILE" description:"CSV file to import" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'import' COMMAND
+	register(&dgrzImportCmd{})
+}
+
+func (x *dgrzImportCmd) Execute(args []string) error {
+
+	if len(args) < 1 {
+		return errMissingFilePath
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("unexpected arguments: %v", args[1:])
+	}
+
+	ctxt := getCmdContext()
+
+	var verboseWriter io.Writer
+
+	if len(x.Verbose) > 0 && x.Verbose[0] {
+		verboseWriter = os.Stdout
+	}
+
+	options := grapp.ImportOptions{
+		Format:  x.Format,
+		Context: x.Context,
+		Output:  x.Output,
+		Force:   x.Force,
+		Source:  x.Source,
+	}
+
+	outputPath, err := resource.GetGrapplicationResource(ctxt).Import(ctxt, args[0], options, verboseWriter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(outputPath)
+
+	return nil
+}
+
+func (x *dgrzImportCSVCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	var verboseWriter io.Writer
+
+	if len(x.Verbose) > 0 && x.Verbose[0] {
+		verboseWriter = os.Stdout
+	}
+
+	options := grapp.CSVImportOptions{
+		ImportOptions: grapp.ImportOptions{
+			Context: x.Context,
+			Output:  x.Output,
+			Force:   x.Force,
+			Source:  x.Source,
+		},
+		Metadata: x.Metadata,
+		Mode:     x.Mode,
+	}
+
+	outputPath, err := resource.GetGrapplicationResource(ctxt).ImportCSV(ctxt, x.Positional.File, options, verboseWriter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(outputPath)
+
+	return nil
+}
+
+func (o *dgrzImportCmd) SubcommandsOptional() bool {
+	return true
+}
+
+func (o *dgrzImportCmd) Usage() string {
+	return "[import-OPTIONS] FILE | csv [csv-OPTIONS] FILE"
+}
+
+func (o *dgrzImportCmd) CommandName() string {
+	return "import"
+}
+
+func (o *dgrzImportCmd) ShortDescription() string {
+	return "import RDF into the grapplication"
+}
+
+func (o *dgrzImportCmd) LongDescription() string {
+	return "convert a Turtle, N-Triples, N-Quads, TriG, Parquet or HDT file to a compacted JSON-LD project file. " +
+		"named graphs are preserved and the source file is recorded as provenance of each subject and named graph. " +
+		"'import csv' converts a CSV file to RDF as described by its CSVW metadata, validating its cells against the schema"
+}
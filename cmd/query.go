@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/datacequia/go-dogg3rz/resource"
+	"github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+type dgrzQueryCmd struct {
+	At      string `long:"at" description:"snapshot to query: branch, tag, snapshot id or id prefix with an optional ~n ancestor suffix (default: working tree, with --blame the current branch head)"`
+	Blame   bool   `long:"blame" description:"report the snapshots in which each triple of a CONSTRUCT or DESCRIBE result appeared and disappeared"`
+	Since   string `long:"since" description:"with --blame, the oldest snapshot to evaluate (default: the first snapshot)"`
+	Format  string `short:"f" long:"format" default:"table" choice:"table" choice:"json" choice:"csv" choice:"tsv" description:"result serialization"`
+	Output  string `short:"o" long:"output" description:"file to write (default: standard output)"`
+	Verbose []bool `short:"v" long:"verbose" description:"Show verbose query information"`
+
+	Positional struct {
+		Query string `positional-arg-name:"QUERY" description:"SPARQL 1.1 SELECT, ASK, CONSTRUCT or DESCRIBE query" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'query' COMMAND
+	register(&dgrzQueryCmd{})
+}
+
+func (x *dgrzQueryCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	var verboseWriter io.Writer
+
+	// STANDARD OUTPUT MAY CARRY THE QUERY RESULT
+	if len(x.Verbose) > 0 && x.Verbose[0] {
+		verboseWriter = os.Stderr
+	}
+
+	options := grapp.QueryOptions{
+		Snapshot: x.At,
+		Format:   x.Format,
+		Blame:    x.Blame,
+		Since:    x.Since,
+	}
+
+	var out io.Writer = os.Stdout
+	if len(x.Output) > 0 {
+		f, err := os.Create(x.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return resource.GetGrapplicationResource(ctxt).Query(ctxt, x.Positional.Query, out, options, verboseWriter)
+}
+
+func (o *dgrzQueryCmd) CommandName() string {
+	return "query"
+}
+
+func (o *dgrzQueryCmd) ShortDescription() string {
+	return "query grapplication data with SPARQL"
+}
+
+func (o *dgrzQueryCmd) LongDescription() string {
+	return "evaluate a SPARQL 1.1 SELECT, ASK, CONSTRUCT or DESCRIBE query over the statements in the grapplication's " +
+		"project files (or in those of a snapshot). the query's default graph is the grapplication's default graph " +
+		"and its named graphs are matched with GRAPH. solutions are written as a table, SPARQL JSON, CSV or TSV results; " +
+		"graphs are written as Turtle (table), JSON-LD (json) or subject, predicate and object rows (csv and tsv). " +
+		"with --blame the query is evaluated over each snapshot from --since to --at along the snapshot chain and " +
+		"each triple of the results is listed with the snapshot it appeared in and the one it disappeared in, " +
+		"once for every time it was added"
+}
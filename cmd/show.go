@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/datacequia/go-dogg3rz/resource"
+)
+
+type dgrzShowCmd struct {
+	Verbose []bool `short:"v" long:"verbose" description:"Show verbose snapshot resolution information"`
+
+	Positional struct {
+		Snapshot string `positional-arg-name:"SNAPSHOT" description:"snapshot to show: branch, tag, snapshot id or id prefix with an optional ~n ancestor suffix (default: current branch head)"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'show' COMMAND
+	register(&dgrzShowCmd{})
+}
+
+func (x *dgrzShowCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	var verboseWriter io.Writer
+
+	// STANDARD OUTPUT CARRIES THE SNAPSHOT
+	if len(x.Verbose) > 0 && x.Verbose[0] {
+		verboseWriter = os.Stderr
+	}
+
+	return resource.GetGrapplicationResource(ctxt).Show(ctxt, x.Positional.Snapshot, os.Stdout, verboseWriter)
+}
+
+func (o *dgrzShowCmd) CommandName() string {
+	return "show"
+}
+
+func (o *dgrzShowCmd) ShortDescription() string {
+	return "show a grapplication snapshot"
+}
+
+func (o *dgrzShowCmd) LongDescription() string {
+	return "show the id, parent, branches, tags, creation time and message of a snapshot followed by its files " +
+		"marked A (added), M (modified) or D (deleted) relative to its parent"
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/datacequia/go-dogg3rz/ipfs"
+	"github.com/datacequia/go-dogg3rz/resource"
+)
+
+type dgrzSnapshotCmd struct {
+	Message string `short:"m" long:"message" description:"describe the snapshot"`
+	Verbose []bool `short:"v" long:"verbose" description:"Show verbose snapshot information"`
+	Publish bool   `long:"publish" description:"put the snapshot's CBOR-LD objects as blocks to the local IPFS node and print their CIDs"`
+}
+
+func init() {
+	// REGISTER THE 'snapshot' COMMAND
+	register(&dgrzSnapshotCmd{})
+}
+
+func (x *dgrzSnapshotCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	var verboseWriter io.Writer
+
+	if len(x.Verbose) > 0 && x.Verbose[0] {
+		verboseWriter = os.Stdout
+	}
+
+	id, err := resource.GetGrapplicationResource(ctxt).Snapshot(ctxt, x.Message, verboseWriter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(id)
+
+	if x.Publish {
+		return resource.GetGrapplicationResource(ctxt).Publish(ctxt, id, ipfs.BlockPut, os.Stdout, verboseWriter)
+	}
+
+	return nil
+}
+
+func (o *dgrzSnapshotCmd) CommandName() string {
+	return "snapshot"
+}
+
+func (o *dgrzSnapshotCmd) ShortDescription() string {
+	return "snapshot grapplication project files"
+}
+
+func (o *dgrzSnapshotCmd) LongDescription() string {
+	return "validate grapplication project files and store them as a new snapshot on the current branch"
+}
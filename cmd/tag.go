@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/datacequia/go-dogg3rz/resource"
+)
+
+type dgrzTagCmd struct {
+	Positional struct {
+		Name     string `positional-arg-name:"NAME" description:"tag name" required:"yes"`
+		Snapshot string `positional-arg-name:"SNAPSHOT" description:"snapshot to tag: branch, tag, snapshot id or id prefix with an optional ~n ancestor suffix (default: current branch head)"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'tag' COMMAND
+	register(&dgrzTagCmd{})
+}
+
+func (x *dgrzTagCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	id, err := resource.GetGrapplicationResource(ctxt).Tag(ctxt, x.Positional.Name, x.Positional.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(id)
+
+	return nil
+}
+
+func (o *dgrzTagCmd) CommandName() string {
+	return "tag"
+}
+
+func (o *dgrzTagCmd) ShortDescription() string {
+	return "name a grapplication snapshot"
+}
+
+func (o *dgrzTagCmd) LongDescription() string {
+	return "name a snapshot with a tag which then selects it wherever a snapshot is expected. " +
+		"tags cannot be moved once created"
+}
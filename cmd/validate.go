@@ -14,16 +14,23 @@
 package cmd
 
 import (
+	"context"
 	"io"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/datacequia/go-dogg3rz/env"
 	"github.com/datacequia/go-dogg3rz/resource"
 )
 
 type dgrzValidateCmd struct {
 	//Init dgrzConfigInitCmd `command:"init" description:"initialize the user environment configuration" `
 	//Grapp dgrzInitGrapp `command:"grapplication" alias:"grapp" description:"initialize a new grapplication" `
-	Verbose []bool `short:"v" long:"verbose" description:"Show verbose validate information"`
+	Verbose    []bool `short:"v" long:"verbose" description:"Show verbose validate information"`
+	Offline    bool   `long:"offline" description:"load remote documents only from the object cache as pinned in dogg3rz.lock"`
+	UpdateLock bool   `long:"update-lock" description:"revalidate remote documents pinned in dogg3rz.lock and pin their current content"`
+	Watch      bool   `short:"w" long:"watch" description:"keep running and re-validate project files as they change"`
 }
 
 func init() {
@@ -36,6 +43,14 @@ func (x *dgrzValidateCmd) Execute(args []string) error {
 	// INITIALIZE USER ENVIRONMENT
 	ctxt := getCmdContext()
 
+	if x.Offline {
+		ctxt = context.WithValue(ctxt, env.EnvDogg3rzOffline, "true")
+	}
+
+	if x.UpdateLock {
+		ctxt = context.WithValue(ctxt, env.EnvDogg3rzUpdateLock, "true")
+	}
+
 	var verboseWriter io.Writer
 
 	if len(x.Verbose) > 0 && x.Verbose[0] {
@@ -44,6 +59,15 @@ func (x *dgrzValidateCmd) Execute(args []string) error {
 
 	}
 
+	if x.Watch {
+		// STOP WATCHING ON CTRL-C
+		var stop context.CancelFunc
+		ctxt, stop = signal.NotifyContext(ctxt, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return resource.GetGrapplicationResource(ctxt).Watch(ctxt, os.Stdout, verboseWriter)
+	}
+
 	if err := resource.GetGrapplicationResource(ctxt).Validate(ctxt, verboseWriter); err != nil {
 		return err
 	}
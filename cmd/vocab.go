@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/vocab"
+)
+
+type dgrzVocabCmd struct {
+	List   dgrzVocabListCmd   `command:"list" description:"list vocabularies bundled with dogg3rz"`
+	Update dgrzVocabUpdateCmd `command:"update" description:"refresh a bundled vocabulary from a local file"`
+}
+
+type dgrzVocabListCmd struct {
+	Verbose bool `short:"v" long:"verbose" description:"show the IRIs served by and the upstream source of each vocabulary"`
+}
+
+type dgrzVocabUpdateCmd struct {
+	Positional struct {
+		Name string `positional-arg-name:"NAME" description:"short name of the bundled vocabulary" required:"yes"`
+		File string `positional-arg-name:"FILE" description:"local JSON-LD file with the refreshed vocabulary" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	// REGISTER THE 'vocab' COMMAND
+	register(&dgrzVocabCmd{})
+}
+
+// VOCAB CMD
+func (o *dgrzVocabCmd) CommandName() string {
+	return "vocab"
+}
+
+func (o *dgrzVocabCmd) ShortDescription() string {
+	return "bundled vocabulary commands"
+}
+
+func (o *dgrzVocabCmd) LongDescription() string {
+	return "inspect and refresh the well-known contexts and vocabularies bundled with dogg3rz"
+}
+
+// VOCAB LIST CMD
+func (x *dgrzVocabListCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+	vocabDir := file.VocabDirPath(ctxt)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tSHA256\tTITLE")
+
+	for _, v := range vocab.List() {
+		data, source, err := v.Load(vocabDir)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Name, source, vocab.Hash(data)[:12], v.Title)
+		if x.Verbose {
+			fmt.Fprintf(w, "\t%s\t\t\n", strings.Join(v.IRIs, " "))
+			if len(v.Source) > 0 {
+				fmt.Fprintf(w, "\tcopy of %s retrieved %s\t\t\n", v.Source, v.Retrieved)
+			} else {
+				fmt.Fprintf(w, "\twritten for dogg3rz\t\t\n")
+			}
+		}
+	}
+
+	return w.Flush()
+}
+
+// VOCAB UPDATE CMD
+func (x *dgrzVocabUpdateCmd) Execute(args []string) error {
+
+	ctxt := getCmdContext()
+
+	v, ok := vocab.Get(x.Positional.Name)
+	if !ok {
+		return errors.NotFound.Newf("%s: unknown vocabulary. run 'vocab list' for bundled vocabularies",
+			x.Positional.Name)
+	}
+
+	return v.Update(file.VocabDirPath(ctxt), x.Positional.File)
+}
@@ -0,0 +1,803 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package csvw
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+// Mode is the CSV to RDF conversion mode
+type Mode int
+
+const (
+	// Standard describes the table group, tables and rows in addition to
+	// the cell values
+	Standard Mode = iota
+	// Minimal outputs the cell values only
+	Minimal
+)
+
+func (m Mode) String() string {
+
+	if m == Minimal {
+		return "minimal"
+	}
+
+	return "standard"
+}
+
+// ParseMode returns the mode named s
+func ParseMode(s string) (Mode, error) {
+
+	switch strings.ToLower(s) {
+	case "", "standard":
+		return Standard, nil
+	case "minimal":
+		return Minimal, nil
+	}
+
+	return Standard, errors.InvalidValue.Newf("unknown conversion mode %q. expected standard or minimal", s)
+}
+
+// DefaultMaxErrors is the number of validation errors reported before
+// conversion stops
+const DefaultMaxErrors = 10
+
+// Options control a conversion
+type Options struct {
+	Mode      Mode
+	MaxErrors int // 0 is DefaultMaxErrors
+}
+
+// Opener returns the content of a table's CSV file
+type Opener func(table *Table) (io.Reader, error)
+
+// prefixes of the CSVW default context that may be used in
+// propertyUrl, valueUrl and common properties
+var prefixes = map[string]string{
+	"as":      "https://www.w3.org/ns/activitystreams#",
+	"cc":      "http://creativecommons.org/ns#",
+	"csvw":    Namespace,
+	"dc":      "http://purl.org/dc/terms/",
+	"dcat":    "http://www.w3.org/ns/dcat#",
+	"dcterms": "http://purl.org/dc/terms/",
+	"foaf":    "http://xmlns.com/foaf/0.1/",
+	"geo":     "http://www.opengis.net/ont/geosparql#",
+	"org":     "http://www.w3.org/ns/org#",
+	"owl":     "http://www.w3.org/2002/07/owl#",
+	"prov":    "http://www.w3.org/ns/prov#",
+	"qb":      "http://purl.org/linked-data/cube#",
+	"rdf":     rdfNamespace,
+	"rdfs":    "http://www.w3.org/2000/01/rdf-schema#",
+	"schema":  "http://schema.org/",
+	"skos":    "http://www.w3.org/2004/02/skos/core#",
+	"time":    "http://www.w3.org/2006/time#",
+	"vcard":   "http://www.w3.org/2006/vcard/ns#",
+	"void":    "http://rdfs.org/ns/void#",
+	"xsd":     xsdNamespace,
+}
+
+// Convert converts the tables of group to RDF. The CSV file of each
+// table is read through open. Cells are validated against the schema
+// and conversion fails with the first validation errors
+func Convert(group *TableGroup, open Opener, options Options) (*ld.RDFDataset, error) {
+
+	if options.MaxErrors < 1 {
+		options.MaxErrors = DefaultMaxErrors
+	}
+
+	c := &converter{dataset: ld.NewRDFDataset(), options: options}
+	c.dataset.Graphs[defaultGraph] = make([]*ld.Quad, 0)
+	for _, prefix := range []string{"csvw", "xsd"} {
+		c.dataset.SetNamespace(prefix, prefixes[prefix])
+	}
+
+	var groupNode ld.Node
+	if options.Mode == Standard {
+		groupNode = c.subject(group.ID)
+		c.triple(groupNode, ld.NewIRI(rdfNamespace+"type"), ld.NewIRI(Namespace+"TableGroup"))
+		c.annotations(groupNode, group.Annotations, group.Language)
+	}
+
+	for _, table := range group.Tables {
+		if table.SuppressOutput {
+			continue
+		}
+
+		r, err := open(table)
+		if err != nil {
+			return nil, err
+		}
+
+		var tableNode ld.Node
+		if options.Mode == Standard {
+			tableNode = c.subject(table.ID)
+			c.triple(groupNode, ld.NewIRI(Namespace+"table"), tableNode)
+			c.triple(tableNode, ld.NewIRI(rdfNamespace+"type"), ld.NewIRI(Namespace+"Table"))
+			c.triple(tableNode, ld.NewIRI(Namespace+"url"), ld.NewIRI(table.URL))
+			c.annotations(tableNode, table.Annotations, group.Language)
+		}
+
+		err = c.table(table, tableNode, r)
+		if closer, ok := r.(io.Closer); ok {
+			closer.Close()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(c.errs) > 0 {
+		return nil, c.validationError()
+	}
+
+	return c.dataset, nil
+}
+
+const defaultGraph = "@default"
+
+type converter struct {
+	dataset *ld.RDFDataset
+	options Options
+	blanks  int
+	errs    []string
+}
+
+// subject returns the node identified by id or a new blank node
+func (c *converter) subject(id string) ld.Node {
+
+	if len(id) > 0 {
+		return ld.NewIRI(id)
+	}
+
+	return c.blank()
+}
+
+// expandPrefixed expands a prefixed name of the CSVW context to an
+// IRI. The prefix is declared on the dataset for compaction
+func (c *converter) expandPrefixed(s string) string {
+
+	if prefix, local, ok := strings.Cut(s, ":"); ok && !strings.HasPrefix(local, "//") {
+		if ns, known := prefixes[prefix]; known {
+			c.dataset.SetNamespace(prefix, ns)
+			return ns + local
+		}
+	}
+
+	return s
+}
+
+func (c *converter) blank() ld.Node {
+	c.blanks++
+	return ld.NewBlankNode(fmt.Sprintf("_:b%d", c.blanks-1))
+}
+
+func (c *converter) triple(s ld.Node, p ld.Node, o ld.Node) {
+	c.dataset.Graphs[defaultGraph] = append(c.dataset.Graphs[defaultGraph], ld.NewQuad(s, p, o, defaultGraph))
+}
+
+// errorf records a validation error. It returns false once enough
+// errors have been recorded to stop
+func (c *converter) errorf(format string, args ...interface{}) bool {
+
+	c.errs = append(c.errs, fmt.Sprintf(format, args...))
+
+	return len(c.errs) < c.options.MaxErrors
+}
+
+func (c *converter) validationError() error {
+
+	msg := strings.Join(c.errs, "\n  ")
+	if len(c.errs) >= c.options.MaxErrors {
+		msg += "\n  ..."
+	}
+
+	return errors.InvalidValue.Newf("%d validation error(s):\n  %s", len(c.errs), msg)
+}
+
+// annotations converts the common properties of a description
+func (c *converter) annotations(subject ld.Node, annotations map[string]interface{}, lang string) {
+
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		predicate := c.expandPrefixed(key)
+		if key == "notes" {
+			predicate = Namespace + "note"
+		}
+		c.annotation(subject, ld.NewIRI(predicate), annotations[key], lang)
+	}
+}
+
+// annotation converts the JSON-LD value v of a common property
+func (c *converter) annotation(subject ld.Node, predicate ld.Node, v interface{}, lang string) {
+
+	switch value := v.(type) {
+	case []interface{}:
+		for _, item := range value {
+			c.annotation(subject, predicate, item, lang)
+		}
+	case string:
+		if len(lang) > 0 {
+			c.triple(subject, predicate, ld.NewLiteral(value, rdfNamespace+"langString", lang))
+		} else {
+			c.triple(subject, predicate, ld.NewLiteral(value, xsdNamespace+"string", ""))
+		}
+	case bool:
+		c.triple(subject, predicate, ld.NewLiteral(strconv.FormatBool(value), xsdNamespace+"boolean", ""))
+	case float64:
+		if value == float64(int64(value)) {
+			c.triple(subject, predicate, ld.NewLiteral(strconv.FormatInt(int64(value), 10), xsdNamespace+"integer", ""))
+		} else {
+			c.triple(subject, predicate, ld.NewLiteral(canonicalDouble(value), xsdNamespace+"double", ""))
+		}
+	case map[string]interface{}:
+		if literal, ok := value["@value"]; ok {
+			s := fmt.Sprint(literal)
+			switch {
+			case value["@type"] != nil:
+				c.triple(subject, predicate, ld.NewLiteral(s, c.expandPrefixed(fmt.Sprint(value["@type"])), ""))
+			case value["@language"] != nil:
+				c.triple(subject, predicate, ld.NewLiteral(s, rdfNamespace+"langString", fmt.Sprint(value["@language"])))
+			default:
+				c.annotation(subject, predicate, literal, lang)
+			}
+			return
+		}
+		id, _ := value["@id"].(string)
+		var object ld.Node
+		if len(id) > 0 {
+			object = ld.NewIRI(c.expandPrefixed(id))
+		} else {
+			object = c.blank()
+		}
+		c.triple(subject, predicate, object)
+		for key, nested := range value {
+			switch key {
+			case "@id":
+			case "@type":
+				c.annotation(object, ld.NewIRI(rdfNamespace+"type"), typeRefs(nested), "")
+			default:
+				c.annotation(object, ld.NewIRI(c.expandPrefixed(key)), nested, lang)
+			}
+		}
+	}
+}
+
+// typeRefs turns @type values into node references
+func typeRefs(v interface{}) interface{} {
+
+	switch t := v.(type) {
+	case string:
+		return map[string]interface{}{"@id": t}
+	case []interface{}:
+		refs := make([]interface{}, len(t))
+		for i, item := range t {
+			refs[i] = typeRefs(item)
+		}
+		return refs
+	}
+
+	return v
+}
+
+// cell is a parsed cell value
+type cell struct {
+	raw    string
+	values []string // lexical values. nil is null
+	list   bool     // values of a cell with a separator
+}
+
+// table converts the rows of table read from r
+func (c *converter) table(table *Table, tableNode ld.Node, r io.Reader) error {
+
+	reader, err := newCSVReader(table, r)
+	if err != nil {
+		return err
+	}
+
+	// SKIP ROWS, THEN HEADER ROWS
+	var header [][]string
+	for i := 0; i < table.Dialect.SkipRows+table.Dialect.HeaderRowCount; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.InvalidValue.Wrapf(err, "%s", table.URL)
+		}
+		if i >= table.Dialect.SkipRows {
+			header = append(header, skipColumns(record, table.Dialect.SkipColumns))
+		}
+	}
+
+	if err = c.columns(table, header); err != nil {
+		return err
+	}
+	columns := table.Schema.Columns
+
+	primaryKeys := make(map[string]int)
+	rownum := 0
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.InvalidValue.Wrapf(err, "%s", table.URL)
+		}
+		sourceRow, _ := reader.FieldPos(0)
+		record = skipColumns(record, table.Dialect.SkipColumns)
+
+		if table.Dialect.SkipBlankRows && isBlank(record) {
+			continue
+		}
+		rownum++
+
+		where := func(column *Column) string {
+			return fmt.Sprintf("%s row %d column %s", table.URL, sourceRow, column.Name)
+		}
+
+		if n := len(columns) - len(virtualColumns(columns)); len(record) > n {
+			if !c.errorf("%s row %d: %d cells but %d columns", table.URL, sourceRow, len(record), n) {
+				return nil
+			}
+			continue
+		}
+
+		cells := make([]cell, len(columns))
+		valid := true
+		for i, column := range columns {
+			if column.Virtual {
+				continue
+			}
+			raw := ""
+			if i < len(record) {
+				raw = trim(record[i], table.Dialect.Trim)
+			}
+			if cells[i], err = parseCell(column, raw); err != nil {
+				valid = false
+				if !c.errorf("%s: %s", where(column), err) {
+					return nil
+				}
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		if len(table.Schema.PrimaryKey) > 0 {
+			key := primaryKey(table.Schema, cells)
+			if first, dup := primaryKeys[key]; dup {
+				if !c.errorf("%s row %d: duplicate primary key of row %d", table.URL, sourceRow, first) {
+					return nil
+				}
+				continue
+			}
+			primaryKeys[key] = sourceRow
+		}
+
+		if err = c.row(table, tableNode, cells, rownum, sourceRow); err != nil {
+			if !c.errorf("%s row %d: %s", table.URL, sourceRow, err) {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+// newCSVReader returns a reader of the records of r in the table's dialect
+func newCSVReader(table *Table, r io.Reader) (*csv.Reader, error) {
+
+	dialect := table.Dialect
+
+	if enc := strings.ToLower(dialect.Encoding); enc != "utf-8" && enc != "utf8" {
+		return nil, errors.NotImplemented.Newf("%s: encoding %s is not supported", table.URL, dialect.Encoding)
+	}
+	if dialect.QuoteChar != `"` || !dialect.DoubleQuote {
+		return nil, errors.NotImplemented.Newf("%s: only '\"' quoted cells with doubled quotes are supported", table.URL)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = dialect.SkipInitialSpace
+
+	delimiter := []rune(dialect.Delimiter)
+	if len(delimiter) != 1 {
+		return nil, errors.NotImplemented.Newf("%s: delimiter %q must be a single character", table.URL, dialect.Delimiter)
+	}
+	reader.Comma = delimiter[0]
+
+	if prefix := []rune(dialect.CommentPrefix); len(prefix) == 1 {
+		reader.Comment = prefix[0]
+	} else if len(prefix) > 1 {
+		return nil, errors.NotImplemented.Newf("%s: comment prefix %q must be a single character", table.URL, dialect.CommentPrefix)
+	}
+
+	return reader, nil
+}
+
+func skipColumns(record []string, n int) []string {
+
+	if n >= len(record) {
+		return nil
+	}
+
+	return record[n:]
+}
+
+func isBlank(record []string) bool {
+
+	for _, s := range record {
+		if len(strings.TrimSpace(s)) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func trim(s string, mode string) string {
+
+	switch mode {
+	case "true":
+		return strings.TrimSpace(s)
+	case "start":
+		return strings.TrimLeft(s, " \t")
+	case "end":
+		return strings.TrimRight(s, " \t")
+	}
+
+	return s
+}
+
+func virtualColumns(columns []*Column) []*Column {
+
+	var virtual []*Column
+	for _, column := range columns {
+		if column.Virtual {
+			virtual = append(virtual, column)
+		}
+	}
+
+	return virtual
+}
+
+// columns checks the header rows against the schema. Without
+// declared columns the schema's columns are taken from the header
+func (c *converter) columns(table *Table, header [][]string) error {
+
+	schema := table.Schema
+	var titles []string
+	if len(header) > 0 {
+		titles = make([]string, len(header[0]))
+		for _, row := range header {
+			for i := range titles {
+				if i < len(row) && len(row[i]) > 0 {
+					titles[i] = strings.TrimSpace(strings.TrimSpace(titles[i] + " " + row[i]))
+				}
+			}
+		}
+	}
+
+	if len(schema.Columns) < 1 {
+		for i, title := range titles {
+			column := &Column{number: i + 1, Name: fmt.Sprintf("_col.%d", i+1)}
+			if len(title) > 0 {
+				column.Titles = []string{title}
+				column.Name = escapeName(title)
+			}
+			column.Properties = column.Properties.inherit(schema.inherited)
+			schema.Columns = append(schema.Columns, column)
+		}
+		return nil
+	}
+
+	var nonVirtual int
+	for _, column := range schema.Columns {
+		if !column.Virtual {
+			nonVirtual++
+		}
+	}
+	if len(titles) > 0 && len(titles) != nonVirtual {
+		return errors.InvalidValue.Newf("%s: header has %d columns but the schema declares %d", table.URL, len(titles), nonVirtual)
+	}
+
+	for i, title := range titles {
+		column := schema.Columns[i]
+		if len(column.Titles) < 1 || len(title) < 1 {
+			continue
+		}
+		compatible := false
+		for _, t := range column.Titles {
+			if t == title {
+				compatible = true
+				break
+			}
+		}
+		if !compatible {
+			return errors.InvalidValue.Newf("%s: header %q of column %d does not match the schema's titles %q",
+				table.URL, title, i+1, column.Titles)
+		}
+	}
+
+	return nil
+}
+
+// escapeName returns the column name derived from a title
+func escapeName(title string) string {
+	return encodeTemplate(title, false)
+}
+
+// parseCell parses the raw cell string of column
+func parseCell(column *Column, raw string) (cell, error) {
+
+	cell := cell{raw: raw}
+
+	datatype := column.Datatype
+	if datatype == nil {
+		datatype, _ = parseDatatype("string")
+	}
+
+	value := raw
+	if len(value) < 1 && column.Default != nil {
+		value = *column.Default
+	}
+
+	nulls := column.Null
+	if !column.nullSet {
+		nulls = []string{""}
+	}
+	isNull := func(s string) bool {
+		for _, n := range nulls {
+			if s == n {
+				return true
+			}
+		}
+		return false
+	}
+
+	var items []string
+	if column.Separator != nil && !isNull(value) {
+		cell.list = true
+		if len(value) > 0 {
+			items = strings.Split(value, *column.Separator)
+		}
+	} else {
+		items = []string{value}
+	}
+
+	for _, item := range items {
+		item = datatype.normalize(item)
+		if isNull(item) {
+			continue
+		}
+		lexical, err := datatype.parse(item)
+		if err != nil {
+			return cell, err
+		}
+		cell.values = append(cell.values, lexical)
+	}
+
+	if column.Required != nil && *column.Required && len(cell.values) < 1 {
+		return cell, errors.InvalidValue.New("required cell is empty")
+	}
+
+	return cell, nil
+}
+
+// primaryKey returns the primary key value of a row
+func primaryKey(schema *Schema, cells []cell) string {
+
+	var key []string
+	for _, name := range schema.PrimaryKey {
+		for i, column := range schema.Columns {
+			if column.Name == name {
+				key = append(key, strings.Join(cells[i].values, "\x1f"))
+			}
+		}
+	}
+
+	return strings.Join(key, "\x1e")
+}
+
+// row converts the cells of a row
+func (c *converter) row(table *Table, tableNode ld.Node, cells []cell, rownum int, sourceRow int) error {
+
+	columns := table.Schema.Columns
+
+	vars := map[string]interface{}{
+		"_row":       strconv.Itoa(rownum),
+		"_sourceRow": strconv.Itoa(sourceRow),
+	}
+	for i, column := range columns {
+		switch {
+		case column.Virtual || cells[i].values == nil:
+		case cells[i].list:
+			vars[column.Name] = cells[i].values
+		default:
+			vars[column.Name] = cells[i].values[0]
+		}
+	}
+
+	var rowNode ld.Node
+	if c.options.Mode == Standard {
+		rowNode = c.blank()
+		c.triple(tableNode, ld.NewIRI(Namespace+"row"), rowNode)
+		c.triple(rowNode, ld.NewIRI(rdfNamespace+"type"), ld.NewIRI(Namespace+"Row"))
+		c.triple(rowNode, ld.NewIRI(Namespace+"rownum"), ld.NewLiteral(strconv.Itoa(rownum), xsdNamespace+"integer", ""))
+		c.triple(rowNode, ld.NewIRI(Namespace+"url"), ld.NewIRI(fmt.Sprintf("%s#row=%d", table.URL, sourceRow)))
+		for _, name := range table.Schema.RowTitles {
+			for i, column := range columns {
+				if column.Name == name {
+					for _, v := range cells[i].values {
+						c.titleTriple(rowNode, column, v)
+					}
+				}
+			}
+		}
+	}
+
+	var defaultSubject ld.Node
+	described := make(map[string]bool)
+
+	for i, column := range columns {
+		if column.SuppressOutput {
+			continue
+		}
+
+		vars["_column"] = strconv.Itoa(column.number)
+		vars["_sourceColumn"] = strconv.Itoa(column.number + table.Dialect.SkipColumns)
+		vars["_name"] = unescapeName(column.Name)
+
+		var subject ld.Node
+		if column.AboutURL != nil {
+			iri, err := c.expandURL(*column.AboutURL, vars, table.URL, false)
+			if err != nil {
+				return err
+			}
+			subject = ld.NewIRI(iri)
+		} else {
+			if defaultSubject == nil {
+				defaultSubject = c.blank()
+			}
+			subject = defaultSubject
+		}
+
+		propertyTemplate := "#{_name}"
+		if column.PropertyURL != nil {
+			propertyTemplate = *column.PropertyURL
+		}
+		propertyIRI, err := c.expandURL(propertyTemplate, vars, table.URL, true)
+		if err != nil {
+			return err
+		}
+		predicate := ld.NewIRI(propertyIRI)
+
+		var objects []ld.Node
+		switch {
+		case column.ValueURL != nil:
+			if !column.Virtual && cells[i].values == nil {
+				continue
+			}
+			iri, err := c.expandURL(*column.ValueURL, vars, table.URL, true)
+			if err != nil {
+				return err
+			}
+			objects = []ld.Node{ld.NewIRI(iri)}
+		case column.Virtual:
+			continue
+		default:
+			for _, v := range cells[i].values {
+				objects = append(objects, c.literal(column, v))
+			}
+		}
+		if len(objects) < 1 {
+			continue
+		}
+
+		if cells[i].list && column.Ordered != nil && *column.Ordered && column.ValueURL == nil {
+			c.triple(subject, predicate, c.list(objects))
+		} else {
+			for _, object := range objects {
+				c.triple(subject, predicate, object)
+			}
+		}
+
+		if rowNode != nil && !described[subject.GetValue()] {
+			described[subject.GetValue()] = true
+			c.triple(rowNode, ld.NewIRI(Namespace+"describes"), subject)
+		}
+	}
+
+	return nil
+}
+
+func unescapeName(name string) string {
+
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		return unescaped
+	}
+
+	return name
+}
+
+// expandURL expands the URI template t and resolves the result
+// against the table URL. Prefixed names are expanded first if
+// prefixed is true
+func (c *converter) expandURL(t string, vars map[string]interface{}, tableURL string, prefixed bool) (string, error) {
+
+	tmpl, err := parseTemplate(t)
+	if err != nil {
+		return "", err
+	}
+
+	expanded := tmpl.expand(vars)
+	if prefixed {
+		if iri := c.expandPrefixed(expanded); iri != expanded {
+			return iri, nil
+		}
+	}
+
+	return resolve(tableURL, expanded)
+}
+
+// literal returns the RDF literal of a cell value of column
+func (c *converter) literal(column *Column, value string) ld.Node {
+
+	datatype := column.Datatype
+	if datatype == nil || datatype.isString() {
+		if column.Lang != nil && *column.Lang != "und" && len(*column.Lang) > 0 {
+			return ld.NewLiteral(value, rdfNamespace+"langString", *column.Lang)
+		}
+		if datatype == nil {
+			return ld.NewLiteral(value, xsdNamespace+"string", "")
+		}
+	}
+
+	return ld.NewLiteral(value, datatype.IRI(), "")
+}
+
+func (c *converter) titleTriple(rowNode ld.Node, column *Column, value string) {
+	c.triple(rowNode, ld.NewIRI(Namespace+"title"), c.literal(column, value))
+}
+
+// list returns the head of an rdf:List of items
+func (c *converter) list(items []ld.Node) ld.Node {
+
+	var head ld.Node = ld.NewIRI(rdfNamespace + "nil")
+	for i := len(items) - 1; i >= 0; i-- {
+		node := c.blank()
+		c.triple(node, ld.NewIRI(rdfNamespace+"first"), items[i])
+		c.triple(node, ld.NewIRI(rdfNamespace+"rest"), head)
+		head = node
+	}
+
+	return head
+}
@@ -0,0 +1,181 @@
+package csvw
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+const testMetadata = `{
+  "@context": ["http://www.w3.org/ns/csvw", {"@language": "en"}],
+  "url": "countries.csv",
+  "dc:title": "Countries",
+  "tableSchema": {
+    "aboutUrl": "http://example.org/country/{code}",
+    "primaryKey": "code",
+    "rowTitles": "name",
+    "columns": [
+      {"name": "code", "titles": "country code", "datatype": {"base": "string", "format": "[A-Z]{2}"}, "required": true},
+      {"name": "name", "titles": "name", "propertyUrl": "schema:name", "lang": "en"},
+      {"name": "population", "titles": "population", "datatype": {"base": "integer", "minimum": 0}},
+      {"name": "languages", "titles": "languages", "separator": " ", "ordered": true},
+      {"name": "type", "virtual": true, "propertyUrl": "rdf:type", "valueUrl": "schema:Country"}
+    ]
+  }
+}`
+
+// testTriples returns the triples of dataset as sorted strings with
+// blank node labels replaced by _
+func testTriples(dataset *ld.RDFDataset) []string {
+
+	term := func(n ld.Node) string {
+		switch node := n.(type) {
+		case *ld.BlankNode:
+			return "_"
+		case *ld.Literal:
+			s := `"` + node.Value + `"`
+			if len(node.Language) > 0 {
+				return s + "@" + node.Language
+			}
+			return s + "^^" + strings.TrimPrefix(node.Datatype, xsdNamespace)
+		}
+		return n.GetValue()
+	}
+
+	var triples []string
+	for _, quad := range dataset.Graphs[defaultGraph] {
+		triples = append(triples, term(quad.Subject)+" "+term(quad.Predicate)+" "+term(quad.Object))
+	}
+	sort.Strings(triples)
+
+	return triples
+}
+
+func testConvert(t *testing.T, metadata string, csv string, mode Mode) (*ld.RDFDataset, error) {
+
+	group, err := ParseMetadata([]byte(metadata), "http://example.org/data/csv-metadata.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Convert(group, func(table *Table) (io.Reader, error) {
+		if table.URL != "http://example.org/data/countries.csv" {
+			t.Fatalf("unexpected table url %s", table.URL)
+		}
+		return strings.NewReader(csv), nil
+	}, Options{Mode: mode})
+}
+
+func TestConvert(t *testing.T) {
+
+	csv := "country code,name,population,languages\nGB,United Kingdom,67000000,en cy\nFR,France,,fr\n"
+
+	t.Run("Minimal", func(t *testing.T) {
+		dataset, err := testConvert(t, testMetadata, csv, Minimal)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{
+			`_ http://www.w3.org/1999/02/22-rdf-syntax-ns#first "cy"^^string`,
+			`_ http://www.w3.org/1999/02/22-rdf-syntax-ns#first "en"^^string`,
+			`_ http://www.w3.org/1999/02/22-rdf-syntax-ns#rest _`,
+			`_ http://www.w3.org/1999/02/22-rdf-syntax-ns#rest http://www.w3.org/1999/02/22-rdf-syntax-ns#nil`,
+			`_ http://www.w3.org/1999/02/22-rdf-syntax-ns#rest http://www.w3.org/1999/02/22-rdf-syntax-ns#nil`,
+			`_ http://www.w3.org/1999/02/22-rdf-syntax-ns#first "fr"^^string`,
+			`http://example.org/country/FR http://example.org/data/countries.csv#code "FR"^^string`,
+			`http://example.org/country/FR http://example.org/data/countries.csv#languages _`,
+			`http://example.org/country/FR http://schema.org/name "France"@en`,
+			`http://example.org/country/FR http://www.w3.org/1999/02/22-rdf-syntax-ns#type http://schema.org/Country`,
+			`http://example.org/country/GB http://example.org/data/countries.csv#code "GB"^^string`,
+			`http://example.org/country/GB http://example.org/data/countries.csv#languages _`,
+			`http://example.org/country/GB http://example.org/data/countries.csv#population "67000000"^^integer`,
+			`http://example.org/country/GB http://schema.org/name "United Kingdom"@en`,
+			`http://example.org/country/GB http://www.w3.org/1999/02/22-rdf-syntax-ns#type http://schema.org/Country`,
+		}
+		sort.Strings(expected)
+
+		if actual := testTriples(dataset); strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+		}
+	})
+
+	t.Run("Standard", func(t *testing.T) {
+		dataset, err := testConvert(t, testMetadata, csv, Standard)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		triples := strings.Join(testTriples(dataset), "\n")
+		for _, expected := range []string{
+			`_ http://purl.org/dc/terms/title "Countries"@en`,
+			`_ http://www.w3.org/1999/02/22-rdf-syntax-ns#type http://www.w3.org/ns/csvw#TableGroup`,
+			`_ http://www.w3.org/ns/csvw#url http://example.org/data/countries.csv`,
+			`_ http://www.w3.org/ns/csvw#rownum "2"^^integer`,
+			`_ http://www.w3.org/ns/csvw#url http://example.org/data/countries.csv#row=3`,
+			`_ http://www.w3.org/ns/csvw#describes http://example.org/country/FR`,
+			`_ http://www.w3.org/ns/csvw#title "France"@en`,
+		} {
+			if !strings.Contains(triples, expected) {
+				t.Errorf("missing %s in\n%s", expected, triples)
+			}
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		invalid := "country code,name,population,languages\nGBR,United Kingdom,-1,en\n,Nowhere,,\nFR,France,,fr\nFR,France,,fr,extra\nFR,France,,fr\n"
+
+		_, err := testConvert(t, testMetadata, invalid, Minimal)
+		if errors.GetType(err) != errors.InvalidValue {
+			t.Fatalf("expected InvalidValue error, got %v", err)
+		}
+		for _, expected := range []string{"row 2 column code", "row 2 column population", "row 3 column code: InvalidValue: required",
+			"row 5: 5 cells", "row 6: duplicate primary key of row 4"} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected %q in %s", expected, err)
+			}
+		}
+	})
+
+	t.Run("Header", func(t *testing.T) {
+		_, err := testConvert(t, testMetadata, "code,name,population,languages\nGB,United Kingdom,1,en\n", Minimal)
+		if errors.GetType(err) != errors.InvalidValue || !strings.Contains(err.Error(), `"code" of column 1`) {
+			t.Errorf("expected header mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("NoSchema", func(t *testing.T) {
+		dataset, err := testConvert(t, `{"@context": "http://www.w3.org/ns/csvw", "url": "countries.csv"}`,
+			"given name,age\nAlice,42\n", Minimal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `_ http://example.org/data/countries.csv#age "42"^^string
+_ http://example.org/data/countries.csv#given%20name "Alice"^^string`
+		if actual := strings.Join(testTriples(dataset), "\n"); actual != expected {
+			t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+		}
+	})
+}
+
+func TestParseMetadata(t *testing.T) {
+
+	for name, metadata := range map[string]string{
+		"context":       `{"url": "a.csv"}`,
+		"not a table":   `{"@context": "http://www.w3.org/ns/csvw"}`,
+		"column name":   `{"@context": "http://www.w3.org/ns/csvw", "url": "a.csv", "tableSchema": {"columns": [{"name": "_a"}]}}`,
+		"duplicate":     `{"@context": "http://www.w3.org/ns/csvw", "url": "a.csv", "tableSchema": {"columns": [{"name": "a"}, {"name": "a"}]}}`,
+		"virtual order": `{"@context": "http://www.w3.org/ns/csvw", "url": "a.csv", "tableSchema": {"columns": [{"name": "a", "virtual": true}, {"name": "b"}]}}`,
+		"primary key":   `{"@context": "http://www.w3.org/ns/csvw", "url": "a.csv", "tableSchema": {"columns": [{"name": "a"}], "primaryKey": "b"}}`,
+		"datatype":      `{"@context": "http://www.w3.org/ns/csvw", "url": "a.csv", "datatype": "unknown"}`,
+		"dialect":       `{"@context": "http://www.w3.org/ns/csvw", "url": "a.csv", "dialect": {"headerRowCount": -1}}`,
+	} {
+		if _, err := ParseMetadata([]byte(metadata), "http://example.org/"); errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("%s: expected InvalidValue error, got %v", name, err)
+		}
+	}
+}
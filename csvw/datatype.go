@@ -0,0 +1,807 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package csvw
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+const (
+	xsdNamespace = "http://www.w3.org/2001/XMLSchema#"
+	rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// kinds of built-in datatypes that share parsing rules
+type kind int
+
+const (
+	kindString kind = iota
+	kindBoolean
+	kindNumber
+	kindTemporal
+	kindOther
+)
+
+// builtin is a datatype of the metadata vocabulary
+type builtin struct {
+	iri      string
+	kind     kind
+	integer  bool           // integer numbers only
+	min, max *big.Int       // value range of integer datatypes
+	lexical  *regexp.Regexp // lexical space of other datatypes
+}
+
+var (
+	timezone = `(Z|[+-]\d{2}:\d{2})?`
+
+	numberLexical = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+
+	temporalLexical = map[string]*regexp.Regexp{
+		"date":          regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}` + timezone + `$`),
+		"dateTime":      regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?` + timezone + `$`),
+		"dateTimeStamp": regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`),
+		"time":          regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?` + timezone + `$`),
+	}
+)
+
+func integerRange(min string, max string) (*big.Int, *big.Int) {
+
+	parse := func(s string) *big.Int {
+		if len(s) < 1 {
+			return nil
+		}
+		n, _ := new(big.Int).SetString(s, 10)
+		return n
+	}
+
+	return parse(min), parse(max)
+}
+
+var builtins = map[string]*builtin{}
+
+func init() {
+
+	for _, name := range []string{"string", "normalizedString", "token", "language", "Name", "NMTOKEN", "QName",
+		"anyURI", "base64Binary", "hexBinary", "gDay", "gMonth", "gMonthDay", "gYear", "gYearMonth",
+		"duration", "dayTimeDuration", "yearMonthDuration", "anyAtomicType"} {
+		builtins[name] = &builtin{iri: xsdNamespace + name, kind: kindOther}
+	}
+	builtins["string"].kind = kindString
+
+	lexical := map[string]string{
+		"normalizedString":  `^[^\r\n\t]*$`,
+		"token":             `^(\S+( \S+)*)?$`,
+		"language":          `^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`,
+		"Name":              `^[\pL_:][\pL\pN_:.\-]*$`,
+		"NMTOKEN":           `^[\pL\pN_:.\-]+$`,
+		"QName":             `^([\pL_][\pL\pN_.\-]*:)?[\pL_][\pL\pN_.\-]*$`,
+		"hexBinary":         `^([0-9a-fA-F]{2})*$`,
+		"gDay":              `^---\d{2}` + timezone + `$`,
+		"gMonth":            `^--\d{2}` + timezone + `$`,
+		"gMonthDay":         `^--\d{2}-\d{2}` + timezone + `$`,
+		"gYear":             `^-?\d{4,}` + timezone + `$`,
+		"gYearMonth":        `^-?\d{4,}-\d{2}` + timezone + `$`,
+		"duration":          `^-?P(\d+Y)?(\d+M)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`,
+		"dayTimeDuration":   `^-?P(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`,
+		"yearMonthDuration": `^-?P(\d+Y)?(\d+M)?$`,
+	}
+	for name, re := range lexical {
+		builtins[name].lexical = regexp.MustCompile(re)
+	}
+
+	builtins["boolean"] = &builtin{iri: xsdNamespace + "boolean", kind: kindBoolean}
+
+	for name := range temporalLexical {
+		builtins[name] = &builtin{iri: xsdNamespace + name, kind: kindTemporal}
+	}
+	builtins["datetime"] = builtins["dateTime"]
+
+	for _, name := range []string{"decimal", "double", "float"} {
+		builtins[name] = &builtin{iri: xsdNamespace + name, kind: kindNumber}
+	}
+	builtins["number"] = builtins["double"]
+
+	for name, r := range map[string][2]string{
+		"integer":            {"", ""},
+		"long":               {"-9223372036854775808", "9223372036854775807"},
+		"int":                {"-2147483648", "2147483647"},
+		"short":              {"-32768", "32767"},
+		"byte":               {"-128", "127"},
+		"nonNegativeInteger": {"0", ""},
+		"positiveInteger":    {"1", ""},
+		"unsignedLong":       {"0", "18446744073709551615"},
+		"unsignedInt":        {"0", "4294967295"},
+		"unsignedShort":      {"0", "65535"},
+		"unsignedByte":       {"0", "255"},
+		"nonPositiveInteger": {"", "0"},
+		"negativeInteger":    {"", "-1"},
+	} {
+		min, max := integerRange(r[0], r[1])
+		builtins[name] = &builtin{iri: xsdNamespace + name, kind: kindNumber, integer: true, min: min, max: max}
+	}
+
+	builtins["json"] = &builtin{iri: Namespace + "JSON", kind: kindOther}
+	builtins["xml"] = &builtin{iri: rdfNamespace + "XMLLiteral", kind: kindOther}
+	builtins["html"] = &builtin{iri: rdfNamespace + "HTML", kind: kindOther}
+	builtins["any"] = builtins["anyAtomicType"]
+}
+
+// Datatype is the datatype of a column: a built-in datatype optionally
+// restricted by a format and value constraints
+type Datatype struct {
+	Base      string // name of the built-in datatype
+	ID        string // IRI of a derived datatype
+	Format    string // pattern values must match
+	Decimal   string // decimal separator of numbers
+	Group     string // grouping separator of numbers
+	Length    *int
+	MinLength *int
+	MaxLength *int
+	Minimum   string // inclusive lower bound
+	Maximum   string // inclusive upper bound
+	MinExcl   string // exclusive lower bound
+	MaxExcl   string // exclusive upper bound
+	builtin   *builtin
+	format    *regexp.Regexp // compiled string format
+	temporal  *temporalFormat
+}
+
+// parseDatatype parses the datatype property value v
+func parseDatatype(v interface{}) (*Datatype, error) {
+
+	d := &Datatype{Base: "string", Decimal: "."}
+
+	switch desc := v.(type) {
+	case string:
+		d.Base = desc
+	case map[string]interface{}:
+		if base, ok := desc["base"].(string); ok {
+			d.Base = base
+		}
+		if id, ok := desc["@id"].(string); ok {
+			d.ID = id
+		}
+		switch f := desc["format"].(type) {
+		case string:
+			d.Format = f
+		case map[string]interface{}:
+			d.Format, _ = f["pattern"].(string)
+			if s, ok := f["decimalChar"].(string); ok {
+				d.Decimal = s
+			}
+			if s, ok := f["groupChar"].(string); ok {
+				d.Group = s
+			}
+		}
+		for key, target := range map[string]**int{"length": &d.Length, "minLength": &d.MinLength, "maxLength": &d.MaxLength} {
+			if n, ok := desc[key].(float64); ok {
+				i := int(n)
+				*target = &i
+			}
+		}
+		bound := func(keys ...string) string {
+			for _, key := range keys {
+				switch b := desc[key].(type) {
+				case string:
+					return b
+				case float64:
+					return strconv.FormatFloat(b, 'f', -1, 64)
+				}
+			}
+			return ""
+		}
+		d.Minimum = bound("minimum", "minInclusive")
+		d.Maximum = bound("maximum", "maxInclusive")
+		d.MinExcl = bound("minExclusive")
+		d.MaxExcl = bound("maxExclusive")
+	default:
+		return nil, errors.InvalidValue.New("datatype must be a string or an object")
+	}
+
+	var ok bool
+	if d.builtin, ok = builtins[strings.TrimPrefix(d.Base, xsdNamespace)]; !ok {
+		return nil, errors.InvalidValue.Newf("unknown datatype %q", d.Base)
+	}
+
+	if len(d.Format) > 0 {
+		switch d.builtin.kind {
+		case kindString, kindOther:
+			re, err := regexp.Compile(`^(?:` + d.Format + `)$`)
+			if err != nil {
+				return nil, errors.InvalidValue.Wrapf(err, "datatype %s: invalid format %q", d.Base, d.Format)
+			}
+			d.format = re
+		case kindTemporal:
+			tf, err := parseTemporalFormat(d.Format)
+			if err != nil {
+				return nil, errors.Wrapf(err, "datatype %s", d.Base)
+			}
+			d.temporal = tf
+		case kindBoolean:
+			if parts := strings.Split(d.Format, "|"); len(parts) != 2 {
+				return nil, errors.InvalidValue.Newf("datatype boolean: format %q must be of the form true|false", d.Format)
+			}
+		case kindNumber:
+			if strings.Contains(d.Format, ",") && len(d.Group) < 1 {
+				d.Group = ","
+			}
+		}
+	}
+
+	return d, nil
+}
+
+// IRI returns the IRI of the datatype
+func (d *Datatype) IRI() string {
+
+	if len(d.ID) > 0 {
+		return d.ID
+	}
+
+	return d.builtin.iri
+}
+
+// isString returns true for datatypes whose values are strings that
+// may carry a language
+func (d *Datatype) isString() bool {
+	return d.builtin.kind == kindString
+}
+
+// normalize applies the whitespace normalization of the datatype to s
+func (d *Datatype) normalize(s string) string {
+
+	switch strings.TrimPrefix(d.Base, xsdNamespace) {
+	case "string", "json", "xml", "html", "anyAtomicType", "any":
+		return s
+	}
+
+	s = strings.NewReplacer("\r", " ", "\n", " ", "\t", " ").Replace(s)
+	if strings.TrimPrefix(d.Base, xsdNamespace) == "normalizedString" {
+		return s
+	}
+
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// parse returns the lexical form of the value s in the datatype's
+// lexical space after checking it against the datatype's format and
+// constraints
+func (d *Datatype) parse(s string) (string, error) {
+
+	var value string
+	var err error
+
+	switch d.builtin.kind {
+	case kindBoolean:
+		value, err = d.parseBoolean(s)
+	case kindNumber:
+		value, err = d.parseNumber(s)
+	case kindTemporal:
+		value, err = d.parseTemporal(s)
+	default:
+		value, err = d.parseOther(s)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err = d.checkLength(value); err != nil {
+		return "", err
+	}
+
+	return value, d.checkBounds(value)
+}
+
+func (d *Datatype) invalid(s string) error {
+
+	if len(d.Format) > 0 {
+		return errors.InvalidValue.Newf("%q is not a valid %s with format %q", s, d.Base, d.Format)
+	}
+
+	return errors.InvalidValue.Newf("%q is not a valid %s", s, d.Base)
+}
+
+func (d *Datatype) parseBoolean(s string) (string, error) {
+
+	if len(d.Format) > 0 {
+		parts := strings.Split(d.Format, "|")
+		switch s {
+		case parts[0]:
+			return "true", nil
+		case parts[1]:
+			return "false", nil
+		}
+		return "", d.invalid(s)
+	}
+
+	switch s {
+	case "true", "1":
+		return "true", nil
+	case "false", "0":
+		return "false", nil
+	}
+
+	return "", d.invalid(s)
+}
+
+func (d *Datatype) parseNumber(s string) (string, error) {
+
+	name := strings.TrimPrefix(d.Base, xsdNamespace)
+	if name == "double" || name == "float" || name == "number" {
+		switch s {
+		case "NaN", "INF", "-INF":
+			return s, nil
+		}
+	}
+
+	n := s
+	if len(d.Group) > 0 {
+		n = strings.ReplaceAll(n, d.Group, "")
+	}
+	if d.Decimal != "." {
+		n = strings.ReplaceAll(n, d.Decimal, ".")
+	}
+
+	// PERCENT AND PER MILLE VALUES ARE SCALED
+	scale := int64(1)
+	for suffix, factor := range map[string]int64{"%": 100, "‰": 1000} {
+		if strings.HasSuffix(n, suffix) {
+			n, scale = strings.TrimSuffix(n, suffix), factor
+		} else if strings.HasPrefix(n, suffix) {
+			n, scale = strings.TrimPrefix(n, suffix), factor
+		}
+	}
+
+	if !numberLexical.MatchString(n) || !d.matchesNumberFormat(n) {
+		return "", d.invalid(s)
+	}
+
+	if name == "double" || name == "float" || name == "number" {
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil && !math.IsInf(f, 0) {
+			return "", d.invalid(s)
+		}
+		return canonicalDouble(f / float64(scale)), nil
+	}
+
+	r, ok := new(big.Rat).SetString(n)
+	if !ok {
+		return "", d.invalid(s)
+	}
+	r.Quo(r, new(big.Rat).SetInt64(scale))
+
+	if d.builtin.integer {
+		if !r.IsInt() {
+			return "", d.invalid(s)
+		}
+		i := r.Num()
+		if (d.builtin.min != nil && i.Cmp(d.builtin.min) < 0) || (d.builtin.max != nil && i.Cmp(d.builtin.max) > 0) {
+			return "", errors.OutOfRange.Newf("%q is out of range for %s", s, d.Base)
+		}
+		return i.String(), nil
+	}
+
+	return canonicalDecimal(r), nil
+}
+
+// matchesNumberFormat checks the number n (with '.' as decimal
+// separator and without grouping) against the digits a format pattern
+// such as #,##0.00 requires
+func (d *Datatype) matchesNumberFormat(n string) bool {
+
+	if len(d.Format) < 1 {
+		return true
+	}
+
+	pattern := strings.NewReplacer(d.Group, "", "%", "", "‰", "").Replace(d.Format)
+	if d.Decimal != "." {
+		pattern = strings.ReplaceAll(pattern, d.Decimal, ".")
+	}
+
+	n = strings.TrimLeft(n, "+-")
+	patternExp, valueExp := strings.ContainsAny(pattern, "Ee"), strings.ContainsAny(n, "Ee")
+	if valueExp && !patternExp {
+		return false
+	}
+	if i := strings.IndexAny(pattern, "Ee"); i >= 0 {
+		pattern = pattern[:i]
+	}
+	if i := strings.IndexAny(n, "Ee"); i >= 0 {
+		n = n[:i]
+	}
+
+	patternInt, patternFrac, patternHasFrac := strings.Cut(pattern, ".")
+	valueInt, valueFrac, _ := strings.Cut(n, ".")
+
+	if len(valueInt) < strings.Count(patternInt, "0") {
+		return false
+	}
+	if !patternHasFrac {
+		return len(valueFrac) < 1
+	}
+
+	return len(valueFrac) >= strings.Count(patternFrac, "0") && len(valueFrac) <= len(patternFrac)
+}
+
+// canonicalDecimal returns the canonical xsd:decimal form of r
+func canonicalDecimal(r *big.Rat) string {
+
+	s := r.FloatString(64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+
+	return s
+}
+
+// canonicalDouble returns the canonical xsd:double form of f
+func canonicalDouble(f float64) string {
+
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	}
+
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(f, 'E', -1, 64), "E")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	e, _ := strconv.Atoi(exponent)
+
+	return fmt.Sprintf("%sE%d", mantissa, e)
+}
+
+func (d *Datatype) parseTemporal(s string) (string, error) {
+
+	name := strings.TrimPrefix(d.Base, xsdNamespace)
+	if name == "datetime" {
+		name = "dateTime"
+	}
+
+	value := s
+	if d.temporal != nil {
+		var err error
+		if value, err = d.temporal.parse(s, name); err != nil {
+			return "", d.invalid(s)
+		}
+	}
+
+	if !temporalLexical[name].MatchString(value) {
+		return "", d.invalid(s)
+	}
+	if _, err := temporalTime(name, value); err != nil {
+		return "", d.invalid(s)
+	}
+
+	return value, nil
+}
+
+// temporalTime returns the instant of the date, dateTime or time value
+func temporalTime(name string, value string) (time.Time, error) {
+
+	layout := map[string]string{
+		"date":          "2006-01-02",
+		"dateTime":      "2006-01-02T15:04:05.999999999",
+		"dateTimeStamp": "2006-01-02T15:04:05.999999999",
+		"time":          "15:04:05.999999999",
+	}[name]
+
+	if strings.HasSuffix(value, "Z") {
+		value = strings.TrimSuffix(value, "Z") + "+00:00"
+	}
+	if tz := len(value) - 6; tz > 0 && (value[tz] == '+' || value[tz] == '-') && value[tz+3] == ':' {
+		layout += "-07:00"
+	}
+
+	return time.Parse(layout, value)
+}
+
+func (d *Datatype) parseOther(s string) (string, error) {
+
+	if d.format != nil && !d.format.MatchString(s) {
+		return "", d.invalid(s)
+	}
+
+	name := strings.TrimPrefix(d.Base, xsdNamespace)
+	switch name {
+	case "anyURI":
+		if _, err := url.Parse(s); err != nil {
+			return "", d.invalid(s)
+		}
+	case "base64Binary":
+		if _, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(s, " ", "")); err != nil {
+			return "", d.invalid(s)
+		}
+	case "json":
+		if !json.Valid([]byte(s)) {
+			return "", d.invalid(s)
+		}
+	case "duration", "dayTimeDuration", "yearMonthDuration":
+		if strings.HasSuffix(s, "P") || strings.HasSuffix(s, "T") {
+			return "", d.invalid(s)
+		}
+	}
+
+	if d.builtin.lexical != nil && !d.builtin.lexical.MatchString(s) {
+		return "", d.invalid(s)
+	}
+
+	return s, nil
+}
+
+// checkLength checks the length constraints of the datatype. Lengths
+// of binary values are counted in bytes, others in characters
+func (d *Datatype) checkLength(value string) error {
+
+	if d.Length == nil && d.MinLength == nil && d.MaxLength == nil {
+		return nil
+	}
+
+	length := len([]rune(value))
+	switch strings.TrimPrefix(d.Base, xsdNamespace) {
+	case "hexBinary":
+		length = len(value) / 2
+	case "base64Binary":
+		data, _ := base64.StdEncoding.DecodeString(strings.ReplaceAll(value, " ", ""))
+		length = len(data)
+	}
+
+	switch {
+	case d.Length != nil && length != *d.Length:
+		return errors.OutOfRange.Newf("%q does not have length %d", value, *d.Length)
+	case d.MinLength != nil && length < *d.MinLength:
+		return errors.OutOfRange.Newf("%q is shorter than %d", value, *d.MinLength)
+	case d.MaxLength != nil && length > *d.MaxLength:
+		return errors.OutOfRange.Newf("%q is longer than %d", value, *d.MaxLength)
+	}
+
+	return nil
+}
+
+// checkBounds checks the value constraints of numeric and temporal
+// datatypes
+func (d *Datatype) checkBounds(value string) error {
+
+	compare := d.comparator()
+	if compare == nil {
+		return nil
+	}
+
+	for _, bound := range []struct {
+		limit string
+		ok    func(int) bool
+		desc  string
+	}{
+		{d.Minimum, func(c int) bool { return c >= 0 }, "less than"},
+		{d.Maximum, func(c int) bool { return c <= 0 }, "greater than"},
+		{d.MinExcl, func(c int) bool { return c > 0 }, "not greater than"},
+		{d.MaxExcl, func(c int) bool { return c < 0 }, "not less than"},
+	} {
+		if len(bound.limit) < 1 {
+			continue
+		}
+		c, err := compare(value, bound.limit)
+		if err != nil {
+			return errors.InvalidValue.Wrapf(err, "datatype %s: invalid bound %q", d.Base, bound.limit)
+		}
+		if !bound.ok(c) {
+			return errors.OutOfRange.Newf("%s is %s %s", value, bound.desc, bound.limit)
+		}
+	}
+
+	return nil
+}
+
+// comparator returns a function that compares values of the datatype
+// or nil if the datatype has no order
+func (d *Datatype) comparator() func(a string, b string) (int, error) {
+
+	name := strings.TrimPrefix(d.Base, xsdNamespace)
+
+	switch d.builtin.kind {
+	case kindNumber:
+		if name == "double" || name == "float" || name == "number" {
+			return func(a string, b string) (int, error) {
+				x, err := strconv.ParseFloat(strings.Replace(a, "INF", "Inf", 1), 64)
+				if err != nil {
+					return 0, err
+				}
+				y, err := strconv.ParseFloat(strings.Replace(b, "INF", "Inf", 1), 64)
+				if err != nil {
+					return 0, err
+				}
+				switch {
+				case x < y:
+					return -1, nil
+				case x > y:
+					return 1, nil
+				}
+				return 0, nil
+			}
+		}
+		return func(a string, b string) (int, error) {
+			x, ok := new(big.Rat).SetString(a)
+			y, ok2 := new(big.Rat).SetString(b)
+			if !ok || !ok2 {
+				return 0, errors.InvalidValue.Newf("%q is not a number", b)
+			}
+			return x.Cmp(y), nil
+		}
+
+	case kindTemporal:
+		if name == "datetime" {
+			name = "dateTime"
+		}
+		return func(a string, b string) (int, error) {
+			x, err := temporalTime(name, a)
+			if err != nil {
+				return 0, err
+			}
+			y, err := temporalTime(name, b)
+			if err != nil {
+				return 0, err
+			}
+			return x.Compare(y), nil
+		}
+	}
+
+	return nil
+}
+
+// temporalFormat parses dates and times with a format pattern such as
+// dd/MM/yyyy or HH:mm:ss
+type temporalFormat struct {
+	re     *regexp.Regexp
+	fields []string // pattern field of each group
+}
+
+func parseTemporalFormat(pattern string) (*temporalFormat, error) {
+
+	tf := &temporalFormat{}
+	var re strings.Builder
+	re.WriteString("^")
+
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		j := i
+		for j < len(pattern) && pattern[j] == c {
+			j++
+		}
+		field := pattern[i:j]
+
+		var group string
+		switch {
+		case field == "yyyy":
+			group = `(\d{4})`
+		case field == "MM" || field == "dd" || field == "HH" || field == "mm" || field == "ss":
+			group = `(\d{2})`
+		case field == "M" || field == "d":
+			group = `(\d{1,2})`
+		case c == 'S':
+			group = fmt.Sprintf(`(\d{1,%d})`, len(field))
+		case c == 'X' || c == 'x':
+			if len(field) > 3 {
+				return nil, errors.InvalidValue.Newf("invalid format %q", pattern)
+			}
+			group = `(Z|[+-]\d{2}(?::?\d{2})?)`
+			if c == 'x' {
+				group = `([+-]\d{2}(?::?\d{2})?)`
+			}
+		case strings.ContainsRune("yMdHmsSXx", rune(c)):
+			return nil, errors.InvalidValue.Newf("unsupported field %q in format %q", field, pattern)
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			if field != "T" {
+				return nil, errors.InvalidValue.Newf("unsupported field %q in format %q", field, pattern)
+			}
+			group = ""
+			re.WriteString("T")
+		default:
+			re.WriteString(regexp.QuoteMeta(field))
+		}
+
+		if len(group) > 0 {
+			re.WriteString(group)
+			tf.fields = append(tf.fields, field)
+		}
+		i = j
+	}
+	re.WriteString("$")
+
+	var err error
+	if tf.re, err = regexp.Compile(re.String()); err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "invalid format %q", pattern)
+	}
+
+	return tf, nil
+}
+
+// parse returns the XSD lexical form of the date, dateTime or time
+// value s
+func (tf *temporalFormat) parse(s string, name string) (string, error) {
+
+	match := tf.re.FindStringSubmatch(s)
+	if match == nil {
+		return "", errors.InvalidValue.Newf("%q does not match format", s)
+	}
+
+	var year, month, day, hour, minute, second, fraction, tz string
+	for i, field := range tf.fields {
+		v := match[i+1]
+		switch field[0] {
+		case 'y':
+			year = v
+		case 'M':
+			month = pad2(v)
+		case 'd':
+			day = pad2(v)
+		case 'H':
+			hour = v
+		case 'm':
+			minute = v
+		case 's':
+			second = v
+		case 'S':
+			fraction = "." + v
+		case 'X', 'x':
+			tz = v
+			if tz != "Z" {
+				if len(tz) == 3 {
+					tz += ":00"
+				} else if !strings.Contains(tz, ":") {
+					tz = tz[:3] + ":" + tz[3:]
+				}
+			}
+		}
+	}
+	if len(second) < 1 {
+		second = "00"
+	}
+
+	date := year + "-" + month + "-" + day
+	clock := hour + ":" + minute + ":" + second + fraction
+
+	switch name {
+	case "date":
+		return date + tz, nil
+	case "time":
+		return clock + tz, nil
+	}
+
+	return date + "T" + clock + tz, nil
+}
+
+func pad2(s string) string {
+
+	if len(s) < 2 {
+		return "0" + s
+	}
+
+	return s
+}
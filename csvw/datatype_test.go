@@ -0,0 +1,75 @@
+package csvw
+
+import (
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+func TestDatatypeParse(t *testing.T) {
+
+	for _, test := range []struct {
+		datatype interface{}
+		value    string
+		expected string
+		errType  errors.ErrorType
+	}{
+		{"string", " a b ", " a b ", errors.NoType},
+		{"integer", "0042", "42", errors.NoType},
+		{"integer", "4.2", "", errors.InvalidValue},
+		{"byte", "128", "", errors.OutOfRange},
+		{"nonNegativeInteger", "-1", "", errors.OutOfRange},
+		{"decimal", "1.50", "1.5", errors.NoType},
+		{"double", "1.5e3", "1.5E3", errors.NoType},
+		{"number", "INF", "INF", errors.NoType},
+		{"boolean", "1", "true", errors.NoType},
+		{"boolean", "yes", "", errors.InvalidValue},
+		{"date", "2024-02-29", "2024-02-29", errors.NoType},
+		{"date", "2023-02-29", "", errors.InvalidValue},
+		{"dateTime", "2024-01-02T03:04:05Z", "2024-01-02T03:04:05Z", errors.NoType},
+		{"time", "25:00:00", "", errors.InvalidValue},
+		{"gYear", "2024", "2024", errors.NoType},
+		{"duration", "P1Y2MT3H", "P1Y2MT3H", errors.NoType},
+		{"duration", "P", "", errors.InvalidValue},
+		{"hexBinary", "0fA0", "0fA0", errors.NoType},
+		{"json", `{"a":1}`, `{"a":1}`, errors.NoType},
+		{"anyURI", "http://example.org/", "http://example.org/", errors.NoType},
+		{map[string]interface{}{"base": "boolean", "format": "Y|N"}, "N", "false", errors.NoType},
+		{map[string]interface{}{"base": "date", "format": "dd/MM/yyyy"}, "31/12/2023", "2023-12-31", errors.NoType},
+		{map[string]interface{}{"base": "dateTime", "format": "M/d/yyyy HH:mm X"}, "1/2/2024 09:30 +0100", "2024-01-02T09:30:00+01:00", errors.NoType},
+		{map[string]interface{}{"base": "decimal", "format": "#,##0.00"}, "1,234.50", "1234.5", errors.NoType},
+		{map[string]interface{}{"base": "decimal", "format": "#,##0.00"}, "1,234.5", "", errors.InvalidValue},
+		{map[string]interface{}{"base": "decimal", "format": map[string]interface{}{"decimalChar": ",", "groupChar": "."}}, "1.234,5", "1234.5", errors.NoType},
+		{map[string]interface{}{"base": "decimal"}, "50%", "0.5", errors.NoType},
+		{map[string]interface{}{"base": "string", "format": "[A-Z]{2}"}, "GB", "GB", errors.NoType},
+		{map[string]interface{}{"base": "string", "format": "[A-Z]{2}"}, "GBR", "", errors.InvalidValue},
+		{map[string]interface{}{"base": "string", "maxLength": 3.0}, "abcd", "", errors.OutOfRange},
+		{map[string]interface{}{"base": "integer", "minimum": 1.0, "maxExclusive": "10"}, "10", "", errors.OutOfRange},
+		{map[string]interface{}{"base": "integer", "minimum": 1.0, "maxExclusive": "10"}, "9", "9", errors.NoType},
+		{map[string]interface{}{"base": "date", "minimum": "2024-01-01"}, "2023-12-31", "", errors.OutOfRange},
+	} {
+		d, err := parseDatatype(test.datatype)
+		if err != nil {
+			t.Errorf("%v: %s", test.datatype, err)
+			continue
+		}
+		actual, err := d.parse(d.normalize(test.value))
+		if test.errType != errors.NoType {
+			if errors.GetType(err) != test.errType {
+				t.Errorf("%v %q: expected %v error, got %v", test.datatype, test.value, test.errType, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v %q: %s", test.datatype, test.value, err)
+		} else if actual != test.expected {
+			t.Errorf("%v %q: expected %q, got %q", test.datatype, test.value, test.expected, actual)
+		}
+	}
+
+	for _, datatype := range []interface{}{"unknown", 1.0, map[string]interface{}{"base": "string", "format": "("}} {
+		if _, err := parseDatatype(datatype); errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("%v: expected InvalidValue error, got %v", datatype, err)
+		}
+	}
+}
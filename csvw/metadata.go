@@ -0,0 +1,635 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package csvw converts tabular data to RDF as described by the W3C
+// CSV on the Web recommendations: the metadata vocabulary for tabular
+// data (https://www.w3.org/TR/tabular-metadata/) and the CSV to RDF
+// conversion (https://www.w3.org/TR/csv2rdf/) in standard and minimal mode
+package csvw
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// namespace of the CSVW vocabulary. Also the required @context value
+const Namespace = "http://www.w3.org/ns/csvw#"
+
+const contextIRI = "http://www.w3.org/ns/csvw"
+
+// TableGroup is a group of tables described by a metadata document
+type TableGroup struct {
+	ID          string
+	Tables      []*Table
+	Annotations map[string]interface{} // common properties (e.g. dc:title) -> JSON-LD value
+	Language    string                 // default language of annotations
+	Base        string                 // IRI relative references resolve against
+}
+
+// Table describes a CSV file
+type Table struct {
+	ID             string
+	URL            string // absolute URL of the CSV file
+	Schema         *Schema
+	Dialect        Dialect
+	SuppressOutput bool
+	Annotations    map[string]interface{}
+	Group          *TableGroup
+}
+
+// Schema describes the columns of a table
+type Schema struct {
+	ID         string
+	Columns    []*Column
+	PrimaryKey []string // column names
+	RowTitles  []string // column names
+	inherited  Properties
+	virtual    bool // has virtual columns
+}
+
+// Column describes a column of a table. Properties not set on the
+// column are inherited from the schema, table and table group
+type Column struct {
+	Name           string
+	Titles         []string
+	Virtual        bool
+	SuppressOutput bool
+	Properties          // effective inherited properties
+	number         int  // 1 based column number
+	explicitName   bool // name given by the metadata
+}
+
+// Properties are the inherited properties of the metadata vocabulary
+type Properties struct {
+	AboutURL     *string
+	PropertyURL  *string
+	ValueURL     *string
+	Datatype     *Datatype
+	Default      *string
+	Lang         *string
+	Null         []string
+	Ordered      *bool
+	Required     *bool
+	Separator    *string
+	nullSet      bool
+	separatorSet bool
+}
+
+// inherit returns p with the properties it does not set taken from parent
+func (p Properties) inherit(parent Properties) Properties {
+
+	if p.AboutURL == nil {
+		p.AboutURL = parent.AboutURL
+	}
+	if p.PropertyURL == nil {
+		p.PropertyURL = parent.PropertyURL
+	}
+	if p.ValueURL == nil {
+		p.ValueURL = parent.ValueURL
+	}
+	if p.Datatype == nil {
+		p.Datatype = parent.Datatype
+	}
+	if p.Default == nil {
+		p.Default = parent.Default
+	}
+	if p.Lang == nil {
+		p.Lang = parent.Lang
+	}
+	if !p.nullSet {
+		p.Null, p.nullSet = parent.Null, parent.nullSet
+	}
+	if p.Ordered == nil {
+		p.Ordered = parent.Ordered
+	}
+	if p.Required == nil {
+		p.Required = parent.Required
+	}
+	if !p.separatorSet {
+		p.Separator, p.separatorSet = parent.Separator, parent.separatorSet
+	}
+
+	return p
+}
+
+// Dialect describes how to parse a CSV file
+type Dialect struct {
+	CommentPrefix    string
+	Delimiter        string
+	DoubleQuote      bool
+	Encoding         string
+	Header           bool
+	HeaderRowCount   int
+	QuoteChar        string
+	SkipBlankRows    bool
+	SkipColumns      int
+	SkipInitialSpace bool
+	SkipRows         int
+	Trim             string // true, false, start or end
+}
+
+// DefaultDialect returns the dialect used when the metadata declares none
+func DefaultDialect() Dialect {
+	return Dialect{
+		CommentPrefix:  "#",
+		Delimiter:      ",",
+		DoubleQuote:    true,
+		Encoding:       "utf-8",
+		Header:         true,
+		HeaderRowCount: 1,
+		QuoteChar:      `"`,
+		Trim:           "true",
+	}
+}
+
+// ParseMetadata parses the metadata document data located at base.
+// The document may describe a table group or a single table. Relative
+// table URLs resolve against base unless the document sets @base
+func ParseMetadata(data []byte, base string) (*TableGroup, error) {
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "%s: invalid metadata", base)
+	}
+
+	p := &metadataParser{src: base, base: base}
+	if err := p.context(doc["@context"]); err != nil {
+		return nil, err
+	}
+
+	group := &TableGroup{Language: p.lang, Base: p.base}
+
+	if _, isGroup := doc["tables"]; isGroup {
+		return group, p.tableGroup(doc, group)
+	}
+	if _, isTable := doc["url"]; isTable {
+		// A SINGLE TABLE IS AN IMPLICIT GROUP OF ONE
+		if err := p.tableGroup(map[string]interface{}{"tables": []interface{}{doc}}, group); err != nil {
+			return nil, err
+		}
+		return group, nil
+	}
+
+	return nil, errors.InvalidValue.Newf("%s: metadata describes neither a table group nor a table", base)
+}
+
+type metadataParser struct {
+	src  string // metadata location for errors
+	base string
+	lang string
+}
+
+func (p *metadataParser) errorf(format string, args ...interface{}) error {
+	return errors.InvalidValue.Newf("%s: %s", p.src, fmt.Sprintf(format, args...))
+}
+
+// context checks the @context of the metadata and takes @base and
+// @language from it
+func (p *metadataParser) context(c interface{}) error {
+
+	switch v := c.(type) {
+	case string:
+		if v == contextIRI {
+			return nil
+		}
+	case []interface{}:
+		if len(v) == 2 && v[0] == contextIRI {
+			if local, ok := v[1].(map[string]interface{}); ok {
+				if b, ok := local["@base"].(string); ok {
+					resolved, err := resolve(p.base, b)
+					if err != nil {
+						return p.errorf("invalid @base %q", b)
+					}
+					p.base = resolved
+				}
+				if l, ok := local["@language"].(string); ok {
+					p.lang = l
+				}
+				return nil
+			}
+		}
+	}
+
+	return p.errorf("@context must be %q or an array of it and an object with @base and/or @language", contextIRI)
+}
+
+func (p *metadataParser) tableGroup(doc map[string]interface{}, group *TableGroup) error {
+
+	var err error
+	var props Properties
+
+	if group.ID, err = p.id(doc); err != nil {
+		return err
+	}
+	if props, err = p.properties(doc); err != nil {
+		return err
+	}
+	dialect := DefaultDialect()
+	if err = p.dialect(doc["dialect"], &dialect); err != nil {
+		return err
+	}
+	group.Annotations = p.annotations(doc)
+
+	tables, ok := doc["tables"].([]interface{})
+	if !ok || len(tables) < 1 {
+		return p.errorf("tables must be a non-empty array")
+	}
+
+	for _, t := range tables {
+		desc, ok := t.(map[string]interface{})
+		if !ok {
+			return p.errorf("tables must contain table descriptions")
+		}
+		table, err := p.table(desc, props, dialect)
+		if err != nil {
+			return err
+		}
+		table.Group = group
+		group.Tables = append(group.Tables, table)
+	}
+
+	return nil
+}
+
+func (p *metadataParser) table(doc map[string]interface{}, groupProps Properties, groupDialect Dialect) (*Table, error) {
+
+	table := &Table{Dialect: groupDialect, Annotations: p.annotations(doc)}
+	var err error
+
+	u, ok := doc["url"].(string)
+	if !ok {
+		return nil, p.errorf("table url must be a string")
+	}
+	if table.URL, err = resolve(p.base, u); err != nil {
+		return nil, p.errorf("invalid table url %q", u)
+	}
+	if table.ID, err = p.id(doc); err != nil {
+		return nil, err
+	}
+	table.SuppressOutput, _ = doc["suppressOutput"].(bool)
+
+	props, err := p.properties(doc)
+	if err != nil {
+		return nil, err
+	}
+	props = props.inherit(groupProps)
+
+	if err = p.dialect(doc["dialect"], &table.Dialect); err != nil {
+		return nil, err
+	}
+
+	switch s := doc["tableSchema"].(type) {
+	case nil:
+		table.Schema = &Schema{inherited: props}
+	case map[string]interface{}:
+		if table.Schema, err = p.schema(s, props); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.errorf("tableSchema must be an object. schemas referenced by URL are not supported")
+	}
+
+	return table, nil
+}
+
+func (p *metadataParser) schema(doc map[string]interface{}, tableProps Properties) (*Schema, error) {
+
+	schema := &Schema{}
+	var err error
+
+	if schema.ID, err = p.id(doc); err != nil {
+		return nil, err
+	}
+	props, err := p.properties(doc)
+	if err != nil {
+		return nil, err
+	}
+	schema.inherited = props.inherit(tableProps)
+
+	columns, _ := doc["columns"].([]interface{})
+	names := make(map[string]bool)
+	for i, c := range columns {
+		desc, ok := c.(map[string]interface{})
+		if !ok {
+			return nil, p.errorf("columns must contain column descriptions")
+		}
+		column := &Column{number: i + 1}
+		if column.Properties, err = p.properties(desc); err != nil {
+			return nil, err
+		}
+		column.Properties = column.Properties.inherit(schema.inherited)
+		column.Titles = p.titles(desc["titles"])
+		column.Virtual, _ = desc["virtual"].(bool)
+		column.SuppressOutput, _ = desc["suppressOutput"].(bool)
+
+		if name, ok := desc["name"].(string); ok {
+			if strings.HasPrefix(name, "_") {
+				return nil, p.errorf("column name %q must not start with '_'", name)
+			}
+			column.Name, column.explicitName = name, true
+		} else if len(column.Titles) > 0 {
+			column.Name = escapeName(column.Titles[0])
+		} else {
+			column.Name = fmt.Sprintf("_col.%d", i+1)
+		}
+		if names[column.Name] {
+			return nil, p.errorf("duplicate column name %q", column.Name)
+		}
+		names[column.Name] = true
+
+		if column.Virtual {
+			schema.virtual = true
+		} else if schema.virtual {
+			return nil, p.errorf("column %q: virtual columns must follow all non-virtual columns", column.Name)
+		}
+
+		schema.Columns = append(schema.Columns, column)
+	}
+
+	columnNames := func(key string) ([]string, error) {
+		var refs []string
+		switch v := doc[key].(type) {
+		case nil:
+			return nil, nil
+		case string:
+			refs = []string{v}
+		case []interface{}:
+			for _, r := range v {
+				if s, ok := r.(string); ok {
+					refs = append(refs, s)
+				}
+			}
+		}
+		for _, ref := range refs {
+			if !names[ref] {
+				return nil, p.errorf("%s refers to unknown column %q", key, ref)
+			}
+		}
+		return refs, nil
+	}
+	if schema.PrimaryKey, err = columnNames("primaryKey"); err != nil {
+		return nil, err
+	}
+	if schema.RowTitles, err = columnNames("rowTitles"); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// id returns the @id of a description resolved against the base
+func (p *metadataParser) id(doc map[string]interface{}) (string, error) {
+
+	switch v := doc["@id"].(type) {
+	case nil:
+		return "", nil
+	case string:
+		if strings.HasPrefix(v, "_:") {
+			return "", p.errorf("@id %q must not be a blank node", v)
+		}
+		return resolve(p.base, v)
+	}
+
+	return "", p.errorf("@id must be a string")
+}
+
+// titles returns the titles of a column for the default language
+// followed by those of other languages
+func (p *metadataParser) titles(v interface{}) []string {
+
+	var titles []string
+	add := func(t interface{}) {
+		switch s := t.(type) {
+		case string:
+			titles = append(titles, s)
+		case []interface{}:
+			for _, item := range s {
+				if str, ok := item.(string); ok {
+					titles = append(titles, str)
+				}
+			}
+		}
+	}
+
+	if m, ok := v.(map[string]interface{}); ok {
+		for _, t := range m {
+			add(t)
+		}
+	} else {
+		add(v)
+	}
+
+	return titles
+}
+
+// properties returns the inherited properties set on a description
+func (p *metadataParser) properties(doc map[string]interface{}) (Properties, error) {
+
+	var props Properties
+
+	str := func(key string) (*string, error) {
+		switch v := doc[key].(type) {
+		case nil:
+			return nil, nil
+		case string:
+			return &v, nil
+		}
+		return nil, p.errorf("%s must be a string", key)
+	}
+	boolean := func(key string) (*bool, error) {
+		switch v := doc[key].(type) {
+		case nil:
+			return nil, nil
+		case bool:
+			return &v, nil
+		}
+		return nil, p.errorf("%s must be a boolean", key)
+	}
+
+	var err error
+	if props.AboutURL, err = str("aboutUrl"); err != nil {
+		return props, err
+	}
+	if props.PropertyURL, err = str("propertyUrl"); err != nil {
+		return props, err
+	}
+	if props.ValueURL, err = str("valueUrl"); err != nil {
+		return props, err
+	}
+	if props.Default, err = str("default"); err != nil {
+		return props, err
+	}
+	if props.Lang, err = str("lang"); err != nil {
+		return props, err
+	}
+	if props.Ordered, err = boolean("ordered"); err != nil {
+		return props, err
+	}
+	if props.Required, err = boolean("required"); err != nil {
+		return props, err
+	}
+
+	if v, ok := doc["separator"]; ok {
+		props.separatorSet = true
+		if v != nil {
+			s, ok := v.(string)
+			if !ok {
+				return props, p.errorf("separator must be a string or null")
+			}
+			props.Separator = &s
+		}
+	}
+
+	if v, ok := doc["null"]; ok {
+		props.nullSet = true
+		switch n := v.(type) {
+		case string:
+			props.Null = []string{n}
+		case []interface{}:
+			for _, item := range n {
+				if s, ok := item.(string); ok {
+					props.Null = append(props.Null, s)
+				}
+			}
+		default:
+			return props, p.errorf("null must be a string or an array of strings")
+		}
+	}
+
+	if v, ok := doc["datatype"]; ok {
+		if props.Datatype, err = parseDatatype(v); err != nil {
+			return props, errors.Wrapf(err, "%s", p.src)
+		}
+	}
+
+	return props, nil
+}
+
+// dialect sets the dialect properties in v on d
+func (p *metadataParser) dialect(v interface{}, d *Dialect) error {
+
+	if v == nil {
+		return nil
+	}
+	desc, ok := v.(map[string]interface{})
+	if !ok {
+		return p.errorf("dialect must be an object. dialects referenced by URL are not supported")
+	}
+
+	for key, value := range desc {
+		var valid bool
+		switch key {
+		case "commentPrefix", "delimiter", "encoding", "quoteChar":
+			var s string
+			if s, valid = value.(string); valid {
+				switch key {
+				case "commentPrefix":
+					d.CommentPrefix = s
+				case "delimiter":
+					d.Delimiter = s
+				case "encoding":
+					d.Encoding = s
+				case "quoteChar":
+					d.QuoteChar = s
+				}
+			} else if value == nil && key == "quoteChar" {
+				d.QuoteChar, valid = "", true
+			}
+		case "doubleQuote", "header", "skipBlankRows", "skipInitialSpace":
+			var b bool
+			if b, valid = value.(bool); valid {
+				switch key {
+				case "doubleQuote":
+					d.DoubleQuote = b
+				case "header":
+					d.Header = b
+					if !b {
+						d.HeaderRowCount = 0
+					} else if d.HeaderRowCount < 1 {
+						d.HeaderRowCount = 1
+					}
+				case "skipBlankRows":
+					d.SkipBlankRows = b
+				case "skipInitialSpace":
+					d.SkipInitialSpace = b
+				}
+			}
+		case "headerRowCount", "skipColumns", "skipRows":
+			var n float64
+			if n, valid = value.(float64); valid && n >= 0 && n == float64(int(n)) {
+				switch key {
+				case "headerRowCount":
+					d.HeaderRowCount = int(n)
+					d.Header = n > 0
+				case "skipColumns":
+					d.SkipColumns = int(n)
+				case "skipRows":
+					d.SkipRows = int(n)
+				}
+			} else {
+				valid = false
+			}
+		case "trim":
+			switch t := value.(type) {
+			case bool:
+				d.Trim, valid = fmt.Sprint(t), true
+			case string:
+				d.Trim, valid = t, t == "true" || t == "false" || t == "start" || t == "end"
+			}
+		default:
+			// lineTerminators, @type AND @id DO NOT CHANGE PARSING
+			valid = true
+		}
+		if !valid {
+			return p.errorf("invalid dialect %s: %v", key, value)
+		}
+	}
+
+	return nil
+}
+
+// annotations returns the common properties (prefixed names or
+// absolute IRIs) of a description
+func (p *metadataParser) annotations(doc map[string]interface{}) map[string]interface{} {
+
+	annotations := make(map[string]interface{})
+	for key, value := range doc {
+		if key == "notes" || strings.Contains(key, ":") {
+			annotations[key] = value
+		}
+	}
+
+	return annotations
+}
+
+// resolve resolves reference against base
+func resolve(base string, reference string) (string, error) {
+
+	ref, err := url.Parse(reference)
+	if err != nil {
+		return "", err
+	}
+	if len(base) < 1 {
+		return ref.String(), nil
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	return b.ResolveReference(ref).String(), nil
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package csvw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// template is a URI template (RFC 6570) as used by the aboutUrl,
+// propertyUrl and valueUrl properties
+type template struct {
+	src   string
+	parts []templatePart
+}
+
+// templatePart is either a literal or an expression
+type templatePart struct {
+	literal string
+	op      *templateOperator
+	vars    []templateVar
+}
+
+type templateVar struct {
+	name    string
+	prefix  int // max characters of the value. 0 is unlimited
+	explode bool
+}
+
+type templateOperator struct {
+	first    string
+	sep      string
+	named    bool
+	ifEmpty  string
+	reserved bool // allow reserved characters
+}
+
+var templateOperators = map[byte]*templateOperator{
+	0:   {first: "", sep: ","},
+	'+': {first: "", sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+// parseTemplate parses the URI template s
+func parseTemplate(s string) (*template, error) {
+
+	t := &template{src: s}
+
+	for rest := s; len(rest) > 0; {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			t.parts = append(t.parts, templatePart{literal: rest})
+			break
+		}
+		if open > 0 {
+			t.parts = append(t.parts, templatePart{literal: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, errors.InvalidValue.Newf("URI template %q: unclosed expression", s)
+		}
+		expr := rest[open+1 : open+end]
+		rest = rest[open+end+1:]
+
+		part := templatePart{op: templateOperators[0]}
+		if len(expr) > 0 {
+			if op, ok := templateOperators[expr[0]]; ok && expr[0] != 0 {
+				part.op = op
+				expr = expr[1:]
+			} else if strings.ContainsRune("=,!@|", rune(expr[0])) {
+				return nil, errors.InvalidValue.Newf("URI template %q: reserved operator %q", s, expr[0])
+			}
+		}
+
+		for _, spec := range strings.Split(expr, ",") {
+			v := templateVar{name: spec}
+			if strings.HasSuffix(spec, "*") {
+				v.name, v.explode = strings.TrimSuffix(spec, "*"), true
+			} else if i := strings.IndexByte(spec, ':'); i >= 0 {
+				n, err := strconv.Atoi(spec[i+1:])
+				if err != nil || n < 1 || n > 9999 {
+					return nil, errors.InvalidValue.Newf("URI template %q: invalid prefix in %q", s, spec)
+				}
+				v.name, v.prefix = spec[:i], n
+			}
+			if len(v.name) < 1 {
+				return nil, errors.InvalidValue.Newf("URI template %q: empty variable name", s)
+			}
+			part.vars = append(part.vars, v)
+		}
+		t.parts = append(t.parts, part)
+	}
+
+	return t, nil
+}
+
+// expand expands the template with the variable values in vars. A
+// value is a string, a []string or nil for an undefined variable
+func (t *template) expand(vars map[string]interface{}) string {
+
+	var b strings.Builder
+
+	for _, part := range t.parts {
+		if part.op == nil {
+			b.WriteString(encodeTemplate(part.literal, true))
+			continue
+		}
+
+		first := true
+		for _, v := range part.vars {
+			value, ok := vars[v.name]
+			if !ok || value == nil {
+				continue
+			}
+			if list, isList := value.([]string); isList && len(list) < 1 {
+				continue
+			}
+
+			if first {
+				b.WriteString(part.op.first)
+				first = false
+			} else {
+				b.WriteString(part.op.sep)
+			}
+			expandVar(&b, part.op, v, value)
+		}
+	}
+
+	return b.String()
+}
+
+func expandVar(b *strings.Builder, op *templateOperator, v templateVar, value interface{}) {
+
+	switch val := value.(type) {
+	case string:
+		if op.named {
+			b.WriteString(encodeTemplate(v.name, true))
+			if len(val) < 1 {
+				b.WriteString(op.ifEmpty)
+				return
+			}
+			b.WriteString("=")
+		}
+		if v.prefix > 0 {
+			if runes := []rune(val); len(runes) > v.prefix {
+				val = string(runes[:v.prefix])
+			}
+		}
+		b.WriteString(encodeTemplate(val, op.reserved))
+
+	case []string:
+		sep := ","
+		if v.explode {
+			sep = op.sep
+		}
+		if op.named && !v.explode {
+			b.WriteString(encodeTemplate(v.name, true) + "=")
+		}
+		for i, item := range val {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			if op.named && v.explode {
+				b.WriteString(encodeTemplate(v.name, true))
+				if len(item) < 1 {
+					b.WriteString(op.ifEmpty)
+					continue
+				}
+				b.WriteString("=")
+			}
+			b.WriteString(encodeTemplate(item, op.reserved))
+		}
+	}
+}
+
+// encodeTemplate percent-encodes s leaving unreserved characters and,
+// if reserved is true, reserved characters and percent-encoded
+// triplets untouched
+func encodeTemplate(s string, reserved bool) string {
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || strings.IndexByte("-._~", c) >= 0:
+			b.WriteByte(c)
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			b.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
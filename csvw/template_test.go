@@ -0,0 +1,47 @@
+package csvw
+
+import "testing"
+
+func TestTemplateExpand(t *testing.T) {
+
+	vars := map[string]interface{}{
+		"var":   "value",
+		"hello": "Hello World!",
+		"path":  "/foo/bar",
+		"list":  []string{"red", "green", "blue"},
+		"empty": "",
+	}
+
+	for template, expected := range map[string]string{
+		"{var}":                    "value",
+		"{hello}":                  "Hello%20World%21",
+		"{+hello}":                 "Hello%20World!",
+		"{+path}/here":             "/foo/bar/here",
+		"{#hello}":                 "#Hello%20World!",
+		"X{.var}":                  "X.value",
+		"{/var,list}":              "/value/red,green,blue",
+		"{/list*}":                 "/red/green/blue",
+		"{;list}":                  ";list=red,green,blue",
+		"{;list*}":                 ";list=red;list=green;list=blue",
+		"{?var,empty,undef}":       "?var=value&empty=",
+		"{&list*}":                 "&list=red&list=green&list=blue",
+		"{var:3}":                  "val",
+		"http://example.org/{var}": "http://example.org/value",
+		"{undef}":                  "",
+	} {
+		tmpl, err := parseTemplate(template)
+		if err != nil {
+			t.Errorf("parseTemplate(%q): %s", template, err)
+			continue
+		}
+		if actual := tmpl.expand(vars); actual != expected {
+			t.Errorf("%s: expected %q, got %q", template, expected, actual)
+		}
+	}
+
+	for _, template := range []string{"{var", "{}", "{var:0}", "{=var}"} {
+		if _, err := parseTemplate(template); err == nil {
+			t.Errorf("parseTemplate(%q): expected error", template)
+		}
+	}
+}
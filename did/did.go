@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package did resolves decentralized identifiers (DIDs), the
+// identifiers of dogg3rz users and data products, to DID documents.
+// did:key identifiers are resolved locally and did:web identifiers
+// are resolved over HTTPS
+package did
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// context of every DID document
+const ContextV1 = "https://www.w3.org/ns/did/v1"
+
+// DID is a parsed decentralized identifier without its path, query
+// and fragment parts
+type DID struct {
+	Method string // i.e. 'key', 'web'
+	ID     string // method specific identifier
+}
+
+// Parse parses the DID (or DID URL) s
+func Parse(s string) (DID, error) {
+
+	// DROP DID URL PATH, QUERY AND FRAGMENT
+	didPart := s
+	if i := strings.IndexAny(didPart, "/?#"); i >= 0 {
+		didPart = didPart[:i]
+	}
+
+	parts := strings.SplitN(didPart, ":", 3)
+	if len(parts) != 3 || parts[0] != "did" {
+		return DID{}, errors.InvalidValue.Newf("%s: not a DID: expected 'did:<method>:<identifier>'", s)
+	}
+
+	d := DID{Method: parts[1], ID: parts[2]}
+
+	if len(d.Method) < 1 {
+		return DID{}, errors.InvalidValue.Newf("%s: DID method is empty", s)
+	}
+	for _, c := range d.Method {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') {
+			return DID{}, errors.InvalidValue.Newf("%s: DID method '%s' contains invalid character '%c'",
+				s, d.Method, c)
+		}
+	}
+
+	if len(d.ID) < 1 || strings.HasSuffix(d.ID, ":") {
+		return DID{}, errors.InvalidValue.Newf("%s: method specific identifier is empty", s)
+	}
+	for i := 0; i < len(d.ID); i++ {
+		c := d.ID[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.' || c == '-' || c == '_' || c == ':':
+		case c == '%' && i+2 < len(d.ID) && isHex(d.ID[i+1]) && isHex(d.ID[i+2]):
+			i += 2
+		default:
+			return DID{}, errors.InvalidValue.Newf("%s: method specific identifier contains invalid character '%c'",
+				s, c)
+		}
+	}
+
+	return d, nil
+}
+
+func (d DID) String() string {
+	return "did:" + d.Method + ":" + d.ID
+}
+
+// CheckDocument verifies that data is a DID document for d
+func CheckDocument(d DID, data []byte) error {
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return errors.InvalidValue.Wrapf(err, "%s: DID document is not a JSON object", d)
+	}
+
+	if id, _ := doc["id"].(string); id != d.String() {
+		return errors.UnexpectedValue.Newf("%s: DID document has id '%v'", d, doc["id"])
+	}
+
+	// application/did+json DOCUMENTS MAY OMIT THE CONTEXT. IF PRESENT
+	// THE DID CONTEXT MUST COME FIRST
+	if context, ok := doc["@context"]; ok {
+		first := context
+		if contexts, ok := context.([]interface{}); ok && len(contexts) > 0 {
+			first = contexts[0]
+		}
+		if first != ContextV1 {
+			return errors.UnexpectedValue.Newf("%s: DID document @context must start with %s", d, ContextV1)
+		}
+	}
+
+	return nil
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
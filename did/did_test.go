@@ -0,0 +1,139 @@
+package did
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/multiformats/go-multibase"
+)
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		did      string
+		expected DID
+		valid    bool
+	}{
+		{"did:example:123456789abcdefghi", DID{"example", "123456789abcdefghi"}, true},
+		{"did:web:example.com%3A8443:user:alice#key-1", DID{"web", "example.com%3A8443:user:alice"}, true},
+		{"did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK/path?query", DID{"key", "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"}, true},
+		{"urn:uuid:1234", DID{}, false},
+		{"did:Web:example.com", DID{}, false},
+		{"did:web:", DID{}, false},
+		{"did:web:example.com:", DID{}, false},
+		{"did:web:exa mple.com", DID{}, false},
+		{"did:web:example.com%3", DID{}, false},
+	}
+
+	for _, test := range tests {
+		d, err := Parse(test.did)
+		if !test.valid {
+			if errors.GetType(err) != errors.InvalidValue {
+				t.Errorf("%s: expected InvalidValue error, got %v", test.did, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.did, err)
+		} else if d != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.did, test.expected, d)
+		}
+	}
+}
+
+func TestResolveKey(t *testing.T) {
+
+	// TEST VECTOR FROM THE did:key SPECIFICATION
+	const ed25519DID = "did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+
+	data, err := NewResolver(http.DefaultClient, 1<<20).Resolve(context.Background(), ed25519DID+"#z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc keyDocument
+	if err = json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	methodID := ed25519DID + "#z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+	if doc.ID != ed25519DID || len(doc.VerificationMethod) != 1 || doc.VerificationMethod[0].ID != methodID {
+		t.Errorf("unexpected DID document %s", data)
+	}
+	if len(doc.Authentication) != 1 || doc.Authentication[0] != methodID || len(doc.KeyAgreement) != 0 {
+		t.Errorf("expected ed25519 key to be an authentication key, got %s", data)
+	}
+
+	invalid := map[string]errors.ErrorType{
+		"did:key:6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK":                                                  errors.InvalidValue,   // NOT MULTIBASE
+		"did:key:2:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK":                                               errors.NotImplemented, // UNKNOWN VERSION
+		"did:key:z2J9gaYxrKVpdoG9A4gRnmpnRCcxU6agDtFVVBVdn1JedouoZN7SzcyREXXzWgt3gGiwpoHq7K68X4m32D8HgzG8wv3sY5j7": errors.NotImplemented, // RSA
+	}
+
+	// ED25519 KEY ONE BYTE SHORT
+	truncated, _ := multibase.Encode(multibase.Base58BTC, append([]byte{0xed, 0x01}, make([]byte, 31)...))
+	invalid["did:key:"+truncated] = errors.InvalidValue
+
+	for d, expected := range invalid {
+		if _, err := NewResolver(http.DefaultClient, 1<<20).Resolve(context.Background(), d); errors.GetType(err) != expected {
+			t.Errorf("%s: expected error type %d, got %v", d, expected, err)
+		}
+	}
+}
+
+func TestResolveWeb(t *testing.T) {
+
+	var host string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/did.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/did+ld+json")
+		w.Write([]byte(`{"@context": ["` + ContextV1 + `"], "id": "did:web:` + host + `"}`))
+	})
+	mux.HandleFunc("/users/alice/did.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "did:web:` + host + `:users:alice"}`))
+	})
+	mux.HandleFunc("/users/mallory/did.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "did:web:` + host + `:users:alice"}`))
+	})
+
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	host = strings.Replace(serverURL.Host, ":", "%3A", 1)
+
+	resolver := NewResolver(server.Client(), 1<<20)
+
+	for _, d := range []string{"did:web:" + host, "did:web:" + host + ":users:alice#key-1"} {
+		if _, err := resolver.Resolve(context.Background(), d); err != nil {
+			t.Errorf("%s: %s", d, err)
+		}
+	}
+
+	invalid := map[string]errors.ErrorType{
+		"did:web:" + host + ":users:mallory": errors.UnexpectedValue,
+		"did:web:" + host + ":users:bob":     errors.NotFound,
+		"did:example:123":                    errors.NotImplemented,
+	}
+	for d, expected := range invalid {
+		if _, err := resolver.Resolve(context.Background(), d); errors.GetType(err) != expected {
+			t.Errorf("%s: expected error type %d, got %v", d, expected, err)
+		}
+	}
+
+	if _, err := NewResolver(server.Client(), 16).Resolve(context.Background(), "did:web:"+host); errors.GetType(err) != errors.OutOfRange {
+		t.Errorf("expected OutOfRange error for DID document exceeding maximum size, got %v", err)
+	}
+
+	webURL, err := WebURL(DID{"web", "w3c-ccg.github.io:user:alice"})
+	if err != nil || webURL != "https://w3c-ccg.github.io/user/alice/did.json" {
+		t.Errorf("unexpected did:web URL %s (%v)", webURL, err)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package did
+
+import (
+	"encoding/json"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-varint"
+)
+
+// context of Multikey verification methods
+const ContextMultikey = "https://w3id.org/security/multikey/v1"
+
+// public key types that may be encoded in a did:key identifier
+type keyType struct {
+	name       string // multicodec name
+	size       int    // length of the raw public key
+	compressed bool   // compressed elliptic curve point
+	agreement  bool   // key agreement key (as opposed to a signing key)
+}
+
+// MULTICODEC CODE -> KEY TYPE
+var keyTypes = map[uint64]keyType{
+	0xed:   {name: "ed25519-pub", size: 32},
+	0xec:   {name: "x25519-pub", size: 32, agreement: true},
+	0xe7:   {name: "secp256k1-pub", size: 33, compressed: true},
+	0x1200: {name: "p256-pub", size: 33, compressed: true},
+	0x1201: {name: "p384-pub", size: 49, compressed: true},
+	0xeb:   {name: "bls12_381-g2-pub", size: 96},
+}
+
+// VerificationMethod is a public key in a DID document
+type VerificationMethod struct {
+	ID                 string `json:"id"`
+	Type               string `json:"type"`
+	Controller         string `json:"controller"`
+	PublicKeyMultibase string `json:"publicKeyMultibase"`
+}
+
+// keyDocument is the DID document of a did:key identifier
+type keyDocument struct {
+	Context              []string             `json:"@context"`
+	ID                   string               `json:"id"`
+	VerificationMethod   []VerificationMethod `json:"verificationMethod"`
+	Authentication       []string             `json:"authentication,omitempty"`
+	AssertionMethod      []string             `json:"assertionMethod,omitempty"`
+	CapabilityInvocation []string             `json:"capabilityInvocation,omitempty"`
+	CapabilityDelegation []string             `json:"capabilityDelegation,omitempty"`
+	KeyAgreement         []string             `json:"keyAgreement,omitempty"`
+}
+
+// ResolveKey returns the DID document of the did:key identifier d.
+// The document is derived from the public key encoded in d
+func ResolveKey(d DID) ([]byte, error) {
+
+	if d.Method != "key" {
+		return nil, errors.InvalidValue.Newf("%s: not a did:key identifier", d)
+	}
+
+	// did:key:[<version>:]<multibase-value>
+	fingerprint := d.ID
+	if version, value, ok := cutLast(d.ID); ok {
+		if version != "1" {
+			return nil, errors.NotImplemented.Newf("%s: did:key version %s is not supported", d, version)
+		}
+		fingerprint = value
+	}
+
+	if len(fingerprint) < 1 || fingerprint[0] != 'z' {
+		return nil, errors.InvalidValue.Newf("%s: did:key value must be base58btc multibase encoded", d)
+	}
+
+	_, data, err := multibase.Decode(fingerprint)
+	if err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "%s", d)
+	}
+
+	code, n, err := varint.FromUvarint(data)
+	if err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "%s: multicodec prefix", d)
+	}
+
+	kt, ok := keyTypes[code]
+	if !ok {
+		return nil, errors.NotImplemented.Newf("%s: unsupported public key type 0x%x", d, code)
+	}
+
+	key := data[n:]
+	if len(key) != kt.size {
+		return nil, errors.InvalidValue.Newf("%s: %s key is %d bytes long, expected %d",
+			d, kt.name, len(key), kt.size)
+	}
+	if kt.compressed && key[0] != 0x02 && key[0] != 0x03 {
+		return nil, errors.InvalidValue.Newf("%s: %s key is not a compressed point", d, kt.name)
+	}
+
+	id := d.String()
+	methodID := id + "#" + fingerprint
+
+	doc := keyDocument{
+		Context: []string{ContextV1, ContextMultikey},
+		ID:      id,
+		VerificationMethod: []VerificationMethod{
+			{ID: methodID, Type: "Multikey", Controller: id, PublicKeyMultibase: fingerprint},
+		},
+	}
+
+	if kt.agreement {
+		doc.KeyAgreement = []string{methodID}
+	} else {
+		doc.Authentication = []string{methodID}
+		doc.AssertionMethod = []string{methodID}
+		doc.CapabilityInvocation = []string{methodID}
+		doc.CapabilityDelegation = []string{methodID}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// cutLast splits s around its last ':'
+func cutLast(s string) (string, string, bool) {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == ':' {
+			return s[:i], s[i+1:], true
+		}
+	}
+	return "", s, false
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package did
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// WebURL returns the HTTPS URL of the DID document of the did:web
+// identifier d
+func WebURL(d DID) (string, error) {
+
+	if d.Method != "web" {
+		return "", errors.InvalidValue.Newf("%s: not a did:web identifier", d)
+	}
+
+	segments := strings.Split(d.ID, ":")
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil || len(decoded) < 1 || strings.Contains(decoded, "/") {
+			return "", errors.InvalidValue.Newf("%s: invalid did:web path segment '%s'", d, segment)
+		}
+		segments[i] = decoded
+	}
+
+	// THE FIRST SEGMENT IS THE HOST, OPTIONALLY WITH A PERCENT ENCODED PORT
+	u := &url.URL{Scheme: "https", Host: segments[0]}
+	if _, err := url.Parse("https://" + u.Host); err != nil || len(u.Hostname()) < 1 {
+		return "", errors.InvalidValue.Newf("%s: invalid did:web host '%s'", d, segments[0])
+	}
+
+	if len(segments) == 1 {
+		u.Path = "/.well-known/did.json"
+	} else {
+		u.Path = "/" + strings.Join(segments[1:], "/") + "/did.json"
+	}
+
+	return u.String(), nil
+}
+
+// Resolver resolves DIDs to DID documents
+type Resolver struct {
+	httpClient      *http.Client
+	maxDocumentSize int64 // largest DID document accepted from a web server
+}
+
+// NewResolver returns a resolver that fetches did:web documents of at
+// most maxDocumentSize bytes with httpClient. The timeout and redirect
+// policy of httpClient apply to every request
+func NewResolver(httpClient *http.Client, maxDocumentSize int64) *Resolver {
+
+	return &Resolver{httpClient: httpClient, maxDocumentSize: maxDocumentSize}
+}
+
+// Resolve returns the DID document of the DID (or DID URL) s
+func (r *Resolver) Resolve(ctxt context.Context, s string) ([]byte, error) {
+
+	d, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+
+	switch d.Method {
+	case "key":
+		data, err = ResolveKey(d)
+	case "web":
+		data, err = r.resolveWeb(ctxt, d)
+	default:
+		return nil, errors.NotImplemented.Newf("%s: DID method '%s' is not supported", s, d.Method)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = CheckDocument(d, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (r *Resolver) resolveWeb(ctxt context.Context, d DID) ([]byte, error) {
+
+	webURL, err := WebURL(d)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctxt, http.MethodGet, webURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/did+ld+json, application/did+json, application/json")
+
+	res, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.ExternalError.Wrapf(err, "%s", d)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, errors.NotFound.Newf("%s: no DID document at %s", d, webURL)
+	case res.StatusCode != http.StatusOK:
+		return nil, errors.ExternalError.Newf("%s: %s returned %s", d, webURL, res.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, r.maxDocumentSize+1))
+	if err != nil {
+		return nil, errors.ExternalError.Wrapf(err, "%s", d)
+	}
+	if int64(len(data)) > r.maxDocumentSize {
+		return nil, errors.OutOfRange.Newf("%s: DID document exceeds %d bytes", d, r.maxDocumentSize)
+	}
+
+	return data, nil
+}
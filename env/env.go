@@ -29,6 +29,12 @@ const (
 	// SPECIFIES THE PERSISTENCE TYPE FOR PERSISTING STATE IN DOGG3RZ
 	// (CURRENTLY DEFAULTS TO 'file' IF NOT SET)
 	EnvDogg3rzStateStore = EnvDogg3rzPrefix + "STATE_STORE"
+	// WHEN 'true', REMOTE DOCUMENTS ARE ONLY SERVED FROM THE LOCAL OBJECT CACHE
+	// AS PINNED IN THE GRAPP'S CONTEXT LOCK FILE (OPTIONAL)
+	EnvDogg3rzOffline = EnvDogg3rzPrefix + "OFFLINE"
+	// WHEN 'true', REMOTE DOCUMENTS PINNED IN THE GRAPP'S CONTEXT LOCK FILE ARE
+	// REVALIDATED THROUGH THE HTTP CACHE AND RE-PINNED IF CHANGED (OPTIONAL)
+	EnvDogg3rzUpdateLock = EnvDogg3rzPrefix + "UPDATE_LOCK"
 )
 
 var (
@@ -44,6 +50,8 @@ var envNames = []string{
 	EnvDogg3rzGrapp,
 	EnvDogg3rzHome,
 	EnvDogg3rzStateStore,
+	EnvDogg3rzOffline,
+	EnvDogg3rzUpdateLock,
 }
 
 // InitContextFromEnv sets and returns  a new context initialized from
@@ -129,7 +129,7 @@ func (error badDogg3rz) Error() string {
 	}
 
 	if error.errorType == NoType {
-		fmt.Sprintf("%v", error.originalError)
+		return fmt.Sprintf("%v", error.originalError)
 	}
 
 	return fmt.Sprintf("%s: %v", errorTypeToString(error.errorType), error.originalError)
@@ -154,6 +154,10 @@ func (errType ErrorType) Wrap(err error, msg string) error {
 
 // Wrap creates a new wrapped error with formatted message
 func (errType ErrorType) Wrapf(err error, msg string, args ...interface{}) error {
+	if customErr, ok := err.(badDogg3rz); ok {
+		// DON'T REPEAT THE TYPE OF THE WRAPPED ERROR
+		err = customErr.originalError
+	}
 	newErr := errors.Wrapf(err, msg, args...)
 
 	return badDogg3rz{errorType: errType, originalError: newErr}
@@ -181,8 +185,9 @@ func Cause(err error) error {
 
 // Wrapf wraps an error with format string
 func Wrapf(err error, msg string, args ...interface{}) error {
-	wrappedError := errors.Wrapf(err, msg, args...)
 	if customErr, ok := err.(badDogg3rz); ok {
+		// KEEP THE TYPE BUT DON'T REPEAT IT IN THE MESSAGE
+		wrappedError := errors.Wrapf(customErr.originalError, msg, args...)
 		return badDogg3rz{
 			errorType:     customErr.errorType,
 			originalError: wrappedError,
@@ -190,7 +195,7 @@ func Wrapf(err error, msg string, args ...interface{}) error {
 		}
 	}
 
-	return badDogg3rz{errorType: NoType, originalError: wrappedError}
+	return badDogg3rz{errorType: NoType, originalError: errors.Wrapf(err, msg, args...)}
 }
 
 // AddErrorContext adds a context to an error
@@ -39,3 +39,22 @@ func TestNew(t *testing.T) {
 
 	}
 }
+
+func TestWrapf(t *testing.T) {
+
+	err := Wrapf(InvalidValue.New("bad value"), "%s", "e.jsonld")
+
+	if GetType(err) != InvalidValue {
+		t.Errorf("expected ErrorType %v, found %v", InvalidValue, GetType(err))
+	}
+
+	if expected := "InvalidValue: e.jsonld: bad value"; err.Error() != expected {
+		t.Errorf("expected error '%s', found '%s'", expected, err.Error())
+	}
+
+	err = OutOfRange.Wrapf(InvalidValue.New("bad value"), "%s", "e.jsonld")
+
+	if expected := "OutOfRange: e.jsonld: bad value"; err.Error() != expected {
+		t.Errorf("expected error '%s', found '%s'", expected, err.Error())
+	}
+}
@@ -0,0 +1,307 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package hdt
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// section is a plain front coded dictionary section: sorted strings in
+// blocks that start with a complete string followed by strings stored
+// as the length of the prefix shared with their predecessor and the
+// remaining suffix
+type section struct {
+	numStrings uint64
+	blockSize  uint64
+	blocks     *logArray // offset of each block in text
+	text       []byte
+}
+
+func newSection(sorted []string) *section {
+
+	s := &section{numStrings: uint64(len(sorted)), blockSize: blockSize}
+
+	var offsets []uint64
+	var text []byte
+	for i, str := range sorted {
+		if i%blockSize == 0 {
+			offsets = append(offsets, uint64(len(text)))
+			text = append(text, str...)
+		} else {
+			prev := sorted[i-1]
+			shared := 0
+			for shared < len(prev) && shared < len(str) && prev[shared] == str[shared] {
+				shared++
+			}
+			text = appendVByte(text, uint64(shared))
+			text = append(text, str[shared:]...)
+		}
+		text = append(text, 0)
+	}
+	offsets = append(offsets, uint64(len(text)))
+
+	s.blocks = newLogArray(offsets)
+	s.text = text
+
+	return s
+}
+
+func (s *section) appendTo(buf []byte) []byte {
+
+	start := len(buf)
+	buf = append(buf, typePFC)
+	buf = appendVByte(buf, s.numStrings)
+	buf = appendVByte(buf, uint64(len(s.text)))
+	buf = appendVByte(buf, s.blockSize)
+	buf = append(buf, crc8(buf[start:]))
+
+	buf = s.blocks.appendTo(buf)
+
+	return appendWords(buf, bytesToWords(s.text), uint64(len(s.text)))
+}
+
+func (d *decoder) readSection(what string) *section {
+
+	if typ := d.byte(); typ != typePFC && d.err == nil {
+		d.fail(errors.InvalidValue.Newf("%s: unsupported dictionary section type %d", what, typ))
+	}
+	s := &section{numStrings: d.vbyte()}
+	textLength := d.vbyte()
+	s.blockSize = d.vbyte()
+	d.checkCRC8(what)
+
+	s.blocks = d.readLogArray(what)
+	s.text = d.bytes(textLength)
+	d.checkCRC32(what)
+
+	if d.err != nil {
+		return s
+	}
+
+	if s.blockSize < 1 || s.blocks.length < (s.numStrings+s.blockSize-1)/s.blockSize {
+		d.fail(errors.InvalidValue.Newf("%s: invalid block index", what))
+		return s
+	}
+	// EVERY BLOCK MUST DECODE SO LOOKUPS CANNOT FAIL LATER
+	var prev string
+	for b := uint64(0); b*s.blockSize < s.numStrings; b++ {
+		ok := s.scanBlock(b, func(i uint64, str string) bool {
+			if i > 0 && str <= prev {
+				d.fail(errors.InvalidValue.Newf("%s: strings are not sorted", what))
+				return false
+			}
+			prev = str
+			return true
+		})
+		if !ok {
+			d.fail(errors.InvalidValue.Newf("%s: invalid block %d", what, b))
+		}
+		if d.err != nil {
+			return s
+		}
+	}
+
+	return s
+}
+
+// scanBlock calls fn with the strings of block b and their 0-based
+// positions in the section until fn returns false. It returns false if
+// the block is corrupt
+func (s *section) scanBlock(b uint64, fn func(i uint64, str string) bool) bool {
+
+	pos := s.blocks.get(b)
+	first := b * s.blockSize
+
+	var prev []byte
+	for i := first; i < s.numStrings && i < first+s.blockSize; i++ {
+		if pos > uint64(len(s.text)) {
+			return false
+		}
+		var shared uint64
+		if i > first {
+			var n int
+			if shared, n = readVByte(s.text[pos:]); n < 1 || shared > uint64(len(prev)) {
+				return false
+			}
+			pos += uint64(n)
+		}
+		end := bytes.IndexByte(s.text[pos:], 0)
+		if end < 0 {
+			return false
+		}
+		str := append(prev[:shared:shared], s.text[pos:pos+uint64(end)]...)
+		pos += uint64(end) + 1
+
+		if !fn(i, string(str)) {
+			break
+		}
+		prev = str
+	}
+
+	return true
+}
+
+// extract returns the string with 1-based id
+func (s *section) extract(id uint64) string {
+
+	var str string
+	s.scanBlock((id-1)/s.blockSize, func(i uint64, value string) bool {
+		str = value
+		return i < id-1
+	})
+
+	return str
+}
+
+// locate returns the 1-based id of str or 0 if the section does not
+// contain it
+func (s *section) locate(str string) uint64 {
+
+	numBlocks := (s.numStrings + s.blockSize - 1) / s.blockSize
+
+	// FIRST BLOCK STARTING AFTER STR
+	b := uint64(sort.Search(int(numBlocks), func(b int) bool {
+		pos := s.blocks.get(uint64(b))
+		end := bytes.IndexByte(s.text[pos:], 0)
+		return string(s.text[pos:pos+uint64(end)]) > str
+	}))
+	if b < 1 {
+		return 0
+	}
+
+	var id uint64
+	s.scanBlock(b-1, func(i uint64, value string) bool {
+		if value == str {
+			id = i + 1
+		}
+		return value < str
+	})
+
+	return id
+}
+
+// readVByte decodes a variable length integer from data. It returns
+// the number of bytes read or 0 if data does not start with one
+func readVByte(data []byte) (uint64, int) {
+
+	var v uint64
+	for i, b := range data {
+		if i > 9 {
+			break
+		}
+		v |= uint64(b&0x7F) << (7 * uint(i))
+		if b&0x80 != 0 {
+			return v, i + 1
+		}
+	}
+
+	return 0, 0
+}
+
+// dictionary maps the terms of an HDT file to identifiers. Terms that
+// are both subject and object are shared and numbered first in both roles
+type dictionary struct {
+	shared, subjects, predicates, objects *section
+}
+
+func (d *dictionary) numSubjects() uint64 {
+	return d.shared.numStrings + d.subjects.numStrings
+}
+
+func (d *dictionary) numObjects() uint64 {
+	return d.shared.numStrings + d.objects.numStrings
+}
+
+func (d *dictionary) subject(id uint64) string {
+
+	if id <= d.shared.numStrings {
+		return d.shared.extract(id)
+	}
+
+	return d.subjects.extract(id - d.shared.numStrings)
+}
+
+func (d *dictionary) object(id uint64) string {
+
+	if id <= d.shared.numStrings {
+		return d.shared.extract(id)
+	}
+
+	return d.objects.extract(id - d.shared.numStrings)
+}
+
+func (d *dictionary) subjectID(term string) uint64 {
+
+	if id := d.shared.locate(term); id > 0 {
+		return id
+	}
+	if id := d.subjects.locate(term); id > 0 {
+		return d.shared.numStrings + id
+	}
+
+	return 0
+}
+
+func (d *dictionary) objectID(term string) uint64 {
+
+	if id := d.shared.locate(term); id > 0 {
+		return id
+	}
+	if id := d.objects.locate(term); id > 0 {
+		return d.shared.numStrings + id
+	}
+
+	return 0
+}
+
+func (d *dictionary) appendTo(buf []byte) []byte {
+
+	numStrings := d.shared.numStrings + d.subjects.numStrings + d.predicates.numStrings + d.objects.numStrings
+	buf = controlInfo{typ: controlDictionary, format: formatDictionary,
+		properties: map[string]string{"mapping": "1", "elements": strconv.FormatUint(numStrings, 10)}}.appendTo(buf)
+
+	for _, s := range []*section{d.shared, d.subjects, d.predicates, d.objects} {
+		buf = s.appendTo(buf)
+	}
+
+	return buf
+}
+
+func (d *decoder) readDictionary() *dictionary {
+
+	if c := d.readControlInfo(controlDictionary); d.err == nil && c.format != formatDictionary {
+		d.fail(errors.InvalidValue.Newf("unsupported dictionary format %s", c.format))
+	}
+
+	return &dictionary{
+		shared:     d.readSection("shared dictionary section"),
+		subjects:   d.readSection("subject dictionary section"),
+		predicates: d.readSection("predicate dictionary section"),
+		objects:    d.readSection("object dictionary section"),
+	}
+}
+
+func bytesToWords(data []byte) []uint64 {
+
+	words := make([]uint64, (len(data)+7)/8)
+	for i, b := range data {
+		words[i/8] |= uint64(b) << (8 * (i % 8))
+	}
+
+	return words
+}
@@ -0,0 +1,462 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package hdt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// crc8 returns the CRC-8-CCITT checksum of data
+func crc8(data []byte) byte {
+
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// crc16 returns the CRC-16-ANSI checksum of data
+func crc16(data []byte) uint16 {
+
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// appendVByte appends v to buf in the variable length encoding of HDT:
+// 7 bits per byte, least significant first, with the high bit set on
+// the last byte
+func appendVByte(buf []byte, v uint64) []byte {
+
+	for v > 0x7F {
+		buf = append(buf, byte(v&0x7F))
+		v >>= 7
+	}
+
+	return append(buf, byte(v)|0x80)
+}
+
+// decoder reads the components of an HDT file
+type decoder struct {
+	r   *bufio.Reader
+	buf []byte // bytes read since the last checksum
+	err error  // first read error
+}
+
+func newDecoder(r io.Reader) *decoder {
+	return &decoder{r: bufio.NewReader(r)}
+}
+
+func (d *decoder) byte() byte {
+
+	if d.err != nil {
+		return 0
+	}
+
+	b, err := d.r.ReadByte()
+	if err != nil {
+		d.fail(err)
+		return 0
+	}
+	d.buf = append(d.buf, b)
+
+	return b
+}
+
+func (d *decoder) bytes(n uint64) []byte {
+
+	if d.err != nil {
+		return nil
+	}
+
+	// GROWS WITH THE DATA READ SO A CORRUPT LENGTH CANNOT EXHAUST MEMORY
+	if n > math.MaxInt64 {
+		d.fail(errors.InvalidValue.Newf("invalid length %d", n))
+		return nil
+	}
+	var data bytes.Buffer
+	if _, err := io.CopyN(&data, d.r, int64(n)); err != nil {
+		d.fail(err)
+		return nil
+	}
+	d.buf = append(d.buf, data.Bytes()...)
+
+	return data.Bytes()
+}
+
+func (d *decoder) vbyte() uint64 {
+
+	var v uint64
+	for shift := uint(0); d.err == nil; shift += 7 {
+		if shift > 63 {
+			d.fail(errors.InvalidValue.New("invalid variable length integer"))
+			break
+		}
+		b := d.byte()
+		v |= uint64(b&0x7F) << shift
+		if b&0x80 != 0 {
+			break
+		}
+	}
+
+	return v
+}
+
+// cstring reads a NUL terminated string
+func (d *decoder) cstring() string {
+
+	var s []byte
+	for b := d.byte(); d.err == nil && b != 0; b = d.byte() {
+		s = append(s, b)
+	}
+
+	return string(s)
+}
+
+// reset starts the bytes covered by the next checksum
+func (d *decoder) reset() {
+	d.buf = d.buf[:0]
+}
+
+func (d *decoder) checkCRC8(what string) {
+
+	crc := crc8(d.buf)
+	if d.byte() != crc && d.err == nil {
+		d.fail(errors.UnexpectedValue.Newf("%s: checksum mismatch", what))
+	}
+	d.reset()
+}
+
+func (d *decoder) checkCRC16(what string) {
+
+	crc := crc16(d.buf)
+	lo, hi := d.byte(), d.byte()
+	if uint16(lo)|uint16(hi)<<8 != crc && d.err == nil {
+		d.fail(errors.UnexpectedValue.Newf("%s: checksum mismatch", what))
+	}
+	d.reset()
+}
+
+func (d *decoder) checkCRC32(what string) {
+
+	crc := crc32.Checksum(d.buf, castagnoli)
+	data := d.bytes(4)
+	if d.err == nil && binary.LittleEndian.Uint32(data) != crc {
+		d.fail(errors.UnexpectedValue.Newf("%s: checksum mismatch", what))
+	}
+	d.reset()
+}
+
+func (d *decoder) fail(err error) {
+
+	if d.err != nil {
+		return
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	d.err = err
+}
+
+// controlInfo precedes each component of an HDT file
+type controlInfo struct {
+	typ        byte
+	format     string
+	properties map[string]string
+}
+
+func (c controlInfo) appendTo(buf []byte) []byte {
+
+	start := len(buf)
+	buf = append(buf, cookie...)
+	buf = append(buf, c.typ)
+	buf = append(buf, c.format...)
+	buf = append(buf, 0)
+
+	keys := make([]string, 0, len(c.properties))
+	for key := range c.properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		buf = append(buf, key+"="+c.properties[key]+";"...)
+	}
+	buf = append(buf, 0)
+
+	return binary.LittleEndian.AppendUint16(buf, crc16(buf[start:]))
+}
+
+// readControlInfo reads control information of type typ
+func (d *decoder) readControlInfo(typ byte) controlInfo {
+
+	c := controlInfo{properties: make(map[string]string)}
+
+	d.reset()
+	if string(d.bytes(uint64(len(cookie)))) != cookie && d.err == nil {
+		d.fail(errors.InvalidValue.New("not an HDT file: missing control information"))
+	}
+	c.typ = d.byte()
+	c.format = d.cstring()
+	properties := d.cstring()
+	d.checkCRC16("control information")
+
+	if d.err == nil && c.typ != typ {
+		d.fail(errors.InvalidValue.Newf("unexpected control information type %d, expected %d", c.typ, typ))
+	}
+	for _, property := range strings.Split(properties, ";") {
+		if key, value, ok := strings.Cut(property, "="); ok {
+			c.properties[key] = value
+		}
+	}
+
+	return c
+}
+
+// intProperty returns the integer value of property key
+func (c controlInfo) intProperty(key string) (uint64, error) {
+
+	v, err := strconv.ParseUint(c.properties[key], 10, 64)
+	if err != nil {
+		return 0, errors.InvalidValue.Newf("invalid %s property '%s'", key, c.properties[key])
+	}
+
+	return v, nil
+}
+
+// logArray is a sequence of unsigned integers of a fixed number of bits
+type logArray struct {
+	numBits uint
+	length  uint64
+	words   []uint64
+}
+
+func newLogArray(values []uint64) *logArray {
+
+	var max uint64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	numBits := uint(bits.Len64(max))
+	if numBits < 1 {
+		numBits = 1
+	}
+
+	a := &logArray{numBits: numBits, length: uint64(len(values)),
+		words: make([]uint64, (uint64(len(values))*uint64(numBits)+63)/64)}
+	for i, v := range values {
+		a.set(uint64(i), v)
+	}
+
+	return a
+}
+
+func (a *logArray) set(i uint64, v uint64) {
+
+	bit := i * uint64(a.numBits)
+	word, offset := bit/64, bit%64
+	a.words[word] |= v << offset
+	if offset+uint64(a.numBits) > 64 {
+		a.words[word+1] |= v >> (64 - offset)
+	}
+}
+
+func (a *logArray) get(i uint64) uint64 {
+
+	if a.numBits == 0 {
+		return 0
+	}
+
+	bit := i * uint64(a.numBits)
+	word, offset := bit/64, bit%64
+	v := a.words[word] >> offset
+	if offset+uint64(a.numBits) > 64 {
+		v |= a.words[word+1] << (64 - offset)
+	}
+	if a.numBits < 64 {
+		v &= 1<<a.numBits - 1
+	}
+
+	return v
+}
+
+func (a *logArray) appendTo(buf []byte) []byte {
+
+	start := len(buf)
+	buf = append(buf, typeLogArray, byte(a.numBits))
+	buf = appendVByte(buf, a.length)
+	buf = append(buf, crc8(buf[start:]))
+
+	return appendWords(buf, a.words, (a.length*uint64(a.numBits)+7)/8)
+}
+
+func (d *decoder) readLogArray(what string) *logArray {
+
+	if typ := d.byte(); typ != typeLogArray && d.err == nil {
+		d.fail(errors.InvalidValue.Newf("%s: unsupported sequence type %d", what, typ))
+	}
+	a := &logArray{numBits: uint(d.byte())}
+	a.length = d.vbyte()
+	d.checkCRC8(what)
+
+	if a.numBits > 64 && d.err == nil {
+		d.fail(errors.InvalidValue.Newf("%s: invalid number of bits %d", what, a.numBits))
+	}
+	if d.err != nil {
+		return a
+	}
+	a.words = d.words(what, (a.length*uint64(a.numBits)+7)/8)
+
+	return a
+}
+
+// bitmap is a sequence of bits that can be searched for the position
+// of the n-th set bit
+type bitmap struct {
+	length uint64
+	words  []uint64
+	ranks  []uint64 // number of set bits preceding each word
+}
+
+func newBitmap(length uint64) *bitmap {
+	return &bitmap{length: length, words: make([]uint64, (length+63)/64)}
+}
+
+func (b *bitmap) set(i uint64) {
+	b.words[i/64] |= 1 << (i % 64)
+}
+
+func (b *bitmap) get(i uint64) bool {
+	return b.words[i/64]&(1<<(i%64)) != 0
+}
+
+// index computes the ranks of the words. It returns the number of set bits
+func (b *bitmap) index() uint64 {
+
+	b.ranks = make([]uint64, len(b.words))
+
+	var count uint64
+	for i, word := range b.words {
+		b.ranks[i] = count
+		count += uint64(bits.OnesCount64(word))
+	}
+
+	return count
+}
+
+// select1 returns the position of the n-th (1-based) set bit
+func (b *bitmap) select1(n uint64) uint64 {
+
+	// LAST WORD WITH FEWER THAN N PRECEDING SET BITS
+	w := sort.Search(len(b.ranks), func(i int) bool { return b.ranks[i] >= n }) - 1
+	word := b.words[w]
+	for remaining := n - b.ranks[w]; remaining > 1; remaining-- {
+		word &= word - 1
+	}
+
+	return uint64(w)*64 + uint64(bits.TrailingZeros64(word))
+}
+
+func (b *bitmap) appendTo(buf []byte) []byte {
+
+	start := len(buf)
+	buf = append(buf, typeBitmap)
+	buf = appendVByte(buf, b.length)
+	buf = append(buf, crc8(buf[start:]))
+
+	return appendWords(buf, b.words, (b.length+7)/8)
+}
+
+func (d *decoder) readBitmap(what string) *bitmap {
+
+	if typ := d.byte(); typ != typeBitmap && d.err == nil {
+		d.fail(errors.InvalidValue.Newf("%s: unsupported bitmap type %d", what, typ))
+	}
+	b := &bitmap{length: d.vbyte()}
+	d.checkCRC8(what)
+	if d.err != nil {
+		return b
+	}
+	b.words = d.words(what, (b.length+7)/8)
+	if d.err == nil && uint64(len(b.words))*64 > b.length && b.words[len(b.words)-1]>>(b.length%64) != 0 {
+		d.fail(errors.InvalidValue.Newf("%s: bits set past the end of the bitmap", what))
+	}
+
+	return b
+}
+
+// appendWords appends the first n bytes of words in little endian order
+// followed by their CRC-32C checksum
+func appendWords(buf []byte, words []uint64, n uint64) []byte {
+
+	start := len(buf)
+	for _, word := range words {
+		buf = binary.LittleEndian.AppendUint64(buf, word)
+	}
+	buf = buf[:start+int(n)]
+
+	return binary.LittleEndian.AppendUint32(buf, crc32.Checksum(buf[start:], castagnoli))
+}
+
+// words reads n bytes of little endian words and their checksum
+func (d *decoder) words(what string, n uint64) []uint64 {
+
+	data := d.bytes(n)
+	d.checkCRC32(what)
+	if d.err != nil {
+		return nil
+	}
+
+	words := make([]uint64, (n+7)/8)
+	for i := range words {
+		var word [8]byte
+		copy(word[:], data[i*8:])
+		words[i] = binary.LittleEndian.Uint64(word[:])
+	}
+
+	return words
+}
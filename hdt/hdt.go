@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package hdt stores RDF triples in HDT (Header-Dictionary-Triples)
+// files (https://www.rdfhdt.org/hdt-binary-format/): a compact, read
+// only layout in which every term is stored once in a front coded
+// dictionary and the triples are bit packed term identifiers indexed
+// by subject. Files can be searched by triple pattern without
+// decompressing them
+package hdt
+
+// magic number of control information
+const cookie = "$HDT"
+
+// control information types
+const (
+	controlGlobal     = 1
+	controlHeader     = 2
+	controlDictionary = 3
+	controlTriples    = 4
+)
+
+// formats of the components of an HDT file
+const (
+	formatHDT        = "<http://purl.org/HDT/hdt#HDTv1>"
+	formatHeader     = "ntriples"
+	formatDictionary = "<http://purl.org/HDT/hdt#dictionaryFour>"
+	formatTriples    = "<http://purl.org/HDT/hdt#triplesBitmap>"
+)
+
+// types of the sequences, bitmaps and dictionary sections
+const (
+	typeLogArray = 1
+	typeBitmap   = 1
+	typePFC      = 2
+)
+
+// order of the triples (subject, predicate, object)
+const orderSPO = 1
+
+// number of strings in a front coded dictionary block
+const blockSize = 16
+
+// vocabularies of the header
+const (
+	hdtNS  = "http://purl.org/HDT/hdt#"
+	voidNS = "http://rdfs.org/ns/void#"
+)
+
+// Triple is a statement stored in an HDT file. Terms are in the form
+// of the HDT dictionary: IRIs without angle brackets, blank nodes
+// labelled "_:..." and literals in quotes followed by an optional
+// "@language" or "^^<datatype>" suffix. Quoted lexical forms are not
+// escaped
+type Triple struct {
+	Subject   string
+	Predicate string
+	Object    string
+}
@@ -0,0 +1,172 @@
+package hdt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+func testTriples(n int) []Triple {
+
+	triples := make([]Triple, n)
+	for i := range triples {
+		triples[i] = Triple{
+			Subject:   fmt.Sprintf("http://example.org/s%d", i/6),
+			Predicate: fmt.Sprintf("http://example.org/p%d", i%3),
+			Object:    fmt.Sprintf(`"value %d"@en`, i),
+		}
+		if i%4 == 0 {
+			// SUBJECTS THAT ARE ALSO OBJECTS ARE SHARED
+			triples[i].Object = fmt.Sprintf("http://example.org/s%d", i/5)
+		}
+		if i%7 == 0 {
+			triples[i].Subject = fmt.Sprintf("_:b%d", i)
+		}
+	}
+
+	return triples
+}
+
+func writeTestFile(t *testing.T, triples []Triple) []byte {
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WriterOptions{BaseIRI: "http://example.org/dataset"})
+	for _, triple := range triples {
+		if err := w.Write(triple); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func searchAll(t *testing.T, doc *Document, s, p, o string) []Triple {
+
+	var found []Triple
+	it := doc.Search(s, p, o)
+	for {
+		triple, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		found = append(found, triple)
+	}
+
+	return found
+}
+
+func sortTriples(triples []Triple) []Triple {
+
+	sorted := append([]Triple(nil), triples...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Subject+" "+sorted[i].Predicate+" "+sorted[i].Object <
+			sorted[j].Subject+" "+sorted[j].Predicate+" "+sorted[j].Object
+	})
+
+	return sorted
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	triples := testTriples(500)
+	// DUPLICATES ARE STORED ONCE
+	data := writeTestFile(t, append(triples, triples[:10]...))
+
+	doc, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.NumTriples() != len(triples) {
+		t.Errorf("expected %d triples, got %d", len(triples), doc.NumTriples())
+	}
+	if !strings.Contains(doc.Header(), `<http://example.org/dataset> <http://rdfs.org/ns/void#triples> "500"`) {
+		t.Errorf("expected header to describe the dataset, got\n%s", doc.Header())
+	}
+
+	all := sortTriples(searchAll(t, doc, "", "", ""))
+	if expected := sortTriples(triples); !reflect.DeepEqual(all, expected) {
+		t.Errorf("expected all triples, got %v", all)
+	}
+
+	for _, pattern := range []Triple{
+		{Subject: "http://example.org/s3"},
+		{Subject: "http://example.org/s3", Predicate: "http://example.org/p1"},
+		{Predicate: "http://example.org/p2"},
+		{Object: "http://example.org/s4"},
+		{Subject: "_:b14", Object: "http://example.org/s2"},
+		{Subject: "http://example.org/s0"},
+		{Subject: "http://example.org/s83"},
+		{Subject: "http://example.org/missing"},
+		{Predicate: "http://example.org/missing"},
+	} {
+		var expected []Triple
+		for _, triple := range all {
+			if (pattern.Subject == "" || pattern.Subject == triple.Subject) &&
+				(pattern.Predicate == "" || pattern.Predicate == triple.Predicate) &&
+				(pattern.Object == "" || pattern.Object == triple.Object) {
+				expected = append(expected, triple)
+			}
+		}
+		if found := sortTriples(searchAll(t, doc, pattern.Subject, pattern.Predicate, pattern.Object)); !reflect.DeepEqual(found, expected) {
+			t.Errorf("%v: expected %v, got %v", pattern, expected, found)
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+
+	doc, err := Read(bytes.NewReader(writeTestFile(t, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.NumTriples() != 0 || len(searchAll(t, doc, "", "", "")) != 0 {
+		t.Errorf("expected no triples")
+	}
+}
+
+func TestChecksums(t *testing.T) {
+
+	if crc := crc8([]byte("123456789")); crc != 0xF4 {
+		t.Errorf("expected CRC-8 0xF4, got %#x", crc)
+	}
+	if crc := crc16([]byte("123456789")); crc != 0xBB3D {
+		t.Errorf("expected CRC-16 0xBB3D, got %#x", crc)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+
+	data := writeTestFile(t, testTriples(50))
+
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{"not HDT", []byte("PAR1....")},
+		{"truncated", data[:len(data)/2]},
+		{"corrupt", append(append([]byte(nil), data[:len(data)-40]...), append([]byte{data[len(data)-40] ^ 0xFF}, data[len(data)-39:]...)...)},
+	} {
+		if _, err := Read(bytes.NewReader(test.data)); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		} else if test.name == "corrupt" && errors.GetType(err) != errors.UnexpectedValue {
+			t.Errorf("%s: expected checksum mismatch, got %v", test.name, err)
+		}
+	}
+
+	w := NewWriter(io.Discard, WriterOptions{})
+	if err := w.Write(Triple{Subject: "http://example.org/s", Predicate: "http://example.org/p"}); errors.GetType(err) != errors.InvalidValue {
+		t.Errorf("expected InvalidValue for a triple without object, got %v", err)
+	}
+}
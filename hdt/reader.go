@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package hdt
+
+import (
+	"io"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// Document is an HDT file loaded for searching. The dictionary and the
+// triples stay in their compact form
+type Document struct {
+	header  string
+	dict    *dictionary
+	bitmapY *bitmap
+	bitmapZ *bitmap
+	seqY    *logArray
+	seqZ    *logArray
+}
+
+// Read loads the HDT file from r. Only files with a four section
+// front coded dictionary and bitmap triples in subject, predicate,
+// object order are supported
+func Read(r io.Reader) (*Document, error) {
+
+	d := newDecoder(r)
+
+	if c := d.readControlInfo(controlGlobal); d.err == nil && c.format != formatHDT {
+		return nil, errors.InvalidValue.Newf("unsupported HDT format %s", c.format)
+	}
+
+	c := d.readControlInfo(controlHeader)
+	if d.err != nil {
+		return nil, d.err
+	}
+	length, err := c.intProperty("length")
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{header: string(d.bytes(length))}
+
+	doc.dict = d.readDictionary()
+
+	c = d.readControlInfo(controlTriples)
+	if d.err != nil {
+		return nil, d.err
+	}
+	if c.format != formatTriples {
+		return nil, errors.InvalidValue.Newf("unsupported triples format %s", c.format)
+	}
+	if order, err := c.intProperty("order"); err != nil || order != orderSPO {
+		return nil, errors.InvalidValue.Newf("unsupported triple order '%s'", c.properties["order"])
+	}
+
+	doc.bitmapY = d.readBitmap("bitmap Y")
+	doc.bitmapZ = d.readBitmap("bitmap Z")
+	doc.seqY = d.readLogArray("sequence Y")
+	doc.seqZ = d.readLogArray("sequence Z")
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	if err := doc.validate(); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// validate verifies that the triples are consistent with the
+// dictionary so searches cannot fail
+func (doc *Document) validate() error {
+
+	if doc.seqY.length != doc.bitmapY.length || doc.seqZ.length != doc.bitmapZ.length {
+		return errors.InvalidValue.New("sequence and bitmap lengths differ")
+	}
+	if n := doc.bitmapY.index(); n != doc.dict.numSubjects() || n > 0 && !doc.bitmapY.get(doc.bitmapY.length-1) {
+		return errors.InvalidValue.Newf("bitmap Y marks %d subjects, dictionary has %d", n, doc.dict.numSubjects())
+	}
+	if n := doc.bitmapZ.index(); n != doc.seqY.length || n > 0 && !doc.bitmapZ.get(doc.bitmapZ.length-1) {
+		return errors.InvalidValue.Newf("bitmap Z marks %d subject predicate pairs, sequence Y has %d", n, doc.seqY.length)
+	}
+	for i := uint64(0); i < doc.seqY.length; i++ {
+		if p := doc.seqY.get(i); p < 1 || p > doc.dict.predicates.numStrings {
+			return errors.InvalidValue.Newf("invalid predicate identifier %d", p)
+		}
+	}
+	for i := uint64(0); i < doc.seqZ.length; i++ {
+		if o := doc.seqZ.get(i); o < 1 || o > doc.dict.numObjects() {
+			return errors.InvalidValue.Newf("invalid object identifier %d", o)
+		}
+	}
+
+	return nil
+}
+
+// Header returns the N-Triples description of the dataset
+func (doc *Document) Header() string {
+	return doc.header
+}
+
+// NumTriples returns the number of triples in the file
+func (doc *Document) NumTriples() int {
+	return int(doc.seqZ.length)
+}
+
+// Search returns the triples matching the pattern of subject,
+// predicate and object terms in which empty terms match any term.
+// Triples are grouped by subject and predicate. Patterns
+// with a subject only scan the triples of that subject, other patterns
+// scan all triples
+func (doc *Document) Search(subject string, predicate string, object string) *Iterator {
+
+	it := &Iterator{doc: doc, x: 1, end: doc.seqZ.length}
+
+	if len(predicate) > 0 {
+		if it.predicate = doc.dict.predicates.locate(predicate); it.predicate < 1 {
+			it.end = 0
+		}
+	}
+	if len(object) > 0 {
+		if it.object = doc.dict.objectID(object); it.object < 1 {
+			it.end = 0
+		}
+	}
+	if len(subject) > 0 {
+		x := doc.dict.subjectID(subject)
+		if x < 1 {
+			it.end = 0
+			return it
+		}
+		// FIRST AND LAST PREDICATE OF THE SUBJECT
+		if x > 1 {
+			it.y = doc.bitmapY.select1(x-1) + 1
+		}
+		lastY := doc.bitmapY.select1(x)
+		// FIRST AND LAST OBJECT OF THOSE PREDICATES
+		if it.y > 0 {
+			it.z = doc.bitmapZ.select1(it.y) + 1
+		}
+		if end := doc.bitmapZ.select1(lastY+1) + 1; end < it.end {
+			it.end = end
+		}
+		it.x = x
+	}
+
+	return it
+}
+
+// Iterator returns the triples matching a search pattern
+type Iterator struct {
+	doc       *Document
+	predicate uint64 // 0 matches any predicate
+	object    uint64 // 0 matches any object
+	x, y, z   uint64 // subject identifier and positions in sequence Y and Z of the next triple
+	end       uint64 // position in sequence Z past the last triple
+}
+
+// Next returns the next matching triple or io.EOF when there are no more
+func (it *Iterator) Next() (Triple, error) {
+
+	doc := it.doc
+
+	for it.z < it.end {
+		x, p, o := it.x, doc.seqY.get(it.y), doc.seqZ.get(it.z)
+
+		// ADVANCE TO THE NEXT TRIPLE
+		if doc.bitmapZ.get(it.z) {
+			if doc.bitmapY.get(it.y) {
+				it.x++
+			}
+			it.y++
+		}
+		it.z++
+
+		if it.predicate > 0 && p != it.predicate || it.object > 0 && o != it.object {
+			continue
+		}
+
+		return Triple{
+			Subject:   doc.dict.subject(x),
+			Predicate: doc.dict.predicates.extract(p),
+			Object:    doc.dict.object(o),
+		}, nil
+	}
+
+	return Triple{}, io.EOF
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package hdt
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// WriterOptions controls the content of the files a Writer produces
+type WriterOptions struct {
+	BaseIRI string // IRI of the dataset described by the header (default: a blank node)
+}
+
+// Writer writes triples to an HDT file. Triples are buffered because
+// the dictionary and the triple index depend on all of them. Close must
+// be called to write the file
+type Writer struct {
+	w       io.Writer
+	options WriterOptions
+	triples []Triple
+	closed  bool
+}
+
+func NewWriter(w io.Writer, options WriterOptions) *Writer {
+	return &Writer{w: w, options: options}
+}
+
+// Write adds triple t to the file. Duplicate triples are stored once
+func (w *Writer) Write(t Triple) error {
+
+	if w.closed {
+		return errors.InvalidState.New("HDT writer is closed")
+	}
+	for _, term := range []string{t.Subject, t.Predicate, t.Object} {
+		if len(term) < 1 || strings.IndexByte(term, 0) >= 0 {
+			return errors.InvalidValue.Newf("invalid HDT term '%s'", term)
+		}
+	}
+
+	w.triples = append(w.triples, t)
+
+	return nil
+}
+
+// Close writes the file. The underlying writer is not closed
+func (w *Writer) Close() error {
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	dict, ids := w.dictionary()
+
+	// ORDER BY SUBJECT, PREDICATE AND OBJECT
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := ids[i], ids[j]
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		if a[1] != b[1] {
+			return a[1] < b[1]
+		}
+		return a[2] < b[2]
+	})
+	distinct := ids[:0]
+	for i, t := range ids {
+		if i == 0 || t != ids[i-1] {
+			distinct = append(distinct, t)
+		}
+	}
+	ids = distinct
+
+	buf := controlInfo{typ: controlGlobal, format: formatHDT}.appendTo(nil)
+
+	header := w.header(dict, uint64(len(ids)))
+	buf = controlInfo{typ: controlHeader, format: formatHeader,
+		properties: map[string]string{"length": strconv.Itoa(len(header))}}.appendTo(buf)
+	buf = append(buf, header...)
+
+	buf = dict.appendTo(buf)
+	buf = appendTriples(buf, ids)
+
+	_, err := w.w.Write(buf)
+
+	return err
+}
+
+// dictionary returns the dictionary of the terms of the triples and the
+// triples as identifiers
+func (w *Writer) dictionary() (*dictionary, [][3]uint64) {
+
+	subjects := make(map[string]uint64)
+	predicates := make(map[string]uint64)
+	objects := make(map[string]uint64)
+	for _, t := range w.triples {
+		subjects[t.Subject] = 0
+		predicates[t.Predicate] = 0
+		objects[t.Object] = 0
+	}
+
+	var shared, subjectOnly, objectOnly []string
+	for term := range subjects {
+		if _, ok := objects[term]; ok {
+			shared = append(shared, term)
+		} else {
+			subjectOnly = append(subjectOnly, term)
+		}
+	}
+	for term := range objects {
+		if _, ok := subjects[term]; !ok {
+			objectOnly = append(objectOnly, term)
+		}
+	}
+
+	// number returns the sorted terms after assigning identifiers from first
+	number := func(terms []string, ids map[string]uint64, first uint64) []string {
+		sort.Strings(terms)
+		for i, term := range terms {
+			ids[term] = first + uint64(i)
+		}
+		return terms
+	}
+	number(shared, subjects, 1)
+	number(shared, objects, 1)
+	number(subjectOnly, subjects, uint64(len(shared))+1)
+	number(objectOnly, objects, uint64(len(shared))+1)
+
+	predicateTerms := make([]string, 0, len(predicates))
+	for term := range predicates {
+		predicateTerms = append(predicateTerms, term)
+	}
+	number(predicateTerms, predicates, 1)
+
+	ids := make([][3]uint64, len(w.triples))
+	for i, t := range w.triples {
+		ids[i] = [3]uint64{subjects[t.Subject], predicates[t.Predicate], objects[t.Object]}
+	}
+
+	return &dictionary{
+		shared:     newSection(shared),
+		subjects:   newSection(subjectOnly),
+		predicates: newSection(predicateTerms),
+		objects:    newSection(objectOnly),
+	}, ids
+}
+
+// header returns the N-Triples description of the dataset
+func (w *Writer) header(dict *dictionary, numTriples uint64) string {
+
+	dataset := "_:dataset"
+	if len(w.options.BaseIRI) > 0 {
+		dataset = "<" + w.options.BaseIRI + ">"
+	}
+
+	var sb strings.Builder
+	statement := func(predicate string, object string) {
+		sb.WriteString(dataset + " <" + predicate + "> " + object + " .\n")
+	}
+	count := func(n uint64) string {
+		return `"` + strconv.FormatUint(n, 10) + `"^^<http://www.w3.org/2001/XMLSchema#integer>`
+	}
+
+	statement("http://www.w3.org/1999/02/22-rdf-syntax-ns#type", "<"+hdtNS+"Dataset>")
+	statement("http://www.w3.org/1999/02/22-rdf-syntax-ns#type", "<"+voidNS+"Dataset>")
+	statement(voidNS+"triples", count(numTriples))
+	statement(voidNS+"properties", count(dict.predicates.numStrings))
+	statement(voidNS+"distinctSubjects", count(dict.numSubjects()))
+	statement(voidNS+"distinctObjects", count(dict.numObjects()))
+
+	return sb.String()
+}
+
+// appendTriples appends the bitmap triples of the sorted identifier
+// triples ids: the predicates of each subject in sequence Y with the
+// last one of each subject marked in bitmap Y, and the objects of each
+// subject and predicate in sequence Z with the last one marked in bitmap Z
+func appendTriples(buf []byte, ids [][3]uint64) []byte {
+
+	var seqY, seqZ []uint64
+	var lastY, lastZ []uint64 // positions of the set bits
+
+	for i, t := range ids {
+		if i == 0 || t[0] != ids[i-1][0] || t[1] != ids[i-1][1] {
+			seqY = append(seqY, t[1])
+		}
+		seqZ = append(seqZ, t[2])
+		if i == len(ids)-1 || t[0] != ids[i+1][0] {
+			lastY = append(lastY, uint64(len(seqY)-1))
+		}
+		if i == len(ids)-1 || t[0] != ids[i+1][0] || t[1] != ids[i+1][1] {
+			lastZ = append(lastZ, uint64(i))
+		}
+	}
+
+	bitmapY, bitmapZ := newBitmap(uint64(len(seqY))), newBitmap(uint64(len(seqZ)))
+	for _, i := range lastY {
+		bitmapY.set(i)
+	}
+	for _, i := range lastZ {
+		bitmapZ.set(i)
+	}
+
+	buf = controlInfo{typ: controlTriples, format: formatTriples,
+		properties: map[string]string{"order": strconv.Itoa(orderSPO)}}.appendTo(buf)
+	buf = bitmapY.appendTo(buf)
+	buf = bitmapZ.appendTo(buf)
+	buf = newLogArray(seqY).appendTo(buf)
+
+	return newLogArray(seqZ).appendTo(buf)
+}
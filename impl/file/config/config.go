@@ -89,9 +89,107 @@ func configPath(ctxt context.Context) string {
 
 }
 
+// GetGrappConfig returns the configuration declared by the grapp in
+// grappDir. An empty configuration is returned if the grapp declares none
+func GetGrappConfig(grappDir string) (*resourceconfig.GrappConfig, error) {
+
+	grappCfg := &resourceconfig.GrappConfig{}
+
+	grappConfigPath := path.Join(grappDir, file.DgrzDirName, configFileName)
+	if !file.FileExists(grappConfigPath) {
+		return grappCfg, nil
+	}
+
+	if err := validateConfigSchema(resourceconfig.GRAPP_CONFIG_JSON_SCHEMA, grappConfigPath); err != nil {
+		return nil, err
+	}
+
+	byteValue, err := os.ReadFile(grappConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(byteValue, grappCfg); err != nil {
+		return nil, err
+	}
+
+	return grappCfg, nil
+
+}
+
+// GetDocumentLoaderConfig returns the document loader configuration
+// for the grapp in grappDir merged with the user's configuration.
+// Settings declared by the grapp take precedence, except for allowed
+// file paths which are taken from the user's configuration only
+func GetDocumentLoaderConfig(ctxt context.Context, grappDir string) (*resourceconfig.DocumentLoaderConfig, error) {
+
+	grappCfg, err := GetGrappConfig(grappDir)
+	if err != nil {
+		return nil, err
+	}
+
+	loaderCfg := grappCfg.DocumentLoader
+
+	// A GRAPP CAN'T WIDEN THE FILES ITS DOCUMENTS MAY LOAD. ONLY THE USER CAN
+	loaderCfg.Limits.AllowFilePaths = nil
+
+	// USER CONFIG IS OPTIONAL HERE
+	if file.FileExists(configPath(ctxt)) {
+		userCfg, err := (&FileConfigResource{}).GetConfig(ctxt)
+		if err != nil {
+			return nil, err
+		}
+		loaderCfg.Rewrites = append(loaderCfg.Rewrites, userCfg.DocumentLoader.Rewrites...)
+		loaderCfg.Limits = loaderCfg.Limits.Merge(userCfg.DocumentLoader.Limits)
+
+		loaderCfg.IPFS.CarFiles = append(loaderCfg.IPFS.CarFiles, userCfg.DocumentLoader.IPFS.CarFiles...)
+		if len(loaderCfg.IPFS.ApiEndpoint) == 0 {
+			loaderCfg.IPFS.ApiEndpoint = userCfg.DocumentLoader.IPFS.ApiEndpoint
+		}
+		if len(loaderCfg.IPFS.ApiEndpoint) == 0 {
+			loaderCfg.IPFS.ApiEndpoint = userCfg.IPFS.ApiEndpoint
+		}
+		loaderCfg.IPFS.Deployment = userCfg.IPFS.Deployment
+
+		// DATA PRODUCTS DECLARED BY THE GRAPP TAKE PRECEDENCE
+		for name, dir := range userCfg.DocumentLoader.DataProducts {
+			if loaderCfg.DataProducts == nil {
+				loaderCfg.DataProducts = make(map[string]string)
+			}
+			if _, ok := loaderCfg.DataProducts[name]; !ok {
+				loaderCfg.DataProducts[name] = dir
+			}
+		}
+	}
+
+	return &loaderCfg, nil
+
+}
+
+// GetUserDocumentLoaderConfig returns the document loader configuration
+// of the user for use outside of a grapp. An empty configuration is
+// returned if the user has no configuration
+func GetUserDocumentLoaderConfig(ctxt context.Context) (*resourceconfig.DocumentLoaderConfig, error) {
+
+	if !file.FileExists(configPath(ctxt)) {
+		return &resourceconfig.DocumentLoaderConfig{}, nil
+	}
+
+	userCfg, err := (&FileConfigResource{}).GetConfig(ctxt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userCfg.DocumentLoader, nil
+}
+
 func validateConfig(path string) error {
+	return validateConfigSchema(resourceconfig.CONFIG_JSON_SCHEMA, path)
+}
+
+func validateConfigSchema(schema string, path string) error {
 
-	schemaLoader := gojsonschema.NewStringLoader(resourceconfig.CONFIG_JSON_SCHEMA)
+	schemaLoader := gojsonschema.NewStringLoader(schema)
 
 	absPath, err := filepath.Abs(path)
 	if err != nil {
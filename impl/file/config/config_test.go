@@ -14,12 +14,16 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path"
 	"testing"
+	"time"
 
+	"github.com/datacequia/go-dogg3rz/env"
 	"github.com/datacequia/go-dogg3rz/resource/config"
 	//"github.com/datacequia/go-dogg3rz/impl/config"
 )
@@ -97,3 +101,121 @@ func TestValidateConfig(t *testing.T) {
 	}
 
 }
+
+func TestGetGrappConfig(t *testing.T) {
+
+	stageGrappConfig := func(src string) string {
+		grappDir := t.TempDir()
+		if err := os.Mkdir(path.Join(grappDir, ".dgrz"), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if src != "" {
+			data, err := os.ReadFile(path.Join("testfiles", "grapp", src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = os.WriteFile(path.Join(grappDir, ".dgrz", configFileName), data, 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return grappDir
+	}
+
+	// NO GRAPP CONFIG IS AN EMPTY CONFIG
+	if grappCfg, err := GetGrappConfig(stageGrappConfig("")); err != nil {
+		t.Errorf("expected empty config for grapp without config file, got %s", err)
+	} else if len(grappCfg.DocumentLoader.Rewrites) != 0 {
+		t.Errorf("expected no rewrites, got %v", grappCfg.DocumentLoader.Rewrites)
+	}
+
+	grappCfg, err := GetGrappConfig(stageGrappConfig("good-grapp-config.json"))
+	if err != nil {
+		t.Fatalf("good grapp config failed validation: %s", err)
+	}
+	if len(grappCfg.DocumentLoader.Rewrites) != 2 {
+		t.Fatalf("expected 2 rewrites, got %d", len(grappCfg.DocumentLoader.Rewrites))
+	}
+
+	if target, ok := grappCfg.DocumentLoader.Rewrites[0].Rewrite("https://vocab.example.org/terms.jsonld"); !ok || target != "vocab/terms.jsonld" {
+		t.Errorf("expected prefix rewrite to vocab/terms.jsonld, got %s (matched = %t)", target, ok)
+	}
+	if _, ok := grappCfg.DocumentLoader.Rewrites[1].Rewrite("https://schema.org/Person"); ok {
+		t.Errorf("exact rewrite matched a longer IRI")
+	}
+
+	if _, err := GetGrappConfig(stageGrappConfig("bad-grapp-config.json")); err == nil {
+		t.Errorf("grapp config with unknown rewrite match type passed validation")
+	}
+
+}
+
+func TestGetDocumentLoaderConfigLimits(t *testing.T) {
+
+	homeDir := t.TempDir()
+	grappDir := t.TempDir()
+	ctxt := context.WithValue(context.Background(), env.EnvDogg3rzHome, homeDir)
+
+	if err := os.Mkdir(path.Join(grappDir, ".dgrz"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path.Join("testfiles", "grapp", "good-grapp-config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// AN UNTRUSTED GRAPP WIDENING THE FILES IT MAY LOAD
+	data = bytes.Replace(data, []byte(`"httpsOnly": true,`), []byte(`"httpsOnly": true, "allowFilePaths": ["/"],`), 1)
+	if err = os.WriteFile(path.Join(grappDir, ".dgrz", configFileName), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	userConfig := `{
+    "ipfs": { "apiEndpoint": "http://localhost:5001/" },
+    "user": { "activityPubUserHandle": "@jane@example.org" },
+    "documentLoader": {
+        "limits": {
+            "timeoutSeconds": 60,
+            "maxRedirects": 2,
+            "denyDomains": ["ads.example.net"],
+            "allowFilePaths": ["/srv/vocab"]
+        }
+    }
+}`
+	if err = os.WriteFile(path.Join(homeDir, configFileName), []byte(userConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaderCfg, err := GetDocumentLoaderConfig(ctxt, grappDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits := loaderCfg.Limits
+
+	// GRAPP SETTINGS TAKE PRECEDENCE. UNSET ONES FALL BACK TO USER SETTINGS, THEN DEFAULTS
+	if limits.Timeout() != 5*time.Second {
+		t.Errorf("expected grapp timeout 5s, got %s", limits.Timeout())
+	}
+	if limits.Redirects() != 2 {
+		t.Errorf("expected user max redirects 2, got %d", limits.Redirects())
+	}
+	if limits.DocumentSize() != config.DefaultLoaderMaxDocumentSize {
+		t.Errorf("expected default max document size, got %d", limits.DocumentSize())
+	}
+	if !limits.HTTPSRequired() {
+		t.Errorf("expected grapp https only setting")
+	}
+
+	if len(limits.AllowFilePaths) != 1 || limits.AllowFilePaths[0] != "/srv/vocab" {
+		t.Errorf("expected only user allowed file paths, got %v", limits.AllowFilePaths)
+	}
+
+	for host, allowed := range map[string]bool{
+		"schema.org":          true,
+		"tracker.example.com": false,
+		"cdn.ads.example.net": false,
+	} {
+		if limits.DomainAllowed(host) != allowed {
+			t.Errorf("%s: expected allowed = %t", host, allowed)
+		}
+	}
+
+}
@@ -34,6 +34,7 @@ import (
 const dotDirName = ".dogg3rz"
 const LOCK_FILE_SUFFIX = ".lock"
 const dataDirName = "data"
+const vocabDirName = "vocab" // user refreshed copies of bundled vocabularies
 const grapplicationsDirName = "grapplications"
 
 // BASE GRAP DIR FOR ALL STATE FILES
@@ -41,8 +42,9 @@ const DgrzDirName = ".dgrz"
 const RefsDirName = "refs"
 const ObjectsDirName = "objects" // where file objects are cached
 const HeadsDirName = "heads"
+const TagsDirName = "tags" // tags naming snapshots
 const MasterBranchName = "main"
-const IndexFileName = ".index"
+const IndexDirName = "index" // persistent graph index
 const DirLockFileName = ".__dirlock__"
 const ResourceCacheSignature = "RESC"
 const IndexFormatVersion = uint32(1)
@@ -50,6 +52,7 @@ const HeadFileName = "HEAD"
 const GrapplicationIdFileName = "ID"
 const JSONLDDocumentName = ".document.jsonld"
 const IPFSAPIPortCounterFileName = ".ipfs-api-port-counter"
+const ContextLockFileName = "dogg3rz.lock" // pins remote contexts resolved by a grapp
 
 var validPathElementRegex = regexp.MustCompilePOSIX("^[a-z][-a-z0-9]*$")
 
@@ -164,6 +167,12 @@ func DataDirPath(ctxt context.Context) string {
 	return path.Join(DotDirPath(ctxt), dataDirName)
 }
 
+// VocabDirPath returns the directory holding local copies of bundled
+// vocabularies which take precedence over the bundled copy
+func VocabDirPath(ctxt context.Context) string {
+	return path.Join(DotDirPath(ctxt), vocabDirName)
+}
+
 func GrapplicationDirPath(ctxt context.Context) (string, error) {
 
 	var pwd string
@@ -235,14 +244,13 @@ func GrapplicationDatasetDirPath(ctxt context.Context, datasetName string) (stri
 
 }
 
-func IndexFilePath(ctxt context.Context) (string, error) {
-	//return path.Join(GrapplicationsDirPath(ctxt), IndexFileName),nil
-	gdp, err := GrapplicationDirPath(ctxt)
+func GrapplicationIndexDirPath(ctxt context.Context) (string, error) {
+	gdp, err := GrapplicationDgrzDirPath(ctxt)
 	if err != nil {
 		return "", err
 	}
 
-	return path.Join(gdp, IndexFileName), nil
+	return path.Join(gdp, IndexDirName), nil
 }
 
 // returns list of directory names that are grapplication dirs
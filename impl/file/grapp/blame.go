@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/datacequia/go-dogg3rz/sparql"
+	"github.com/piprate/json-gold/ld"
+)
+
+// variables of the solutions written by blameGrapp
+var blameVars = []string{"subject", "predicate", "object", "added", "added_at", "removed", "removed_at"}
+
+// blameGrapp evaluates a CONSTRUCT or DESCRIBE query over each snapshot
+// from since (default: the first snapshot) to until (default: the head
+// of the current branch) along the snapshot chain. For each run of
+// consecutive snapshots a triple of the results appears in, it writes the
+// snapshot the run starts with and, unless the run reaches until, the
+// snapshot following it. A triple removed and added again is written once
+// per run. Triples compare by their terms so those with blank nodes
+// appear anew whenever the file holding them changes
+func blameGrapp(ctxt context.Context, grappDir string, objectsDir string, q *sparql.Query, since string, until string,
+	w io.Writer, format sparql.Format, vw io.Writer) error {
+
+	if q.Form != sparql.Construct && q.Form != sparql.Describe {
+		return errors.InvalidValue.Newf("blame requires a CONSTRUCT or DESCRIBE query, not %s", q.Form)
+	}
+
+	snapshots, err := snapshotRange(grappDir, objectsDir, since, until)
+	if err != nil {
+		return err
+	}
+
+	loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		return err
+	}
+
+	index, err := file.OpenIndex(grappDir)
+	if err != nil {
+		return err
+	}
+	defer index.Close()
+
+	// A RUN OF SNAPSHOTS A TRIPLE APPEARS IN
+	type presence struct {
+		key         string
+		quad        *ld.Quad
+		added, last int
+	}
+	var presences []*presence
+	latest := make(map[string]*presence)
+
+	for i, snapshot := range snapshots {
+		verbose(vw, "Evaluating %s query over snapshot %s (%d of %d)...", q.Form, snapshot.ID, i+1, len(snapshots))
+
+		if err = indexProjectFiles(grappDir, objectsDir, loader, index, snapshot, vw); err != nil {
+			return err
+		}
+		store, err := index.Store(snapshot.ID)
+		if err != nil {
+			return err
+		}
+		result, err := q.Evaluate(store)
+		store.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, quad := range result.Graph.Graphs[quadstore.DefaultGraph] {
+			key := rdf.NTriplesTerm(quad.Subject) + " " + rdf.NTriplesTerm(quad.Predicate) + " " + rdf.NTriplesTerm(quad.Object)
+			p, ok := latest[key]
+			if !ok || p.last < i-1 {
+				p = &presence{key: key, quad: quad, added: i}
+				presences = append(presences, p)
+				latest[key] = p
+			}
+			p.last = i
+		}
+	}
+
+	if err = loader.PinResolved(); err != nil {
+		return err
+	}
+
+	sort.Slice(presences, func(i, j int) bool {
+		if a, b := presences[i], presences[j]; a.added != b.added {
+			return a.added < b.added
+		}
+		return presences[i].key < presences[j].key
+	})
+
+	result := &sparql.Result{Form: sparql.Select, Vars: blameVars, Prefixes: q.Prefixes}
+	for _, p := range presences {
+		solution := sparql.Binding{"subject": p.quad.Subject, "predicate": p.quad.Predicate, "object": p.quad.Object}
+		solution["added"], solution["added_at"] = snapshotTerms(snapshots[p.added])
+		if p.last < len(snapshots)-1 {
+			solution["removed"], solution["removed_at"] = snapshotTerms(snapshots[p.last+1])
+		}
+		result.Solutions = append(result.Solutions, solution)
+	}
+
+	return result.Write(w, format)
+}
+
+// snapshotTerms returns the id and the creation time of snapshot as literals
+func snapshotTerms(snapshot *Snapshot) (ld.Node, ld.Node) {
+	return ld.NewLiteral(snapshot.ID, rdf.XSDString, ""),
+		ld.NewLiteral(snapshot.Created.Format(time.RFC3339Nano), rdf.XSDDateTime, "")
+}
+
+// snapshotRange returns the snapshots from since to until along the
+// snapshot chain, oldest first. An empty since selects the first
+// snapshot and an empty until the head of the current branch
+func snapshotRange(grappDir string, objectsDir string, since string, until string) ([]*Snapshot, error) {
+
+	last, err := ResolveSnapshot(grappDir, objectsDir, until)
+	if err != nil {
+		return nil, err
+	}
+
+	var first *Snapshot
+	if len(since) > 0 {
+		if first, err = ResolveSnapshot(grappDir, objectsDir, since); err != nil {
+			return nil, err
+		}
+	}
+
+	snapshots := []*Snapshot{last}
+	for s := last; first == nil || s.ID != first.ID; {
+		if len(s.Parent) < 1 {
+			if first != nil {
+				return nil, errors.InvalidValue.Newf("%s: snapshot %s is not an ancestor of snapshot %s", since, first.ID, last.ID)
+			}
+			break
+		}
+		if s, err = ReadSnapshot(objectsDir, s.Parent); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	for i, j := 0, len(snapshots)-1; i < j; i, j = i+1, j-1 {
+		snapshots[i], snapshots[j] = snapshots[j], snapshots[i]
+	}
+
+	return snapshots, nil
+}
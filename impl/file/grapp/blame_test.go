@@ -0,0 +1,95 @@
+package grapp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+func TestBlame(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	var ids []string
+	snapshot := func(files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			if len(content) < 1 {
+				if err := os.Remove(filepath.Join(grappDir, name)); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			writeTestFile(t, filepath.Join(grappDir, name), content)
+		}
+		s, err := CreateSnapshot(ctxt, grappDir, objectsDir, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, s.ID)
+	}
+
+	const alice = `{"@id": "http://example.org/alice", "http://example.org/name": "Alice"}`
+	const bob = `{"@id": "http://example.org/bob", "http://example.org/name": "Bob"}`
+	snapshot(map[string]string{"alice.jsonld": alice})
+	snapshot(map[string]string{"bob.jsonld": bob})
+	snapshot(map[string]string{"alice.jsonld": ""})
+	snapshot(map[string]string{"alice.jsonld": alice})
+
+	blame := func(options resourcegrapp.QueryOptions) []string {
+		t.Helper()
+		options.Blame, options.Format = true, "tsv"
+		var buf bytes.Buffer
+		err := queryGrapp(ctxt, grappDir, objectsDir, `CONSTRUCT WHERE { ?s ?p ?o }`, &buf, options, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		// DROP THE CREATION TIMES
+		for i, line := range lines {
+			fields := strings.Split(line, "\t")
+			lines[i] = strings.Join([]string{fields[0], fields[3], fields[5]}, "\t")
+		}
+		return lines
+	}
+
+	expected := []string{
+		"?subject\t?added\t?removed",
+		"<http://example.org/alice>\t\"" + ids[0] + "\"\t\"" + ids[2] + "\"",
+		"<http://example.org/bob>\t\"" + ids[1] + "\"\t",
+		// ADDED AGAIN AFTER IT WAS REMOVED
+		"<http://example.org/alice>\t\"" + ids[3] + "\"\t",
+	}
+	if lines := blame(resourcegrapp.QueryOptions{}); strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+
+	// A RANGE OF SNAPSHOTS
+	expected = []string{
+		"?subject\t?added\t?removed",
+		"<http://example.org/alice>\t\"" + ids[1] + "\"\t",
+		"<http://example.org/bob>\t\"" + ids[1] + "\"\t",
+	}
+	if lines := blame(resourcegrapp.QueryOptions{Since: ids[1], Snapshot: "HEAD~2"}); strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+
+	for _, test := range []struct {
+		query   string
+		options resourcegrapp.QueryOptions
+	}{
+		{`SELECT * WHERE { ?s ?p ?o }`, resourcegrapp.QueryOptions{Blame: true}},
+		{`CONSTRUCT WHERE { ?s ?p ?o }`, resourcegrapp.QueryOptions{Blame: true, Since: ids[2], Snapshot: ids[0]}},
+		{`CONSTRUCT WHERE { ?s ?p ?o }`, resourcegrapp.QueryOptions{Since: ids[0]}},
+	} {
+		err := queryGrapp(ctxt, grappDir, objectsDir, test.query, &bytes.Buffer{}, test.options, nil)
+		if errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("%s %+v: expected InvalidValue error, got %v", test.query, test.options, err)
+		}
+	}
+}
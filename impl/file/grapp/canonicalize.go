@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/piprate/json-gold/ld"
+)
+
+func (grapp *FileGrapplicationResource) Canonicalize(ctxt context.Context, src string, w io.Writer,
+	vw io.Writer) error {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	nquads, err := canonicalizeFile(ctxt, grappDir, objectsDir, src, vw)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, nquads)
+
+	return err
+}
+
+// canonicalizeFile returns the canonical N-Quads of the statements in
+// src: an RDF file or a JSON-LD or YAML-LD document, which is
+// processed like the grapp's project files
+func canonicalizeFile(ctxt context.Context, grappDir string, objectsDir string, src string,
+	vw io.Writer) (string, error) {
+
+	var dataset *ld.RDFDataset
+
+	if format, err := rdf.FormatOf(src); err == nil {
+
+		verbose(vw, "Parsing %s as %s...", src, format)
+		f, err := os.Open(src)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		if dataset, err = rdf.Parse(f, format, "", src); err != nil {
+			return "", err
+		}
+
+	} else {
+
+		absPath, err := filepath.Abs(src)
+		if err != nil {
+			return "", err
+		}
+
+		loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+		if err != nil {
+			return "", err
+		}
+
+		verbose(vw, "Processing %s...", src)
+		doc, err := loader.nested().LoadDocument(absPath)
+		if err != nil {
+			return "", err
+		}
+
+		flattened, err := ReadFlattened(objectsDir, doc.DocumentURL)
+		if err != nil {
+			return "", err
+		}
+
+		if dataset, err = ld.NewJsonLdApi().ToRDF(flattened, ld.NewJsonLdOptions("")); err != nil {
+			return "", errors.InvalidValue.Wrapf(err, "%s", src)
+		}
+	}
+
+	return rdf.Canonicalize(dataset)
+}
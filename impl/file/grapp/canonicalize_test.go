@@ -0,0 +1,46 @@
+package grapp
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalizeFile(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	jsonldFile := filepath.Join(grappDir, "person.jsonld")
+	writeTestFile(t, jsonldFile, `{"@context": {"@vocab": "http://example.org/"},
+  "@id": "http://example.org/jane", "name": "Jane\ttab", "address": {"city": "Paris"}}`)
+
+	yamlldFile := filepath.Join(grappDir, "person.yamlld")
+	writeTestFile(t, yamlldFile, `"@context":
+  "@vocab": http://example.org/
+"@id": http://example.org/jane
+address:
+  "@id": _:elsewhere
+  city: Paris
+name: "Jane\ttab"
+`)
+
+	turtleFile := filepath.Join(t.TempDir(), "person.ttl")
+	writeTestFile(t, turtleFile, `@prefix ex: <http://example.org/> .
+ex:jane ex:address [ ex:city "Paris" ] ; ex:name "Jane\ttab" .
+`)
+
+	expected := `<http://example.org/jane> <http://example.org/address> _:c14n0 .
+<http://example.org/jane> <http://example.org/name> "Jane\ttab" .
+_:c14n0 <http://example.org/city> "Paris" .
+`
+
+	for _, src := range []string{jsonldFile, yamlldFile, turtleFile} {
+		actual, err := canonicalizeFile(ctxt, grappDir, objectsDir, src, nil)
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", src, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/datacequia/go-dogg3rz/did"
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	fileconfig "github.com/datacequia/go-dogg3rz/impl/file/config"
+	"github.com/datacequia/go-dogg3rz/resource/config"
+)
+
+// loadDIDDocument resolves the DID (or DID URL) u to its DID document.
+// did:web documents are fetched, cached and pinned like other remote
+// documents. Returns the document and the DID it describes
+func (dl *DocumentLoader) loadDIDDocument(u string) ([]byte, string, error) {
+
+	d, err := did.Parse(u)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var buf []byte
+
+	switch d.Method {
+	case "key":
+		buf, err = did.ResolveKey(d)
+
+	case "web":
+		var webURL string
+		if webURL, err = did.WebURL(d); err != nil {
+			return nil, "", err
+		}
+		// PINNED BY DID SO THAT DID URLS SHARE THE DOCUMENT
+		buf, _, _, err = dl.loadRemoteDocument(d.String(), webURL)
+
+	default:
+		return nil, "", errors.NotImplemented.Newf("%s: DID method '%s' is not supported", u, d.Method)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = did.CheckDocument(d, buf); err != nil {
+		return nil, "", err
+	}
+
+	return buf, d.String(), nil
+}
+
+func (grapp *FileGrapplicationResource) ResolveDID(ctxt context.Context, id string) ([]byte, error) {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		if errors.GetType(err) != errors.NotFound {
+			return nil, err
+		}
+		// OUTSIDE OF A GRAPP. NOTHING TO CACHE OR PIN DOCUMENTS IN
+		loaderConfig, err := fileconfig.GetUserDocumentLoaderConfig(ctxt)
+		if err != nil {
+			return nil, err
+		}
+		return resolveDID(ctxt, loaderConfig.Limits, id)
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return nil, err
+	}
+
+	dl, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveDIDDocument(dl, id)
+}
+
+// resolveDID resolves id to its DID document within the remote url
+// policy, timeout, redirect and size limits
+func resolveDID(ctxt context.Context, limits config.DocumentLoaderLimits, id string) ([]byte, error) {
+
+	d, err := did.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Method == "web" {
+		webURL, err := did.WebURL(d)
+		if err != nil {
+			return nil, err
+		}
+		u, err := url.Parse(webURL)
+		if err != nil {
+			return nil, err
+		}
+		if err = checkRemoteURL(limits, u); err != nil {
+			return nil, err
+		}
+	}
+
+	return did.NewResolver(newHTTPClient(limits), limits.DocumentSize()).Resolve(ctxt, id)
+}
+
+// resolveDIDDocument loads the DID document of id with dl. Loading
+// the document validates it as JSON-LD
+func resolveDIDDocument(dl *DocumentLoader, id string) ([]byte, error) {
+
+	remoteDoc, err := dl.LoadDocument(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = dl.PinResolved(); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(remoteDoc.Document, "", "  ")
+}
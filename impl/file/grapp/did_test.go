@@ -0,0 +1,126 @@
+package grapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/resource/config"
+)
+
+func TestDocumentLoaderDID(t *testing.T) {
+
+	_, grappDir, objectsDir := initTestGrapp(t)
+
+	var host string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/did.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+    "@context": ["https://www.w3.org/ns/did/v1", "https://w3id.org/security/multikey/v1"],
+    "id": "did:web:` + host + `",
+    "verificationMethod": [{
+        "id": "did:web:` + host + `#key-1",
+        "type": "Multikey",
+        "controller": "did:web:` + host + `",
+        "publicKeyMultibase": "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+    }],
+    "authentication": ["did:web:` + host + `#key-1"]
+}`))
+	})
+	mux.HandleFunc("/impostor/did.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"@context": "https://www.w3.org/ns/did/v1", "id": "did:web:example.com"}`))
+	})
+
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	host = strings.Replace(serverURL.Host, ":", "%3A", 1)
+
+	lock, err := ReadContextLock(grappDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dl := NewDocumentLoader(server.Client(), grappDir, objectsDir)
+	dl.SetContextLock(lock)
+
+	for _, id := range []string{
+		"did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK#z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK",
+		"did:web:" + host + "#key-1",
+	} {
+		doc, err := resolveDIDDocument(dl, id)
+		if err != nil {
+			t.Fatalf("%s: %s", id, err)
+		}
+		if !strings.Contains(string(doc), "verificationMethod") {
+			t.Errorf("%s: expected DID document, got %s", id, doc)
+		}
+	}
+
+	// did:web DOCUMENTS ARE PINNED BY DID
+	lock, err = ReadContextLock(grappDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lock.Get("did:web:" + host); !ok {
+		t.Errorf("expected did:web:%s to be pinned", host)
+	}
+
+	if _, err := resolveDIDDocument(dl, "did:web:"+host+":impostor"); errors.GetType(err) != errors.UnexpectedValue {
+		t.Errorf("expected UnexpectedValue error for DID document with wrong id, got %v", err)
+	}
+
+	if _, err := resolveDIDDocument(dl, "did:example:123"); errors.GetType(err) != errors.NotImplemented {
+		t.Errorf("expected NotImplemented error for unsupported DID method, got %v", err)
+	}
+
+}
+
+func TestResolveDIDLimits(t *testing.T) {
+
+	var host string
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"@context": "https://www.w3.org/ns/did/v1", "id": "did:web:` + host + `"}`))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	host = strings.Replace(serverURL.Host, ":", "%3A", 1)
+
+	// THE LIMITED CLIENT USES THE DEFAULT TRANSPORT
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	defer func() { http.DefaultTransport = defaultTransport }()
+
+	maxDocumentSize := int64(16)
+
+	tests := []struct {
+		name     string
+		limits   config.DocumentLoaderLimits
+		expected errors.ErrorType
+	}{
+		{"defaults", config.DocumentLoaderLimits{}, errors.NoType},
+		{"max document size", config.DocumentLoaderLimits{MaxDocumentSize: &maxDocumentSize}, errors.OutOfRange},
+		{"deny domain", config.DocumentLoaderLimits{DenyDomains: []string{"127.0.0.1"}}, errors.InvalidValue},
+	}
+
+	for _, test := range tests {
+		_, err := resolveDID(context.Background(), test.limits, "did:web:"+host)
+		if test.expected == errors.NoType {
+			if err != nil {
+				t.Errorf("%s: %s", test.name, err)
+			}
+			continue
+		}
+		if errors.GetType(err) != test.expected {
+			t.Errorf("%s: expected error type %d, got %v", test.name, test.expected, err)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package grapp
 
 import (
 	"bytes"
+	"context"
 	"crypto"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"hash"
@@ -13,10 +15,18 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/datacequia/go-dogg3rz/cborld"
+	"github.com/datacequia/go-dogg3rz/env"
 	"github.com/datacequia/go-dogg3rz/errors"
 	"github.com/datacequia/go-dogg3rz/impl/file"
-	"github.com/fxamacker/cbor/v2"
+	fileconfig "github.com/datacequia/go-dogg3rz/impl/file/config"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/datacequia/go-dogg3rz/resource/config"
+	"github.com/datacequia/go-dogg3rz/util"
+	"github.com/datacequia/go-dogg3rz/vocab"
+	"github.com/datacequia/go-dogg3rz/yamlld"
 	"github.com/piprate/json-gold/ld"
 )
 
@@ -29,9 +39,25 @@ const (
 )
 
 type DocumentLoader struct {
-	httpClient *http.Client // optional: http client to use to load documents
-	grappDir   string       // base project dir
-	objectsDir string       // where to place object files
+	httpClient *http.Client                // optional: http client to use to load documents
+	grappDir   string                      // base project dir
+	objectsDir string                      // where to place object files
+	offline    bool                        // serve remote documents from object cache only
+	lock       *ContextLock                // optional: pins remote documents to cached content
+	updateLock bool                        // revalidate pinned remote documents and re-pin changes
+	loadErr    error                       // first error returned by LoadDocument
+	rewrites   []config.IRIRewrite         // IRI rewrite rules from grapp and user config
+	vocabDir   string                      // optional: local copies of bundled vocabularies
+	limits     config.DocumentLoaderLimits // safety limits applied when loading documents
+	chain      []string                    // documents being processed that led to this loader
+
+	ctxt         context.Context           // cancels in flight requests
+	carResolver  IPFSResolver              // optional: resolves ipfs:// IRIs from local CAR files
+	nodeResolver IPFSResolver              // optional: resolves ipfs:// and ipns:// IRIs through an IPFS node
+	dataProducts map[string]string         // data product name -> grapp dir for dgrz:// IRIs
+	dataDir      string                    // optional: default location of data product grapps
+	onLoad       func(processedDependency) // optional: called for each document loaded
+	snapshot     *Snapshot                 // optional: serves grapp files as of this snapshot
 	//cachedDocumentIndex map[string]
 }
 
@@ -43,22 +69,214 @@ type CachedDocument struct {
 	realReader io.ReadCloser // actual reader that is proxied
 	hash       hash.Hash     // hash object that computes hash of cached doc
 
+	cachedDocPath string // path to cached doc once fully read
 }
 
 func NewDocumentLoader(httpClient *http.Client, grappDir string, objectsDir string) *DocumentLoader {
-	rval := &DocumentLoader{httpClient: httpClient, grappDir: grappDir, objectsDir: objectsDir}
+	rval := &DocumentLoader{httpClient: httpClient, grappDir: grappDir, objectsDir: objectsDir,
+		ctxt: context.Background()}
 
 	if rval.httpClient == nil {
-		rval.httpClient = http.DefaultClient
+		rval.httpClient = newHTTPClient(rval.limits)
 	}
 
 	return rval
 }
 
+// newHTTPClient returns an http client that enforces the request
+// timeout, redirect count and remote url policy in limits
+func newHTTPClient(limits config.DocumentLoaderLimits) *http.Client {
+
+	return &http.Client{
+		Timeout: limits.Timeout(),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > limits.Redirects() {
+				return errors.OutOfRange.Newf("%s: stopped after %d redirects", via[0].URL, limits.Redirects())
+			}
+			return checkRemoteURL(limits, req.URL)
+		},
+	}
+}
+
+// checkRemoteURL returns an error if the remote document at u may not
+// be loaded according to the https-only and domain policy in limits
+func checkRemoteURL(limits config.DocumentLoaderLimits, u *url.URL) error {
+
+	if limits.HTTPSRequired() && u.Scheme != "https" {
+		return errors.InvalidValue.Newf("%s: remote documents must be loaded over https", u)
+	}
+
+	if !limits.DomainAllowed(u.Hostname()) {
+		return errors.InvalidValue.Newf("%s: loading remote documents from %s is not allowed", u, u.Hostname())
+	}
+
+	return nil
+}
+
+// NewGrappDocumentLoader returns a DocumentLoader for the grapp in grappDir
+// configured from the grapp and user configuration, the grapp's context
+// lock and the offline and update lock settings in ctxt
+func NewGrappDocumentLoader(ctxt context.Context, grappDir string, objectsDir string) (*DocumentLoader, error) {
+
+	loaderConfig, err := fileconfig.GetDocumentLoaderConfig(ctxt, grappDir)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := ReadContextLock(grappDir)
+	if err != nil {
+		return nil, err
+	}
+
+	dl := NewDocumentLoader(nil, grappDir, objectsDir)
+	dl.SetLimits(loaderConfig.Limits)
+	dl.rewrites = loaderConfig.Rewrites
+	dl.vocabDir = file.VocabDirPath(ctxt)
+	dl.lock = lock
+	dl.offline = util.ContextValueAsBool(ctxt, env.EnvDogg3rzOffline)
+	dl.updateLock = util.ContextValueAsBool(ctxt, env.EnvDogg3rzUpdateLock)
+	dl.ctxt = ctxt
+	dl.carResolver, dl.nodeResolver = newIPFSResolvers(ctxt, grappDir, loaderConfig.IPFS, loaderConfig.Limits)
+	dl.dataProducts = loaderConfig.DataProducts
+	dl.dataDir = file.DataDirPath(ctxt)
+
+	return dl, nil
+}
+
+// ContextLock returns the lock used to pin remote documents (if any)
+func (dl *DocumentLoader) ContextLock() *ContextLock {
+	return dl.lock
+}
+
+// PinResolved saves the remote documents pinned while loading to the
+// context lock file. Nothing is written offline or without a lock
+func (dl *DocumentLoader) PinResolved() error {
+
+	if dl.offline || dl.lock == nil {
+		return nil
+	}
+
+	return dl.lock.Write()
+}
+
+// Offline returns true if remote documents are served from the object cache only
+func (dl *DocumentLoader) Offline() bool {
+	return dl.offline
+}
+
+// SetOffline restricts the loader to serving remote documents from
+// the local object cache as pinned in its context lock
+func (dl *DocumentLoader) SetOffline(offline bool) {
+	dl.offline = offline
+}
+
+// SetUpdateLock makes the loader revalidate remote documents pinned in
+// its context lock through the http cache and re-pin those that changed.
+// Pinned documents are served as is while offline
+func (dl *DocumentLoader) SetUpdateLock(updateLock bool) {
+	dl.updateLock = updateLock
+}
+
+// revalidatePin returns true if the pin of remote document iri is
+// revalidated because the lock is being updated and iri was not yet
+// resolved by this or a related loader
+func (dl *DocumentLoader) revalidatePin(iri string) bool {
+	return dl.updateLock && !dl.offline && dl.lock != nil && !dl.lock.pinnedSinceRead(iri)
+}
+
+// SetContextLock assigns the lock used to pin remote documents
+func (dl *DocumentLoader) SetContextLock(lock *ContextLock) {
+	dl.lock = lock
+}
+
+// SetIPFSResolvers assigns the resolvers of ipfs:// and ipns:// IRIs.
+// Content found by carResolver takes precedence over nodeResolver
+func (dl *DocumentLoader) SetIPFSResolvers(carResolver IPFSResolver, nodeResolver IPFSResolver) {
+	dl.carResolver = carResolver
+	dl.nodeResolver = nodeResolver
+}
+
+// SetSnapshot makes the loader read local documents within the grapp
+// directory from snapshot s instead of the working tree
+func (dl *DocumentLoader) SetSnapshot(s *Snapshot) {
+	dl.snapshot = s
+}
+
+// SetLimits assigns the safety limits applied when loading documents.
+// The loader's http client is replaced by one enforcing limits
+func (dl *DocumentLoader) SetLimits(limits config.DocumentLoaderLimits) {
+	dl.limits = limits
+	dl.httpClient = newHTTPClient(limits)
+}
+
+// nested returns a loader sharing this loader's configuration for
+// use when processing the documents it loads
+func (dl *DocumentLoader) nested() *DocumentLoader {
+
+	n := NewDocumentLoader(dl.httpClient, dl.grappDir, dl.objectsDir)
+	n.offline = dl.offline
+	n.lock = dl.lock
+	n.updateLock = dl.updateLock
+	n.rewrites = dl.rewrites
+	n.vocabDir = dl.vocabDir
+	n.limits = dl.limits
+	n.chain = dl.chain
+	n.ctxt = dl.ctxt
+	n.carResolver = dl.carResolver
+	n.nodeResolver = dl.nodeResolver
+	n.dataProducts = dl.dataProducts
+	n.dataDir = dl.dataDir
+	n.onLoad = dl.onLoad
+	n.snapshot = dl.snapshot
+
+	return n
+}
+
+// descend returns a nested loader for the documents referenced
+// by the document iri
+func (dl *DocumentLoader) descend(iri string) *DocumentLoader {
+
+	n := dl.nested()
+	n.chain = append(append([]string(nil), dl.chain...), iri)
+
+	return n
+}
+
+// checkRecursion returns an error if loading iri would exceed the
+// maximum context depth or revisit a document being processed
+func (dl *DocumentLoader) checkRecursion(iri string) error {
+
+	for i, ancestor := range dl.chain {
+		if ancestor == iri {
+			return errors.InvalidValue.Newf("%s: context cycle detected: %s -> %s",
+				iri, strings.Join(dl.chain[i:], " -> "), iri)
+		}
+	}
+
+	if len(dl.chain) > dl.limits.ContextDepth() {
+		return errors.OutOfRange.Newf("%s: maximum context depth %d exceeded: %s",
+			iri, dl.limits.ContextDepth(), strings.Join(dl.chain, " -> "))
+	}
+
+	return nil
+}
+
 // Loads JSON-LD documents from local or http paths
 // Implements github.com/piprate/ld/DocumentLoader interface
 func (dl *DocumentLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
 
+	doc, err := dl.loadDocument(u)
+	if err != nil && dl.loadErr == nil {
+		// REMEMBER CAUSE. THE JSON-LD PROCESSOR REPLACES
+		// ERRORS RETURNED WHILE LOADING REMOTE CONTEXTS
+		dl.loadErr = err
+	}
+
+	return doc, err
+}
+
+func (dl *DocumentLoader) loadDocument(u string) (*ld.RemoteDocument, error) {
+
 	//fmt.Println("loading document...", u)
 	/*
 		f := func() {
@@ -71,31 +289,123 @@ func (dl *DocumentLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
 		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, fmt.Sprintf("error parsing URL: %s", u))
 	}
 
+	// RELATIVE REFERENCES IN REMOTE DOCUMENTS ARE RESOLVED AGAINST THEIR URL
+	if base, ok := dl.remoteReferrer(); ok && parsedURL.Scheme == "" {
+		parsedURL = base.ResolveReference(parsedURL)
+		u = parsedURL.String()
+	}
+
+	if err := dl.checkRecursion(u); err != nil {
+		return nil, err
+	}
+
 	var documentBody io.ReadCloser
 	var finalURL, contextURL string
+	var baseURL *url.URL // location relative references in the document resolve against
+	var localPath string // absolute path of a local document
 	//var loadedDocument *CachedDocument
 
 	protocol := parsedURL.Scheme
+	target := u
+	rewritten := false
+
+	// APPLY IRI REWRITE RULES TO ABSOLUTE IRIs
+	// FOLLOWED BY VOCABULARIES BUNDLED WITH DOGG3RZ
+	if protocol != "" && protocol != "file" {
+		if target = dl.resolveIRI(u); target == u {
+			if v, ok := vocab.Lookup(u); ok {
+				return dl.loadBundledDocument(u, v)
+			}
+		} else {
+			if parsedURL, err = url.Parse(target); err != nil {
+				return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, fmt.Sprintf("error parsing URL: %s", target))
+			}
+			rewritten = true
+			protocol = parsedURL.Scheme
+			if protocol == "" && !filepath.IsAbs(target) {
+				// REWRITTEN TO FILE PATH RELATIVE TO GRAPP DIR
+				target = filepath.Join(dl.grappDir, target)
+			} else if protocol == "file" {
+				target = filepath.FromSlash(parsedURL.Path)
+			}
+		}
+	}
+
+	switch protocol {
+	case "ipfs", "ipns", "dgrz":
+
+		var buf []byte
+
+		if protocol == "dgrz" {
+			buf, err = dl.loadDataProductDocument(u, parsedURL)
+		} else {
+			buf, err = dl.loadIPFSDocument(u, parsedURL)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// IPFS BLOCKS MAY HOLD CBOR-LD ENCODED DOCUMENTS
+		if cborld.IsCBORLD(buf) {
+			if buf, err = dl.decodeCBORLD(u, buf); err != nil {
+				return nil, err
+			}
+		}
+
+		finalURL = u
+		baseURL = parsedURL
+		documentBody = io.NopCloser(bytes.NewReader(buf))
+
+	case "did":
+
+		var buf []byte
 
-	if protocol != "http" && protocol != "https" {
+		if buf, finalURL, err = dl.loadDIDDocument(u); err != nil {
+			return nil, err
+		}
+
+		baseURL = parsedURL
+		documentBody = io.NopCloser(bytes.NewReader(buf))
+
+	case "http", "https":
+
+		var buf []byte
+
+		if buf, finalURL, contextURL, err = dl.loadRemoteDocument(u, target); err != nil {
+			return nil, err
+		}
+
+		documentBody = io.NopCloser(bytes.NewReader(buf))
+
+		if baseURL, err = url.Parse(finalURL); err != nil {
+			return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+		}
+
+	default:
 		// Can't use the HTTP client for those!
 
-		var file *os.File
+		var buf []byte
 		var absolutePath string
 		var relativePath string
 		var absolutePathGrappDir string
 
-		file, err = os.Open(u)
-		if err != nil {
-			return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+		// LOCAL DOCUMENTS, INCLUDING REWRITE TARGETS (WHICH MAY COME
+		// FROM THE GRAPP'S OWN CONFIG), ARE CONFINED TO THE GRAPP
+		// AND ALLOWED PATHS
+		if !rewritten {
+			if target, err = dl.resolveLocalPath(u, parsedURL); err != nil {
+				return nil, err
+			}
+		} else if err = dl.checkLocalPath(u, target); err != nil {
+			return nil, err
 		}
-		defer file.Close()
 
 		// GET CANONICAL PATH
-		absolutePath, err = filepath.Abs(u)
+		absolutePath, err = filepath.Abs(target)
 		if err != nil {
 			return nil, err
 		}
+		baseURL = &url.URL{Scheme: "file", Path: filepath.ToSlash(absolutePath)}
+		localPath = absolutePath
 		absolutePathGrappDir, err = filepath.Abs(dl.grappDir)
 		if err != nil {
 			return nil, err
@@ -105,81 +415,469 @@ func (dl *DocumentLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
 		if err != nil {
 			return nil, err
 		}
-		finalURL = relativePath
+		finalURL = filepath.ToSlash(relativePath)
+		if rewritten {
+			// DOCUMENT IS IDENTIFIED BY THE IRI IT STANDS IN FOR
+			finalURL = u
+		}
 
-		documentBody = file
-	} else {
+		// LARGE JSON-LD AND NDJSON-LD DOCUMENTS ARE PROCESSED AS A STREAM
+		if doc, streamed, err := dl.loadStreamedDocument(u, finalURL, localPath, baseURL); streamed {
+			return doc, err
+		}
 
-		req, err := http.NewRequest("GET", resolveIRI(u), nil)
-		if err != nil {
-			return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+		if buf, err = dl.readLocalFile(target); err != nil {
+			return nil, err
 		}
-		// We prefer application/ld+json, but fallback to application/json
-		// or whatever is available
-		req.Header.Add("Accept", acceptHeader)
 
-		res, err := dl.httpClient.Do(req)
-		if err != nil {
-			return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+		documentBody = io.NopCloser(bytes.NewReader(buf))
+	}
+
+	// read whole document body into memory
+	var buf []byte
+	if buf, err = io.ReadAll(documentBody); err != nil {
+
+		return nil, err
+	}
+
+	dl.loaded(u, localPath, buf)
+
+	if finalURL != u {
+		// REDIRECTED OR REWRITTEN TO A DOCUMENT BEING PROCESSED
+		if err = dl.checkRecursion(finalURL); err != nil {
+			return nil, err
 		}
-		defer res.Body.Close()
+	}
 
-		if res.StatusCode != http.StatusOK {
-			return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed,
-				fmt.Sprintf("Bad response status code: %d", res.StatusCode))
+	parsedJSON, _, err := dl.createObjectFile(finalURL, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	// THE JSON-LD PROCESSOR RESOLVES CONTEXTS REFERENCED BY A REMOTE
+	// CONTEXT AGAINST THE REFERRING DOCUMENT. MAKE THEM ABSOLUTE FIRST
+	resolveContextReferences(parsedJSON, baseURL)
+
+	//fmt.Println("after createObjectFile returns ", objectFilePath)
+	return &ld.RemoteDocument{DocumentURL: finalURL, Document: parsedJSON, ContextURL: contextURL}, nil
+
+}
+
+// readLocalFile returns the content of the local document at
+// localPath. Documents within the grapp directory are read from the
+// loader's snapshot (if any)
+func (dl *DocumentLoader) readLocalFile(localPath string) ([]byte, error) {
+
+	size := func(n int64) error {
+		if n > dl.limits.DocumentSize() {
+			return errors.OutOfRange.Newf("%s: document size %d exceeds maximum document size %d",
+				localPath, n, dl.limits.DocumentSize())
 		}
+		return nil
+	}
+
+	if rel, ok := dl.snapshotPath(localPath); ok {
+		buf, err := dl.snapshot.ReadFile(dl.objectsDir, rel)
+		if err != nil {
+			return nil, err
+		}
+		return buf, size(int64(len(buf)))
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err = size(info.Size()); err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(file)
+}
+
+// snapshotPath returns the snapshot path of localPath if the loader
+// reads from a snapshot and localPath lies within the grapp directory
+func (dl *DocumentLoader) snapshotPath(localPath string) (string, bool) {
+
+	if dl.snapshot == nil {
+		return "", false
+	}
+
+	absPath, err := filepath.Abs(localPath)
+	if err != nil {
+		return "", false
+	}
+	grappRoot, err := filepath.Abs(dl.grappDir)
+	if err != nil {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(grappRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel), true
+}
+
+// loadBundledDocument loads the bundled vocabulary v which serves iri
+func (dl *DocumentLoader) loadBundledDocument(iri string, v vocab.Vocabulary) (*ld.RemoteDocument, error) {
+
+	buf, _, err := v.Load(dl.vocabDir)
+	if err != nil {
+		return nil, err
+	}
+
+	dl.loaded(iri, "", buf)
+
+	parsedJSON, _, err := dl.createObjectFile(iri, buf)
+	if err != nil {
+		return nil, err
+	}
 
-		finalURL = res.Request.URL.String()
-		//	fmt.Println("finalURL", finalURL, "resolveIRI", resolveIRI(u)) // deleteme
+	return &ld.RemoteDocument{DocumentURL: iri, Document: parsedJSON}, nil
+}
+
+// loadRemoteDocument returns the content of the http(s) document at u
+// (fetched from target) along with its final url and linked context url. Documents pinned in the
+// context lock are served from the object cache unless the lock is being
+// updated. Otherwise they are fetched, cached and pinned. Cached responses
+// are reused while fresh according to their Cache-Control max-age and are
+// revalidated with conditional requests once stale
+func (dl *DocumentLoader) loadRemoteDocument(u string, target string) ([]byte, string, string, error) {
+
+	remoteURL, err := url.Parse(target)
+	if err != nil {
+		return nil, "", "", ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+	}
+	if err = checkRemoteURL(dl.limits, remoteURL); err != nil {
+		return nil, "", "", err
+	}
 
-		//fmt.Println("finalURL", finalURL)
-		contentType := res.Header.Get("Content-Type")
-		linkHeader := res.Header.Get("Link")
+	buf, locked, isLocked, err := dl.pinnedDocument(u)
+	if err != nil || buf != nil {
+		return buf, locked.URL, locked.ContextURL, err
+	}
+	if !isLocked && dl.offline {
+		return nil, "", "", errors.NotFound.Newf("%s: remote document is not pinned in %s and cannot be loaded offline",
+			u, file.ContextLockFileName)
+	}
+
+	req, err := http.NewRequestWithContext(dl.ctxt, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, "", "", ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+	}
+	// We prefer application/ld+json, but fallback to application/json
+	// or whatever is available
+	req.Header.Add("Accept", acceptHeader)
 
-		if len(linkHeader) > 0 && contentType != "application/ld+json" {
-			header := ld.ParseLinkHeader(linkHeader)[linkHeaderRel]
-			if len(header) > 1 {
-				return nil, ld.NewJsonLdError(ld.MultipleContextLinkHeaders, nil)
-			} else if len(header) == 1 {
-				contextURL = header[0]["target"]
+	// LOOK FOR A PREVIOUSLY CACHED RESPONSE
+	var cachedBuf []byte
+	cacheEntry, err := readHTTPCacheEntry(dl.objectsDir, target)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if cacheEntry != nil && (!isLocked || cacheEntry.SHA256 == locked.SHA256) {
+		if cachedBuf, err = os.ReadFile(dl.cachedDocumentPath(cacheEntry.SHA256)); err != nil {
+			if !os.IsNotExist(err) {
+				return nil, "", "", err
 			}
+			// CACHED CONTENT WAS REMOVED. FETCH IT AGAIN
+			cacheEntry = nil
 		}
+	} else {
+		cacheEntry = nil
+	}
 
-		documentBody = res.Body
+	if cacheEntry != nil {
+		if cacheEntry.fresh(time.Now()) {
+			dl.pin(u, cacheEntry.FinalURL, cacheEntry.ContextURL, cacheEntry.SHA256)
+			return cachedBuf, cacheEntry.FinalURL, cacheEntry.ContextURL, nil
+		}
+		cacheEntry.revalidate(req)
+	}
 
+	res, err := dl.httpClient.Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok && errors.GetType(urlErr.Err) != errors.NoType {
+			// REDIRECT REFUSED BY POLICY
+			return nil, "", "", urlErr.Err
+		}
+		if dl.ctxt.Err() != nil {
+			return nil, "", "", errors.Cancelled.Wrapf(dl.ctxt.Err(), "%s: request cancelled", target)
+		}
+		if os.IsTimeout(err) {
+			return nil, "", "", errors.TimedOut.Wrapf(err, "%s: request timed out after %s", target, dl.limits.Timeout())
+		}
+		return nil, "", "", ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
 	}
+	defer res.Body.Close()
 
-	// read whole document body into memory
-	var buf []byte
-	if buf, err = io.ReadAll(documentBody); err != nil {
+	if res.StatusCode == http.StatusNotModified && cacheEntry != nil {
+		// CACHED CONTENT IS STILL CURRENT
+		cacheEntry.refresh(res)
+		if err = cacheEntry.write(dl.objectsDir); err != nil {
+			return nil, "", "", err
+		}
+		dl.pin(u, cacheEntry.FinalURL, cacheEntry.ContextURL, cacheEntry.SHA256)
+		return cachedBuf, cacheEntry.FinalURL, cacheEntry.ContextURL, nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", "", ld.NewJsonLdError(ld.LoadingDocumentFailed,
+			fmt.Sprintf("Bad response status code: %d", res.StatusCode))
+	}
+
+	var contextURL string
+
+	finalURL := res.Request.URL.String()
+	contentType := res.Header.Get("Content-Type")
+	linkHeader := res.Header.Get("Link")
+
+	if len(linkHeader) > 0 && contentType != "application/ld+json" {
+		header := ld.ParseLinkHeader(linkHeader)[linkHeaderRel]
+		if len(header) > 1 {
+			return nil, "", "", ld.NewJsonLdError(ld.MultipleContextLinkHeaders, nil)
+		} else if len(header) == 1 {
+			contextURL = header[0]["target"]
+		}
+	}
+
+	if res.ContentLength > dl.limits.DocumentSize() {
+		return nil, "", "", errors.OutOfRange.Newf("%s: document size %d exceeds maximum document size %d",
+			target, res.ContentLength, dl.limits.DocumentSize())
+	}
+
+	buf, docHash, err := dl.cacheDocument(u, res.Body, locked, isLocked)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	if entry := newHTTPCacheEntry(target, res, contextURL, docHash); entry != nil {
+		if err = entry.write(dl.objectsDir); err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	dl.pin(u, finalURL, contextURL, docHash)
+
+	return buf, finalURL, contextURL, nil
+
+}
 
+// pinnedDocument returns the document u pinned in the context lock.
+// The returned content is nil if u is not pinned or, when online, if
+// the pinned content is missing from the object cache. Documents are
+// treated as unpinned while their pin is revalidated
+func (dl *DocumentLoader) pinnedDocument(u string) ([]byte, LockedDocument, bool, error) {
+
+	locked, isLocked := dl.lockedDocument(u)
+	if !isLocked || dl.revalidatePin(u) {
+		return nil, locked, false, nil
+	}
+
+	buf, err := os.ReadFile(dl.cachedDocumentPath(locked.SHA256))
+	if err == nil {
+		return buf, locked, true, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, locked, true, err
+	}
+	if dl.offline {
+		return nil, locked, true, errors.NotFound.Newf("%s: document pinned in %s (sha256 %s) is missing from the object cache",
+			u, file.ContextLockFileName, locked.SHA256)
+	}
+
+	return nil, locked, true, nil
+}
+
+// cacheDocument reads the document u from body while teeing it into
+// the object cache. The content must match the pinned hash if isLocked
+func (dl *DocumentLoader) cacheDocument(u string, body io.ReadCloser, locked LockedDocument, isLocked bool) ([]byte, string, error) {
+
+	limited := &limitedReadCloser{ReadCloser: body, remaining: dl.limits.DocumentSize(), name: u}
+	cachedDoc, err := NewCachedDocument(limited, dl.objectsDir, crypto.SHA256)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cachedDoc.Close()
+
+	buf, err := io.ReadAll(cachedDoc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	docHash := cachedDoc.Hash()
+
+	if isLocked && docHash != locked.SHA256 {
+		return nil, "", errors.UnexpectedValue.Newf("%s: content hash %s does not match hash %s pinned in %s",
+			u, docHash, locked.SHA256, file.ContextLockFileName)
+	}
+
+	return buf, docHash, nil
+}
+
+// loadIPFSDocument returns the content of the ipfs:// or ipns://
+// document u (resolved from target). Content found in CAR files takes
+// precedence over the IPFS node, which is not used when offline
+func (dl *DocumentLoader) loadIPFSDocument(u string, target *url.URL) ([]byte, error) {
+
+	buf, locked, isLocked, err := dl.pinnedDocument(u)
+	if err != nil || buf != nil {
+		return buf, err
+	}
+
+	ipfsPath := "/" + target.Scheme + "/" + target.Host + target.EscapedPath()
+
+	resolvers := []IPFSResolver{dl.carResolver}
+	if !dl.offline {
+		resolvers = append(resolvers, dl.nodeResolver)
+	}
+
+	var body io.ReadCloser
+
+	for _, resolver := range resolvers {
+		if resolver == nil {
+			continue
+		}
+		if body, err = resolver.Cat(dl.ctxt, ipfsPath); err == nil {
+			break
+		}
+		if errors.GetType(err) != errors.NotFound {
+			return nil, errors.Wrapf(err, "%s", u)
+		}
+	}
+
+	if body == nil {
+		if err == nil {
+			err = errors.NotFound.Newf("%s: no CAR files or IPFS node configured", u)
+		} else if dl.offline {
+			err = errors.Wrapf(err, "%s: IPFS node is not used offline", u)
+		}
 		return nil, err
 	}
+	defer body.Close()
 
-	parsedJSON, _, err := dl.createObjectFile(finalURL, buf)
+	buf, docHash, err := dl.cacheDocument(u, body, locked, isLocked)
 	if err != nil {
 		return nil, err
 	}
-	//fmt.Println("after createObjectFile returns ", objectFilePath)
-	return &ld.RemoteDocument{DocumentURL: finalURL, Document: parsedJSON, ContextURL: contextURL}, nil
 
+	dl.pin(u, u, "", docHash)
+
+	return buf, nil
+}
+
+// loadDataProductDocument returns the content of the dgrz:// document
+// u which names a file in a snapshot of another data product:
+// dgrz://<data product>[@<snapshot>]/<path>. The head of the data
+// product's current branch is used if no snapshot is selected
+func (dl *DocumentLoader) loadDataProductDocument(u string, target *url.URL) ([]byte, error) {
+
+	name, selector := target.Host, ""
+	if target.User != nil {
+		name, selector = target.User.Username(), target.Host
+	}
+
+	productDir, err := dl.dataProductDir(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", u)
+	}
+	productObjectsDir := filepath.Join(productDir, file.DgrzDirName, file.ObjectsDirName)
+
+	var buf []byte
+
+	if locked, isLocked := dl.lockedDocument(u); isLocked {
+		// PINNED SNAPSHOT CONTENT REMAINS IN THE DATA PRODUCT'S OBJECT STORE
+		buf, err = readContent(productObjectsDir, locked.SHA256)
+	} else {
+		var snapshot *Snapshot
+		if snapshot, err = ResolveSnapshot(productDir, productObjectsDir, selector); err == nil {
+			buf, err = snapshot.ReadFile(productObjectsDir, strings.TrimPrefix(target.Path, "/"))
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", u)
+	}
+
+	if int64(len(buf)) > dl.limits.DocumentSize() {
+		return nil, errors.OutOfRange.Newf("%s: document size %d exceeds maximum document size %d",
+			u, len(buf), dl.limits.DocumentSize())
+	}
+
+	dl.pin(u, u, "", fmt.Sprintf("%x", sha256.Sum256(buf)))
+
+	return buf, nil
+}
+
+// lockedDocument returns the pinned document for u (if any)
+func (dl *DocumentLoader) lockedDocument(u string) (LockedDocument, bool) {
+
+	if dl.lock == nil {
+		return LockedDocument{}, false
+	}
+
+	return dl.lock.Get(u)
+}
+
+// dataProductDir returns the grapp dir of the data product 'name'
+func (dl *DocumentLoader) dataProductDir(name string) (string, error) {
+
+	productDir, ok := dl.dataProducts[name]
+	if ok {
+		if !filepath.IsAbs(productDir) {
+			productDir = filepath.Join(dl.grappDir, productDir)
+		}
+	} else if len(dl.dataDir) > 0 {
+		productDir = filepath.Join(dl.dataDir, name)
+	}
+
+	if len(productDir) < 1 || !file.DirExists(filepath.Join(productDir, file.DgrzDirName)) {
+		return "", errors.NotFound.Newf("%s: unknown data product. declare its grapp dir in documentLoader.dataProducts", name)
+	}
+
+	return productDir, nil
+}
+
+// pin records the remote document u in the context lock (if any)
+func (dl *DocumentLoader) pin(u string, finalURL string, contextURL string, docHash string) {
+
+	if dl.lock != nil {
+		dl.lock.Put(u, LockedDocument{URL: finalURL, ContextURL: contextURL, SHA256: docHash})
+	}
+}
+
+// cachedDocumentPath returns the object cache path of a remote document
+// with the hex encoded content hash docHash
+func (dl *DocumentLoader) cachedDocumentPath(docHash string) string {
+	return path.Join(dl.objectsDir, docHash+".jsonld")
 }
 
 func (dl *DocumentLoader) createObjectFile(iri string, data []byte) (interface{}, string, error) {
 
 	//fmt.Println("1.", iri)
 
-	// compute hash on iri
-	iriHash := crypto.SHA1.New()
-	_, err := io.Copy(iriHash, strings.NewReader(iri))
-	if err != nil {
-		return nil, "", err
+	// SKIP PROCESSING OF UNCHANGED DOCUMENTS
+	docSHA256 := fmt.Sprintf("%x", sha256.Sum256(data))
+	if entry := dl.processedEntry(iri, docSHA256); entry != nil {
+		jsonTree, err := parseDocument(data, iri)
+		if err != nil {
+			return nil, "", err
+		}
+		entry.replay(dl)
+		return jsonTree, entry.objectPath(dl.objectsDir), nil
 	}
-	iriHashStr := fmt.Sprintf("%x", iriHash.Sum(nil))
+
 	//fmt.Println("2.", iri)
 	// compute hash on document
 	docHash := crypto.SHA1.New()
-	_, err = io.Copy(docHash, bytes.NewReader(data))
+	_, err := io.Copy(docHash, bytes.NewReader(data))
 	if err != nil {
 		return nil, "", err
 	}
@@ -205,7 +903,7 @@ func (dl *DocumentLoader) createObjectFile(iri string, data []byte) (interface{}
 	//fmt.Println("4.", iri)
 	// PARSE DOC CONTENTS
 	var jsonTree map[string]interface{}
-	jsonTree, err = parseJSON(bytes.NewBuffer(data), iri)
+	jsonTree, err = parseDocument(data, iri)
 	if err != nil {
 		return nil, "", err
 	}
@@ -215,17 +913,31 @@ func (dl *DocumentLoader) createObjectFile(iri string, data []byte) (interface{}
 	var flattenedDoc interface{}
 	proc := ld.NewJsonLdProcessor()
 	options := ld.NewJsonLdOptions("")
-	options.DocumentLoader = NewDocumentLoader(dl.httpClient, dl.grappDir, dl.objectsDir)
+	nestedLoader := dl.descend(iri)
+	options.DocumentLoader = nestedLoader
+
+	// RECORD THE DOCUMENTS THE RESULT DEPENDS ON
+	entry := dl.newProcessedEntry(iri, docSHA256)
+	nestedLoader.onLoad = entry.recorder(dl.onLoad)
 
 	// EXPAND DOC (I.E. EXPAND JSON-LD TERMS TO FULL IRIs)
 
 	expandedDoc, err = proc.Expand(jsonTree, options)
 	if err != nil {
 		//fmt.Println("expand failed", err, iri, jsonTree)
+		if nestedLoader.loadErr != nil {
+			// REPORT WHY THE REFERENCED DOCUMENT FAILED TO LOAD
+			return nil, "", errors.Wrapf(nestedLoader.loadErr, "%s", iri)
+		}
 		return nil, "", err
 	}
 	if len(expandedDoc) < 1 {
 
+		if _, ok := jsonTree["@context"]; ok && len(jsonTree) == 1 {
+			// CONTEXT ONLY DOCUMENT (I.E. A REMOTE CONTEXT). NOTHING TO STORE
+			return jsonTree, "", entry.write(dl.objectsDir)
+		}
+
 		return nil, "", errors.NotFound.New("No RDF statements found after JSON-LD doc expansion")
 
 	}
@@ -237,11 +949,21 @@ func (dl *DocumentLoader) createObjectFile(iri string, data []byte) (interface{}
 		return nil, "", err
 	}
 
+	// NAME THE OBJECT BY THE CANONICAL HASH OF ITS STATEMENTS SO THAT IT
+	// DOES NOT DEPEND ON FORMATTING OR BLANK NODE LABELS
+	dataset, err := ld.NewJsonLdApi().ToRDF(flattenedDoc, options)
+	if err != nil {
+		return nil, "", err
+	}
+	if entry.Canonical, err = rdf.CanonicalHash(dataset); err != nil {
+		return nil, "", errors.Wrapf(err, "%s", iri)
+	}
+
 	//fmt.Println("7.", iri)
-	// ENCODE OBJECT TO CBOR FORMAT
+	// ENCODE OBJECT TO CBOR-LD FORMAT
 	var cborObject []byte
 
-	cborObject, err = cbor.Marshal(flattenedDoc)
+	cborObject, err = dl.encodeObject(iri, jsonTree["@context"], flattenedDoc, entry.Canonical)
 	if err != nil {
 		return nil, "", err
 	}
@@ -253,7 +975,7 @@ func (dl *DocumentLoader) createObjectFile(iri string, data []byte) (interface{}
 	}
 	//fmt.Println("9.", iri)
 	// CONSTRUCT OBJECT FILE  PATH NAME
-	objectFilePath := path.Join(dl.objectsDir, iriHashStr)
+	objectFilePath := path.Join(dl.objectsDir, entry.Canonical)
 
 	//tmp.Close()
 
@@ -263,6 +985,11 @@ func (dl *DocumentLoader) createObjectFile(iri string, data []byte) (interface{}
 		//fmt.Println("rename err", err)
 		return nil, "", err
 	}
+
+	entry.Object = entry.Canonical
+	if err = entry.write(dl.objectsDir); err != nil {
+		return nil, "", err
+	}
 	//fmt.Println("10.", iri)
 	return jsonTree, objectFilePath, nil
 
@@ -273,6 +1000,36 @@ func DocumentFromReader(documentBody io.Reader, src string) (interface{}, error)
 	return parseJSON(documentBody, src)
 }
 
+// parseDocument parses the JSON-LD or, if the path of iri has a
+// YAML-LD extension, the YAML-LD document data
+func parseDocument(data []byte, iri string) (map[string]interface{}, error) {
+
+	if !isYAMLLDDocument(iri) {
+		return parseJSON(bytes.NewReader(data), iri)
+	}
+
+	doc, err := yamlld.Decode(data, iri)
+	if err != nil {
+		return nil, err
+	}
+	jsonMap, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errors.UnexpectedType.Newf("%s: YAML-LD document must be a mapping", iri)
+	}
+
+	return jsonMap, nil
+}
+
+// isYAMLLDDocument returns true if the path of iri has a YAML-LD extension
+func isYAMLLDDocument(iri string) bool {
+
+	if u, err := url.Parse(iri); err == nil {
+		return yamlld.IsYAMLLD(u.Path)
+	}
+
+	return yamlld.IsYAMLLD(iri)
+}
+
 func parseJSON(r io.Reader, src string) (map[string]interface{}, error) {
 
 	jsonMap := make(map[string]interface{})
@@ -318,18 +1075,17 @@ func parseJSON(r io.Reader, src string) (map[string]interface{}, error) {
 
 }
 
-// resolves Prefix IRIs in an alternate location
-// where prefix IRI does not make ontology available in their designated namespace
-func resolveIRI(iri string) string {
+// resolves IRIs in an alternate location using the rewrite rules
+// configured by the grapp and user. The first matching rule is applied
+func (dl *DocumentLoader) resolveIRI(iri string) string {
 
-	switch iri {
-	case "https://schema.org/":
-		return "https://schema.org/version/latest/schemaorg-current-https.jsonld"
-	case "http://schema.org/":
-		return "http://schema.org/version/latest/schemaorg-current-https.jsonld"
-	default:
-		return iri
+	for _, rule := range dl.rewrites {
+		if target, ok := rule.Rewrite(iri); ok {
+			return target
+		}
 	}
+
+	return iri
 }
 
 func NewCachedDocument(r io.ReadCloser, cacheDir string, docHashType crypto.Hash) (*CachedDocument, error) {
@@ -382,7 +1138,7 @@ func (d *CachedDocument) Read(p []byte) (int, error) {
 			if err = os.Rename(d.tmpFile.Name(), docPath); err != nil {
 				return bytesRead, err
 			}
-			//d.cachedDocPath = docPath
+			d.cachedDocPath = docPath
 
 			//fmt.Println("moved cache Doc to ", docPath)
 			// RETURN  EOF
@@ -402,17 +1158,67 @@ func (d *CachedDocument) Read(p []byte) (int, error) {
 
 }
 
+// Hash returns the hex encoded hash of the bytes read so far
+func (d *CachedDocument) Hash() string {
+	return fmt.Sprintf("%x", d.hash.Sum(nil))
+}
+
+// Path returns the path of the cached document once it has been
+// read completely, otherwise an empty string
+func (d *CachedDocument) Path() string {
+	return d.cachedDocPath
+}
+
 func (d *CachedDocument) Close() error {
 
 	err := d.realReader.Close()
 	if err != nil {
 		d.tmpFile.Close()
+		d.removeIncomplete()
 		// return first error
 		return err
 	}
 
-	return d.tmpFile.Close()
+	err = d.tmpFile.Close()
+	d.removeIncomplete()
+
+	return err
+
+}
+
+// removeIncomplete removes the cache temp file of a document
+// that was not read completely
+func (d *CachedDocument) removeIncomplete() {
+	if len(d.cachedDocPath) < 1 {
+		os.Remove(d.tmpFile.Name())
+	}
+}
+
+// limitedReadCloser fails reads past the maximum document size
+type limitedReadCloser struct {
+	io.ReadCloser
+	remaining int64  // bytes that may still be read
+	name      string // document being read
+}
+
+func (r *limitedReadCloser) Read(p []byte) (int, error) {
+
+	if r.remaining < 0 {
+		return 0, errors.OutOfRange.Newf("%s: document exceeds maximum document size", r.name)
+	}
+
+	// READ ONE BYTE PAST THE LIMIT TO DETECT OVERSIZED DOCUMENTS
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return 0, errors.OutOfRange.Newf("%s: document exceeds maximum document size", r.name)
+	}
 
+	return n, err
 }
 
 func (d *CachedDocument) cacheBytesReadAndAddToHash(p []byte) (int, error) {
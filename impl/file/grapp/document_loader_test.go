@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/datacequia/go-dogg3rz/env"
@@ -136,9 +137,19 @@ func TestDocumentLoader(t *testing.T) {
 
 	dl := NewDocumentLoader(nil, gd, od)
 
+	// LOCAL DOCUMENTS MUST LIVE IN THE GRAPP
+	data, err := os.ReadFile("testfiles/schemaorg-current-https.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grappFile := filepath.Join(gd, "schemaorg-current-https.jsonld")
+	if err = os.WriteFile(grappFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
 	var doc *ld.RemoteDocument
 
-	doc, err = dl.LoadDocument("testfiles/schemaorg-current-https.jsonld")
+	doc, err = dl.LoadDocument(grappFile)
 	if err != nil {
 		t.Fatal("dl.LoadDocument", err)
 
@@ -153,3 +164,47 @@ func TestDocumentLoader(t *testing.T) {
 	//t.FailNow()
 
 }
+
+func TestDocumentLoaderIRIRewrite(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	// PRIVATE VOCABULARY SHIPPED INSIDE THE GRAPP
+	if err := os.Mkdir(filepath.Join(grappDir, "vocab"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(grappDir, "vocab", "person.jsonld"), testContext)
+
+	writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"), `{
+    "documentLoader": {
+        "rewrites": [
+            { "match": "prefix", "from": "https://vocab.example.org/", "to": "vocab/" }
+        ]
+    }
+}`)
+
+	writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+		`{"@context": "https://vocab.example.org/person.jsonld", "@type": "Person", "name": "Jane Doe"}`)
+
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal("validation with context rewritten to grapp file failed", err)
+	}
+
+	dl, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := dl.LoadDocument("https://vocab.example.org/person.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.DocumentURL != "https://vocab.example.org/person.jsonld" {
+		t.Errorf("expected rewritten document to keep its IRI, got %s", doc.DocumentURL)
+	}
+
+	if target := dl.resolveIRI("https://schema.org/"); target != "https://schema.org/" {
+		t.Errorf("expected unmatched IRI to be left as is, got %s", target)
+	}
+
+}
@@ -0,0 +1,301 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+	"github.com/piprate/json-gold/ld"
+)
+
+// export formats in addition to the RDF serializations
+const (
+	exportJSONLDExpanded  = "jsonld-expanded"
+	exportJSONLDCompacted = "jsonld-compacted"
+	exportJSONLDFlattened = "jsonld-flattened"
+)
+
+// name of the default graph in a dataset
+const defaultGraphName = "@default"
+
+// ExportFormats returns the names of the formats a grapp can be exported in
+func ExportFormats() []string {
+	return []string{"nquads", "ntriples", "turtle", "trig", exportJSONLDExpanded, exportJSONLDCompacted, exportJSONLDFlattened,
+		"parquet", "hdt"}
+}
+
+func (grapp *FileGrapplicationResource) Export(ctxt context.Context, w io.Writer, options resourcegrapp.ExportOptions,
+	vw io.Writer) error {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	return exportGrapp(ctxt, grappDir, objectsDir, w, options, vw)
+}
+
+// exportGrapp writes the union of the statements in the project files
+// of the grapp in grappDir (or of one of its snapshots) to w
+func exportGrapp(ctxt context.Context, grappDir string, objectsDir string, w io.Writer,
+	options resourcegrapp.ExportOptions, vw io.Writer) error {
+
+	format := strings.ToLower(options.Format)
+	if len(format) < 1 {
+		format = "nquads"
+	}
+
+	var rdfFormat rdf.Format
+	switch format {
+	case exportJSONLDExpanded, exportJSONLDCompacted, exportJSONLDFlattened:
+	default:
+		var err error
+		if rdfFormat, err = rdf.ParseFormat(format); err != nil {
+			return errors.InvalidValue.Newf("%s: unknown export format. expected one of %s",
+				options.Format, strings.Join(ExportFormats(), ", "))
+		}
+	}
+
+	loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		return err
+	}
+
+	dataset, prefixes, err := exportDataset(grappDir, objectsDir, loader, options, vw)
+	if err != nil {
+		return err
+	}
+
+	if err = loader.PinResolved(); err != nil {
+		return err
+	}
+
+	if len(rdfFormat) > 0 {
+		return rdf.Write(w, dataset, rdfFormat, prefixes)
+	}
+
+	ldOptions := ld.NewJsonLdOptions("")
+	ldOptions.DocumentLoader = loader
+
+	expanded, err := ld.NewJsonLdApi().FromRDF(dataset, ldOptions)
+	if err != nil {
+		return err
+	}
+
+	var output interface{} = expanded
+
+	switch format {
+	case exportJSONLDFlattened:
+		if output, err = ld.NewJsonLdProcessor().Flatten(expanded, nil, ldOptions); err != nil {
+			return err
+		}
+
+	case exportJSONLDCompacted:
+		prefixContext := make(map[string]interface{}, len(prefixes))
+		for prefix, ns := range prefixes {
+			prefixContext[prefix] = ns
+		}
+
+		var outputContext, compactContext interface{} = prefixContext, prefixContext
+		if len(options.Context) > 0 {
+			outputContext = options.Context
+			compactContext = options.Context
+			if ref, err := url.Parse(options.Context); err == nil && !ref.IsAbs() {
+				// RELATIVE TO THE GRAPP DIRECTORY
+				compactContext = (&url.URL{Scheme: "file",
+					Path: filepath.ToSlash(filepath.Join(grappDir, filepath.FromSlash(options.Context)))}).String()
+			}
+		}
+
+		compacted, err := ld.NewJsonLdProcessor().Compact(expanded, map[string]interface{}{"@context": compactContext}, ldOptions)
+		if err != nil {
+			if loader.loadErr != nil {
+				return errors.Wrapf(loader.loadErr, "%s", options.Context)
+			}
+			return errors.InvalidValue.Wrapf(err, "compaction failed")
+		}
+		compacted["@context"] = outputContext
+		output = compacted
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(output)
+}
+
+// exportDataset returns the union of the statements of the project
+// files selected by options along with the prefixes their inline
+// contexts declare
+func exportDataset(grappDir string, objectsDir string, loader *DocumentLoader,
+	options resourcegrapp.ExportOptions, vw io.Writer) (*ld.RDFDataset, map[string]string, error) {
+
+	projectFiles, err := exportProjectFiles(grappDir, objectsDir, loader, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prefixes := make(map[string]string)
+
+	union, err := unionDataset(grappDir, objectsDir, loader, projectFiles, prefixes, "Exporting", vw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(options.Graph) > 0 {
+		graph := options.Graph
+		if graph == "default" {
+			graph = defaultGraphName
+		}
+		if _, ok := union.Graphs[graph]; !ok || len(union.Graphs[graph]) < 1 {
+			return nil, nil, errors.NotFound.Newf("%s: graph not found", options.Graph)
+		}
+		selected := ld.NewRDFDataset()
+		selected.Graphs[graph] = union.Graphs[graph]
+		union = selected
+	}
+
+	return union, prefixes, nil
+}
+
+// unionDataset returns the union of the statements of projectFiles
+// (processing them with loader if necessary) and adds the prefixes
+// their inline contexts declare to prefixes. action names the
+// operation in verbose output
+func unionDataset(grappDir string, objectsDir string, loader *DocumentLoader, projectFiles []string,
+	prefixes map[string]string, action string, vw io.Writer) (*ld.RDFDataset, error) {
+
+	var datasets []*ld.RDFDataset
+
+	for _, projectFile := range projectFiles {
+
+		verbose(vw, "%s %s...", action, projectFile)
+
+		doc, err := loader.nested().LoadDocument(filepath.Join(grappDir, filepath.FromSlash(projectFile)))
+		if err != nil {
+			return nil, err
+		}
+		collectPrefixes(doc.Document, prefixes)
+
+		flattened, err := ReadFlattened(objectsDir, projectFile)
+		if err != nil {
+			if errors.GetType(err) == errors.NotFound {
+				// CONTEXT ONLY DOCUMENT
+				continue
+			}
+			return nil, err
+		}
+
+		dataset, err := ld.NewJsonLdApi().ToRDF(flattened, ld.NewJsonLdOptions(""))
+		if err != nil {
+			return nil, errors.InvalidValue.Wrapf(err, "%s", projectFile)
+		}
+		datasets = append(datasets, dataset)
+	}
+
+	return rdf.Union(datasets), nil
+}
+
+// exportProjectFiles returns the grapp relative paths of the project
+// files to export. A snapshot selected by options is assigned to loader
+func exportProjectFiles(grappDir string, objectsDir string, loader *DocumentLoader,
+	options resourcegrapp.ExportOptions) ([]string, error) {
+
+	var projectFiles []string
+	source := grappDir
+
+	if len(options.Snapshot) > 0 {
+		snapshot, err := ResolveSnapshot(grappDir, objectsDir, options.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		loader.SetSnapshot(snapshot)
+		projectFiles = snapshot.ProjectFiles()
+		source = "snapshot " + snapshot.ID
+	} else {
+		files, err := listProjectFiles(grappDir, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			projectFiles = append(projectFiles, filepath.Base(f))
+		}
+		sort.Strings(projectFiles)
+	}
+
+	if len(options.File) > 0 {
+		selected := path.Clean(filepath.ToSlash(options.File))
+		for _, projectFile := range projectFiles {
+			if projectFile == selected {
+				return []string{projectFile}, nil
+			}
+		}
+		return nil, errors.NotFound.Newf("%s: project file not found in %s", options.File, source)
+	}
+
+	if len(projectFiles) < 1 {
+		return nil, errors.NotFound.Newf("%s: no JSON-LD files found.", source)
+	}
+
+	return projectFiles, nil
+}
+
+// collectPrefixes adds the prefixes (i.e. terms mapped to IRIs ending
+// in '/' or '#') declared by the inline context of doc to prefixes.
+// Prefixes already present are kept
+func collectPrefixes(doc interface{}, prefixes map[string]string) {
+
+	node, ok := doc.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	contexts, ok := node["@context"].([]interface{})
+	if !ok {
+		contexts = []interface{}{node["@context"]}
+	}
+
+	for _, c := range contexts {
+		definitions, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for term, definition := range definitions {
+			ns, ok := definition.(string)
+			if !ok || strings.HasPrefix(term, "@") || !(strings.HasSuffix(ns, "/") || strings.HasSuffix(ns, "#")) {
+				continue
+			}
+			if _, exists := prefixes[term]; !exists {
+				prefixes[term] = ns
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package grapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+func TestExport(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	if err := os.Mkdir(filepath.Join(grappDir, "contexts"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(grappDir, "contexts", "people.jsonld"),
+		`{"@context": {"name": "http://schema.org/name", "Person": "http://schema.org/Person"}}`)
+	writeTestFile(t, filepath.Join(grappDir, "people.jsonld"),
+		`{"@context": ["contexts/people.jsonld", {"ex": "http://example.org/"}],
+		  "@id": "ex:jane", "@type": "Person", "name": "Jane Doe"}`)
+	writeTestFile(t, filepath.Join(grappDir, "reviews.jsonld"),
+		`{"@context": {"ex": "http://example.org/", "body": "http://schema.org/reviewBody"},
+		  "@id": "ex:reviews", "@graph": [{"@id": "ex:review1", "body": "Great"}]}`)
+
+	export := func(options resourcegrapp.ExportOptions) string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := exportGrapp(ctxt, grappDir, objectsDir, &buf, options, nil); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	nquads := export(resourcegrapp.ExportOptions{})
+	expected := `<http://example.org/jane> <http://schema.org/name> "Jane Doe" .
+<http://example.org/jane> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://schema.org/Person> .
+<http://example.org/review1> <http://schema.org/reviewBody> "Great" <http://example.org/reviews> .
+`
+	if nquads != expected {
+		t.Errorf("expected N-Quads\n%s\ngot\n%s", expected, nquads)
+	}
+
+	parquet, err := rdf.Parse(strings.NewReader(export(resourcegrapp.ExportOptions{Format: "parquet"})), rdf.Parquet, "", "export.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var quads bytes.Buffer
+	if err = rdf.Write(&quads, parquet, rdf.NQuads, nil); err != nil {
+		t.Fatal(err)
+	}
+	if quads.String() != expected {
+		t.Errorf("expected Parquet export to hold\n%s\ngot\n%s", expected, quads.String())
+	}
+
+	hdt, err := rdf.Parse(strings.NewReader(export(resourcegrapp.ExportOptions{Format: "hdt"})), rdf.HDT, "", "export.hdt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quads.Reset()
+	if err = rdf.Write(&quads, hdt, rdf.NQuads, nil); err != nil {
+		t.Fatal(err)
+	}
+	// GRAPHS ARE MERGED IN HDT FILES
+	if expected := strings.Replace(expected, " <http://example.org/reviews> .", " .", 1); quads.String() != expected {
+		t.Errorf("expected HDT export to hold\n%s\ngot\n%s", expected, quads.String())
+	}
+
+	if ntriples := export(resourcegrapp.ExportOptions{Format: "ntriples"}); strings.Contains(ntriples, "<http://example.org/reviews> .") {
+		t.Errorf("expected graphs merged in N-Triples output, got\n%s", ntriples)
+	}
+
+	trig := export(resourcegrapp.ExportOptions{Format: "trig"})
+	for _, expected := range []string{
+		"@prefix ex: <http://example.org/> .\n",
+		"ex:jane a <http://schema.org/Person> ;\n    <http://schema.org/name> \"Jane Doe\" .\n",
+		"ex:reviews {\n",
+	} {
+		if !strings.Contains(trig, expected) {
+			t.Errorf("expected TriG output to contain %q, got\n%s", expected, trig)
+		}
+	}
+
+	var compacted map[string]interface{}
+	if err := json.Unmarshal([]byte(export(resourcegrapp.ExportOptions{Format: "jsonld-compacted"})), &compacted); err != nil {
+		t.Fatal(err)
+	}
+	if context, _ := compacted["@context"].(map[string]interface{}); context["ex"] != "http://example.org/" {
+		t.Errorf("expected context of collected prefixes, got %v", compacted["@context"])
+	}
+	data, _ := json.Marshal(compacted)
+	if !strings.Contains(string(data), `"@id":"ex:jane"`) {
+		t.Errorf("expected IRIs compacted with collected prefixes, got %s", data)
+	}
+
+	compacted = nil
+	if err := json.Unmarshal([]byte(export(resourcegrapp.ExportOptions{Format: "jsonld-compacted",
+		Context: "contexts/people.jsonld"})), &compacted); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = json.Marshal(compacted)
+	if compacted["@context"] != "contexts/people.jsonld" || !strings.Contains(string(data), `"name":"Jane Doe"`) {
+		t.Errorf("expected output compacted with chosen context, got %s", data)
+	}
+
+	for _, format := range []string{"jsonld-expanded", "jsonld-flattened"} {
+		var output []interface{}
+		if err := json.Unmarshal([]byte(export(resourcegrapp.ExportOptions{Format: format})), &output); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(output) != 2 {
+			t.Errorf("%s: expected the default graph node and the named graph, got %v", format, output)
+		}
+	}
+
+	// RESTRICTED TO ONE GRAPH OR FILE
+	if graph := export(resourcegrapp.ExportOptions{Graph: "http://example.org/reviews"}); graph != `<http://example.org/review1> <http://schema.org/reviewBody> "Great" <http://example.org/reviews> .
+` {
+		t.Errorf("expected named graph only, got\n%s", graph)
+	}
+	if graph := export(resourcegrapp.ExportOptions{Graph: "default"}); strings.Contains(graph, "review1") {
+		t.Errorf("expected default graph only, got\n%s", graph)
+	}
+	if people := export(resourcegrapp.ExportOptions{File: "people.jsonld"}); strings.Contains(people, "review1") || !strings.Contains(people, "Jane Doe") {
+		t.Errorf("expected people.jsonld only, got\n%s", people)
+	}
+
+	// SNAPSHOTS EXPORT THE FILES AND LOCAL CONTEXTS AS THEY WERE
+	snapshot, err := CreateSnapshot(ctxt, grappDir, objectsDir, "people")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(grappDir, "contexts", "people.jsonld"),
+		`{"@context": {"name": "http://xmlns.com/foaf/0.1/name", "Person": "http://schema.org/Person"}}`)
+	if err = os.Remove(filepath.Join(grappDir, "reviews.jsonld")); err != nil {
+		t.Fatal(err)
+	}
+
+	if current := export(resourcegrapp.ExportOptions{}); !strings.Contains(current, "<http://xmlns.com/foaf/0.1/name>") ||
+		strings.Contains(current, "review1") {
+		t.Errorf("expected working tree statements, got\n%s", current)
+	}
+	if old := export(resourcegrapp.ExportOptions{Snapshot: snapshot.ID[:8]}); old != expected {
+		t.Errorf("expected snapshot statements\n%s\ngot\n%s", expected, old)
+	}
+
+	var buf bytes.Buffer
+	for _, test := range []struct {
+		options  resourcegrapp.ExportOptions
+		expected errors.ErrorType
+	}{
+		{resourcegrapp.ExportOptions{Format: "rdfxml"}, errors.InvalidValue},
+		{resourcegrapp.ExportOptions{Graph: "http://example.org/unknown"}, errors.NotFound},
+		{resourcegrapp.ExportOptions{File: "reviews.jsonld"}, errors.NotFound},
+		{resourcegrapp.ExportOptions{Snapshot: "unknown"}, errors.NotFound},
+	} {
+		if err := exportGrapp(ctxt, grappDir, objectsDir, &buf, test.options, nil); errors.GetType(err) != test.expected {
+			t.Errorf("%+v: expected %v, got %v", test.options, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+	"github.com/piprate/json-gold/ld"
+)
+
+// keys written before all others (in this order) by the formatter.
+// The remaining keys follow sorted
+var leadingKeys = []string{"@context", "@id", "@type"}
+
+func (grapp *FileGrapplicationResource) Format(ctxt context.Context, files []string, options resourcegrapp.FormatOptions,
+	out io.Writer, vw io.Writer) error {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	return formatFiles(ctxt, grappDir, objectsDir, files, options, out, vw)
+}
+
+// formatFiles rewrites the JSON-LD files (default: the grapp's .jsonld
+// project files) in formatted form. With options.Check the files are
+// left untouched and the unformatted ones listed on out instead
+func formatFiles(ctxt context.Context, grappDir string, objectsDir string, files []string,
+	options resourcegrapp.FormatOptions, out io.Writer, vw io.Writer) error {
+
+	if len(files) < 1 {
+		projectFiles, err := listProjectFiles(grappDir, vw)
+		if err != nil {
+			return err
+		}
+		for _, f := range projectFiles {
+			if strings.ToLower(filepath.Ext(f)) == ".jsonld" {
+				files = append(files, f)
+			}
+		}
+		if len(files) < 1 {
+			return errors.NotFound.Newf("%s: no JSON-LD files found.", grappDir)
+		}
+	}
+
+	loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		return err
+	}
+
+	unformatted := 0
+
+	for _, f := range files {
+
+		if isYAMLLDDocument(f) {
+			return errors.InvalidValue.Newf("%s: only JSON-LD files can be formatted", f)
+		}
+
+		iri, err := documentIRI(grappDir, f)
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := formatDocument(loader.descend(iri), data, f, options.Compact)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(formatted, data) {
+			verbose(vw, "%s is formatted", f)
+			continue
+		}
+
+		// THE FORMATTED DOCUMENT MUST STATE THE SAME
+		before, err := statementsHash(loader.descend(iri), data, f)
+		if err != nil {
+			return err
+		}
+		after, err := statementsHash(loader.descend(iri), formatted, f)
+		if err != nil {
+			return err
+		}
+		if before != after {
+			return errors.UnexpectedValue.Newf("%s: formatting would change the statements of the document", f)
+		}
+
+		if options.Check {
+			unformatted++
+			fmt.Fprintln(out, f)
+			continue
+		}
+
+		if _, err = file.WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(formatted), nil }, f); err != nil {
+			return err
+		}
+		verbose(vw, "Formatted %s", f)
+	}
+
+	if unformatted > 0 {
+		return errors.InvalidState.Newf("%d of %d files are not formatted", unformatted, len(files))
+	}
+
+	return nil
+}
+
+// documentIRI returns the identifier documents referenced by the local
+// document path are loaded for: its grapp relative path if it lies in
+// the grapp, its absolute path otherwise
+func documentIRI(grappDir string, path string) (string, error) {
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	absGrappDir, err := filepath.Abs(grappDir)
+	if err != nil {
+		return "", err
+	}
+
+	if !pathWithin(absGrappDir, absPath) {
+		return absPath, nil
+	}
+
+	relPath, err := filepath.Rel(absGrappDir, absPath)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(relPath), nil
+}
+
+// formatDocument returns the formatted form of the JSON-LD document
+// data. With compact set full IRIs are compacted against the
+// document's own context, resolved by loader
+func formatDocument(loader *DocumentLoader, data []byte, src string, compact bool) ([]byte, error) {
+
+	var doc interface{}
+
+	if compact {
+
+		parsed, err := parseJSON(bytes.NewReader(data), src)
+		if err != nil {
+			return nil, err
+		}
+
+		if documentContext, ok := parsed["@context"]; ok {
+			options := ld.NewJsonLdOptions("")
+			options.DocumentLoader = loader
+			compacted, err := ld.NewJsonLdProcessor().Compact(parsed, map[string]interface{}{"@context": documentContext}, options)
+			if err != nil {
+				if loader.loadErr != nil {
+					return nil, errors.Wrapf(loader.loadErr, "%s", src)
+				}
+				return nil, errors.InvalidValue.Wrapf(err, "%s: compaction failed", src)
+			}
+			doc = compacted
+		}
+	}
+
+	if doc == nil {
+		// KEEP NUMBERS AS WRITTEN
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, errors.InvalidValue.Wrapf(err, "%s", src)
+		}
+	}
+
+	return formatJSON(doc)
+}
+
+// formatJSON encodes doc indented by two spaces with object keys in
+// formatting order
+func formatJSON(doc interface{}) ([]byte, error) {
+
+	var compact bytes.Buffer
+	if err := encodeJSON(&compact, formattedValue(doc)); err != nil {
+		return nil, err
+	}
+
+	var formatted bytes.Buffer
+	if err := json.Indent(&formatted, compact.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	formatted.WriteByte('\n')
+
+	return formatted.Bytes(), nil
+}
+
+// formattedObject is a JSON object encoded with its keys in
+// formatting order
+type formattedObject map[string]interface{}
+
+func (o formattedObject) MarshalJSON() ([]byte, error) {
+
+	keys := make([]string, 0, len(o))
+	for _, key := range leadingKeys {
+		if _, ok := o[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	leading := len(keys)
+	for key := range o {
+		if !isLeadingKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys[leading:])
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeJSON(&buf, key); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := encodeJSON(&buf, formattedValue(o[key])); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func isLeadingKey(key string) bool {
+
+	for _, k := range leadingKeys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formattedValue returns v with its objects replaced by formattedObjects
+func formattedValue(v interface{}) interface{} {
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return formattedObject(value)
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = formattedValue(item)
+		}
+		return items
+	}
+
+	return v
+}
+
+// encodeJSON appends the compact JSON encoding of v to buf without
+// escaping HTML characters
+func encodeJSON(buf *bytes.Buffer, v interface{}) error {
+
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // TRAILING NEWLINE
+
+	return nil
+}
+
+// statementsHash returns the canonical hash of the statements of the
+// JSON-LD document data after expanding it with loader
+func statementsHash(loader *DocumentLoader, data []byte, src string) (string, error) {
+
+	doc, err := parseJSON(bytes.NewReader(data), src)
+	if err != nil {
+		return "", err
+	}
+
+	options := ld.NewJsonLdOptions("")
+	options.DocumentLoader = loader
+
+	expanded, err := ld.NewJsonLdProcessor().Expand(doc, options)
+	if err != nil {
+		if loader.loadErr != nil {
+			return "", errors.Wrapf(loader.loadErr, "%s", src)
+		}
+		return "", errors.InvalidValue.Wrapf(err, "%s", src)
+	}
+
+	dataset, err := ld.NewJsonLdApi().ToRDF(expanded, options)
+	if err != nil {
+		return "", errors.InvalidValue.Wrapf(err, "%s", src)
+	}
+
+	return rdf.CanonicalHash(dataset)
+}
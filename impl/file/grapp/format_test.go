@@ -0,0 +1,97 @@
+package grapp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+func TestFormat(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	personFile := filepath.Join(grappDir, "person.jsonld")
+	writeTestFile(t, personFile, `{"name": "Jane & Joe", "@type": "Person", "height": 1.50,
+"@id": "ex:jane",   "@context": {"ex": "http://example.org/", "name": "ex:name", "Person": "ex:Person", "height": "ex:height"},
+  "http://example.org/knows": {"@id": "ex:joe", "http://example.org/name": "Joe"}, "tags": [], "extra": {}}`)
+
+	formatted := `{
+  "@context": {
+    "Person": "ex:Person",
+    "ex": "http://example.org/",
+    "height": "ex:height",
+    "name": "ex:name"
+  },
+  "@id": "ex:jane",
+  "@type": "Person",
+  "extra": {},
+  "height": 1.50,
+  "http://example.org/knows": {
+    "@id": "ex:joe",
+    "http://example.org/name": "Joe"
+  },
+  "name": "Jane & Joe",
+  "tags": []
+}
+`
+
+	format := func(options resourcegrapp.FormatOptions, files ...string) (string, error) {
+		var out bytes.Buffer
+		err := formatFiles(ctxt, grappDir, objectsDir, files, options, &out, nil)
+		return out.String(), err
+	}
+
+	readPerson := func() string {
+		data, err := os.ReadFile(personFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// CHECK REPORTS BUT DOES NOT REWRITE
+	original := readPerson()
+	listed, err := format(resourcegrapp.FormatOptions{Check: true})
+	if errors.GetType(err) != errors.InvalidState {
+		t.Errorf("expected InvalidState error for unformatted file, got %v", err)
+	}
+	if listed != personFile+"\n" {
+		t.Errorf("expected %s to be listed, got %q", personFile, listed)
+	}
+	if readPerson() != original {
+		t.Errorf("expected check to leave file untouched")
+	}
+
+	if _, err := format(resourcegrapp.FormatOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if actual := readPerson(); actual != formatted {
+		t.Errorf("expected\n%s\ngot\n%s", formatted, actual)
+	}
+
+	// FORMATTED FILES PASS THE CHECK
+	if listed, err := format(resourcegrapp.FormatOptions{Check: true}, personFile); err != nil || len(listed) > 0 {
+		t.Errorf("expected formatted file to pass check, got %q (%v)", listed, err)
+	}
+
+	// COMPACTION USES THE DOCUMENT'S OWN CONTEXT
+	if _, err := format(resourcegrapp.FormatOptions{Compact: true}); err != nil {
+		t.Fatal(err)
+	}
+	compacted := readPerson()
+	for _, expected := range []string{`"ex:knows": {`, `"name": "Joe"`, `"height": 1.5,`} {
+		if !bytes.Contains([]byte(compacted), []byte(expected)) {
+			t.Errorf("expected compacted document to contain %s, got\n%s", expected, compacted)
+		}
+	}
+
+	yamlldFile := filepath.Join(grappDir, "person.yamlld")
+	writeTestFile(t, yamlldFile, "\"@id\": http://example.org/jane\n")
+	if _, err := format(resourcegrapp.FormatOptions{}, yamlldFile); errors.GetType(err) != errors.InvalidValue {
+		t.Errorf("expected InvalidValue error for YAML-LD file, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+	"github.com/piprate/json-gold/ld"
+)
+
+func (grapp *FileGrapplicationResource) Frame(ctxt context.Context, w io.Writer, options resourcegrapp.FrameOptions,
+	vw io.Writer) error {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	return frameGrapp(ctxt, grappDir, objectsDir, w, options, vw)
+}
+
+// frameGrapp writes the union of the statements in the project files
+// of the grapp in grappDir (or of one of its snapshots) to w shaped by
+// a JSON-LD 1.1 frame
+func frameGrapp(ctxt context.Context, grappDir string, objectsDir string, w io.Writer,
+	options resourcegrapp.FrameOptions, vw io.Writer) error {
+
+	if len(options.Frame) < 1 {
+		return errors.InvalidValue.New("no frame given")
+	}
+
+	frame, err := readFrame(options.Frame)
+	if err != nil {
+		return err
+	}
+
+	loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		return err
+	}
+
+	dataset, _, err := exportDataset(grappDir, objectsDir, loader, resourcegrapp.ExportOptions{Snapshot: options.Snapshot}, vw)
+	if err != nil {
+		return err
+	}
+
+	// CONTEXTS REFERENCED BY THE FRAME ARE RESOLVED LIKE THOSE OF PROJECT FILES
+	ldOptions := ld.NewJsonLdOptions("")
+	ldOptions.DocumentLoader = loader
+
+	expanded, err := ld.NewJsonLdApi().FromRDF(dataset, ldOptions)
+	if err != nil {
+		return err
+	}
+
+	// JSON-LD 1.1 DEFAULTS. THE FRAME'S FLAGS OVERRIDE THEM
+	ldOptions.OmitGraph = true
+	ldOptions.RequireAll = false
+
+	verbose(vw, "Framing with %s...", options.Frame)
+	framed, err := ld.NewJsonLdProcessor().Frame(expanded, frame, ldOptions)
+	if err != nil {
+		if loader.loadErr != nil {
+			return errors.Wrapf(loader.loadErr, "%s", options.Frame)
+		}
+		return errors.InvalidValue.Wrapf(err, "%s: framing failed", options.Frame)
+	}
+
+	if err = loader.PinResolved(); err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(framed)
+}
+
+// readFrame reads the JSON-LD or YAML-LD frame in framePath. Relative
+// context references are resolved against its location
+func readFrame(framePath string) (map[string]interface{}, error) {
+
+	data, err := os.ReadFile(framePath)
+	if err != nil {
+		return nil, err
+	}
+
+	frame, err := parseDocument(data, framePath)
+	if err != nil {
+		return nil, err
+	}
+
+	absPath, err := filepath.Abs(framePath)
+	if err != nil {
+		return nil, err
+	}
+	resolveContextReferences(frame, &url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)})
+
+	return frame, nil
+}
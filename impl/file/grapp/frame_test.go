@@ -0,0 +1,82 @@
+package grapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+func TestFrame(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	if err := os.Mkdir(filepath.Join(grappDir, "contexts"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(grappDir, "contexts", "dcat.jsonld"),
+		`{"@context": {"dcat": "http://www.w3.org/ns/dcat#", "ex": "http://example.org/",
+		  "Dataset": "dcat:Dataset", "Distribution": "dcat:Distribution",
+		  "distribution": {"@id": "dcat:distribution", "@type": "@id"},
+		  "title": "http://purl.org/dc/terms/title"}}`)
+	writeTestFile(t, filepath.Join(grappDir, "datasets.jsonld"),
+		`{"@context": "contexts/dcat.jsonld",
+		  "@graph": [{"@id": "ex:ds1", "@type": "Dataset", "title": "One", "distribution": "ex:csv"}]}`)
+	writeTestFile(t, filepath.Join(grappDir, "distributions.jsonld"),
+		`{"@context": "contexts/dcat.jsonld", "@id": "ex:csv", "@type": "Distribution", "title": "CSV"}`)
+
+	// THE FRAME'S CONTEXT IS RESOLVED RELATIVE TO THE FRAME
+	frameFile := filepath.Join(grappDir, "contexts", "datasets.frame.yamlld")
+	writeTestFile(t, frameFile, `"@context": dcat.jsonld
+"@type": Dataset
+distribution:
+  "@embed": "@always"
+`)
+
+	frame := func(options resourcegrapp.FrameOptions) map[string]interface{} {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := frameGrapp(ctxt, grappDir, objectsDir, &buf, options, nil); err != nil {
+			t.Fatal(err)
+		}
+		var framed map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &framed); err != nil {
+			t.Fatal(err)
+		}
+		delete(framed, "@context")
+		return framed
+	}
+
+	expected := map[string]interface{}{
+		"@id": "ex:ds1", "@type": "Dataset", "title": "One",
+		"distribution": map[string]interface{}{"@id": "ex:csv", "@type": "Distribution", "title": "CSV"},
+	}
+	if framed := frame(resourcegrapp.FrameOptions{Frame: frameFile}); !reflect.DeepEqual(framed, expected) {
+		t.Errorf("expected\n%v\ngot\n%v", expected, framed)
+	}
+
+	// FRAME A SNAPSHOT
+	snapshot, err := CreateSnapshot(ctxt, grappDir, objectsDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(grappDir, "distributions.jsonld")); err != nil {
+		t.Fatal(err)
+	}
+	if framed := frame(resourcegrapp.FrameOptions{Frame: frameFile, Snapshot: snapshot.ID}); !reflect.DeepEqual(framed, expected) {
+		t.Errorf("snapshot: expected\n%v\ngot\n%v", expected, framed)
+	}
+
+	for _, options := range []resourcegrapp.FrameOptions{{}, {Frame: filepath.Join(grappDir, "missing.jsonld")}} {
+		if err := frameGrapp(ctxt, grappDir, objectsDir, &bytes.Buffer{}, options, nil); err == nil {
+			t.Errorf("%q: expected error", options.Frame)
+		} else if options.Frame == "" && errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("expected InvalidValue error without frame, got %v", err)
+		}
+	}
+}
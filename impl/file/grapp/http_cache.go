@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+// suffix of http cache metadata files in the object cache
+const httpCacheEntrySuffix = ".http.json"
+
+// httpCacheEntry is the http caching metadata of a remote document
+// stored next to its cached content in the object cache
+type httpCacheEntry struct {
+	URL          string    `json:"url"`                    // requested url
+	FinalURL     string    `json:"finalUrl"`               // url after redirects
+	ContextURL   string    `json:"contextUrl,omitempty"`   // context from http Link header (if any)
+	SHA256       string    `json:"sha256"`                 // content hash of cached document
+	ETag         string    `json:"etag,omitempty"`         // ETag response header
+	LastModified string    `json:"lastModified,omitempty"` // Last-Modified response header
+	CacheControl string    `json:"cacheControl,omitempty"` // Cache-Control response header
+	Fetched      time.Time `json:"fetched"`                // when the response was (re)validated
+}
+
+// httpCacheEntryPath returns the location of the http cache metadata
+// for the document at url
+func httpCacheEntryPath(objectsDir string, url string) string {
+	return filepath.Join(objectsDir, fmt.Sprintf("%x", sha256.Sum256([]byte(url)))+httpCacheEntrySuffix)
+}
+
+// readHTTPCacheEntry returns the http cache metadata for the document
+// at url or nil if the document has not been cached
+func readHTTPCacheEntry(objectsDir string, url string) (*httpCacheEntry, error) {
+
+	entryPath := httpCacheEntryPath(objectsDir, url)
+
+	data, err := os.ReadFile(entryPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	entry := &httpCacheEntry{}
+	if err = json.Unmarshal(data, entry); err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "%s", entryPath)
+	}
+
+	if entry.URL != url {
+		// HASH COLLISION OR TAMPERED ENTRY. TREAT AS NOT CACHED
+		return nil, nil
+	}
+
+	return entry, nil
+}
+
+// newHTTPCacheEntry creates the http cache metadata for the response res
+// to a request for url. nil is returned if the response may not be stored
+func newHTTPCacheEntry(url string, res *http.Response, contextURL string, docHash string) *httpCacheEntry {
+
+	cacheControl := res.Header.Get("Cache-Control")
+	if _, noStore := parseCacheControl(cacheControl)["no-store"]; noStore {
+		return nil
+	}
+
+	return &httpCacheEntry{
+		URL:          url,
+		FinalURL:     res.Request.URL.String(),
+		ContextURL:   contextURL,
+		SHA256:       docHash,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		CacheControl: cacheControl,
+		Fetched:      time.Now().UTC(),
+	}
+}
+
+// write saves the entry in objectsDir
+func (e *httpCacheEntry) write(objectsDir string) error {
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(data), nil },
+		httpCacheEntryPath(objectsDir, e.URL))
+
+	return err
+}
+
+// fresh returns true if the cached response may be used at time now
+// without revalidating it with the origin server
+func (e *httpCacheEntry) fresh(now time.Time) bool {
+
+	directives := parseCacheControl(e.CacheControl)
+
+	if _, noCache := directives["no-cache"]; noCache {
+		return false
+	}
+
+	maxAge, ok := directives["max-age"]
+	if !ok {
+		return false
+	}
+
+	seconds, err := strconv.ParseInt(maxAge, 10, 64)
+	if err != nil || seconds <= 0 {
+		return false
+	}
+
+	return now.Before(e.Fetched.Add(time.Duration(seconds) * time.Second))
+}
+
+// revalidate adds the conditional request headers that ask the origin
+// server whether the cached response is still current
+func (e *httpCacheEntry) revalidate(req *http.Request) {
+
+	if len(e.ETag) > 0 {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if len(e.LastModified) > 0 {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+// refresh updates the entry from a 304 Not Modified response res
+func (e *httpCacheEntry) refresh(res *http.Response) {
+
+	// A 304 RESPONSE MAY CARRY UPDATED VALIDATORS AND FRESHNESS
+	if etag := res.Header.Get("ETag"); len(etag) > 0 {
+		e.ETag = etag
+	}
+	if lastModified := res.Header.Get("Last-Modified"); len(lastModified) > 0 {
+		e.LastModified = lastModified
+	}
+	if cacheControl := res.Header.Get("Cache-Control"); len(cacheControl) > 0 {
+		e.CacheControl = cacheControl
+	}
+	e.Fetched = time.Now().UTC()
+}
+
+// parseCacheControl returns the directives in a Cache-Control header
+// value mapped to their (unquoted) arguments
+func parseCacheControl(value string) map[string]string {
+
+	directives := make(map[string]string)
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) < 1 {
+			continue
+		}
+		name, arg, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+	}
+
+	return directives
+}
@@ -0,0 +1,179 @@
+package grapp
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/env"
+)
+
+func TestDocumentLoaderHTTPCache(t *testing.T) {
+
+	_, grappDir, objectsDir := initTestGrapp(t)
+
+	const etag = `"v1"`
+	var cacheControl string
+	var fetched, notModified int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", cacheControl)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetched++
+		w.Header().Set("Content-Type", "application/ld+json")
+		w.Write([]byte(testContext))
+	}))
+	defer server.Close()
+
+	load := func(iri string) {
+		t.Helper()
+		if _, err := NewDocumentLoader(nil, grappDir, objectsDir).LoadDocument(iri); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// FRESH RESPONSES ARE SERVED FROM CACHE WITHOUT A REQUEST
+	cacheControl = "max-age=3600"
+	freshIRI := server.URL + "/fresh.jsonld"
+	load(freshIRI)
+	load(freshIRI)
+	if fetched != 1 || notModified != 0 {
+		t.Errorf("expected 1 fetch and no revalidation of fresh document, got %d fetches and %d revalidations",
+			fetched, notModified)
+	}
+
+	// STALE RESPONSES ARE REVALIDATED WITH A CONDITIONAL REQUEST
+	fetched = 0
+	cacheControl = "max-age=0"
+	staleIRI := server.URL + "/stale.jsonld"
+	load(staleIRI)
+	load(staleIRI)
+	if fetched != 1 || notModified != 1 {
+		t.Errorf("expected 1 fetch and 1 revalidation of stale document, got %d fetches and %d revalidations",
+			fetched, notModified)
+	}
+
+	// NO-STORE RESPONSES ARE NOT REUSED
+	fetched, notModified = 0, 0
+	cacheControl = "no-store"
+	noStoreIRI := server.URL + "/no-store.jsonld"
+	load(noStoreIRI)
+	load(noStoreIRI)
+	if fetched != 2 || notModified != 0 {
+		t.Errorf("expected 2 fetches of no-store document, got %d fetches and %d revalidations",
+			fetched, notModified)
+	}
+
+}
+
+func TestContextLockUpdate(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	etag := `"v1"`
+	content := testContext
+	var fetched, notModified int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "max-age=0")
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetched++
+		w.Header().Set("Content-Type", "application/ld+json")
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	contextIRI := server.URL + "/context.jsonld"
+	writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+		`{"@context": "`+contextIRI+`", "@type": "Person", "name": "Jane Doe"}`)
+
+	updateCtxt := context.WithValue(ctxt, env.EnvDogg3rzUpdateLock, "true")
+
+	validate := func(ctxt context.Context, expectFetched int, expectNotModified int) string {
+		t.Helper()
+		fetched, notModified = 0, 0
+		if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+			t.Fatal(err)
+		}
+		if fetched != expectFetched || notModified != expectNotModified {
+			t.Errorf("expected %d fetches and %d revalidations, got %d fetches and %d revalidations",
+				expectFetched, expectNotModified, fetched, notModified)
+		}
+		lock, err := ReadContextLock(grappDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pinned, ok := lock.Get(contextIRI)
+		if !ok {
+			t.Fatalf("expected %s to be pinned in %s", contextIRI, lock.Path())
+		}
+		return pinned.SHA256
+	}
+
+	// PINNED DOCUMENTS ARE SERVED WITHOUT A REQUEST
+	pinned := validate(ctxt, 1, 0)
+	if validate(ctxt, 0, 0) != pinned {
+		t.Error("expected pin to be unchanged")
+	}
+
+	// UPDATING THE LOCK REVALIDATES STALE PINNED DOCUMENTS
+	if validate(updateCtxt, 0, 1) != pinned {
+		t.Error("expected pin of unmodified document to be unchanged")
+	}
+
+	// CHANGED DOCUMENTS ARE ONLY RE-PINNED WHEN UPDATING THE LOCK
+	etag = `"v2"`
+	content = strings.Replace(testContext, "http://example.org/name", "http://schema.org/name", 1)
+	if validate(ctxt, 0, 0) != pinned {
+		t.Error("expected pin to be unchanged without updating the lock")
+	}
+	updated := validate(updateCtxt, 1, 0)
+	if updated == pinned {
+		t.Error("expected changed document to be re-pinned when updating the lock")
+	}
+	if expected := fmt.Sprintf("%x", sha256.Sum256([]byte(content))); updated != expected {
+		t.Errorf("expected pin %s of changed content, got %s", expected, updated)
+	}
+
+}
+
+func TestHTTPCacheEntryFresh(t *testing.T) {
+
+	now := time.Now()
+
+	tests := []struct {
+		cacheControl string
+		age          time.Duration
+		fresh        bool
+	}{
+		{"max-age=60", 30 * time.Second, true},
+		{"public, max-age=60", 90 * time.Second, false},
+		{"max-age=60, no-cache", 0, false},
+		{"", 0, false},
+		{"max-age=bogus", 0, false},
+	}
+
+	for _, test := range tests {
+		entry := &httpCacheEntry{CacheControl: test.cacheControl, Fetched: now.Add(-test.age)}
+		if fresh := entry.fresh(now); fresh != test.fresh {
+			t.Errorf("%q fetched %s ago: expected fresh=%v, got %v", test.cacheControl, test.age, test.fresh, fresh)
+		}
+	}
+
+}
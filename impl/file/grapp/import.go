@@ -0,0 +1,282 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+	"github.com/datacequia/go-dogg3rz/yamlld"
+	"github.com/piprate/json-gold/ld"
+)
+
+// vocabularies used to record the provenance of imported data
+const (
+	provNamespace    = "http://www.w3.org/ns/prov#"
+	dctermsNamespace = "http://purl.org/dc/terms/"
+)
+
+func (grapp *FileGrapplicationResource) Import(ctxt context.Context, src string, options resourcegrapp.ImportOptions,
+	vw io.Writer) (string, error) {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return "", err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return "", err
+	}
+
+	return importRDF(ctxt, grappDir, objectsDir, src, options, vw)
+}
+
+// importRDF converts the RDF file src to a compacted JSON-LD project
+// file of the grapp in grappDir
+func importRDF(ctxt context.Context, grappDir string, objectsDir string, src string,
+	options resourcegrapp.ImportOptions, vw io.Writer) (string, error) {
+
+	var format rdf.Format
+	var err error
+
+	if len(options.Format) > 0 {
+		format, err = rdf.ParseFormat(options.Format)
+	} else {
+		format, err = rdf.FormatOf(src)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	outputPath, err := importOutputPath(grappDir, src, options)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+
+	srcURL, err := fileURL(src)
+	if err != nil {
+		return "", err
+	}
+
+	verbose(vw, "Parsing %s as %s...", src, format.MediaType())
+	dataset, err := rdf.Parse(bytes.NewReader(data), format, srcURL, src)
+	if err != nil {
+		return "", err
+	}
+
+	location, err := importLocation(grappDir, src, options)
+	if err != nil {
+		return "", err
+	}
+
+	source := importSource{location: location, hash: fmt.Sprintf("%x", sha256.Sum256(data)), mediaType: format.MediaType()}
+	if err = importDataset(ctxt, grappDir, objectsDir, dataset, src, source, outputPath, options); err != nil {
+		return "", err
+	}
+
+	verbose(vw, "Imported %d graph(s) from %s into %s", len(dataset.Graphs), src, filepath.Base(outputPath))
+
+	return outputPath, nil
+}
+
+// importOutputPath returns the path of the project file an import of
+// src writes
+func importOutputPath(grappDir string, src string, options resourcegrapp.ImportOptions) (string, error) {
+
+	output := options.Output
+	if len(output) < 1 {
+		output = strings.TrimSuffix(filepath.Base(src), filepath.Ext(src)) + ".jsonld"
+	}
+	if filepath.Base(output) != output || !isProjectFileName(output) || isNDJSONLDDocument(output) {
+		return "", errors.InvalidValue.Newf("%s: output must be the name of a .jsonld or .yamlld file in the grapp directory", output)
+	}
+	outputPath := filepath.Join(grappDir, output)
+	if file.FileExists(outputPath) && !options.Force {
+		return "", errors.AlreadyExists.Newf("%s: project file already exists. use --force to overwrite it", output)
+	}
+
+	return outputPath, nil
+}
+
+// importSource describes the source of imported statements
+type importSource struct {
+	location  interface{} // JSON-LD value of its prov:atLocation (nil if not recorded)
+	hash      string      // sha256 of the content
+	mediaType string
+}
+
+// importLocation returns the location recorded for the imported file
+// src: the IRI in options.Source or else the path of src relative to
+// grappDir. Local paths outside the grapp are not recorded
+func importLocation(grappDir string, src string, options resourcegrapp.ImportOptions) (interface{}, error) {
+
+	if len(options.Source) > 0 {
+		if u, err := url.Parse(options.Source); err != nil || !u.IsAbs() {
+			return nil, errors.InvalidValue.Newf("%s: source must be an absolute IRI", options.Source)
+		}
+		return map[string]interface{}{"@id": options.Source}, nil
+	}
+
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return nil, err
+	}
+	relPath, err := filepath.Rel(grappDir, absSrc)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return nil, nil
+	}
+
+	// RECORDED AS A LITERAL. A RELATIVE IRI WOULD BE RESOLVED AGAINST THE PROJECT FILE
+	return map[string]interface{}{"@value": filepath.ToSlash(relPath)}, nil
+}
+
+// importDataset writes dataset imported from src as a compacted
+// JSON-LD project file to outputPath with the provenance of source
+func importDataset(ctxt context.Context, grappDir string, objectsDir string, dataset *ld.RDFDataset, src string,
+	source importSource, outputPath string, options resourcegrapp.ImportOptions) error {
+
+	expanded, err := ld.NewJsonLdApi().FromRDF(dataset, ld.NewJsonLdOptions(""))
+	if err != nil {
+		return errors.InvalidValue.Wrapf(err, "%s", src)
+	}
+
+	expanded = withProvenance(expanded, source)
+
+	loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		return err
+	}
+
+	// COMPACT WITH THE CHOSEN CONTEXT OR THE PREFIXES OF THE SOURCE
+	var outputContext, compactContext interface{}
+
+	if len(options.Context) > 0 {
+		outputContext = options.Context
+		compactContext = options.Context
+		if ref, err := url.Parse(options.Context); err == nil && !ref.IsAbs() {
+			// RELATIVE TO THE PROJECT FILE (I.E. THE GRAPP DIRECTORY)
+			compactContext = (&url.URL{Scheme: "file",
+				Path: filepath.ToSlash(filepath.Join(grappDir, filepath.FromSlash(options.Context)))}).String()
+		}
+	} else {
+		derived := dataset.GetContext()
+		for prefix, ns := range map[string]string{"prov": provNamespace, "dcterms": dctermsNamespace} {
+			if _, ok := derived[prefix]; !ok {
+				derived[prefix] = ns
+			}
+		}
+		outputContext = derived
+		compactContext = derived
+	}
+
+	ldOptions := ld.NewJsonLdOptions("")
+	ldOptions.DocumentLoader = loader
+
+	compacted, err := ld.NewJsonLdProcessor().Compact(expanded, map[string]interface{}{"@context": compactContext}, ldOptions)
+	if err != nil {
+		if loader.loadErr != nil {
+			return errors.Wrapf(loader.loadErr, "%s", options.Context)
+		}
+		return errors.InvalidValue.Wrapf(err, "%s: compaction failed", src)
+	}
+	compacted["@context"] = outputContext
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(compacted); err != nil {
+		return err
+	}
+	content := buf.Bytes()
+	if yamlld.IsYAMLLD(outputPath) {
+		if content, err = yamlld.FromJSON(content, src); err != nil {
+			return err
+		}
+	}
+
+	// A PROJECT FILE REPLACED WITH --force IS RESTORED IF THE NEW ONE IS INVALID
+	previous, err := os.ReadFile(outputPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	replaced := err == nil
+
+	if _, err = file.WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(content), nil },
+		outputPath); err != nil {
+		return err
+	}
+
+	// THE NEW PROJECT FILE MUST BE VALID
+	if _, err = loader.nested().LoadDocument(outputPath); err != nil {
+		if !replaced {
+			os.Remove(outputPath)
+		} else if _, restoreErr := file.WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(previous), nil },
+			outputPath); restoreErr != nil {
+			return errors.Wrapf(err, "%s: failed to restore previous content (%s)", outputPath, restoreErr)
+		}
+		return err
+	}
+	if err = loader.PinResolved(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// withProvenance records in expanded that its statements were derived
+// from source. The source is described as a prov:Entity identified by
+// its content hash and each named graph and each subject of the default
+// graph is linked to it
+func withProvenance(expanded []interface{}, source importSource) []interface{} {
+
+	sourceID := "urn:sha256:" + source.hash
+
+	// THE TOP LEVEL NODES OF RDF CONVERTED TO JSON-LD ARE THE SUBJECTS AND
+	// NAMED GRAPHS OF THE DEFAULT GRAPH
+	for _, item := range expanded {
+		if node, ok := item.(map[string]interface{}); ok {
+			node[provNamespace+"wasDerivedFrom"] = []interface{}{map[string]interface{}{"@id": sourceID}}
+		}
+	}
+
+	entity := map[string]interface{}{
+		"@id":                       sourceID,
+		"@type":                     []interface{}{provNamespace + "Entity"},
+		dctermsNamespace + "format": []interface{}{map[string]interface{}{"@value": source.mediaType}},
+	}
+	if source.location != nil {
+		entity[provNamespace+"atLocation"] = []interface{}{source.location}
+	}
+
+	return append(expanded, entity)
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/datacequia/go-dogg3rz/csvw"
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+func (grapp *FileGrapplicationResource) ImportCSV(ctxt context.Context, src string, options resourcegrapp.CSVImportOptions,
+	vw io.Writer) (string, error) {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return "", err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return "", err
+	}
+
+	return importCSV(ctxt, grappDir, objectsDir, src, options, vw)
+}
+
+// importCSV converts the CSV file src to RDF as described by its CSVW
+// metadata and writes the result as a compacted JSON-LD project file
+// of the grapp in grappDir
+func importCSV(ctxt context.Context, grappDir string, objectsDir string, src string,
+	options resourcegrapp.CSVImportOptions, vw io.Writer) (string, error) {
+
+	mode, err := csvw.ParseMode(options.Mode)
+	if err != nil {
+		return "", err
+	}
+
+	outputPath, err := importOutputPath(grappDir, src, options.ImportOptions)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+
+	srcURL, err := fileURL(src)
+	if err != nil {
+		return "", err
+	}
+
+	group, err := csvMetadata(src, srcURL, options.Metadata, vw)
+	if err != nil {
+		return "", err
+	}
+
+	open := func(table *csvw.Table) (io.Reader, error) {
+		// THE FILE BEING IMPORTED IS THE ONLY TABLE OR THE ONE WITH ITS URL
+		if len(group.Tables) == 1 || table.URL == srcURL {
+			return bytes.NewReader(data), nil
+		}
+		u, err := url.Parse(table.URL)
+		if err != nil || u.Scheme != "file" {
+			return nil, errors.NotImplemented.Newf("%s: only local tables can be converted", table.URL)
+		}
+		return os.Open(filepath.FromSlash(u.Path))
+	}
+
+	verbose(vw, "Converting %s in %s mode...", src, mode)
+	dataset, err := csvw.Convert(group, open, csvw.Options{Mode: mode})
+	if err != nil {
+		return "", errors.Wrapf(err, "%s", src)
+	}
+
+	location, err := importLocation(grappDir, src, options.ImportOptions)
+	if err != nil {
+		return "", err
+	}
+
+	source := importSource{location: location, hash: fmt.Sprintf("%x", sha256.Sum256(data)), mediaType: "text/csv"}
+	if err = importDataset(ctxt, grappDir, objectsDir, dataset, src, source, outputPath, options.ImportOptions); err != nil {
+		return "", err
+	}
+
+	verbose(vw, "Imported %d statement(s) from %s into %s", len(dataset.Graphs[defaultGraphName]), src, filepath.Base(outputPath))
+
+	return outputPath, nil
+}
+
+// csvMetadata returns the table group described by the metadata file
+// metadata. Without one, <src>-metadata.json and csv-metadata.json next
+// to src are tried before falling back to a table without schema
+func csvMetadata(src string, srcURL string, metadata string, vw io.Writer) (*csvw.TableGroup, error) {
+
+	if len(metadata) < 1 {
+		for _, candidate := range []string{src + "-metadata.json", filepath.Join(filepath.Dir(src), "csv-metadata.json")} {
+			if file.FileExists(candidate) {
+				metadata = candidate
+				break
+			}
+		}
+	}
+
+	if len(metadata) < 1 {
+		verbose(vw, "No metadata found for %s. Using the header row as schema", src)
+		doc := fmt.Sprintf(`{"@context": %q, "url": %q}`, "http://www.w3.org/ns/csvw", srcURL)
+		return csvw.ParseMetadata([]byte(doc), srcURL)
+	}
+
+	data, err := os.ReadFile(metadata)
+	if err != nil {
+		return nil, err
+	}
+	metadataURL, err := fileURL(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	verbose(vw, "Reading metadata %s...", metadata)
+	group, err := csvw.ParseMetadata(data, metadataURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(group.Tables) > 1 {
+		for _, table := range group.Tables {
+			if table.URL == srcURL {
+				return group, nil
+			}
+		}
+		return nil, errors.InvalidValue.Newf("%s: metadata does not describe %s", metadata, src)
+	}
+
+	return group, nil
+}
+
+// fileURL returns the file URL of path
+func fileURL(path string) (string, error) {
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
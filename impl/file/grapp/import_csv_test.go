@@ -0,0 +1,101 @@
+package grapp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+const testCSVMetadata = `{
+  "@context": "http://www.w3.org/ns/csvw",
+  "url": "people.csv",
+  "tableSchema": {
+    "aboutUrl": "http://example.org/person/{id}",
+    "columns": [
+      {"name": "id", "titles": "id", "datatype": "integer", "suppressOutput": true},
+      {"name": "name", "titles": "name", "propertyUrl": "schema:name"},
+      {"name": "born", "titles": "born", "propertyUrl": "schema:birthDate", "datatype": {"base": "date", "format": "dd.MM.yyyy"}}
+    ]
+  }
+}`
+
+func TestImportCSV(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "people.csv")
+	writeTestFile(t, src, "id,name,born\n1,Jane Doe,01.02.1990\n2,John Doe,\n")
+	writeTestFile(t, filepath.Join(dir, "people.csv-metadata.json"), testCSVMetadata)
+
+	// METADATA IS FOUND NEXT TO THE CSV FILE
+	outputPath, err := importCSV(ctxt, grappDir, objectsDir, src, resourcegrapp.CSVImportOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputPath != filepath.Join(grappDir, "people.jsonld") {
+		t.Errorf("unexpected output path %s", outputPath)
+	}
+
+	doc := readTestJSON(t, outputPath)
+	context, _ := doc["@context"].(map[string]interface{})
+	if context["schema"] != "http://schema.org/" || context["csvw"] != "http://www.w3.org/ns/csvw#" {
+		t.Errorf("expected context with the prefixes used, got %v", doc["@context"])
+	}
+
+	quads := testNQuads(t, doc)
+	for _, expected := range []string{
+		`<http://example.org/person/1> <http://schema.org/name> "Jane Doe" .`,
+		`<http://example.org/person/1> <http://schema.org/birthDate> "1990-02-01"^^<http://www.w3.org/2001/XMLSchema#date> .`,
+		`<http://example.org/person/2> <http://schema.org/name> "John Doe" .`,
+		`<http://www.w3.org/ns/csvw#describes> <http://example.org/person/2> .`,
+		`<http://www.w3.org/ns/csvw#url> <file://` + filepath.ToSlash(src) + `> .`,
+		`<http://purl.org/dc/terms/format> "text/csv" .`,
+	} {
+		if !strings.Contains(quads, expected) {
+			t.Errorf("expected imported statements to contain %s, got:\n%s", expected, quads)
+		}
+	}
+	if strings.Contains(quads, "people.csv#id") {
+		t.Errorf("expected suppressed column to be left out, got:\n%s", quads)
+	}
+
+	// MINIMAL MODE LEAVES OUT THE TABLE DESCRIPTION
+	metadata := filepath.Join(t.TempDir(), "other.json")
+	writeTestFile(t, metadata, strings.Replace(testCSVMetadata, `"url": "people.csv"`,
+		`"url": "`+filepath.ToSlash(src)+`"`, 1))
+	options := resourcegrapp.CSVImportOptions{Metadata: metadata, Mode: "minimal"}
+	options.Output = "minimal.jsonld"
+	if outputPath, err = importCSV(ctxt, grappDir, objectsDir, src, options, nil); err != nil {
+		t.Fatal(err)
+	}
+	if quads = testNQuads(t, readTestJSON(t, outputPath)); strings.Contains(quads, "csvw#") {
+		t.Errorf("expected no table description in minimal mode, got:\n%s", quads)
+	}
+
+	// CELLS ARE VALIDATED AGAINST THE SCHEMA
+	invalid := filepath.Join(dir, "invalid.csv")
+	writeTestFile(t, invalid, "id,name,born\none,Jane Doe,1990-02-01\n")
+	options = resourcegrapp.CSVImportOptions{Metadata: filepath.Join(dir, "people.csv-metadata.json")}
+	_, err = importCSV(ctxt, grappDir, objectsDir, invalid, options, nil)
+	if errors.GetType(err) != errors.InvalidValue || !strings.Contains(err.Error(), "row 2 column born") {
+		t.Errorf("expected InvalidValue error, got %v", err)
+	}
+
+	// WITHOUT METADATA THE HEADER DESCRIBES THE COLUMNS
+	plain := filepath.Join(t.TempDir(), "plain.csv")
+	writeTestFile(t, plain, "a,b\n1,2\n")
+	if outputPath, err = importCSV(ctxt, grappDir, objectsDir, plain, resourcegrapp.CSVImportOptions{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if quads = testNQuads(t, readTestJSON(t, outputPath)); !strings.Contains(quads, `plain.csv#b> "2" .`) {
+		t.Errorf("expected columns from header, got:\n%s", quads)
+	}
+
+	if _, err = importCSV(ctxt, grappDir, objectsDir, src, resourcegrapp.CSVImportOptions{Mode: "maximal"}, nil); errors.GetType(err) != errors.InvalidValue {
+		t.Errorf("expected InvalidValue error, got %v", err)
+	}
+}
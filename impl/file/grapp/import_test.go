@@ -0,0 +1,171 @@
+package grapp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+	"github.com/piprate/json-gold/ld"
+)
+
+const testTriG = `
+@prefix ex: <http://example.org/> .
+@prefix schema: <http://schema.org/> .
+
+ex:jane a schema:Person ; schema:name "Jane Doe" .
+
+ex:reviews {
+    ex:review1 schema:author ex:jane ; schema:reviewBody "Great"@en .
+}
+`
+
+func TestImport(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	src := filepath.Join(t.TempDir(), "people.trig")
+	writeTestFile(t, src, testTriG)
+
+	outputPath, err := importRDF(ctxt, grappDir, objectsDir, src, resourcegrapp.ImportOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputPath != filepath.Join(grappDir, "people.jsonld") {
+		t.Errorf("unexpected output path %s", outputPath)
+	}
+
+	doc := readTestJSON(t, outputPath)
+	context, _ := doc["@context"].(map[string]interface{})
+	if context["schema"] != "http://schema.org/" || context["prov"] != provNamespace {
+		t.Errorf("expected context derived from source prefixes, got %v", doc["@context"])
+	}
+
+	quads := testNQuads(t, doc)
+	for _, expected := range []string{
+		`<http://example.org/jane> <http://schema.org/name> "Jane Doe" .`,
+		`<http://example.org/review1> <http://schema.org/reviewBody> "Great"@en <http://example.org/reviews> .`,
+		`<http://example.org/reviews> <http://www.w3.org/ns/prov#wasDerivedFrom> <urn:sha256:`,
+		`<http://example.org/jane> <http://www.w3.org/ns/prov#wasDerivedFrom> <urn:sha256:`,
+	} {
+		if !strings.Contains(quads, expected) {
+			t.Errorf("expected imported statements to contain %s, got:\n%s", expected, quads)
+		}
+	}
+	// LOCAL PATHS OUTSIDE THE GRAPP ARE NOT RECORDED
+	if strings.Contains(quads, "atLocation") || strings.Contains(quads, "file://") {
+		t.Errorf("expected no location of a source outside the grapp, got:\n%s", quads)
+	}
+
+	// THE LOCATION OF A SOURCE IN THE GRAPP IS RELATIVE TO THE GRAPP
+	if err = os.Mkdir(filepath.Join(grappDir, "data"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(grappDir, "data", "people.trig"), testTriG)
+	for source, expected := range map[string]string{
+		"":                                `<http://www.w3.org/ns/prov#atLocation> "data/people.trig" .`,
+		"https://example.org/people.trig": `<http://www.w3.org/ns/prov#atLocation> <https://example.org/people.trig> .`,
+	} {
+		outputPath, err := importRDF(ctxt, grappDir, objectsDir, filepath.Join(grappDir, "data", "people.trig"),
+			resourcegrapp.ImportOptions{Output: "located.jsonld", Source: source, Force: true}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if quads := testNQuads(t, readTestJSON(t, outputPath)); !strings.Contains(quads, expected) {
+			t.Errorf("%q: expected imported statements to contain %s, got:\n%s", source, expected, quads)
+		}
+	}
+	if _, err = importRDF(ctxt, grappDir, objectsDir, src,
+		resourcegrapp.ImportOptions{Output: "located.jsonld", Source: "people.trig", Force: true}, nil); errors.GetType(err) != errors.InvalidValue {
+		t.Errorf("expected InvalidValue error for relative source, got %v", err)
+	}
+	os.Remove(filepath.Join(grappDir, "located.jsonld"))
+
+	// EXISTING PROJECT FILES ARE ONLY REPLACED ON REQUEST
+	if _, err = importRDF(ctxt, grappDir, objectsDir, src, resourcegrapp.ImportOptions{}, nil); errors.GetType(err) != errors.AlreadyExists {
+		t.Errorf("expected AlreadyExists error, got %v", err)
+	}
+
+	// COMPACT WITH A CONTEXT FROM THE GRAPP
+	if err = os.Mkdir(filepath.Join(grappDir, "contexts"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(grappDir, "contexts", "schema.jsonld"),
+		`{"@context": {"name": "http://schema.org/name", "Person": "http://schema.org/Person"}}`)
+
+	outputPath, err = importRDF(ctxt, grappDir, objectsDir, src,
+		resourcegrapp.ImportOptions{Format: "trig", Context: "contexts/schema.jsonld", Force: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc = readTestJSON(t, outputPath)
+	if doc["@context"] != "contexts/schema.jsonld" {
+		t.Errorf("expected chosen context, got %v", doc["@context"])
+	}
+	data, _ := json.Marshal(doc)
+	if !strings.Contains(string(data), `"name":"Jane Doe"`) {
+		t.Errorf("expected terms compacted with chosen context, got %s", data)
+	}
+
+	// A REPLACED PROJECT FILE IS RESTORED IF THE NEW ONE IS INVALID
+	previous, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"),
+		`{"documentLoader": {"limits": {"maxDocumentSize": 64}}}`)
+	if _, err = importRDF(ctxt, grappDir, objectsDir, src, resourcegrapp.ImportOptions{Force: true}, nil); errors.GetType(err) != errors.OutOfRange {
+		t.Errorf("expected OutOfRange error for project file exceeding maximum document size, got %v", err)
+	}
+	if data, err := os.ReadFile(outputPath); err != nil || string(data) != string(previous) {
+		t.Errorf("expected previous project file to be restored, got %s (%v)", data, err)
+	}
+	os.Remove(filepath.Join(grappDir, file.DgrzDirName, "config"))
+
+	// INVALID SOURCES DO NOT CREATE PROJECT FILES
+	invalid := filepath.Join(t.TempDir(), "invalid.ttl")
+	writeTestFile(t, invalid, `@prefix ex: <http://example.org/> . ex:s ex:p `)
+	if _, err = importRDF(ctxt, grappDir, objectsDir, invalid, resourcegrapp.ImportOptions{}, nil); errors.GetType(err) != errors.InvalidValue {
+		t.Errorf("expected InvalidValue error for invalid Turtle, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(grappDir, "invalid.jsonld")); !os.IsNotExist(err) {
+		t.Errorf("expected no project file for invalid source")
+	}
+
+}
+
+func readTestJSON(t *testing.T, path string) map[string]interface{} {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+// testNQuads returns the statements of the JSON-LD document doc (whose
+// contexts resolve from the grapp) as N-Quads
+func testNQuads(t *testing.T, doc map[string]interface{}) string {
+
+	options := ld.NewJsonLdOptions("")
+	options.Format = "application/n-quads"
+	options.DocumentLoader = NewDocumentLoader(nil, "", t.TempDir())
+
+	quads, err := ld.NewJsonLdProcessor().ToRDF(doc, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return quads.(string)
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	"github.com/piprate/json-gold/ld"
+)
+
+// indexedStore is a store over a scope of a grapp's index. Closing it
+// closes the index
+type indexedStore struct {
+	quadstore.Store
+	index *file.Index
+}
+
+func (s *indexedStore) Close() error {
+	return s.index.Close()
+}
+
+// OpenIndexedStore returns a read-only store over the statements of the
+// project files of the grapp in grappDir or, if snapshot is set, of
+// the snapshot it selects. The statements are read from the grapp's
+// persistent index which is brought up to date first. The store must
+// be closed
+func OpenIndexedStore(ctxt context.Context, grappDir string, objectsDir string, snapshot string,
+	vw io.Writer) (quadstore.Store, error) {
+
+	loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var s *Snapshot
+	scope := file.IndexWorkingTree
+	if len(snapshot) > 0 {
+		if s, err = ResolveSnapshot(grappDir, objectsDir, snapshot); err != nil {
+			return nil, err
+		}
+		scope = s.ID
+	}
+
+	index, err := file.OpenIndex(grappDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = indexProjectFiles(grappDir, objectsDir, loader, index, s, vw); err != nil {
+		index.Close()
+		return nil, err
+	}
+
+	if err = loader.PinResolved(); err != nil {
+		index.Close()
+		return nil, err
+	}
+
+	store, err := index.Store(scope)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+
+	return &indexedStore{Store: store, index: index}, nil
+}
+
+// updateIndex indexes the processed project files of the working tree
+// (or of snapshot if not nil). An index in use by another process is
+// left to be brought up to date when it is next opened
+func updateIndex(grappDir string, objectsDir string, loader *DocumentLoader, snapshot *Snapshot, vw io.Writer) error {
+
+	index, err := file.OpenIndex(grappDir)
+	if errors.GetType(err) == errors.TryAgain {
+		verbose(vw, "Skipped updating index: %s", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer index.Close()
+
+	return indexProjectFiles(grappDir, objectsDir, loader, index, snapshot, vw)
+}
+
+// indexProjectFiles makes the project files of the working tree (or of
+// snapshot if not nil) the documents of their index scope. Each
+// version of a project file is indexed once under its grapp relative
+// path and the canonical hash of its statements. Project files are
+// processed with loader if necessary. Snapshots already indexed are
+// not read again
+func indexProjectFiles(grappDir string, objectsDir string, loader *DocumentLoader, index *file.Index,
+	snapshot *Snapshot, vw io.Writer) error {
+
+	scope := file.IndexWorkingTree
+	var projectFiles []string
+
+	if snapshot != nil {
+		scope = snapshot.ID
+		if _, ok := index.Scope(scope); ok {
+			// SNAPSHOTS DO NOT CHANGE
+			return nil
+		}
+		loader.SetSnapshot(snapshot)
+		projectFiles = snapshot.ProjectFiles()
+	} else {
+		files, err := listProjectFiles(grappDir, nil)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			projectFiles = append(projectFiles, filepath.Base(f))
+		}
+		sort.Strings(projectFiles)
+	}
+
+	docs := make(map[string]string)
+
+	for _, projectFile := range projectFiles {
+
+		if _, err := loader.nested().LoadDocument(filepath.Join(grappDir, filepath.FromSlash(projectFile))); err != nil {
+			return err
+		}
+
+		entry, err := readProcessedEntry(objectsDir, projectFile)
+		if err != nil {
+			return err
+		}
+		if len(entry.Object) < 1 && len(entry.Chunks) < 1 {
+			// CONTEXT ONLY DOCUMENT
+			continue
+		}
+
+		version := entry.Canonical
+		if len(version) < 1 {
+			version = entry.SHA256
+		}
+		docs[projectFile] = projectFile + "@" + version
+	}
+
+	added, err := index.UpdateScope(scope, docs, func(relPath string) (*ld.RDFDataset, error) {
+
+		verbose(vw, "Indexing %s...", relPath)
+
+		flattened, err := ReadFlattened(objectsDir, relPath)
+		if err != nil {
+			return nil, err
+		}
+		dataset, err := ld.NewJsonLdApi().ToRDF(flattened, ld.NewJsonLdOptions(""))
+		if err != nil {
+			return nil, errors.InvalidValue.Wrapf(err, "%s", relPath)
+		}
+
+		return dataset, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	verbose(vw, "Indexed %d of %d project files", added, len(docs))
+
+	return nil
+}
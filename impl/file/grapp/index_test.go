@@ -0,0 +1,100 @@
+package grapp
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	"github.com/piprate/json-gold/ld"
+)
+
+func TestIndexedStore(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	writeTestFile(t, filepath.Join(grappDir, "people.jsonld"),
+		`{"@context": {"@vocab": "http://schema.org/"}, "@id": "http://example.org/jane", "name": "Jane Doe",
+		  "knows": {"name": "John Doe"}}`)
+	writeTestFile(t, filepath.Join(grappDir, "places.jsonld"),
+		`{"@context": {"@vocab": "http://schema.org/"}, "@id": "http://example.org/paris", "name": "Paris"}`)
+
+	// VALIDATION INDEXES THE WORKING TREE
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	name := ld.NewIRI("http://schema.org/name")
+
+	open := func(snapshot string, indexed string) quadstore.Store {
+		t.Helper()
+		var vw bytes.Buffer
+		store, err := OpenIndexedStore(ctxt, grappDir, objectsDir, snapshot, &vw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(indexed) < 1 && strings.Contains(vw.String(), "Indexing") {
+			t.Errorf("expected nothing to be indexed:\n%s", vw.String())
+		} else if !strings.Contains(vw.String(), indexed) {
+			t.Errorf("expected %q in verbose output:\n%s", indexed, vw.String())
+		}
+		return store
+	}
+	count := func(store quadstore.Store, pattern quadstore.Pattern) int {
+		t.Helper()
+		n, err := store.Count(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	store := open("", "Indexed 0 of 2 project files")
+	if n := count(store, quadstore.Pattern{Predicate: name}); n != 3 {
+		t.Errorf("expected 3 names, got %d", n)
+	}
+	if n := count(store, quadstore.Pattern{Subject: ld.NewIRI("http://example.org/jane")}); n != 2 {
+		t.Errorf("expected 2 statements about jane, got %d", n)
+	}
+	// THE STORE HOLDS THE INDEX UNTIL CLOSED
+	if _, err := OpenIndexedStore(ctxt, grappDir, objectsDir, "", nil); errors.GetType(err) != errors.TryAgain {
+		t.Errorf("expected TryAgain while the index is open, got %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// COMMITTING A SNAPSHOT INDEXES IT
+	snapshotID, err := (&FileGrapplicationResource{}).Snapshot(ctxt, "people", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ONLY THE CHANGED FILE IS INDEXED
+	writeTestFile(t, filepath.Join(grappDir, "places.jsonld"),
+		`{"@context": {"@vocab": "http://schema.org/"}, "@id": "http://example.org/paris", "name": "Paris",
+		  "containedInPlace": {"@id": "http://example.org/france"}}`)
+
+	store = open("", "Indexed 1 of 2 project files")
+	if n := count(store, quadstore.Pattern{}); n != 5 {
+		t.Errorf("expected 5 statements in the working tree, got %d", n)
+	}
+	store.Close()
+
+	// THE SNAPSHOT KEEPS THE DOCUMENTS OF THE FILES AS THEY WERE
+	store = open(snapshotID[:8], "")
+	if n := count(store, quadstore.Pattern{}); n != 4 {
+		t.Errorf("expected 4 statements in the snapshot, got %d", n)
+	}
+
+	if n := count(store, quadstore.Pattern{Predicate: ld.NewIRI("http://schema.org/containedInPlace")}); n != 0 {
+		t.Errorf("expected no containedInPlace statements in the snapshot, got %d", n)
+	}
+	store.Close()
+
+	if _, err := OpenIndexedStore(ctxt, grappDir, objectsDir, "unknown", nil); errors.GetType(err) != errors.NotFound {
+		t.Errorf("expected NotFound for an unknown snapshot, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/ipfs/car"
+	"github.com/datacequia/go-dogg3rz/resource/config"
+	shell "github.com/ipfs/go-ipfs-api"
+)
+
+// IPFSResolver returns the content at an IPFS path such as
+// /ipfs/<cid>/<path> or /ipns/<name>/<path>
+type IPFSResolver interface {
+	Cat(ctxt context.Context, ipfsPath string) (io.ReadCloser, error)
+}
+
+// newEmbeddedIPFSResolver returns a resolver backed by an IPFS node
+// embedded in dogg3rz. Replaced when built with the ipfs_embed tag
+var newEmbeddedIPFSResolver = func(ctxt context.Context) (IPFSResolver, error) {
+	return nil, errors.NotImplemented.New("dogg3rz was built without an embedded IPFS node (build tag ipfs_embed)")
+}
+
+// newIPFSResolvers returns the resolvers configured for a grapp in
+// grappDir. CAR files take precedence over the IPFS node, which is
+// reached through its API endpoint if set or else embedded in dogg3rz
+func newIPFSResolvers(ctxt context.Context, grappDir string, ipfsConfig config.DocumentLoaderIPFSConfig,
+	limits config.DocumentLoaderLimits) (IPFSResolver, IPFSResolver) {
+
+	var carResolver, nodeResolver IPFSResolver
+
+	if len(ipfsConfig.CarFiles) > 0 {
+		carFiles := make([]string, len(ipfsConfig.CarFiles))
+		for i, carFile := range ipfsConfig.CarFiles {
+			if !filepath.IsAbs(carFile) {
+				carFile = filepath.Join(grappDir, carFile)
+			}
+			carFiles[i] = carFile
+		}
+		carResolver = &carIPFSResolver{carFiles: carFiles}
+	}
+
+	// A CONFIGURED API ENDPOINT TAKES PRECEDENCE OVER SPAWNING AN EMBEDDED NODE
+	switch {
+	case len(ipfsConfig.ApiEndpoint) > 0:
+		nodeResolver = newAPIIPFSResolver(ipfsConfig.ApiEndpoint, limits)
+	case ipfsConfig.Deployment == config.IPFSDeploymentEmbedded:
+		nodeResolver = &lazyIPFSResolver{create: newEmbeddedIPFSResolver}
+	}
+
+	return carResolver, nodeResolver
+}
+
+// apiIPFSResolver resolves IPFS paths through the REST API of an IPFS node
+type apiIPFSResolver struct {
+	sh *shell.Shell
+}
+
+func newAPIIPFSResolver(apiEndpoint string, limits config.DocumentLoaderLimits) *apiIPFSResolver {
+
+	client := &http.Client{Timeout: limits.Timeout()}
+
+	return &apiIPFSResolver{sh: shell.NewShellWithClient(strings.TrimSuffix(apiEndpoint, "/"), client)}
+}
+
+func (r *apiIPFSResolver) Cat(ctxt context.Context, ipfsPath string) (io.ReadCloser, error) {
+
+	res, err := r.sh.Request("cat", ipfsPath).Send(ctxt)
+	if err != nil {
+		return nil, errors.ExternalError.Wrapf(err, "%s: IPFS node request failed", ipfsPath)
+	}
+	if res.Error != nil {
+		res.Close()
+		return nil, errors.NotFound.Wrapf(res.Error, "%s: IPFS node could not resolve path", ipfsPath)
+	}
+
+	return res.Output, nil
+}
+
+// carIPFSResolver resolves IPFS paths from local CAR files
+type carIPFSResolver struct {
+	carFiles []string
+	archives []*car.Archive // opened on first use
+	err      error
+	once     sync.Once
+}
+
+func (r *carIPFSResolver) Cat(ctxt context.Context, ipfsPath string) (io.ReadCloser, error) {
+
+	if strings.HasPrefix(ipfsPath, "/ipns/") {
+		return nil, errors.NotFound.Newf("%s: IPNS names cannot be resolved from CAR files", ipfsPath)
+	}
+
+	r.once.Do(func() {
+		for _, carFile := range r.carFiles {
+			archive, err := car.Open(carFile)
+			if err != nil {
+				r.err = err
+				return
+			}
+			r.archives = append(r.archives, archive)
+		}
+	})
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	for _, archive := range r.archives {
+		data, err := archive.Cat(ipfsPath)
+		if err == nil {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+		if errors.GetType(err) != errors.NotFound {
+			return nil, err
+		}
+	}
+
+	return nil, errors.NotFound.Newf("%s: not found in CAR files %s", ipfsPath, strings.Join(r.carFiles, ", "))
+}
+
+// lazyIPFSResolver creates its resolver on first use
+type lazyIPFSResolver struct {
+	create   func(ctxt context.Context) (IPFSResolver, error)
+	resolver IPFSResolver
+	err      error
+	once     sync.Once
+}
+
+func (r *lazyIPFSResolver) Cat(ctxt context.Context, ipfsPath string) (io.ReadCloser, error) {
+
+	r.once.Do(func() {
+		r.resolver, r.err = r.create(ctxt)
+	})
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return r.resolver.Cat(ctxt, ipfsPath)
+}
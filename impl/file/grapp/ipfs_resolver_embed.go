@@ -0,0 +1,65 @@
+//go:build ipfs_embed
+
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/ipfs"
+	files "github.com/ipfs/go-ipfs-files"
+	icore "github.com/ipfs/interface-go-ipfs-core"
+	icorepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// IPFS REPO OF THE EMBEDDED NODE (RELATIVE TO THE DOGG3RZ HOME DIR)
+const embeddedIPFSRepoDirName = "ipfs"
+
+func init() {
+	newEmbeddedIPFSResolver = func(ctxt context.Context) (IPFSResolver, error) {
+
+		api, err := ipfs.Spawn(ctxt, filepath.Join(file.DotDirPath(ctxt), embeddedIPFSRepoDirName))
+		if err != nil {
+			return nil, errors.ExternalError.Wrapf(err, "starting embedded IPFS node")
+		}
+
+		return &embeddedIPFSResolver{api: api}, nil
+	}
+}
+
+// embeddedIPFSResolver resolves IPFS paths through the embedded node
+type embeddedIPFSResolver struct {
+	api icore.CoreAPI
+}
+
+func (r *embeddedIPFSResolver) Cat(ctxt context.Context, ipfsPath string) (io.ReadCloser, error) {
+
+	node, err := r.api.Unixfs().Get(ctxt, icorepath.New(ipfsPath))
+	if err != nil {
+		return nil, errors.NotFound.Wrapf(err, "%s: embedded IPFS node could not resolve path", ipfsPath)
+	}
+
+	f := files.ToFile(node)
+	if f == nil {
+		node.Close()
+		return nil, errors.UnexpectedType.Newf("%s: not a file", ipfsPath)
+	}
+
+	return f, nil
+}
@@ -0,0 +1,183 @@
+package grapp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/env"
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/ipfs/car"
+	"github.com/datacequia/go-dogg3rz/resource/config"
+	cid "github.com/ipfs/go-cid"
+	merkledag "github.com/ipfs/go-merkledag"
+	unixfs "github.com/ipfs/go-unixfs"
+)
+
+// writeTestCAR writes a CAR with a UnixFS directory holding content
+// as 'context.jsonld' and returns the directory's CID
+func writeTestCAR(t *testing.T, carPath string, content string) cid.Cid {
+
+	fileNode := merkledag.NodeWithData(unixfs.FilePBData([]byte(content), uint64(len(content))))
+	dirNode := unixfs.EmptyDirNode()
+	if err := dirNode.AddNodeLink("context.jsonld", fileNode); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := car.Write(&buf, []cid.Cid{dirNode.Cid()}, []car.Block{
+		{Cid: dirNode.Cid(), Data: dirNode.RawData()},
+		{Cid: fileNode.Cid(), Data: fileNode.RawData()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, carPath, buf.String())
+
+	return dirNode.Cid()
+}
+
+func TestDocumentLoaderIPFS(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	dirCid := writeTestCAR(t, filepath.Join(grappDir, "contexts.car"), testContext)
+
+	// IPFS NODE API THAT ONLY RESOLVES ONE IPNS NAME
+	var catRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		catRequests++
+		if r.URL.Path != "/api/v0/cat" || r.URL.Query().Get("arg") != "/ipns/contexts.example.org/context.jsonld" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"Message": "no link named", "Code": 0, "Type": "error"}`))
+			return
+		}
+		w.Write([]byte(testContext))
+	}))
+	defer server.Close()
+
+	writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"), `{
+    "documentLoader": {
+        "ipfs": { "apiEndpoint": "`+server.URL+`", "carFiles": ["contexts.car"] }
+    }
+}`)
+
+	personFile := filepath.Join(grappDir, "person.jsonld")
+
+	// IPFS CONTENT IN A CAR FILE
+	ipfsIRI := "ipfs://" + dirCid.String() + "/context.jsonld"
+	writeTestFile(t, personFile, `{"@context": "`+ipfsIRI+`", "@type": "Person", "name": "Jane Doe"}`)
+
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal("validation with ipfs context from CAR file failed", err)
+	}
+	if catRequests != 0 {
+		t.Errorf("expected content in CAR file to be resolved without the IPFS node")
+	}
+
+	// IPNS NAME RESOLVED THROUGH THE IPFS NODE
+	ipnsIRI := "ipns://contexts.example.org/context.jsonld"
+	writeTestFile(t, personFile, `{"@context": "`+ipnsIRI+`", "@type": "Person", "name": "Jane Doe"}`)
+
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal("validation with ipns context from IPFS node failed", err)
+	}
+
+	lock, err := ReadContextLock(grappDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, iri := range []string{ipfsIRI, ipnsIRI} {
+		if _, ok := lock.Get(iri); !ok {
+			t.Errorf("expected %s to be pinned", iri)
+		}
+	}
+
+	// UNRESOLVABLE PATH
+	writeTestFile(t, personFile, `{"@context": "ipfs://`+dirCid.String()+`/missing.jsonld", "@type": "Person"}`)
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); errors.GetType(err) != errors.NotFound {
+		t.Errorf("expected NotFound error for missing ipfs path, got %v", err)
+	}
+
+	// OFFLINE: PINNED IPNS CONTENT IS SERVED FROM CACHE, THE NODE IS NOT USED
+	server.Close()
+	offlineCtxt := context.WithValue(ctxt, env.EnvDogg3rzOffline, "true")
+	writeTestFile(t, personFile, `{"@context": "`+ipnsIRI+`", "@type": "Person", "name": "Jane Doe"}`)
+	if err := validateGrappProjectFiles(offlineCtxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal("offline validation with pinned ipns context failed", err)
+	}
+
+}
+
+func TestDocumentLoaderDataProduct(t *testing.T) {
+
+	// DATA PRODUCT PUBLISHING A CONTEXT
+	productCtxt, productDir, productObjectsDir := initTestGrapp(t)
+	writeTestFile(t, filepath.Join(productDir, "context.jsonld"), testContext)
+
+	snapshot1, err := CreateSnapshot(productCtxt, productDir, productObjectsDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// LATER SNAPSHOT DROPS THE 'name' TERM
+	writeTestFile(t, filepath.Join(productDir, "context.jsonld"),
+		`{"@context": {"Person": "http://example.org/Person"}}`)
+	if _, err = CreateSnapshot(productCtxt, productDir, productObjectsDir, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"),
+		`{"documentLoader": {"dataProducts": {"people": "`+filepath.ToSlash(productDir)+`"}}}`)
+
+	personFile := filepath.Join(grappDir, "person.jsonld")
+
+	writeTestFile(t, personFile, `{"@context": "dgrz://people@`+snapshot1.ID[:12]+`/context.jsonld", "@type": "Person", "name": "Jane Doe"}`)
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal("validation with context from data product snapshot failed", err)
+	}
+
+	writeTestFile(t, personFile, `{"@context": "dgrz://people/context.jsonld", "@type": "Person"}`)
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal("validation with context from data product head failed", err)
+	}
+
+	writeTestFile(t, personFile, `{"@context": "dgrz://unknown/context.jsonld", "@type": "Person"}`)
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); errors.GetType(err) != errors.NotFound {
+		t.Errorf("expected NotFound error for unknown data product, got %v", err)
+	}
+
+	if err := os.Remove(personFile); err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+func TestIPFSNodeResolver(t *testing.T) {
+
+	tests := []struct {
+		ipfsConfig config.DocumentLoaderIPFSConfig
+		expected   IPFSResolver
+	}{
+		{config.DocumentLoaderIPFSConfig{Deployment: config.IPFSDeploymentEmbedded}, &lazyIPFSResolver{}},
+		{config.DocumentLoaderIPFSConfig{ApiEndpoint: "http://localhost:5001/"}, &apiIPFSResolver{}},
+		// THE API ENDPOINT IS PREFERRED OVER THE EMBEDDED NODE
+		{config.DocumentLoaderIPFSConfig{Deployment: config.IPFSDeploymentEmbedded, ApiEndpoint: "http://localhost:5001/"}, &apiIPFSResolver{}},
+		{config.DocumentLoaderIPFSConfig{}, nil},
+	}
+
+	for _, test := range tests {
+		_, nodeResolver := newIPFSResolvers(context.Background(), t.TempDir(), test.ipfsConfig, config.DocumentLoaderLimits{})
+		if reflect.TypeOf(nodeResolver) != reflect.TypeOf(test.expected) {
+			t.Errorf("%+v: expected node resolver %T, got %T", test.ipfsConfig, test.expected, nodeResolver)
+		}
+	}
+}
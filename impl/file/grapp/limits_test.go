@@ -0,0 +1,127 @@
+package grapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+func TestDocumentLoaderLimits(t *testing.T) {
+
+	var serverURL string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/context.jsonld", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testContext))
+	})
+	mux.HandleFunc("/large.jsonld", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"@context": {"name": "http://example.org/name"}, "padding": "` + strings.Repeat("x", 4096) + `"}`))
+	})
+	mux.HandleFunc("/redirect/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/redirect/"+r.URL.Path[len("/redirect/"):]+"x", http.StatusFound)
+	})
+	mux.HandleFunc("/slow.jsonld", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(1500 * time.Millisecond)
+		w.Write([]byte(testContext))
+	})
+	// CONTEXTS THAT REFER TO EACH OTHER
+	mux.HandleFunc("/cycle-a.jsonld", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"@context": ["` + serverURL + `/cycle-b.jsonld", {"name": "http://example.org/name"}]}`))
+	})
+	mux.HandleFunc("/cycle-b.jsonld", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"@context": ["` + serverURL + `/cycle-a.jsonld", {"Person": "http://example.org/Person"}]}`))
+	})
+	mux.HandleFunc("/nested.jsonld", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"@context": ["` + serverURL + `/context.jsonld", {"Person": "http://example.org/Person"}]}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	serverURL = server.URL
+
+	tests := []struct {
+		name     string
+		limits   string
+		context  string
+		expected errors.ErrorType
+	}{
+		{"defaults", `{}`, "/context.jsonld", errors.NoType},
+		{"max document size", `{"maxDocumentSize": 1024}`, "/large.jsonld", errors.OutOfRange},
+		{"max redirects", `{"maxRedirects": 3}`, "/redirect/", errors.OutOfRange},
+		{"timeout", `{"timeoutSeconds": 1}`, "/slow.jsonld", errors.TimedOut},
+		{"https only", `{"httpsOnly": true}`, "/context.jsonld", errors.InvalidValue},
+		{"deny domain", `{"denyDomains": ["127.0.0.1"]}`, "/context.jsonld", errors.InvalidValue},
+		{"allow domain", `{"allowDomains": ["example.org"]}`, "/context.jsonld", errors.InvalidValue},
+		{"context cycle", `{}`, "/cycle-a.jsonld", errors.InvalidValue},
+		{"context depth", `{"maxContextDepth": 1}`, "/nested.jsonld", errors.OutOfRange},
+		{"context depth not exceeded", `{"maxContextDepth": 2}`, "/nested.jsonld", errors.NoType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+			writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"),
+				`{"documentLoader": {"limits": `+test.limits+`}}`)
+			writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+				`{"@context": "`+server.URL+test.context+`", "@type": "Person", "name": "Jane Doe"}`)
+
+			err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil)
+			if test.expected == errors.NoType {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if errors.GetType(err) != test.expected {
+				t.Fatalf("expected error type %d, got %v", test.expected, err)
+			}
+
+			// NO PARTIAL DOCUMENTS LEFT IN OBJECT CACHE
+			entries, _ := os.ReadDir(objectsDir)
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), "NewGrapplicationCachedRemoteDocument-") {
+					t.Errorf("incomplete cached document left behind: %s", entry.Name())
+				}
+			}
+		})
+	}
+
+}
+
+func TestDocumentLoaderCancel(t *testing.T) {
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.Write([]byte(testContext))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+		`{"@context": "`+server.URL+`/context.jsonld", "@type": "Person", "name": "Jane Doe"}`)
+
+	ctxt, cancel := context.WithCancel(ctxt)
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil)
+	if errors.GetType(err) != errors.Cancelled {
+		t.Fatalf("expected Cancelled error for request in flight, got %v", err)
+	}
+
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+const contextLockVersion = 1
+
+// ContextLock records every remote document IRI resolved while
+// processing a grapp together with the final URL it was served from
+// and the SHA-256 hash of its content. Pinned documents are served from
+// the local object cache so that processing is reproducible and can
+// run without network access
+type ContextLock struct {
+	Version   int                       `json:"version"`
+	Documents map[string]LockedDocument `json:"documents"`
+
+	path     string          // location of lock file
+	dirty    bool            // true if entries changed since read
+	resolved map[string]bool // iris pinned since read
+	mutex    sync.Mutex      // guards Documents, dirty and resolved
+}
+
+// LockedDocument is a single pinned remote document
+type LockedDocument struct {
+	URL        string `json:"url"`                  // final url after redirects
+	ContextURL string `json:"contextUrl,omitempty"` // context from http Link header (if any)
+	SHA256     string `json:"sha256"`               // hex encoded content hash
+}
+
+// ReadContextLock reads the context lock file in grappDir. An empty
+// lock is returned if the lock file does not exist yet
+func ReadContextLock(grappDir string) (*ContextLock, error) {
+
+	lock := &ContextLock{
+		Version:   contextLockVersion,
+		Documents: make(map[string]LockedDocument),
+		path:      filepath.Join(grappDir, file.ContextLockFileName),
+	}
+
+	if !file.FileExists(lock.path) {
+		return lock, nil
+	}
+
+	data, err := os.ReadFile(lock.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, lock); err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "%s", lock.path)
+	}
+
+	if lock.Version != contextLockVersion {
+		return nil, errors.UnexpectedValue.Newf("%s: unsupported lock file version %d, want %d",
+			lock.path, lock.Version, contextLockVersion)
+	}
+
+	if lock.Documents == nil {
+		lock.Documents = make(map[string]LockedDocument)
+	}
+
+	return lock, nil
+
+}
+
+// Path returns the location of the lock file
+func (l *ContextLock) Path() string {
+	return l.path
+}
+
+// Get returns the pinned document for iri
+func (l *ContextLock) Get(iri string) (LockedDocument, bool) {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	doc, ok := l.Documents[iri]
+
+	return doc, ok
+}
+
+// Put pins iri to doc
+func (l *ContextLock) Put(iri string, doc LockedDocument) {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.resolved == nil {
+		l.resolved = make(map[string]bool)
+	}
+	l.resolved[iri] = true
+
+	if current, ok := l.Documents[iri]; ok && current == doc {
+		return
+	}
+
+	l.Documents[iri] = doc
+	l.dirty = true
+
+}
+
+// pinnedSinceRead returns true if iri was pinned after the lock was read
+func (l *ContextLock) pinnedSinceRead(iri string) bool {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.resolved[iri]
+}
+
+// Write saves the lock file if any entries were added or changed
+// since it was read
+func (l *ContextLock) Write() error {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if _, err = file.WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(data), nil }, l.path); err != nil {
+		return err
+	}
+
+	l.dirty = false
+
+	return nil
+
+}
@@ -0,0 +1,161 @@
+package grapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/env"
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+const testContext = `{
+    "@context": {
+        "name": "http://example.org/name",
+        "Person": "http://example.org/Person"
+    }
+}`
+
+// initTestGrapp initializes a grapp in a temp dir using a temp
+// dogg3rz home and returns a context that points to both
+func initTestGrapp(t *testing.T) (context.Context, string, string) {
+
+	homeDir := t.TempDir()
+	grappDir := t.TempDir()
+
+	if err := os.Mkdir(file.DataDirPath(context.WithValue(context.Background(), env.EnvDogg3rzHome, homeDir)), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	ctxt := context.Background()
+	ctxt = context.WithValue(ctxt, env.EnvDogg3rzHome, homeDir)
+	ctxt = context.WithValue(ctxt, env.EnvDogg3rzGrapp, grappDir)
+
+	if err := initGrappDir(ctxt, grappDir); err != nil {
+		t.Fatal(err)
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ctxt, grappDir, objectsDir
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeUserConfig writes the user configuration of ctxt with the
+// document loader configuration documentLoader
+func writeUserConfig(t *testing.T, ctxt context.Context, documentLoader string) {
+	writeTestFile(t, filepath.Join(file.DotDirPath(ctxt), "config"), `{
+    "ipfs": { "apiEndpoint": "http://localhost:5001/" },
+    "user": { "activityPubUserHandle": "@jane@example.org" },
+    "documentLoader": `+documentLoader+`
+}`)
+}
+
+func TestContextLockOffline(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/ld+json")
+		w.Write([]byte(testContext))
+	}))
+
+	contextIRI := server.URL + "/context.jsonld"
+
+	writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+		`{"@context": "`+contextIRI+`", "@type": "Person", "name": "Jane Doe"}`)
+
+	// ONLINE RUN RESOLVES AND PINS THE CONTEXT
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	lock, err := ReadContextLock(grappDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, ok := lock.Get(contextIRI)
+	if !ok {
+		t.Fatalf("expected %s to be pinned in %s", contextIRI, lock.Path())
+	}
+	if pinned.URL != contextIRI {
+		t.Errorf("expected pinned url %s, got %s", contextIRI, pinned.URL)
+	}
+
+	cachedPath := filepath.Join(objectsDir, pinned.SHA256+".jsonld")
+	if !file.FileExists(cachedPath) {
+		t.Fatalf("expected pinned document in object cache at %s", cachedPath)
+	}
+
+	// OFFLINE RUN IS SERVED FROM THE OBJECT CACHE
+	offlineCtxt := context.WithValue(ctxt, env.EnvDogg3rzOffline, "true")
+	if err := validateGrappProjectFiles(offlineCtxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal("offline validation with pinned context failed", err)
+	}
+
+	// OFFLINE RUN FAILS WHEN PINNED DOCUMENT IS MISSING
+	if err := os.Remove(cachedPath); err != nil {
+		t.Fatal(err)
+	}
+	err = validateGrappProjectFiles(offlineCtxt, grappDir, objectsDir, nil)
+	if errors.GetType(err) != errors.NotFound {
+		t.Fatalf("expected NotFound error for missing pinned document, got %v", err)
+	}
+
+	// OFFLINE RUN FAILS WHEN DOCUMENT IS NOT PINNED
+	if err := os.Remove(lock.Path()); err != nil {
+		t.Fatal(err)
+	}
+	err = validateGrappProjectFiles(offlineCtxt, grappDir, objectsDir, nil)
+	if errors.GetType(err) != errors.NotFound {
+		t.Fatalf("expected NotFound error for unpinned document, got %v", err)
+	}
+
+}
+
+func TestPinResolved(t *testing.T) {
+
+	_, grappDir, objectsDir := initTestGrapp(t)
+
+	lock, err := ReadContextLock(grappDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.Put("https://example.org/context.jsonld", LockedDocument{URL: "https://example.org/context.jsonld", SHA256: "00"})
+
+	loader := NewDocumentLoader(nil, grappDir, objectsDir)
+	if err = loader.PinResolved(); err != nil {
+		t.Fatal("expected no lock to write without a context lock", err)
+	}
+
+	// PINS ARE NOT WRITTEN OFFLINE
+	loader.SetContextLock(lock)
+	loader.SetOffline(true)
+	if err = loader.PinResolved(); err != nil {
+		t.Fatal(err)
+	}
+	if file.FileExists(lock.Path()) {
+		t.Errorf("expected no lock file written offline")
+	}
+
+	loader.SetOffline(false)
+	if err = loader.PinResolved(); err != nil {
+		t.Fatal(err)
+	}
+	if !file.FileExists(lock.Path()) {
+		t.Errorf("expected lock file at %s", lock.Path())
+	}
+}
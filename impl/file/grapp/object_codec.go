@@ -0,0 +1,324 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/cborld"
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/datacequia/go-dogg3rz/vocab"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/piprate/json-gold/ld"
+)
+
+// prefix of the IRIs of contexts kept in the object store by the
+// SHA-256 hash of their content
+const storedContextPrefix = "urn:sha256:"
+
+// objectStoreLoader resolves the contexts objects are encoded with.
+// They are all kept in the object store (bundled vocabularies too) so
+// that objects decode the same whatever the vocabularies bundled with
+// the binary reading them
+type objectStoreLoader struct {
+	objectsDir string
+}
+
+func (l objectStoreLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+
+	if !strings.HasPrefix(u, storedContextPrefix) {
+		return nil, errors.NotFound.Newf("%s: context not found in object store", u)
+	}
+
+	data, err := readContent(l.objectsDir, strings.TrimPrefix(u, storedContextPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := parseJSON(bytes.NewReader(data), u)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ld.RemoteDocument{DocumentURL: u, Document: doc}, nil
+}
+
+// encodeObject returns the object file content of the flattened form
+// of the document iri with context documentContext: CBOR-LD of the
+// flattened document compacted against the context, which is kept in
+// the object store. Plain CBOR of the flattened document is returned
+// if the context cannot be kept or the CBOR-LD does not decode to the
+// statements with canonical hash canonical
+func (dl *DocumentLoader) encodeObject(iri string, documentContext interface{}, flattened interface{},
+	canonical string) ([]byte, error) {
+
+	contextRef, err := dl.storeContext(iri, documentContext)
+	if err != nil {
+		return cbor.Marshal(flattened)
+	}
+
+	return encodeFlattened(dl.objectsDir, contextRef, flattened, canonical)
+}
+
+// encodeFlattened returns CBOR-LD of flattened compacted against the
+// stored context contextRef or, if it does not decode to the statements
+// with canonical hash canonical, plain CBOR of flattened
+func encodeFlattened(objectsDir string, contextRef interface{}, flattened interface{}, canonical string) ([]byte, error) {
+
+	if data, err := encodeCompacted(objectsDir, contextRef, flattened); err == nil &&
+		verifyObject(objectsDir, data, canonical) {
+		return data, nil
+	}
+
+	return cbor.Marshal(flattened)
+}
+
+// encodeCompacted returns the CBOR-LD encoding of flattened compacted
+// against the context contextRef
+func encodeCompacted(objectsDir string, contextRef interface{}, flattened interface{}) ([]byte, error) {
+
+	loader := objectStoreLoader{objectsDir: objectsDir}
+	options := ld.NewJsonLdOptions("")
+	options.DocumentLoader = loader
+
+	var compactContext interface{} = map[string]interface{}{}
+	if contextRef != nil {
+		compactContext = contextRef
+	}
+
+	compacted, err := ld.NewJsonLdProcessor().Compact(flattened, map[string]interface{}{"@context": compactContext}, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return cborld.Encode(compacted, loader)
+}
+
+// verifyObject returns true if the object file content data holds the
+// statements with canonical hash canonical
+func verifyObject(objectsDir string, data []byte, canonical string) bool {
+
+	flattened, err := decodeObject(objectsDir, data)
+	if err != nil {
+		return false
+	}
+
+	dataset, err := ld.NewJsonLdApi().ToRDF(flattened, ld.NewJsonLdOptions(""))
+	if err != nil {
+		return false
+	}
+
+	hash, err := rdf.CanonicalHash(dataset)
+
+	return err == nil && hash == canonical
+}
+
+// decodeObject returns the flattened document stored as object file
+// content data in CBOR-LD or, for objects written by earlier
+// versions, plain CBOR
+func decodeObject(objectsDir string, data []byte) (interface{}, error) {
+
+	if !cborld.IsCBORLD(data) {
+		decMode, err := cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]interface{}{})}.DecMode()
+		if err != nil {
+			return nil, err
+		}
+
+		var flattened interface{}
+		if err = decMode.Unmarshal(data, &flattened); err != nil {
+			return nil, errors.InvalidValue.Wrapf(err, "invalid CBOR object")
+		}
+		return flattened, nil
+	}
+
+	loader := objectStoreLoader{objectsDir: objectsDir}
+
+	compacted, err := cborld.Decode(data, loader)
+	if err != nil {
+		return nil, err
+	}
+
+	options := ld.NewJsonLdOptions("")
+	options.DocumentLoader = loader
+
+	// EXPANDING THE COMPACTED FLATTENED DOCUMENT RESTORES IT. BLANK NODE
+	// LABELS ARE KEPT AS CHUNKS OF STREAMED DOCUMENTS SHARE THEM
+	expanded, err := ld.NewJsonLdProcessor().Expand(compacted, options)
+	if err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "invalid CBOR-LD object")
+	}
+
+	return expanded, nil
+}
+
+// decodeCBORLD returns the JSON form of the CBOR-LD encoded document u
+// with content data
+func (dl *DocumentLoader) decodeCBORLD(u string, data []byte) ([]byte, error) {
+
+	doc, err := cborld.Decode(data, dl.descend(u))
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", u)
+	}
+
+	return json.Marshal(doc)
+}
+
+// storeContext returns a reference to the context documentContext of
+// the document iri that the object store resolves to the same term
+// definitions: the context itself if it only references bundled
+// vocabularies (which inlining replaces with the IRIs of their copies
+// in the object store), otherwise the IRI of a copy with all other
+// referenced contexts inlined that is kept in the object store
+func (dl *DocumentLoader) storeContext(iri string, documentContext interface{}) (interface{}, error) {
+
+	inlined, err := dl.descend(iri).inlineContext(documentContext, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if bundledOnly(inlined) {
+		return inlined, nil
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"@context": inlined})
+	if err != nil {
+		return nil, err
+	}
+
+	contentHash, err := putContent(dl.objectsDir, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return storedContextPrefix + contentHash, nil
+}
+
+// bundledOnly returns true if context c is empty or only references
+// contexts (i.e. stored bundled vocabularies) by IRI
+func bundledOnly(c interface{}) bool {
+
+	switch value := c.(type) {
+	case nil, string:
+		return true
+	case []interface{}:
+		for _, item := range value {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// inlineContext returns context c with the contexts it references
+// replaced by their content. Unmodified bundled vocabularies are
+// replaced by the IRI of a copy kept in the object store instead
+func (dl *DocumentLoader) inlineContext(c interface{}, depth int) (interface{}, error) {
+
+	if depth > dl.limits.ContextDepth() {
+		return nil, errors.OutOfRange.Newf("maximum context depth %d exceeded", dl.limits.ContextDepth())
+	}
+
+	switch value := c.(type) {
+	case string:
+		if v, ok := vocab.Lookup(value); ok && dl.resolveIRI(value) == value {
+			if data, from, err := v.Load(dl.vocabDir); err == nil && from == "bundled" {
+				// PINNED BY CONTENT AS A RELEASE MAY UPDATE THE VOCABULARY
+				contentHash, err := putContent(dl.objectsDir, data)
+				if err != nil {
+					return nil, err
+				}
+				return storedContextPrefix + contentHash, nil
+			}
+		}
+
+		doc, err := dl.LoadDocument(value)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := doc.Document.(map[string]interface{})
+		if !ok {
+			return nil, errors.InvalidValue.Newf("%s: context document is not an object", value)
+		}
+		inlined, err := dl.descend(doc.DocumentURL).inlineContext(m["@context"], depth+1)
+		if err != nil {
+			return nil, err
+		}
+		// @base IS IGNORED IN REMOTE CONTEXTS
+		if definitions, ok := inlined.(map[string]interface{}); ok {
+			delete(definitions, "@base")
+		}
+		return inlined, nil
+
+	case []interface{}:
+		var inlined []interface{}
+		for _, item := range value {
+			i, err := dl.inlineContext(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if items, ok := i.([]interface{}); ok {
+				inlined = append(inlined, items...)
+			} else {
+				inlined = append(inlined, i)
+			}
+		}
+		return inlined, nil
+
+	case map[string]interface{}:
+		inlined := make(map[string]interface{}, len(value))
+		if imported, ok := value["@import"]; ok {
+			i, err := dl.inlineContext(imported, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			definitions, ok := i.(map[string]interface{})
+			if !ok {
+				return nil, errors.InvalidValue.Newf("%v: imported context is not an object", imported)
+			}
+			for term, definition := range definitions {
+				inlined[term] = definition
+			}
+		}
+		for term, definition := range value {
+			if term == "@import" {
+				continue
+			}
+			if d, ok := definition.(map[string]interface{}); ok {
+				if scoped, ok := d["@context"]; ok {
+					i, err := dl.inlineContext(scoped, depth+1)
+					if err != nil {
+						return nil, err
+					}
+					copied := make(map[string]interface{}, len(d))
+					for k, v := range d {
+						copied[k] = v
+					}
+					copied["@context"] = i
+					definition = copied
+				}
+			}
+			inlined[term] = definition
+		}
+		return inlined, nil
+	}
+
+	return c, nil
+}
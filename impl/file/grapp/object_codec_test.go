@@ -0,0 +1,91 @@
+package grapp
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/cborld"
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/vocab"
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestObjectEncoding(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	writeTestFile(t, grappDir+"/context.jsonld", testContext)
+	writeTestFile(t, grappDir+"/person.jsonld", `{
+    "@context": ["https://schema.org/", "context.jsonld"],
+    "@id": "http://example.org/jane",
+    "@type": "Person",
+    "name": "Jane Doe",
+    "birthDate": "1990-01-02",
+    "knows": {"@id": "http://example.org/joe", "@type": "Person", "name": "Joe"}
+}`)
+
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := readProcessedEntry(objectsDir, "person.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(entry.objectPath(objectsDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cborld.IsCBORLD(data) {
+		t.Fatalf("expected object to be encoded as CBOR-LD")
+	}
+
+	// BUNDLED VOCABULARIES ARE PINNED IN THE OBJECT STORE
+	if _, err := (objectStoreLoader{objectsDir: objectsDir}).LoadDocument("https://schema.org/"); errors.GetType(err) != errors.NotFound {
+		t.Errorf("expected objects not to be decoded with the bundled vocabulary, got %v", err)
+	}
+	schemaorg, _ := vocab.Lookup("https://schema.org/")
+	if _, err := readContent(objectsDir, fmt.Sprintf("%x", sha256.Sum256(schemaorg.Bundled()))); err != nil {
+		t.Errorf("expected bundled vocabulary to be kept in the object store: %v", err)
+	}
+
+	flattened, err := ReadFlattened(objectsDir, "person.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verifyObject(objectsDir, data, entry.Canonical) {
+		t.Errorf("expected CBOR-LD object to hold the statements of the document")
+	}
+	decoded, err := json.Marshal(flattened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`"http://example.org/name"`, `"http://schema.org/birthDate"`,
+		`"http://example.org/Person"`, `"http://example.org/joe"`} {
+		if !strings.Contains(string(decoded), expected) {
+			t.Errorf("expected decoded object to contain %s, got %s", expected, decoded)
+		}
+	}
+
+	plain, err := cbor.Marshal(flattened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) >= len(plain) {
+		t.Errorf("expected CBOR-LD object (%d bytes) to be smaller than plain CBOR (%d bytes)", len(data), len(plain))
+	}
+
+	// OBJECTS WRITTEN AS PLAIN CBOR ARE STILL READ
+	writeTestFile(t, entry.objectPath(objectsDir), string(plain))
+	legacy, err := ReadFlattened(objectsDir, "person.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacyData, _ := json.Marshal(legacy); string(legacyData) != string(decoded) {
+		t.Errorf("expected plain CBOR object %s, got %s", decoded, legacyData)
+	}
+}
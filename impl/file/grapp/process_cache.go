@@ -0,0 +1,316 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/did"
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/resource/config"
+	"github.com/datacequia/go-dogg3rz/vocab"
+)
+
+// suffix of processing cache entries in the object cache
+const processedEntrySuffix = ".processed.json"
+
+// version of the processing cache entry format and of the processing
+// it records. Entries of other versions are ignored
+const processedEntryVersion = 3
+
+// processedDependency is a document loaded while processing another
+type processedDependency struct {
+	IRI    string `json:"iri"`            // as referenced
+	Path   string `json:"path,omitempty"` // absolute path of a local document
+	SHA256 string `json:"sha256"`         // content hash
+}
+
+// processedEntry records the result of processing (i.e. expanding and
+// flattening) a document. It remains valid while the document's
+// content and the content of every document it resolved are unchanged
+type processedEntry struct {
+	Version      int                   `json:"version"`
+	IRI          string                `json:"iri"`
+	SHA256       string                `json:"sha256"`              // content hash of the document
+	Loader       string                `json:"loader"`              // hash of loader settings that affect resolution
+	Object       string                `json:"object,omitempty"`    // flattened CBOR object (none for context only documents)
+	Chunks       []string              `json:"chunks,omitempty"`    // flattened CBOR objects of a streamed document
+	Canonical    string                `json:"canonical,omitempty"` // hash of the canonical N-Quads of the statements (the content hash of streamed documents)
+	Dependencies []processedDependency `json:"dependencies,omitempty"`
+}
+
+// loaded notifies the loader's listener (if any) that the document u
+// was loaded with content buf
+func (dl *DocumentLoader) loaded(u string, localPath string, buf []byte) {
+
+	if dl.onLoad != nil {
+		dl.onLoad(processedDependency{IRI: u, Path: localPath, SHA256: fmt.Sprintf("%x", sha256.Sum256(buf))})
+	}
+}
+
+// loaderFingerprint returns a hash of the settings of dl that change
+// which documents IRIs resolve to or whether they may be loaded
+func (dl *DocumentLoader) loaderFingerprint() string {
+
+	data, _ := json.Marshal(struct {
+		Rewrites     []config.IRIRewrite         `json:"rewrites"`
+		Limits       config.DocumentLoaderLimits `json:"limits"`
+		Offline      bool                        `json:"offline"`
+		VocabDir     string                      `json:"vocabDir"`
+		DataProducts map[string]string           `json:"dataProducts"`
+		DataDir      string                      `json:"dataDir"`
+	}{dl.rewrites, dl.limits, dl.offline, dl.vocabDir, dl.dataProducts, dl.dataDir})
+
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+func processedEntryPath(objectsDir string, iri string) string {
+	return filepath.Join(objectsDir, fmt.Sprintf("%x", sha256.Sum256([]byte(iri)))+processedEntrySuffix)
+}
+
+func (dl *DocumentLoader) newProcessedEntry(iri string, docSHA256 string) *processedEntry {
+
+	return &processedEntry{
+		Version: processedEntryVersion,
+		IRI:     iri,
+		SHA256:  docSHA256,
+		Loader:  dl.loaderFingerprint(),
+	}
+}
+
+// processedEntry returns the processing cache entry of document iri
+// with content hash docSHA256 or nil if the cached result is missing
+// or stale
+func (dl *DocumentLoader) processedEntry(iri string, docSHA256 string) *processedEntry {
+
+	data, err := os.ReadFile(processedEntryPath(dl.objectsDir, iri))
+	if err != nil {
+		return nil
+	}
+
+	entry := &processedEntry{}
+	if err = json.Unmarshal(data, entry); err != nil {
+		return nil
+	}
+
+	if entry.Version != processedEntryVersion || entry.IRI != iri || entry.SHA256 != docSHA256 ||
+		entry.Loader != dl.loaderFingerprint() {
+		return nil
+	}
+
+	for _, objectPath := range entry.objectPaths(dl.objectsDir) {
+		if !file.FileExists(objectPath) {
+			return nil
+		}
+	}
+
+	for _, dep := range entry.Dependencies {
+		if !dl.current(dep) {
+			return nil
+		}
+	}
+
+	return entry
+}
+
+// current returns true if the document dep would still load with the
+// same content under the loader's sandbox and limits
+func (dl *DocumentLoader) current(dep processedDependency) bool {
+
+	if len(dep.Path) > 0 {
+		if dl.checkLocalPath(dep.IRI, dep.Path) != nil {
+			return false
+		}
+		data, err := dl.readLocalFile(dep.Path)
+		return err == nil && fmt.Sprintf("%x", sha256.Sum256(data)) == dep.SHA256
+	}
+
+	if dl.resolveIRI(dep.IRI) == dep.IRI {
+		if v, ok := vocab.Lookup(dep.IRI); ok {
+			data, _, err := v.Load(dl.vocabDir)
+			return err == nil && vocab.Hash(data) == dep.SHA256
+		}
+	}
+
+	// REMOTE DOCUMENTS ARE CURRENT WHILE PINNED TO THE SAME CACHED CONTENT
+	// THAT MAY STILL BE LOADED AND IS NOT BEING REVALIDATED
+	if locked, ok := dl.lockedDocument(dep.IRI); ok {
+		if dl.revalidatePin(dep.IRI) || locked.SHA256 != dep.SHA256 || !dl.remoteAllowed(dep.IRI, locked) {
+			return false
+		}
+		info, err := os.Stat(dl.cachedDocumentPath(locked.SHA256))
+		return err == nil && info.Size() <= dl.limits.DocumentSize()
+	}
+
+	// did:key DOCUMENTS ARE DERIVED FROM THE DID ITSELF
+	if strings.HasPrefix(dep.IRI, "did:key:") {
+		if d, err := did.Parse(dep.IRI); err == nil {
+			data, err := did.ResolveKey(d)
+			return err == nil && fmt.Sprintf("%x", sha256.Sum256(data)) == dep.SHA256
+		}
+	}
+
+	return false
+}
+
+// remoteAllowed returns true if the remote url policy of the loader
+// allows the http(s) document iri pinned as locked to be loaded
+func (dl *DocumentLoader) remoteAllowed(iri string, locked LockedDocument) bool {
+
+	for _, u := range []string{dl.resolveIRI(iri), locked.URL} {
+		remoteURL, err := url.Parse(u)
+		if err != nil {
+			return false
+		}
+		if remoteURL.Scheme != "http" && remoteURL.Scheme != "https" {
+			continue
+		}
+		if checkRemoteURL(dl.limits, remoteURL) != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// recorder returns a listener that records loaded documents as
+// dependencies of the entry and passes them on to next (if any)
+func (e *processedEntry) recorder(next func(processedDependency)) func(processedDependency) {
+
+	return func(dep processedDependency) {
+		e.Dependencies = append(e.Dependencies, dep)
+		if next != nil {
+			next(dep)
+		}
+	}
+}
+
+// replay reports the dependencies of the entry to the listener of dl
+// as if the document had been processed again
+func (e *processedEntry) replay(dl *DocumentLoader) {
+
+	if dl.onLoad == nil {
+		return
+	}
+	for _, dep := range e.Dependencies {
+		dl.onLoad(dep)
+	}
+}
+
+func (e *processedEntry) objectPath(objectsDir string) string {
+
+	if len(e.Object) < 1 {
+		return ""
+	}
+
+	return filepath.Join(objectsDir, e.Object)
+}
+
+// objectPaths returns the paths of the object files of the entry
+func (e *processedEntry) objectPaths(objectsDir string) []string {
+
+	if len(e.Object) > 0 {
+		return []string{e.objectPath(objectsDir)}
+	}
+
+	var chunkPaths []string
+	for _, chunk := range e.Chunks {
+		chunkPaths = append(chunkPaths, filepath.Join(objectsDir, chunk))
+	}
+
+	return chunkPaths
+}
+
+// write saves the entry in objectsDir
+func (e *processedEntry) write(objectsDir string) error {
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(data), nil },
+		processedEntryPath(objectsDir, e.IRI))
+
+	return err
+}
+
+// readProcessedEntry returns the processing cache entry of document
+// iri as last written, whether current or not
+func readProcessedEntry(objectsDir string, iri string) (*processedEntry, error) {
+
+	data, err := os.ReadFile(processedEntryPath(objectsDir, iri))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NotFound.Newf("%s: document has not been processed", iri)
+		}
+		return nil, err
+	}
+
+	entry := &processedEntry{}
+	if err = json.Unmarshal(data, entry); err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "%s", processedEntryPath(objectsDir, iri))
+	}
+
+	return entry, nil
+}
+
+// ReadFlattened returns the flattened form of the document iri as
+// cached when it was last processed. NotFound is returned if the
+// document has not been processed or is a context only document
+func ReadFlattened(objectsDir string, iri string) (interface{}, error) {
+
+	entry, err := readProcessedEntry(objectsDir, iri)
+	if err != nil {
+		return nil, err
+	}
+	objectPaths := entry.objectPaths(objectsDir)
+	if len(objectPaths) < 1 {
+		return nil, errors.NotFound.Newf("%s: context only document has no statements", iri)
+	}
+
+	// THE CHUNKS OF A STREAMED DOCUMENT ARE CONCATENATED
+	var flattened []interface{}
+	for _, objectPath := range objectPaths {
+		data, err := os.ReadFile(objectPath)
+		if err != nil {
+			return nil, err
+		}
+
+		object, err := decodeObject(objectsDir, data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s", objectPath)
+		}
+		if len(objectPaths) == 1 {
+			return object, nil
+		}
+
+		nodes, ok := object.([]interface{})
+		if !ok {
+			return nil, errors.UnexpectedType.Newf("%s: expected flattened node objects, got %T", objectPath, object)
+		}
+		flattened = append(flattened, nodes...)
+	}
+
+	return flattened, nil
+}
@@ -0,0 +1,145 @@
+package grapp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/resource/config"
+)
+
+func TestProcessingCache(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	if err := os.Mkdir(filepath.Join(grappDir, "contexts"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	contextFile := filepath.Join(grappDir, "contexts", "person.jsonld")
+	writeTestFile(t, contextFile, testContext)
+	writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+		`{"@context": "contexts/person.jsonld", "@id": "http://example.org/jane", "@type": "Person", "name": "Jane Doe"}`)
+
+	// objectInfo returns the flattened object of person.jsonld as
+	// cached and the file info of the object file
+	objectInfo := func() (string, os.FileInfo) {
+
+		flattened, err := ReadFlattened(objectsDir, "person.jsonld")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(flattened)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entryData, err := os.ReadFile(processedEntryPath(objectsDir, "person.jsonld"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry := &processedEntry{}
+		if err = json.Unmarshal(entryData, entry); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(entry.objectPath(objectsDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(data), info
+	}
+
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	flattened, info := objectInfo()
+	if !strings.Contains(flattened, "http://example.org/name") {
+		t.Errorf("expected cached flattened document to contain expanded terms, got %s", flattened)
+	}
+
+	// UNCHANGED FILES ARE NOT PROCESSED AGAIN
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, unchangedInfo := objectInfo(); !os.SameFile(info, unchangedInfo) {
+		t.Errorf("expected unchanged document to be served from the processing cache")
+	}
+
+	// A CHANGED CONTEXT INVALIDATES THE DOCUMENTS THAT RESOLVED IT
+	writeTestFile(t, contextFile, `{"@context": {"name": "http://example.org/fullName", "Person": "http://example.org/Person"}}`)
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	flattened, _ = objectInfo()
+	if !strings.Contains(flattened, "http://example.org/fullName") {
+		t.Errorf("expected document to be processed again after its context changed, got %s", flattened)
+	}
+
+	if _, err := ReadFlattened(objectsDir, "unknown.jsonld"); errors.GetType(err) != errors.NotFound {
+		t.Errorf("expected NotFound error for unprocessed document, got %v", err)
+	}
+
+}
+
+func TestProcessingCacheLimits(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testContext))
+	}))
+	defer server.Close()
+
+	writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+		`{"@context": "`+server.URL+`/context.jsonld", "@type": "Person", "name": "Jane Doe"}`)
+
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// CACHED RESULTS DON'T OUTLIVE TIGHTER LIMITS
+	writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"),
+		`{"documentLoader": {"limits": {"denyDomains": ["127.0.0.1"]}}}`)
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); errors.GetType(err) != errors.InvalidValue {
+		t.Errorf("expected InvalidValue error for cached document depending on a denied domain, got %v", err)
+	}
+
+	// NOR DO THE PINNED DEPENDENCIES THEY RECORD
+	os.Remove(filepath.Join(grappDir, file.DgrzDirName, "config"))
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryData, err := os.ReadFile(processedEntryPath(objectsDir, "person.jsonld"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := &processedEntry{}
+	if err = json.Unmarshal(entryData, entry); err != nil || len(entry.Dependencies) != 1 {
+		t.Fatalf("expected one recorded dependency, got %v (%v)", entry, err)
+	}
+	if !loader.current(entry.Dependencies[0]) {
+		t.Errorf("expected pinned dependency to be current")
+	}
+	maxDocumentSize := int64(16)
+	for name, limits := range map[string]config.DocumentLoaderLimits{
+		"deny domain":       {DenyDomains: []string{"127.0.0.1"}},
+		"https only":        {HTTPSOnly: &[]bool{true}[0]},
+		"max document size": {MaxDocumentSize: &maxDocumentSize},
+	} {
+		loader.SetLimits(limits)
+		if loader.current(entry.Dependencies[0]) {
+			t.Errorf("%s: expected pinned dependency not to be current", name)
+		}
+	}
+}
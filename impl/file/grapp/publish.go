@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+func (grapp *FileGrapplicationResource) Publish(ctxt context.Context, selector string,
+	put func(block []byte) (string, error), out io.Writer, vw io.Writer) error {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	return publishSnapshot(grappDir, objectsDir, selector, put, out, vw)
+}
+
+// publishSnapshot puts the CBOR-LD objects of the project files in the
+// snapshot of the grapp in grappDir chosen by selector as blocks with put
+// and writes the CID and object file name of each to out. The objects
+// must have been processed from the snapshot's version of each file
+func publishSnapshot(grappDir string, objectsDir string, selector string,
+	put func(block []byte) (string, error), out io.Writer, vw io.Writer) error {
+
+	snapshot, err := ResolveSnapshot(grappDir, objectsDir, selector)
+	if err != nil {
+		return err
+	}
+	verbose(vw, "Resolved %q to snapshot %s", selector, snapshot.ID)
+
+	published := make(map[string]bool)
+
+	for _, projectFile := range snapshot.ProjectFiles() {
+
+		entry, err := readProcessedEntry(objectsDir, projectFile)
+		if err != nil {
+			return err
+		}
+		if entry.SHA256 != snapshot.Files[projectFile] {
+			return errors.UnexpectedValue.Newf("%s: processed objects are not of snapshot %s. validate the snapshot's files first",
+				projectFile, snapshot.ID)
+		}
+
+		// EACH OBJECT IS PUBLISHED ONCE
+		for _, objectPath := range entry.objectPaths(objectsDir) {
+			if published[objectPath] {
+				continue
+			}
+
+			data, err := os.ReadFile(objectPath)
+			if err != nil {
+				return err
+			}
+
+			cid, err := put(data)
+			if err != nil {
+				return errors.ExternalError.Wrapf(err, "%s: publishing object %s", projectFile, filepath.Base(objectPath))
+			}
+			published[objectPath] = true
+
+			fmt.Fprintf(out, "%s %s\n", cid, filepath.Base(objectPath))
+			verbose(vw, "Published object %s of %s as block %s", filepath.Base(objectPath), projectFile, cid)
+		}
+	}
+
+	verbose(vw, "Published %d objects of snapshot %s", len(published), snapshot.ID)
+
+	return nil
+}
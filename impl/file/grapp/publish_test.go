@@ -0,0 +1,76 @@
+package grapp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+func TestPublishSnapshot(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	writeTestFile(t, filepath.Join(grappDir, "a.jsonld"), `{"@id": "http://example.org/a", "http://example.org/p": "a"}`)
+	writeTestFile(t, filepath.Join(grappDir, "b.jsonld"), `{"@id": "http://example.org/b", "http://example.org/p": "b"}`)
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := CreateSnapshot(ctxt, grappDir, objectsDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// BLOCKS ARE ADDRESSED BY THEIR HASH
+	blocks := make(map[string][]byte)
+	put := func(block []byte) (string, error) {
+		cid := fmt.Sprintf("%x", sha256.Sum256(block))
+		blocks[cid] = block
+		return cid, nil
+	}
+
+	var out bytes.Buffer
+	if err = publishSnapshot(grappDir, objectsDir, snapshot.ID, put, &out, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a published object per project file, got %q", out.String())
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		data, err := os.ReadFile(filepath.Join(objectsDir, fields[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(blocks[fields[0]], data) {
+			t.Errorf("%s: expected block %s to hold the object", fields[1], fields[0])
+		}
+	}
+
+	// OBJECTS OF A CHANGED FILE ARE NOT THOSE OF THE SNAPSHOT
+	writeTestFile(t, filepath.Join(grappDir, "b.jsonld"), `{"@id": "http://example.org/b", "http://example.org/p": "B"}`)
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+	err = publishSnapshot(grappDir, objectsDir, snapshot.ID, put, &out, nil)
+	if errors.GetType(err) != errors.UnexpectedValue {
+		t.Errorf("expected UnexpectedValue error for objects of a changed file, got %v", err)
+	}
+
+	// PUT ERRORS ARE REPORTED AS EXTERNAL ERRORS
+	failPut := func(block []byte) (string, error) {
+		return "", fmt.Errorf("connection refused")
+	}
+	err = publishSnapshot(grappDir, objectsDir, "", failPut, &out, nil)
+	if errors.GetType(err) != errors.ExternalError {
+		t.Errorf("expected ExternalError for failed put, got %v", err)
+	}
+
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"io"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+	"github.com/datacequia/go-dogg3rz/sparql"
+)
+
+func (grapp *FileGrapplicationResource) Query(ctxt context.Context, query string, w io.Writer,
+	options resourcegrapp.QueryOptions, vw io.Writer) error {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	return queryGrapp(ctxt, grappDir, objectsDir, query, w, options, vw)
+}
+
+// queryGrapp evaluates a SPARQL query over the statements of the
+// project files of the grapp in grappDir (or of one of its snapshots)
+// and writes the result to w. With options.Blame the query is
+// evaluated over a range of snapshots instead (see blameGrapp)
+func queryGrapp(ctxt context.Context, grappDir string, objectsDir string, query string, w io.Writer,
+	options resourcegrapp.QueryOptions, vw io.Writer) error {
+
+	format := sparql.Table
+	if len(options.Format) > 0 {
+		var err error
+		if format, err = sparql.ParseFormat(options.Format); err != nil {
+			return err
+		}
+	}
+
+	// A MALFORMED QUERY IS REPORTED BEFORE THE INDEX IS BROUGHT UP TO DATE
+	q, err := sparql.Parse(query)
+	if err != nil {
+		return err
+	}
+
+	if options.Blame {
+		return blameGrapp(ctxt, grappDir, objectsDir, q, options.Since, options.Snapshot, w, format, vw)
+	}
+	if len(options.Since) > 0 {
+		return errors.InvalidValue.New("a range start is only used when blaming")
+	}
+
+	store, err := OpenIndexedStore(ctxt, grappDir, objectsDir, options.Snapshot, vw)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	verbose(vw, "Evaluating %s query...", q.Form)
+	result, err := q.Evaluate(store)
+	if err != nil {
+		return err
+	}
+	if result.Form == sparql.Select {
+		verbose(vw, "%d solutions", len(result.Solutions))
+	}
+
+	return result.Write(w, format)
+}
@@ -0,0 +1,75 @@
+package grapp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+func TestQuery(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	writeTestFile(t, filepath.Join(grappDir, "people.jsonld"),
+		`{"@context": {"foaf": "http://xmlns.com/foaf/0.1/", "ex": "http://example.org/"},
+		  "@graph": [{"@id": "ex:alice", "foaf:name": "Alice", "foaf:knows": {"@id": "ex:bob"}},
+		             {"@id": "ex:bob", "foaf:name": "Bob"}]}`)
+	writeTestFile(t, filepath.Join(grappDir, "likes.jsonld"),
+		`{"@context": {"ex": "http://example.org/"},
+		  "@id": "ex:likes", "@graph": [{"@id": "ex:alice", "ex:likes": {"@id": "ex:pizza"}}]}`)
+
+	query := func(q string, options resourcegrapp.QueryOptions) string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := queryGrapp(ctxt, grappDir, objectsDir, q, &buf, options, nil); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	const names = `PREFIX foaf: <http://xmlns.com/foaf/0.1/>
+SELECT ?name WHERE { ?p foaf:name ?name } ORDER BY ?name`
+
+	if out, expected := query(names, resourcegrapp.QueryOptions{Format: "csv"}), "name\r\nAlice\r\nBob\r\n"; out != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, out)
+	}
+
+	// NAMED GRAPHS ARE MATCHED WITH GRAPH
+	const likes = `ASK { GRAPH <http://example.org/likes> { <http://example.org/alice> ?p ?o } }`
+	if out := query(likes, resourcegrapp.QueryOptions{}); out != "true\n" {
+		t.Errorf("expected true but got %s", out)
+	}
+
+	// QUERY A SNAPSHOT
+	snapshot, err := CreateSnapshot(ctxt, grappDir, objectsDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Remove(filepath.Join(grappDir, "likes.jsonld")); err != nil {
+		t.Fatal(err)
+	}
+	if out := query(likes, resourcegrapp.QueryOptions{}); out != "false\n" {
+		t.Errorf("working tree: expected false but got %s", out)
+	}
+	if out := query(likes, resourcegrapp.QueryOptions{Snapshot: snapshot.ID}); out != "true\n" {
+		t.Errorf("snapshot: expected true but got %s", out)
+	}
+
+	for _, test := range []struct {
+		query   string
+		options resourcegrapp.QueryOptions
+		errType errors.ErrorType
+	}{
+		{"SELECT ?x WHERE {", resourcegrapp.QueryOptions{}, errors.InvalidValue},
+		{names, resourcegrapp.QueryOptions{Format: "xml"}, errors.InvalidValue},
+	} {
+		err := queryGrapp(ctxt, grappDir, objectsDir, test.query, &bytes.Buffer{}, test.options, nil)
+		if errors.GetType(err) != test.errType {
+			t.Errorf("%s: expected %v error but got %v", test.query, test.errType, err)
+		}
+	}
+}
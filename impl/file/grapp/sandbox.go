@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+// referrer returns the document that references the documents
+// loaded by this loader or an empty string if there is none
+func (dl *DocumentLoader) referrer() string {
+
+	if len(dl.chain) < 1 {
+		return ""
+	}
+
+	return dl.chain[len(dl.chain)-1]
+}
+
+// remoteReferrer returns the url of the referring document if it
+// was loaded from a remote location
+func (dl *DocumentLoader) remoteReferrer() (*url.URL, bool) {
+
+	referrer, err := url.Parse(dl.referrer())
+	if err != nil || referrer.Scheme == "" || referrer.Scheme == "file" {
+		return nil, false
+	}
+
+	return referrer, true
+}
+
+// resolveLocalPath returns the file path of the local document u.
+// Relative references are resolved against the location of the
+// referring document (or the working directory if there is none).
+// The path must lie within the grapp directory or a path allowed by
+// the loader's limits
+func (dl *DocumentLoader) resolveLocalPath(u string, parsedURL *url.URL) (string, error) {
+
+	var localPath string
+
+	switch {
+	case parsedURL.Scheme == "file":
+		if parsedURL.Host != "" && parsedURL.Host != "localhost" {
+			return "", errors.InvalidValue.Newf("%s: file URLs referring to remote hosts are not supported", u)
+		}
+		localPath = filepath.FromSlash(parsedURL.Path)
+	case parsedURL.Scheme != "" && !filepath.IsAbs(u):
+		return "", ld.NewJsonLdError(ld.LoadingDocumentFailed, "unsupported URL scheme: "+u)
+	case filepath.IsAbs(u):
+		localPath = u
+	case dl.referrer() != "":
+		// REFERRING LOCAL DOCUMENTS ARE IDENTIFIED RELATIVE TO THE GRAPP DIR
+		localPath = filepath.Join(dl.grappDir, filepath.Dir(filepath.FromSlash(dl.referrer())), filepath.FromSlash(u))
+	default:
+		localPath = u
+	}
+
+	if err := dl.checkLocalPath(u, localPath); err != nil {
+		return "", err
+	}
+
+	return localPath, nil
+}
+
+// checkLocalPath returns an error if localPath (referenced as u)
+// escapes the grapp directory and the paths allowed by the loader
+func (dl *DocumentLoader) checkLocalPath(u string, localPath string) error {
+
+	resolved, err := canonicalPath(localPath)
+	if err != nil {
+		return err
+	}
+
+	grappRoot, err := canonicalPath(dl.grappDir)
+	if err != nil {
+		return err
+	}
+
+	if pathWithin(grappRoot, resolved) {
+		return nil
+	}
+
+	for _, allowed := range dl.limits.AllowFilePaths {
+		if !filepath.IsAbs(allowed) {
+			allowed = filepath.Join(dl.grappDir, allowed)
+		}
+		if allowedRoot, err := canonicalPath(allowed); err == nil && pathWithin(allowedRoot, resolved) {
+			return nil
+		}
+	}
+
+	return errors.InvalidValue.Newf("%s: resolves to %s outside of grapp directory %s",
+		u, resolved, grappRoot)
+}
+
+// canonicalPath returns the absolute path of p with symbolic links
+// evaluated. Paths that do not exist are only made absolute
+func canonicalPath(p string) (string, error) {
+
+	absPath, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		return resolved, nil
+	}
+
+	return absPath, nil
+}
+
+// pathWithin returns true if p is root or lies below root
+func pathWithin(root string, p string) bool {
+
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveContextReferences replaces relative context (and @import)
+// references anywhere within the contexts of doc by absolute
+// references resolved against base
+func resolveContextReferences(doc interface{}, base *url.URL) {
+
+	if base == nil {
+		return
+	}
+
+	resolve := func(ref interface{}) interface{} {
+		if s, ok := ref.(string); ok {
+			if refURL, err := url.Parse(s); err == nil && refURL.Scheme == "" {
+				return base.ResolveReference(refURL).String()
+			}
+		}
+		return ref
+	}
+
+	var walk func(node interface{}, inContext bool)
+	walk = func(node interface{}, inContext bool) {
+
+		switch n := node.(type) {
+		case map[string]interface{}:
+			for key, value := range n {
+				switch {
+				case key == "@context":
+					if list, ok := value.([]interface{}); ok {
+						for i := range list {
+							list[i] = resolve(list[i])
+						}
+					} else {
+						n[key] = resolve(value)
+					}
+					walk(n[key], true)
+				case key == "@import" && inContext:
+					n[key] = resolve(value)
+				default:
+					walk(value, inContext)
+				}
+			}
+		case []interface{}:
+			for _, item := range n {
+				walk(item, inContext)
+			}
+		}
+	}
+
+	walk(doc, false)
+}
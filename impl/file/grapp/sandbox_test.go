@@ -0,0 +1,131 @@
+package grapp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+func TestDocumentLoaderLocalFileSandbox(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	// CONTEXTS REFERENCE EACH OTHER RELATIVE TO THEIR OWN LOCATION
+	if err := os.Mkdir(filepath.Join(grappDir, "contexts"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(grappDir, "contexts", "person.jsonld"),
+		`{"@context": ["common.jsonld", {"Person": "http://example.org/Person"}]}`)
+	writeTestFile(t, filepath.Join(grappDir, "contexts", "common.jsonld"),
+		`{"@context": {"name": "http://example.org/name"}}`)
+
+	writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+		`{"@context": "contexts/person.jsonld", "@type": "Person", "name": "Jane Doe"}`)
+
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal("validation with relative contexts failed", err)
+	}
+
+	// A CONTEXT OUTSIDE OF THE GRAPP
+	outsideDir := t.TempDir()
+	outsideContext := filepath.Join(outsideDir, "outside.jsonld")
+	writeTestFile(t, outsideContext, testContext)
+
+	relOutside, err := filepath.Rel(grappDir, outsideContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(outsideContext, filepath.Join(grappDir, "contexts", "link.jsonld")); err != nil {
+		t.Fatal(err)
+	}
+
+	escapes := map[string]string{
+		"relative":      filepath.ToSlash(relOutside),
+		"file url":      "file://" + filepath.ToSlash(outsideContext),
+		"symbolic link": "contexts/link.jsonld",
+	}
+
+	for name, contextRef := range escapes {
+		writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+			`{"@context": "`+contextRef+`", "@type": "Person", "name": "Jane Doe"}`)
+
+		err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil)
+		if errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("%s: expected InvalidValue error for context outside of grapp, got %v", name, err)
+		}
+	}
+
+	// A GRAPP CAN'T ALLOW PATHS ITSELF
+	writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"),
+		`{"documentLoader": {"limits": {"allowFilePaths": ["/"]}}}`)
+
+	for name, contextRef := range escapes {
+		writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+			`{"@context": "`+contextRef+`", "@type": "Person", "name": "Jane Doe"}`)
+
+		err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil)
+		if errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("%s: expected path allowed by grapp config to be ignored, got %v", name, err)
+		}
+	}
+
+	// PATHS EXPLICITLY ALLOWED BY THE USER MAY BE LOADED
+	writeUserConfig(t, ctxt, `{"limits": {"allowFilePaths": ["`+filepath.ToSlash(outsideDir)+`"]}}`)
+
+	for name, contextRef := range escapes {
+		writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+			`{"@context": "`+contextRef+`", "@type": "Person", "name": "Jane Doe"}`)
+
+		if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+			t.Errorf("%s: expected context in allowed path to load, got %v", name, err)
+		}
+	}
+
+}
+
+func TestDocumentLoaderRewriteSandbox(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	// GRAPP CONFIG REWRITES A VOCABULARY TO A DIRECTORY OUTSIDE OF THE GRAPP
+	outsideDir := t.TempDir()
+	writeTestFile(t, filepath.Join(outsideDir, "person.jsonld"), testContext)
+
+	relOutside, err := filepath.Rel(grappDir, outsideDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+		`{"@context": "https://vocab.example.org/person.jsonld", "@type": "Person", "name": "Jane Doe"}`)
+
+	targets := map[string]string{
+		"relative": filepath.ToSlash(relOutside) + "/",
+		"absolute": filepath.ToSlash(outsideDir) + "/",
+		"file url": "file://" + filepath.ToSlash(outsideDir) + "/",
+	}
+
+	for name, to := range targets {
+		writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"),
+			`{"documentLoader": {"rewrites": [{"match": "prefix", "from": "https://vocab.example.org/", "to": "`+to+`"}]}}`)
+
+		err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil)
+		if errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("%s: expected InvalidValue error for rewrite outside of grapp, got %v", name, err)
+		}
+	}
+
+	// PATHS EXPLICITLY ALLOWED BY THE USER MAY BE REWRITE TARGETS
+	writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"),
+		`{"documentLoader": {"rewrites": [{"match": "prefix", "from": "https://vocab.example.org/", "to": "`+filepath.ToSlash(outsideDir)+`/"}]}}`)
+	writeUserConfig(t, ctxt, `{"limits": {"allowFilePaths": ["`+filepath.ToSlash(outsideDir)+`"]}}`)
+
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Errorf("expected rewrite to allowed path to load, got %v", err)
+	}
+
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+func (grapp *FileGrapplicationResource) Show(ctxt context.Context, selector string, w io.Writer, vw io.Writer) error {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	return showSnapshot(grappDir, objectsDir, selector, w, vw)
+}
+
+// showSnapshot writes the snapshot of the grapp in grappDir chosen by
+// selector to w: its id, parent, refs, creation time and message
+// followed by its files marked A (added), M (modified) or D (deleted)
+// relative to its parent
+func showSnapshot(grappDir string, objectsDir string, selector string, w io.Writer, vw io.Writer) error {
+
+	snapshot, err := ResolveSnapshot(grappDir, objectsDir, selector)
+	if err != nil {
+		return err
+	}
+	verbose(vw, "Resolved %q to snapshot %s", selector, snapshot.ID)
+
+	var parent *Snapshot
+	if len(snapshot.Parent) > 0 {
+		if parent, err = ReadSnapshot(objectsDir, snapshot.Parent); err != nil {
+			return err
+		}
+	}
+
+	refs, err := refsOf(grappDir, snapshot.ID)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "snapshot %s\n", snapshot.ID)
+	if parent != nil {
+		fmt.Fprintf(bw, "parent   %s\n", parent.ID)
+	}
+	if len(refs) > 0 {
+		fmt.Fprintf(bw, "refs     %s\n", strings.Join(refs, ", "))
+	}
+	fmt.Fprintf(bw, "created  %s\n", snapshot.Created.Format(time.RFC3339))
+	if len(snapshot.Message) > 0 {
+		bw.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimRight(snapshot.Message, "\n"), "\n") {
+			bw.WriteString("    " + line + "\n")
+		}
+	}
+
+	paths := make([]string, 0, len(snapshot.Files))
+	for path := range snapshot.Files {
+		paths = append(paths, path)
+	}
+	if parent != nil {
+		for path := range parent.Files {
+			if _, ok := snapshot.Files[path]; !ok {
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	if len(paths) > 0 {
+		bw.WriteString("\n")
+	}
+	for _, path := range paths {
+		bw.WriteString(fileStatus(snapshot, parent, path) + "  " + path + "\n")
+	}
+
+	return bw.Flush()
+}
+
+// fileStatus returns A if the file at path was added to snapshot since
+// parent, M if it was modified, D if it was deleted and a space if it
+// is unchanged. Files compare by canonical hash when both snapshots
+// record one
+func fileStatus(snapshot *Snapshot, parent *Snapshot, path string) string {
+
+	if parent == nil {
+		return "A"
+	}
+
+	before, inParent := parent.Files[path]
+	after, inSnapshot := snapshot.Files[path]
+	if canonical, ok := snapshot.Canonical[path]; ok {
+		if parentCanonical, ok := parent.Canonical[path]; ok {
+			before, after = parentCanonical, canonical
+		}
+	}
+
+	switch {
+	case !inParent:
+		return "A"
+	case !inSnapshot:
+		return "D"
+	case before != after:
+		return "M"
+	}
+
+	return " "
+}
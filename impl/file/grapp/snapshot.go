@@ -0,0 +1,460 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+// suffix of snapshot objects in the object store
+const snapshotObjectSuffix = ".snapshot.json"
+
+// shortest snapshot id prefix accepted as a selector
+const minSnapshotIDPrefix = 4
+
+// Snapshot is a point-in-time image of a grapp's source files. File
+// contents are stored by their SHA-256 hash next to the snapshot in
+// the object store. Its id is the SHA-256 hash of its signature, which
+// covers the content hash of each file. The canonical hash of each
+// file (see canonicalFileHash) does not change when files are
+// reformatted or blank nodes relabeled, so it is what changes between
+// snapshots are detected by. Snapshots taken before canonical hashes
+// were recorded are identified by the hash of their encoded form
+type Snapshot struct {
+	ID        string            `json:"-"`
+	Parent    string            `json:"parent,omitempty"`  // previous snapshot on the branch
+	Created   time.Time         `json:"created"`           // when the snapshot was taken
+	Message   string            `json:"message,omitempty"` // user supplied description
+	Files     map[string]string `json:"files"`             // grapp relative path -> content hash
+	Canonical map[string]string `json:"canonical"`         // grapp relative path -> canonical hash (nil in old snapshots)
+}
+
+// snapshotSignature is the part of a snapshot its id is computed from
+type snapshotSignature struct {
+	Parent    string            `json:"parent,omitempty"`
+	Created   time.Time         `json:"created"`
+	Message   string            `json:"message,omitempty"`
+	Files     map[string]string `json:"files"`
+	Canonical map[string]string `json:"canonical"`
+}
+
+// signatureID returns the id of the snapshot computed from its signature
+func (s *Snapshot) signatureID() (string, error) {
+
+	encoded, err := json.Marshal(snapshotSignature{Parent: s.Parent, Created: s.Created, Message: s.Message,
+		Files: s.Files, Canonical: s.Canonical})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(encoded)), nil
+}
+
+func (grapp *FileGrapplicationResource) Snapshot(ctxt context.Context, message string, vw io.Writer) (string, error) {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return "", err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return "", err
+	}
+
+	// ONLY VALID GRAPPS ARE SNAPSHOT
+	if err = validateGrappProjectFiles(ctxt, grappDir, objectsDir, vw); err != nil {
+		return "", err
+	}
+
+	snapshot, err := CreateSnapshot(ctxt, grappDir, objectsDir, message)
+	if err != nil {
+		return "", err
+	}
+
+	loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		return "", err
+	}
+	if err = updateIndex(grappDir, objectsDir, loader, snapshot, vw); err != nil {
+		return "", err
+	}
+
+	verbose(vw, "Created snapshot %s with %d files", snapshot.ID, len(snapshot.Files))
+
+	return snapshot.ID, nil
+}
+
+// CreateSnapshot stores the source files of the grapp in grappDir as a
+// new snapshot on the current branch
+func CreateSnapshot(ctxt context.Context, grappDir string, objectsDir string, message string) (*Snapshot, error) {
+
+	projectFiles, err := listSourceFiles(grappDir)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Created:   time.Now().UTC(),
+		Message:   message,
+		Files:     make(map[string]string),
+		Canonical: make(map[string]string),
+	}
+
+	if parent, err := ResolveSnapshot(grappDir, objectsDir, ""); err == nil {
+		snapshot.Parent = parent.ID
+	} else if errors.GetType(err) != errors.NotFound {
+		return nil, err
+	}
+
+	for _, projectFile := range projectFiles {
+
+		data, err := os.ReadFile(projectFile)
+		if err != nil {
+			return nil, err
+		}
+
+		relPath, err := filepath.Rel(grappDir, projectFile)
+		if err != nil {
+			return nil, err
+		}
+
+		contentHash, err := putContent(objectsDir, data)
+		if err != nil {
+			return nil, err
+		}
+
+		relPath = filepath.ToSlash(relPath)
+		snapshot.Files[relPath] = contentHash
+		snapshot.Canonical[relPath] = canonicalFileHash(objectsDir, relPath, data)
+	}
+
+	encoded, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.ID, err = snapshot.signatureID(); err != nil {
+		return nil, err
+	}
+
+	if _, err = file.WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(encoded), nil },
+		filepath.Join(objectsDir, snapshot.ID+snapshotObjectSuffix)); err != nil {
+		return nil, err
+	}
+
+	// ADVANCE THE CURRENT BRANCH
+	if err = file.WriteCommitHashToCurrentBranchHeadFile(ctxt, grappDir, snapshot.ID); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// canonicalFileHash returns a hash of the grapp relative source file
+// relPath with content data that does not depend on its formatting:
+// the canonical hash of the statements of a processed project file or
+// the SHA-256 hash of the re-encoded JSON of any other file. Files
+// that cannot be parsed are hashed as is
+func canonicalFileHash(objectsDir string, relPath string, data []byte) string {
+
+	contentHash := fmt.Sprintf("%x", sha256.Sum256(data))
+
+	if entry, err := readProcessedEntry(objectsDir, relPath); err == nil && entry.SHA256 == contentHash &&
+		len(entry.Canonical) > 0 {
+		return entry.Canonical
+	}
+
+	// NDJSON-LD DOCUMENTS HOLD MORE THAN ONE JSON VALUE
+	if isNDJSONLDDocument(relPath) {
+		return contentHash
+	}
+
+	doc, err := parseDocument(data, relPath)
+	if err != nil {
+		return contentHash
+	}
+
+	// MAP KEYS ARE ENCODED IN SORTED ORDER
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return contentHash
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(encoded))
+}
+
+// listSourceFiles returns the JSON and JSON-LD files in the grapp
+// directory tree (i.e. project files and the local contexts they use).
+// Hidden directories such as .dgrz are skipped
+func listSourceFiles(grappDir string) ([]string, error) {
+
+	var sourceFiles []string
+
+	err := filepath.WalkDir(grappDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != grappDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := strings.ToLower(filepath.Ext(p)); d.Type().IsRegular() && (ext == ".json" || isProjectFileName(p)) {
+			sourceFiles = append(sourceFiles, p)
+		}
+		return nil
+	})
+
+	return sourceFiles, err
+}
+
+// ProjectFiles returns the grapp relative paths of the project files
+// (i.e. the .jsonld and .yamlld files in the grapp directory) in the snapshot
+func (s *Snapshot) ProjectFiles() []string {
+
+	var projectFiles []string
+	for p := range s.Files {
+		if !strings.Contains(p, "/") && isProjectFileName(p) {
+			projectFiles = append(projectFiles, p)
+		}
+	}
+	sort.Strings(projectFiles)
+
+	return projectFiles
+}
+
+// putContent stores data in objectsDir by its SHA-256 hash
+func putContent(objectsDir string, data []byte) (string, error) {
+
+	contentHash := fmt.Sprintf("%x", sha256.Sum256(data))
+	contentPath := filepath.Join(objectsDir, contentHash+".jsonld")
+
+	if !file.FileExists(contentPath) {
+		if _, err := file.WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(data), nil },
+			contentPath); err != nil {
+			return "", err
+		}
+	}
+
+	return contentHash, nil
+}
+
+// ReadSnapshot reads the snapshot with id from objectsDir
+func ReadSnapshot(objectsDir string, id string) (*Snapshot, error) {
+
+	data, err := os.ReadFile(filepath.Join(objectsDir, id+snapshotObjectSuffix))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NotFound.Newf("%s: snapshot not found", id)
+		}
+		return nil, err
+	}
+
+	snapshot := &Snapshot{}
+	if err = json.Unmarshal(data, snapshot); err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "%s", id)
+	}
+
+	actualID := fmt.Sprintf("%x", sha256.Sum256(data))
+	if snapshot.Canonical != nil {
+		// THE SIGNATURE MUST COVER EXACTLY THE SNAPSHOT'S FILES
+		for p := range snapshot.Files {
+			if _, ok := snapshot.Canonical[p]; !ok || len(snapshot.Canonical) != len(snapshot.Files) {
+				return nil, errors.UnexpectedValue.Newf("%s: snapshot files do not match its signature", id)
+			}
+		}
+		if actualID, err = snapshot.signatureID(); err != nil {
+			return nil, err
+		}
+	}
+	if actualID != id {
+		return nil, errors.UnexpectedValue.Newf("%s: snapshot content does not match its id", id)
+	}
+	snapshot.ID = id
+
+	return snapshot, nil
+}
+
+// ResolveSnapshot returns the snapshot of the grapp in grappDir chosen
+// by selector: an empty selector or HEAD selects the head of the
+// current branch, otherwise a branch name, a tag name, a snapshot id or
+// a unique snapshot id prefix. A selector followed by ~n (~ alone
+// meaning ~1) selects the nth ancestor of the snapshot it selects
+func ResolveSnapshot(grappDir string, objectsDir string, selector string) (*Snapshot, error) {
+
+	if i := strings.LastIndexByte(selector, '~'); i >= 0 {
+		generations := 1
+		if len(selector[i+1:]) > 0 {
+			n, err := strconv.Atoi(selector[i+1:])
+			if err != nil || n < 0 {
+				return nil, errors.InvalidValue.Newf("%s: expected a number of generations after '~'", selector)
+			}
+			generations = n
+		}
+		snapshot, err := ResolveSnapshot(grappDir, objectsDir, selector[:i])
+		if err != nil {
+			return nil, err
+		}
+		for n := 0; n < generations; n++ {
+			if len(snapshot.Parent) < 1 {
+				return nil, errors.NotFound.Newf("%s: snapshot %s has no parent", selector, snapshot.ID)
+			}
+			if snapshot, err = ReadSnapshot(objectsDir, snapshot.Parent); err != nil {
+				return nil, err
+			}
+		}
+		return snapshot, nil
+	}
+
+	dgrzDir := filepath.Join(grappDir, file.DgrzDirName)
+
+	if selector == "" || selector == file.HeadFileName {
+		ref, err := currentBranchRef(dgrzDir)
+		if err != nil {
+			return nil, err
+		}
+		return readRefSnapshot(dgrzDir, objectsDir, ref)
+	}
+
+	// BRANCH AND TAG NAMES AND SNAPSHOT IDS NEVER CONTAIN PATH ELEMENTS
+	if strings.ContainsAny(selector, `/\`) || strings.Contains(selector, "..") {
+		return nil, errors.InvalidValue.Newf("%s: snapshot selector cannot contain path separators or '..'", selector)
+	}
+
+	for _, refsDir := range []string{file.HeadsDirName, file.TagsDirName} {
+		ref := filepath.Join(file.RefsDirName, refsDir, selector)
+		if file.FileExists(filepath.Join(dgrzDir, ref)) {
+			return readRefSnapshot(dgrzDir, objectsDir, ref)
+		}
+	}
+
+	id, err := expandSnapshotID(objectsDir, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadSnapshot(objectsDir, id)
+}
+
+// currentBranchRef returns the ref (relative to dgrzDir) of the
+// current branch named in the HEAD file
+func currentBranchRef(dgrzDir string) (string, error) {
+
+	data, err := os.ReadFile(filepath.Join(dgrzDir, file.HeadFileName))
+	if err != nil {
+		return "", err
+	}
+
+	head := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(head, "ref: ") {
+		return "", errors.UnexpectedValue.Newf("%s: unexpected HEAD content %q", dgrzDir, head)
+	}
+
+	return strings.TrimPrefix(head, "ref: "), nil
+}
+
+// readRefSnapshot returns the snapshot a ref points to
+func readRefSnapshot(dgrzDir string, objectsDir string, ref string) (*Snapshot, error) {
+
+	data, err := os.ReadFile(filepath.Join(dgrzDir, ref))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NotFound.Newf("%s: no snapshots", ref)
+		}
+		return nil, err
+	}
+
+	return ReadSnapshot(objectsDir, strings.TrimSpace(string(data)))
+}
+
+// expandSnapshotID returns the id of the single snapshot in
+// objectsDir whose id starts with the hex digits in prefix
+func expandSnapshotID(objectsDir string, prefix string) (string, error) {
+
+	// SNAPSHOT IDS ARE LOWER CASE HEX ENCODED HASHES. ANYTHING ELSE WOULD BE
+	// TAKEN AS A GLOB PATTERN
+	if strings.IndexFunc(prefix, func(r rune) bool { return !strings.ContainsRune("0123456789abcdef", r) }) >= 0 {
+		return "", errors.NotFound.Newf("%s: no such branch, tag or snapshot", prefix)
+	}
+
+	if len(prefix) < minSnapshotIDPrefix {
+		return "", errors.InvalidValue.Newf("%s: snapshot selector is not a branch or tag and too short for a snapshot id", prefix)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(objectsDir, prefix+"*"+snapshotObjectSuffix))
+	if err != nil {
+		return "", err
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", errors.NotFound.Newf("%s: no such branch, tag or snapshot", prefix)
+	case 1:
+		return strings.TrimSuffix(filepath.Base(matches[0]), snapshotObjectSuffix), nil
+	}
+
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = strings.TrimSuffix(filepath.Base(m), snapshotObjectSuffix)
+	}
+	sort.Strings(ids)
+
+	return "", errors.InvalidValue.Newf("%s: ambiguous snapshot id prefix matches %s", prefix, strings.Join(ids, ", "))
+}
+
+// ReadFile returns the content of the file at grapp relative path
+// filePath as it was when the snapshot was taken
+func (s *Snapshot) ReadFile(objectsDir string, filePath string) ([]byte, error) {
+
+	contentHash, ok := s.Files[filePath]
+	if !ok {
+		return nil, errors.NotFound.Newf("%s: not found in snapshot %s", filePath, s.ID)
+	}
+
+	return readContent(objectsDir, contentHash)
+}
+
+// readContent returns the content with SHA-256 hash contentHash
+// from objectsDir after verifying it
+func readContent(objectsDir string, contentHash string) ([]byte, error) {
+
+	data, err := os.ReadFile(filepath.Join(objectsDir, contentHash+".jsonld"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NotFound.Newf("%s: content missing from object store", contentHash)
+		}
+		return nil, err
+	}
+
+	if fmt.Sprintf("%x", sha256.Sum256(data)) != contentHash {
+		return nil, errors.UnexpectedValue.Newf("%s: stored content does not match its hash", contentHash)
+	}
+
+	return data, nil
+}
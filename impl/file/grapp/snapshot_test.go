@@ -0,0 +1,201 @@
+package grapp
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+func TestSnapshot(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	if _, err := ResolveSnapshot(grappDir, objectsDir, ""); errors.GetType(err) != errors.NotFound {
+		t.Fatalf("expected NotFound error before first snapshot, got %v", err)
+	}
+
+	personFile := filepath.Join(grappDir, "person.jsonld")
+	first := `{"@context": {"name": "http://example.org/name"}, "@id": "http://example.org/jane", "name": "Jane"}`
+	writeTestFile(t, personFile, first)
+
+	snapshot1, err := CreateSnapshot(ctxt, grappDir, objectsDir, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, personFile, `{"@context": {"name": "http://example.org/name"}, "@id": "http://example.org/jane", "name": "Jane Doe"}`)
+
+	snapshot2, err := CreateSnapshot(ctxt, grappDir, objectsDir, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snapshot2.Parent != snapshot1.ID {
+		t.Errorf("expected parent %s, got %s", snapshot1.ID, snapshot2.Parent)
+	}
+
+	for _, selector := range []string{"", file.HeadFileName, file.MasterBranchName, snapshot2.ID, snapshot2.ID[:8]} {
+		resolved, err := ResolveSnapshot(grappDir, objectsDir, selector)
+		if err != nil {
+			t.Fatalf("%q: %s", selector, err)
+		}
+		if resolved.ID != snapshot2.ID {
+			t.Errorf("%q: expected snapshot %s, got %s", selector, snapshot2.ID, resolved.ID)
+		}
+	}
+
+	resolved, err := ResolveSnapshot(grappDir, objectsDir, snapshot1.ID[:6])
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := resolved.ReadFile(objectsDir, "person.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != first {
+		t.Errorf("expected content of first snapshot, got %s", content)
+	}
+
+	// ONLY HEX DIGITS ARE EXPANDED TO A SNAPSHOT ID
+	for _, selector := range []string{"no-such-branch", "****", "[", "????????", strings.ToUpper(snapshot2.ID[:8])} {
+		if _, err := ResolveSnapshot(grappDir, objectsDir, selector); errors.GetType(err) != errors.NotFound {
+			t.Errorf("%q: expected NotFound error for unknown selector, got %v", selector, err)
+		}
+	}
+
+	// ANCESTORS
+	for selector, expected := range map[string]string{"~": snapshot1.ID, "HEAD~1": snapshot1.ID,
+		file.MasterBranchName + "~0": snapshot2.ID, snapshot2.ID[:8] + "~1": snapshot1.ID} {
+		resolved, err := ResolveSnapshot(grappDir, objectsDir, selector)
+		if err != nil {
+			t.Fatalf("%q: %s", selector, err)
+		}
+		if resolved.ID != expected {
+			t.Errorf("%q: expected snapshot %s, got %s", selector, expected, resolved.ID)
+		}
+	}
+	if _, err := ResolveSnapshot(grappDir, objectsDir, "~2"); errors.GetType(err) != errors.NotFound {
+		t.Errorf("expected NotFound error beyond the first snapshot, got %v", err)
+	}
+	if _, err := ResolveSnapshot(grappDir, objectsDir, "~x"); errors.GetType(err) != errors.InvalidValue {
+		t.Errorf("expected InvalidValue error for malformed ancestor, got %v", err)
+	}
+
+	// SELECTORS ARE NOT FILE PATHS
+	for _, selector := range []string{"../heads/" + file.MasterBranchName, "../../" + file.HeadFileName,
+		`..\x`, "x/y~1", ".."} {
+		if _, err := ResolveSnapshot(grappDir, objectsDir, selector); errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("%q: expected InvalidValue error for path selector, got %v", selector, err)
+		}
+	}
+}
+
+func TestSnapshotCanonicalHashes(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	personFile := filepath.Join(grappDir, "person.jsonld")
+	contextFile := filepath.Join(grappDir, "context.json")
+
+	// SAME STATEMENTS AND CONTEXT, DIFFERENT FORMATTING AND BLANK NODE LABELS
+	versions := [][2]string{
+		{`{"@context": {"name": "http://example.org/name", "address": "http://example.org/address"},
+  "@id": "http://example.org/jane", "name": "Jane", "address": {"@id": "_:a1", "name": "home"}}`,
+			`{"x": 1, "y": [1, 2]}`},
+		{`{
+    "@id": "http://example.org/jane",
+    "address": {"name": "home", "@id": "_:other"},
+    "name": "Jane",
+    "@context": {"address": "http://example.org/address", "name": "http://example.org/name"}
+}`, `{"y": [1, 2],
+ "x": 1}`},
+	}
+
+	var snapshots []*Snapshot
+	for _, v := range versions {
+		writeTestFile(t, personFile, v[0])
+		writeTestFile(t, contextFile, v[1])
+
+		if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+			t.Fatal(err)
+		}
+		snapshot, err := CreateSnapshot(ctxt, grappDir, objectsDir, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	for _, p := range []string{"person.jsonld", "context.json"} {
+		if snapshots[0].Files[p] == snapshots[1].Files[p] {
+			t.Errorf("%s: expected different content hashes", p)
+		}
+		if snapshots[0].Canonical[p] != snapshots[1].Canonical[p] {
+			t.Errorf("%s: expected equal canonical hashes, got %s and %s", p, snapshots[0].Canonical[p], snapshots[1].Canonical[p])
+		}
+	}
+
+	// THE SIGNATURE COVERS THE FILES' CONTENT
+	repointed := *snapshots[1]
+	repointed.Files = snapshots[0].Files
+	if id, err := repointed.signatureID(); err != nil || id == snapshots[1].ID {
+		t.Errorf("expected id of snapshot with other file content to differ from %s (%v)", snapshots[1].ID, err)
+	}
+
+	// THE PROJECT FILE'S OBJECT IS NAMED BY ITS CANONICAL HASH
+	entry, err := readProcessedEntry(objectsDir, "person.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Object != snapshots[1].Canonical["person.jsonld"] {
+		t.Errorf("expected object %s, got %s", snapshots[1].Canonical["person.jsonld"], entry.Object)
+	}
+
+	// TAMPERING IS DETECTED
+	snapshotFile := filepath.Join(objectsDir, snapshots[1].ID+snapshotObjectSuffix)
+	changedMessage := *snapshots[1]
+	changedMessage.Message = "changed"
+	for name, tampered := range map[string]*Snapshot{"message": &changedMessage, "files": &repointed} {
+		data, err := json.Marshal(tampered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, snapshotFile, string(data))
+		if _, err := ReadSnapshot(objectsDir, snapshots[1].ID); errors.GetType(err) != errors.UnexpectedValue {
+			t.Errorf("%s: expected UnexpectedValue error for tampered snapshot, got %v", name, err)
+		}
+	}
+}
+
+func TestReadLegacySnapshot(t *testing.T) {
+
+	objectsDir := t.TempDir()
+
+	// SNAPSHOTS WITHOUT CANONICAL HASHES ARE IDENTIFIED BY THEIR ENCODED FORM
+	data := []byte(`{
+  "created": "2022-01-02T03:04:05Z",
+  "files": {
+    "person.jsonld": "0000"
+  }
+}`)
+	id := fmt.Sprintf("%x", sha256.Sum256(data))
+	if err := os.WriteFile(filepath.Join(objectsDir, id+snapshotObjectSuffix), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := ReadSnapshot(objectsDir, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snapshot.Created.Equal(time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)) || snapshot.Files["person.jsonld"] != "0000" {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+}
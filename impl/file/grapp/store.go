@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"io"
+
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+// LoadStore returns a memory store holding the union of the statements
+// of the project files of the grapp in grappDir or, if snapshot is
+// set, of the snapshot it selects. Blank nodes of different project
+// files are kept apart as they are on export
+func LoadStore(ctxt context.Context, grappDir string, objectsDir string, snapshot string,
+	vw io.Writer) (quadstore.Store, error) {
+
+	loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	projectFiles, err := exportProjectFiles(grappDir, objectsDir, loader, resourcegrapp.ExportOptions{Snapshot: snapshot})
+	if err != nil {
+		return nil, err
+	}
+
+	union, err := unionDataset(grappDir, objectsDir, loader, projectFiles, make(map[string]string), "Loading", vw)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = loader.PinResolved(); err != nil {
+		return nil, err
+	}
+
+	store := quadstore.NewMemoryStore()
+	if err = quadstore.AddDataset(store, union); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
@@ -0,0 +1,80 @@
+package grapp
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	"github.com/piprate/json-gold/ld"
+)
+
+func TestLoadStore(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	writeTestFile(t, filepath.Join(grappDir, "people.jsonld"),
+		`{"@context": {"@vocab": "http://schema.org/", "knows": {"@type": "@id"}},
+		  "@graph": [{"@id": "http://example.org/jane", "name": "Jane Doe", "knows": {"name": "John Doe"}}]}`)
+	writeTestFile(t, filepath.Join(grappDir, "reviews.jsonld"),
+		`{"@context": {"@vocab": "http://schema.org/"},
+		  "@id": "http://example.org/reviews", "@graph": [{"@id": "_:b0", "reviewBody": "Great"}]}`)
+
+	snapshot, err := CreateSnapshot(ctxt, grappDir, objectsDir, "people")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(grappDir, "people.jsonld"),
+		`{"@context": {"@vocab": "http://schema.org/"}, "@id": "http://example.org/jane", "name": "Jane"}`)
+
+	jane := ld.NewIRI("http://example.org/jane")
+	name := ld.NewIRI("http://schema.org/name")
+
+	for _, test := range []struct {
+		snapshot string
+		name     string
+		quads    int
+		blank    int
+	}{
+		{"", "Jane", 2, 1},
+		{snapshot.ID[:8], "Jane Doe", 4, 2},
+	} {
+		store, err := LoadStore(ctxt, grappDir, objectsDir, test.snapshot, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n, err := store.Count(quadstore.Pattern{}); err != nil || n != test.quads {
+			t.Errorf("%s: expected %d quads, got %d (%v)", test.snapshot, test.quads, n, err)
+		}
+		if n, err := store.Count(quadstore.Pattern{Subject: jane, Predicate: name,
+			Object: ld.NewLiteral(test.name, ld.XSDString, "")}); err != nil || n != 1 {
+			t.Errorf("%s: expected name %s, got %d matches (%v)", test.snapshot, test.name, n, err)
+		}
+
+		// BLANK NODES OF DIFFERENT PROJECT FILES ARE NOT MERGED
+		dataset, err := quadstore.Dataset(store, quadstore.Pattern{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		blank := make(map[string]struct{})
+		for _, quads := range dataset.Graphs {
+			for _, quad := range quads {
+				if ld.IsBlankNode(quad.Subject) {
+					blank[quad.Subject.GetValue()] = struct{}{}
+				}
+			}
+		}
+		if len(blank) != test.blank {
+			t.Errorf("%s: expected %d blank subjects, got %v", test.snapshot, test.blank, blank)
+		}
+
+		if graphs, err := store.Graphs(); err != nil || len(graphs) != 2 || graphs[1] != "http://example.org/reviews" {
+			t.Errorf("%s: unexpected graphs %v (%v)", test.snapshot, graphs, err)
+		}
+	}
+
+	if _, err := LoadStore(ctxt, grappDir, objectsDir, "unknown", nil); errors.GetType(err) != errors.NotFound {
+		t.Errorf("expected NotFound loading an unknown snapshot, got %v", err)
+	}
+}
@@ -0,0 +1,572 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/piprate/json-gold/ld"
+)
+
+// extension of newline-delimited JSON-LD project files: one JSON-LD
+// document per line. A line holding only a @context sets the context
+// of the lines that follow
+const ndjsonLDExtension = ".ndjsonld"
+
+// number of expanded node objects flattened into each chunk object of
+// a streamed document
+const streamChunkNodes = 1000
+
+// notStreamable is returned by node readers for documents that must
+// be processed in memory
+type notStreamable struct{}
+
+func (notStreamable) Error() string {
+	return "document cannot be processed as a stream"
+}
+
+// isNDJSONLDDocument returns true if the path of iri has the
+// newline-delimited JSON-LD extension
+func isNDJSONLDDocument(iri string) bool {
+
+	if u, err := url.Parse(iri); err == nil {
+		iri = u.Path
+	}
+
+	return strings.EqualFold(filepath.Ext(iri), ndjsonLDExtension)
+}
+
+// nodeReader reads the node objects of a document one at a time
+type nodeReader interface {
+	// next returns the next node object (or array of node objects) or
+	// io.EOF. The context of the node objects that follow is passed to
+	// onContext when the reader encounters it
+	next(onContext func(interface{}) error) (interface{}, error)
+	// activeProperty returns the property the node objects are values of
+	activeProperty() string
+}
+
+// newNodeReader returns a reader of the node objects of the document
+// at localPath
+func (dl *DocumentLoader) newNodeReader(r io.Reader, localPath string) nodeReader {
+
+	if isNDJSONLDDocument(localPath) {
+		return &ndjsonNodeReader{r: bufio.NewReader(r), limit: dl.limits.DocumentSize(), src: localPath}
+	}
+
+	limited := &nodeSizeReader{r: r, limit: dl.limits.DocumentSize(), src: localPath}
+
+	return &graphNodeReader{dec: json.NewDecoder(limited), limited: limited, src: localPath}
+}
+
+// nodeSizeReader fails when more than limit bytes are read between
+// calls to reset so a single node object cannot exhaust memory
+type nodeSizeReader struct {
+	r     io.Reader
+	read  int64
+	limit int64
+	src   string
+}
+
+func (r *nodeSizeReader) Read(p []byte) (int, error) {
+
+	if r.read > r.limit {
+		return 0, errors.OutOfRange.Newf("%s: node object size exceeds maximum document size %d", r.src, r.limit)
+	}
+
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+
+	return n, err
+}
+
+func (r *nodeSizeReader) reset() {
+	r.read = 0
+}
+
+// graphNodeReader reads the elements of the top-level @graph array of
+// a JSON-LD document. The document may have a @context preceding the
+// @graph and no other entries
+type graphNodeReader struct {
+	dec     *json.Decoder
+	limited *nodeSizeReader
+	src     string
+	inGraph bool
+	done    bool
+}
+
+func (r *graphNodeReader) activeProperty() string {
+	return "@graph"
+}
+
+func (r *graphNodeReader) next(onContext func(interface{}) error) (interface{}, error) {
+
+	if r.done {
+		return nil, io.EOF
+	}
+
+	if !r.inGraph {
+		if err := r.start(onContext); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.dec.More() {
+		r.limited.reset()
+		var element interface{}
+		if err := r.dec.Decode(&element); err != nil {
+			return nil, r.syntaxError(err)
+		}
+		return element, nil
+	}
+
+	// END OF @graph. ENTRIES FOLLOWING IT COULD NAME THE GRAPH
+	r.done = true
+	if _, err := r.dec.Token(); err != nil {
+		return nil, r.syntaxError(err)
+	}
+	if r.dec.More() {
+		return nil, notStreamable{}
+	}
+	if _, err := r.dec.Token(); err != nil {
+		return nil, r.syntaxError(err)
+	}
+	if _, err := r.dec.Token(); err != io.EOF {
+		return nil, errors.InvalidValue.Newf("%s: unexpected data after the top-level JSON object", r.src)
+	}
+
+	return nil, io.EOF
+}
+
+// start reads the document up to the first element of its @graph
+func (r *graphNodeReader) start(onContext func(interface{}) error) error {
+
+	if t, err := r.dec.Token(); err != nil {
+		return r.syntaxError(err)
+	} else if t != json.Delim('{') {
+		return notStreamable{}
+	}
+
+	for r.dec.More() {
+		t, err := r.dec.Token()
+		if err != nil {
+			return r.syntaxError(err)
+		}
+
+		switch t {
+		case "@context":
+			r.limited.reset()
+			var c interface{}
+			if err = r.dec.Decode(&c); err != nil {
+				return r.syntaxError(err)
+			}
+			if err = onContext(c); err != nil {
+				return err
+			}
+
+		case "@graph":
+			if t, err = r.dec.Token(); err != nil {
+				return r.syntaxError(err)
+			} else if t != json.Delim('[') {
+				return notStreamable{}
+			}
+			r.inGraph = true
+			return nil
+
+		default:
+			return notStreamable{}
+		}
+	}
+
+	return notStreamable{}
+}
+
+func (r *graphNodeReader) syntaxError(err error) error {
+
+	if errors.GetType(err) != errors.NoType {
+		return err
+	}
+
+	return errors.InvalidValue.Newf("%s: offset %d: %s", r.src, r.dec.InputOffset(), err)
+}
+
+// ndjsonNodeReader reads the lines of a newline-delimited JSON-LD document
+type ndjsonNodeReader struct {
+	r     *bufio.Reader
+	limit int64
+	src   string
+	line  int
+}
+
+func (r *ndjsonNodeReader) activeProperty() string {
+	return ""
+}
+
+func (r *ndjsonNodeReader) next(onContext func(interface{}) error) (interface{}, error) {
+
+	for {
+		data, err := r.readLine()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(data) < 1 && err == io.EOF {
+			return nil, io.EOF
+		}
+		r.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) < 1 {
+			continue
+		}
+
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, errors.InvalidValue.Newf("%s:%d: expected a JSON object: %s", r.src, r.line, err)
+		}
+
+		if c, ok := doc["@context"]; ok && len(doc) == 1 {
+			if err := onContext(c); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		return doc, nil
+	}
+}
+
+// readLine reads the next line failing when it is longer than the
+// maximum document size
+func (r *ndjsonNodeReader) readLine() ([]byte, error) {
+
+	var line []byte
+	for {
+		data, err := r.r.ReadSlice('\n')
+		if int64(len(line)+len(data)) > r.limit {
+			return nil, errors.OutOfRange.Newf("%s:%d: node object size exceeds maximum document size %d", r.src, r.line+1, r.limit)
+		}
+		line = append(line, data...)
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+// loadStreamedDocument processes the local document at localPath
+// (loaded as u and identified by iri) as a stream if it is a
+// newline-delimited JSON-LD document or a JSON-LD document with a
+// top-level @graph larger than the stream threshold. Its node objects
+// are expanded one at a time with the shared context and written to
+// the object store in chunks. The returned document only holds the
+// context. False is returned if the document must be processed in memory
+func (dl *DocumentLoader) loadStreamedDocument(u string, iri string, localPath string,
+	baseURL *url.URL) (*ld.RemoteDocument, bool, error) {
+
+	doc, err := dl.streamDocument(u, iri, localPath, baseURL)
+	if _, ok := err.(notStreamable); ok {
+		return nil, false, nil
+	}
+
+	return doc, true, err
+}
+
+func (dl *DocumentLoader) streamDocument(u string, iri string, localPath string,
+	baseURL *url.URL) (*ld.RemoteDocument, error) {
+
+	if !isNDJSONLDDocument(localPath) && !strings.EqualFold(filepath.Ext(localPath), ".jsonld") {
+		return nil, notStreamable{}
+	}
+
+	contentPath, contentHash := localPath, ""
+	if rel, ok := dl.snapshotPath(localPath); ok {
+		if contentHash, ok = dl.snapshot.Files[rel]; !ok {
+			return nil, errors.NotFound.Newf("%s: not found in snapshot %s", rel, dl.snapshot.ID)
+		}
+		contentPath = filepath.Join(dl.objectsDir, contentHash+".jsonld")
+	}
+
+	info, err := os.Stat(contentPath)
+	if err != nil {
+		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+	}
+	if !isNDJSONLDDocument(localPath) && info.Size() <= dl.limits.StreamSize() {
+		return nil, notStreamable{}
+	}
+
+	docSHA256, err := fileSHA256(contentPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(contentHash) > 0 && docSHA256 != contentHash {
+		return nil, errors.UnexpectedValue.Newf("%s: stored content does not match its hash", contentHash)
+	}
+
+	if dl.onLoad != nil {
+		dl.onLoad(processedDependency{IRI: u, Path: localPath, SHA256: docSHA256})
+	}
+
+	if iri != u {
+		if err = dl.checkRecursion(iri); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(contentPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	nodes := dl.newNodeReader(f, localPath)
+
+	doc := make(map[string]interface{})
+
+	if entry := dl.processedEntry(iri, docSHA256); entry != nil {
+		// ONLY THE CONTEXT IS NEEDED FROM UNCHANGED DOCUMENTS
+		_, err = nodes.next(func(c interface{}) error {
+			if _, ok := doc["@context"]; !ok {
+				doc["@context"] = c
+			}
+			return nil
+		})
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		entry.replay(dl)
+	} else if err = dl.streamObjects(iri, docSHA256, nodes, doc); err != nil {
+		return nil, err
+	}
+
+	resolveContextReferences(doc, baseURL)
+
+	return &ld.RemoteDocument{DocumentURL: iri, Document: doc}, nil
+}
+
+// streamObjects expands the node objects read from nodes one at a time
+// with the shared, pre-processed context and writes them flattened to
+// the object store in chunks of streamChunkNodes. Blank node labels are
+// issued across chunks so chunks can be concatenated. The first context
+// of the document is set in doc
+func (dl *DocumentLoader) streamObjects(iri string, docSHA256 string, nodes nodeReader,
+	doc map[string]interface{}) error {
+
+	nestedLoader := dl.descend(iri)
+	options := ld.NewJsonLdOptions("")
+	options.DocumentLoader = nestedLoader
+
+	// RECORD THE DOCUMENTS THE RESULT DEPENDS ON
+	entry := dl.newProcessedEntry(iri, docSHA256)
+	nestedLoader.onLoad = entry.recorder(dl.onLoad)
+
+	api := ld.NewJsonLdApi()
+	initialContext := ld.NewContext(nil, options)
+	activeContext := initialContext
+	var contextRef interface{}
+	var contextErr error
+
+	// onContext processes the context of the node objects that follow
+	onContext := func(c interface{}) error {
+		if _, ok := doc["@context"]; !ok {
+			doc["@context"] = c
+		}
+		var err error
+		if activeContext, err = initialContext.Parse(c); err != nil {
+			if nestedLoader.loadErr != nil {
+				return errors.Wrapf(nestedLoader.loadErr, "%s", iri)
+			}
+			return errors.InvalidValue.Wrapf(err, "%s", iri)
+		}
+		contextRef, contextErr = dl.storeContext(iri, c)
+		return nil
+	}
+
+	issuer := ld.NewIdentifierIssuer("_:b")
+	var chunk []interface{}
+
+	flush := func() error {
+		if len(chunk) < 1 {
+			return nil
+		}
+		nodeMap := map[string]interface{}{"@default": make(map[string]interface{})}
+		if _, err := api.GenerateNodeMap(chunk, nodeMap, "@default", issuer, nil, "", nil); err != nil {
+			return errors.InvalidValue.Wrapf(err, "%s", iri)
+		}
+		chunk = chunk[:0]
+
+		flattened := flattenNodeMap(nodeMap)
+		if len(flattened) < 1 {
+			return nil
+		}
+
+		// CHUNKS ARE NAMED BY CONTENT. THEIR CANONICAL HASH ONLY VERIFIES
+		// THE ENCODING AS BLANK NODES MAY BE SHARED WITH OTHER CHUNKS
+		var chunkCanonical string
+		if dataset, err := api.ToRDF(flattened, options); err == nil {
+			chunkCanonical, _ = rdf.CanonicalHash(dataset)
+		}
+		var data []byte
+		var err error
+		if contextErr != nil {
+			data, err = cbor.Marshal(flattened)
+		} else {
+			data, err = encodeFlattened(dl.objectsDir, contextRef, flattened, chunkCanonical)
+		}
+		if err != nil {
+			return err
+		}
+		name, err := putObject(dl.objectsDir, data)
+		if err != nil {
+			return err
+		}
+		entry.Chunks = append(entry.Chunks, name)
+		return nil
+	}
+
+	for {
+		element, err := nodes.next(onContext)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		expanded, err := api.Expand(activeContext, nodes.activeProperty(), element, options, false, nil)
+		if err != nil {
+			if nestedLoader.loadErr != nil {
+				// REPORT WHY THE REFERENCED DOCUMENT FAILED TO LOAD
+				return errors.Wrapf(nestedLoader.loadErr, "%s", iri)
+			}
+			return errors.InvalidValue.Wrapf(err, "%s", iri)
+		}
+
+		switch value := expanded.(type) {
+		case []interface{}:
+			chunk = append(chunk, value...)
+		case map[string]interface{}:
+			if graph, ok := value["@graph"]; ok && len(value) == 1 {
+				// A DOCUMENT THAT ONLY HOLDS A DEFAULT GRAPH
+				if items, ok := graph.([]interface{}); ok {
+					chunk = append(chunk, items...)
+				}
+			} else if len(value) > 0 {
+				chunk = append(chunk, value)
+			}
+		}
+
+		if len(chunk) >= streamChunkNodes {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if len(entry.Chunks) < 1 {
+		return errors.NotFound.Newf("%s: No RDF statements found after JSON-LD doc expansion", iri)
+	}
+
+	// CANONICALIZING THE WHOLE DATASET WOULD DEFEAT STREAMING AND
+	// CHUNKS CANONICALIZED ON THEIR OWN LOSE THE LINKS BETWEEN BLANK
+	// NODES SHARED ACROSS THEM. THE CONTENT HASH STANDS IN
+	entry.Canonical = docSHA256
+
+	return entry.write(dl.objectsDir)
+}
+
+// flattenNodeMap returns the flattened node objects of nodeMap as the
+// JSON-LD flattening algorithm does: named graphs become the @graph of
+// their node in the default graph
+func flattenNodeMap(nodeMap map[string]interface{}) []interface{} {
+
+	defaultGraph := nodeMap["@default"].(map[string]interface{})
+	delete(nodeMap, "@default")
+
+	for _, graphName := range ld.GetKeys(nodeMap) {
+		graph := nodeMap[graphName].(map[string]interface{})
+		entry, ok := defaultGraph[graphName].(map[string]interface{})
+		if !ok {
+			entry = map[string]interface{}{"@id": graphName}
+			defaultGraph[graphName] = entry
+		}
+		if _, ok := entry["@graph"]; !ok {
+			entry["@graph"] = make([]interface{}, 0)
+		}
+		for _, id := range ld.GetOrderedKeys(graph) {
+			node := graph[id].(map[string]interface{})
+			if _, ok := node["@id"]; !(ok && len(node) == 1) {
+				entry["@graph"] = append(entry["@graph"].([]interface{}), node)
+			}
+		}
+	}
+
+	flattened := make([]interface{}, 0)
+	for _, id := range ld.GetOrderedKeys(defaultGraph) {
+		node := defaultGraph[id].(map[string]interface{})
+		if _, ok := node["@id"]; !(ok && len(node) == 1) {
+			flattened = append(flattened, node)
+		}
+	}
+
+	return flattened
+}
+
+// putObject stores the object file content data in objectsDir by its
+// SHA-256 hash and returns its name
+func putObject(objectsDir string, data []byte) (string, error) {
+
+	name := fmt.Sprintf("%x", sha256.Sum256(data))
+	objectPath := filepath.Join(objectsDir, name)
+
+	if !file.FileExists(objectPath) {
+		if _, err := file.WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(data), nil },
+			objectPath); err != nil {
+			return "", err
+		}
+	}
+
+	return name, nil
+}
+
+// fileSHA256 returns the SHA-256 hash of the content of the file at path
+func fileSHA256(path string) (string, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
@@ -0,0 +1,159 @@
+package grapp
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/piprate/json-gold/ld"
+)
+
+const streamTestContext = `{"@vocab": "http://example.org/", "knows": {"@type": "@id"}}`
+
+// streamTestNodes returns n node objects that all know the same blank node
+func streamTestNodes(n int) []string {
+
+	nodes := make([]string, 0, n+1)
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, fmt.Sprintf(`{"@id": "http://example.org/p%d", "name": "P%d", "knows": "_:shared"}`, i, i))
+	}
+
+	return append(nodes, `{"@id": "_:shared", "name": "Shared"}`)
+}
+
+// readDataset returns the statements of the processed document iri
+func readDataset(t *testing.T, objectsDir string, iri string) *ld.RDFDataset {
+
+	t.Helper()
+
+	flattened, err := ReadFlattened(objectsDir, iri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataset, err := ld.NewJsonLdApi().ToRDF(flattened, ld.NewJsonLdOptions(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dataset
+}
+
+func TestStreamedDocuments(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"),
+		`{"documentLoader": {"limits": {"streamThreshold": 1024}}}`)
+
+	nodes := streamTestNodes(2500)
+	graphDoc := `{"@context": ` + streamTestContext + `, "@graph": [` + strings.Join(nodes, ",\n") + `]}`
+	writeTestFile(t, filepath.Join(grappDir, "graph.jsonld"), graphDoc)
+	writeTestFile(t, filepath.Join(grappDir, "lines.ndjsonld"),
+		`{"@context": `+streamTestContext+"}\n\n"+strings.Join(nodes, "\n")+"\n")
+	// ENTRIES AFTER @graph NAME THE GRAPH. PROCESSED IN MEMORY
+	writeTestFile(t, filepath.Join(grappDir, "named.jsonld"),
+		`{"@context": `+streamTestContext+`, "@graph": [`+strings.Join(nodes[:50], ",")+`], "@id": "http://example.org/g"}`)
+
+	if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// STATEMENTS OF THE DOCUMENT PROCESSED IN MEMORY
+	doc, err := parseDocument([]byte(graphDoc), "graph.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ld.NewJsonLdProcessor().ToRDF(doc, ld.NewJsonLdOptions(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedHash, err := rdf.CanonicalHash(expected.(*ld.RDFDataset))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, iri := range []string{"graph.jsonld", "lines.ndjsonld"} {
+		entry, err := readProcessedEntry(objectsDir, iri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entry.Object) > 0 || len(entry.Chunks) != 3 {
+			t.Errorf("%s: expected 3 chunk objects, got object '%s' and %d chunks", iri, entry.Object, len(entry.Chunks))
+		}
+		// CHUNKS CAN'T BE CANONICALIZED ON THEIR OWN. THE CONTENT HASH STANDS IN
+		if contentHash, err := fileSHA256(filepath.Join(grappDir, iri)); err != nil || entry.Canonical != contentHash {
+			t.Errorf("%s: expected content hash %s as canonical hash, got %s (%v)", iri, contentHash, entry.Canonical, err)
+		}
+
+		dataset := readDataset(t, objectsDir, iri)
+		if n := len(dataset.Graphs["@default"]); n != 5001 {
+			t.Errorf("%s: expected 5001 statements, got %d", iri, n)
+		}
+		// THE BLANK NODE IS SHARED ACROSS CHUNKS
+		if hash, err := rdf.CanonicalHash(dataset); err != nil {
+			t.Fatal(err)
+		} else if hash != expectedHash {
+			t.Errorf("%s: expected streamed statements to match those processed in memory", iri)
+		}
+	}
+
+	if entry, err := readProcessedEntry(objectsDir, "named.jsonld"); err != nil {
+		t.Fatal(err)
+	} else if len(entry.Object) < 1 {
+		t.Errorf("expected named graph document to be processed in memory")
+	}
+	if n := len(readDataset(t, objectsDir, "named.jsonld").Graphs["http://example.org/g"]); n != 100 {
+		t.Errorf("expected 100 statements in named graph, got %d", n)
+	}
+
+	// UNCHANGED STREAMED DOCUMENTS ARE SERVED FROM THE PROCESSING CACHE
+	loader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded []string
+	loader.onLoad = func(dep processedDependency) { loaded = append(loaded, dep.IRI) }
+	remote, err := loader.LoadDocument(filepath.Join(grappDir, "lines.ndjsonld"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := remote.Document.(map[string]interface{})["@context"]; !ok {
+		t.Errorf("expected streamed document to hold its context, got %v", remote.Document)
+	}
+	if len(loaded) != 1 {
+		t.Errorf("expected only the streamed document to be loaded, got %v", loaded)
+	}
+}
+
+func TestStreamedDocumentErrors(t *testing.T) {
+
+	for _, test := range []struct {
+		name     string
+		file     string
+		content  string
+		expected errors.ErrorType
+	}{
+		{"node size", "lines.ndjsonld", `{"@context": {"@vocab": "http://example.org/"}}` + "\n" +
+			`{"@id": "http://example.org/a", "name": "` + strings.Repeat("x", 2048) + `"}`, errors.OutOfRange},
+		{"not an object", "lines.ndjsonld", `["http://example.org/a"]`, errors.InvalidValue},
+		{"syntax", "graph.jsonld", `{"@graph": [{"@id": "http://example.org/a",` + strings.Repeat(" ", 1024), errors.InvalidValue},
+		{"no statements", "lines.ndjsonld", `{"@context": {"@vocab": "http://example.org/"}}`, errors.NotFound},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+			writeTestFile(t, filepath.Join(grappDir, file.DgrzDirName, "config"),
+				`{"documentLoader": {"limits": {"streamThreshold": 512, "maxDocumentSize": 1024}}}`)
+			writeTestFile(t, filepath.Join(grappDir, test.file), test.content)
+
+			if err := validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); errors.GetType(err) != test.expected {
+				t.Errorf("expected error type %d, got %v", test.expected, err)
+			}
+		})
+	}
+}
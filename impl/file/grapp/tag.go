@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+func (grapp *FileGrapplicationResource) Tag(ctxt context.Context, name string, selector string) (string, error) {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return "", err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return "", err
+	}
+
+	snapshot, err := CreateTag(grappDir, objectsDir, name, selector)
+	if err != nil {
+		return "", err
+	}
+
+	return snapshot.ID, nil
+}
+
+// CreateTag names the snapshot of the grapp in grappDir chosen by
+// selector (see ResolveSnapshot). Tags cannot be moved
+func CreateTag(grappDir string, objectsDir string, name string, selector string) (*Snapshot, error) {
+
+	if err := checkTagName(name); err != nil {
+		return nil, err
+	}
+
+	tagsDir := filepath.Join(grappDir, file.DgrzDirName, file.RefsDirName, file.TagsDirName)
+	tagFile := filepath.Join(tagsDir, name)
+	if file.FileExists(tagFile) {
+		return nil, errors.InvalidValue.Newf("%s: tag already exists", name)
+	}
+	if file.FileExists(filepath.Join(grappDir, file.DgrzDirName, file.RefsDirName, file.HeadsDirName, name)) {
+		return nil, errors.InvalidValue.Newf("%s: a branch has the same name", name)
+	}
+
+	snapshot, err := ResolveSnapshot(grappDir, objectsDir, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(tagsDir, 0700); err != nil {
+		return nil, err
+	}
+	if _, err = file.WriteToFileAtomic(func() (io.Reader, error) { return strings.NewReader(snapshot.ID + "\n"), nil },
+		tagFile); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// checkTagName returns an error if name cannot be used as a tag
+func checkTagName(name string) error {
+
+	switch {
+	case len(name) < 1:
+		return errors.InvalidValue.New("no tag name given")
+	case name == file.HeadFileName || strings.HasPrefix(name, "."):
+		return errors.InvalidValue.Newf("%s: reserved tag name", name)
+	case strings.Contains(name, ".."):
+		return errors.InvalidValue.Newf("%s: tag names cannot contain '..'", name)
+	case strings.IndexFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r) || strings.ContainsRune(`/\~:*?[`, r)
+	}) >= 0:
+		return errors.InvalidValue.Newf("%s: tag names cannot contain white space or any of / \\ ~ : * ? [", name)
+	}
+
+	return nil
+}
+
+// refsOf returns the branches and tags (as "tag: <name>") of the grapp
+// in grappDir pointing at the snapshot with id
+func refsOf(grappDir string, id string) ([]string, error) {
+
+	var refs []string
+
+	for _, refsDir := range []string{file.HeadsDirName, file.TagsDirName} {
+		dir := filepath.Join(grappDir, file.DgrzDirName, file.RefsDirName, refsDir)
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(string(data)) == id {
+				names = append(names, entry.Name())
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if refsDir == file.TagsDirName {
+				name = "tag: " + name
+			}
+			refs = append(refs, name)
+		}
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,86 @@
+package grapp
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+func TestTagAndShow(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	writeTestFile(t, filepath.Join(grappDir, "a.jsonld"), `{"@id": "http://example.org/a", "http://example.org/p": "a"}`)
+	writeTestFile(t, filepath.Join(grappDir, "b.jsonld"), `{"@id": "http://example.org/b", "http://example.org/p": "b"}`)
+	snapshot1, err := CreateSnapshot(ctxt, grappDir, objectsDir, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = CreateTag(grappDir, objectsDir, "v1", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// REFORMATTING DOES NOT MODIFY A FILE
+	writeTestFile(t, filepath.Join(grappDir, "a.jsonld"), `{"http://example.org/p": "a",  "@id": "http://example.org/a"}`)
+	writeTestFile(t, filepath.Join(grappDir, "b.jsonld"), `{"@id": "http://example.org/b", "http://example.org/p": "B"}`)
+	writeTestFile(t, filepath.Join(grappDir, "c.jsonld"), `{"@id": "http://example.org/c", "http://example.org/p": "c"}`)
+	snapshot2, err := CreateSnapshot(ctxt, grappDir, objectsDir, "second\n\nwith details")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := ResolveSnapshot(grappDir, objectsDir, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.ID != snapshot1.ID {
+		t.Errorf("expected tag v1 to select %s, got %s", snapshot1.ID, resolved.ID)
+	}
+
+	for _, test := range []struct {
+		name     string
+		selector string
+		errType  errors.ErrorType
+	}{
+		{"v1", "", errors.InvalidValue},
+		{file.MasterBranchName, "", errors.InvalidValue},
+		{"a/b", "", errors.InvalidValue},
+		{"a~1", "", errors.InvalidValue},
+		{"a..b", "", errors.InvalidValue},
+		{"", "", errors.InvalidValue},
+		{"v0", "no-such-snapshot", errors.NotFound},
+	} {
+		if _, err := CreateTag(grappDir, objectsDir, test.name, test.selector); errors.GetType(err) != test.errType {
+			t.Errorf("%q: expected %v error, got %v", test.name, test.errType, err)
+		}
+	}
+
+	show := func(selector string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := showSnapshot(grappDir, objectsDir, selector, &buf, nil); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	out := show("")
+	for _, expected := range []string{"snapshot " + snapshot2.ID + "\n", "parent   " + snapshot1.ID + "\n",
+		"refs     " + file.MasterBranchName + "\n", "\n    second\n    \n    with details\n",
+		"\n   a.jsonld\nM  b.jsonld\nA  c.jsonld\n"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in\n%s", expected, out)
+		}
+	}
+
+	out = show("v1")
+	if !strings.Contains(out, "refs     tag: v1\n") || !strings.Contains(out, "\nA  a.jsonld\nA  b.jsonld\n") ||
+		strings.Contains(out, "parent") {
+		t.Errorf("unexpected first snapshot\n%s", out)
+	}
+}
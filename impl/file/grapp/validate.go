@@ -23,6 +23,7 @@ import (
 
 	"github.com/datacequia/go-dogg3rz/errors"
 	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/datacequia/go-dogg3rz/yamlld"
 )
 
 type jsonParseStats struct {
@@ -66,20 +67,29 @@ func (grapp *FileGrapplicationResource) Validate(ctxt context.Context, vw io.Wri
 
 func validateGrappProjectFiles(ctxt context.Context, grappDir string, objectsDir string, vw io.Writer) error {
 
-	verbose(vw, "Listing .jsonld files in project directory at %s...", grappDir)
-	projectFiles, err := listJsonLdFiles(grappDir, vw)
+	verbose(vw, "Listing JSON-LD and YAML-LD files in project directory at %s...", grappDir)
+	projectFiles, err := listProjectFiles(grappDir, vw)
 	if err != nil {
 		return err
 	}
 
 	if len(projectFiles) < 1 {
-		return errors.NotFound.Newf("%s: no JSON-LD files found.", grappDir)
+		return errors.NotFound.Newf("%s: no JSON-LD or YAML-LD files found.", grappDir)
+	}
+
+	grappLoader, err := NewGrappDocumentLoader(ctxt, grappDir, objectsDir)
+	if err != nil {
+		return err
+	}
+
+	if grappLoader.Offline() {
+		verbose(vw, "Offline: loading remote documents pinned in %s from object cache", grappLoader.ContextLock().Path())
 	}
 
 	// process JSON-LD files against JSON-LD processor for well-formedness
 	for _, jsonLdFile := range projectFiles {
 
-		loader := NewDocumentLoader(nil, grappDir, objectsDir)
+		loader := grappLoader.nested()
 
 		if _, err := loader.LoadDocument(jsonLdFile); err != nil {
 			return err
@@ -87,7 +97,11 @@ func validateGrappProjectFiles(ctxt context.Context, grappDir string, objectsDir
 
 	}
 
-	return nil
+	if err = grappLoader.PinResolved(); err != nil {
+		return err
+	}
+
+	return updateIndex(grappDir, objectsDir, grappLoader, nil, vw)
 
 }
 
@@ -136,9 +150,11 @@ func process(doc interface{}) ([]interface{}, error) {
 }
 */
 
-func listJsonLdFiles(grappDir string, vw io.Writer) ([]string, error) {
+// listProjectFiles returns the JSON-LD and YAML-LD project files in
+// grappDir
+func listProjectFiles(grappDir string, vw io.Writer) ([]string, error) {
 
-	// LIST ALL JSONLD FILES
+	// LIST ALL JSONLD AND YAMLLD FILES
 	files, err := os.ReadDir(grappDir)
 
 	if err != nil {
@@ -148,7 +164,7 @@ func listJsonLdFiles(grappDir string, vw io.Writer) ([]string, error) {
 	var jsonLDFiles []string
 
 	for _, file := range files {
-		if file.Type().IsRegular() && strings.HasSuffix(strings.ToLower(file.Name()), ".jsonld") {
+		if file.Type().IsRegular() && isProjectFileName(file.Name()) {
 			newFile := filepath.Join(grappDir, file.Name())
 			jsonLDFiles = append(jsonLDFiles, newFile)
 
@@ -160,6 +176,12 @@ func listJsonLdFiles(grappDir string, vw io.Writer) ([]string, error) {
 
 }
 
+// isProjectFileName returns true if name is the name of a JSON-LD,
+// NDJSON-LD or YAML-LD project file
+func isProjectFileName(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".jsonld") || isNDJSONLDDocument(name) || yamlld.IsYAMLLD(name)
+}
+
 func (s *jsonParseStats) Read(p []byte) (int, error) {
 
 	bytesRead, err := s.realReader.Read(p)
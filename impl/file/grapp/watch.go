@@ -0,0 +1,338 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package grapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+	"github.com/fsnotify/fsnotify"
+)
+
+// how long the grapp tree must be quiet before changes are validated
+const watchDebounce = 300 * time.Millisecond
+
+// name of the grapp config file in the .dgrz dir
+const grappConfigFileName = "config"
+
+// grappWatch re-validates the project files of a grapp affected by
+// changes in the grapp directory tree
+type grappWatch struct {
+	grappDir   string
+	objectsDir string
+	out        io.Writer // refreshed diagnostics
+	vw         io.Writer // optional: verbose output
+
+	diagnostics map[string]error               // project file -> validation result
+	dependents  map[string]map[string]struct{} // local document -> project files that load it
+}
+
+func (grapp *FileGrapplicationResource) Watch(ctxt context.Context, out io.Writer, vw io.Writer) error {
+
+	grappDir, err := file.GrapplicationDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	objectsDir, err := file.GrapplicationObjectsDirPath(ctxt)
+	if err != nil {
+		return err
+	}
+
+	return watchGrapp(ctxt, grappDir, objectsDir, watchDebounce, out, vw)
+}
+
+// watchGrapp validates the grapp in grappDir and then re-validates the
+// project files affected by each batch of changes until ctxt is done.
+// A batch ends once the tree has been quiet for debounce
+func watchGrapp(ctxt context.Context, grappDir string, objectsDir string, debounce time.Duration,
+	out io.Writer, vw io.Writer) error {
+
+	grappDir, err := filepath.Abs(grappDir)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.ExternalError.Wrapf(err, "%s: failed to watch grapp directory", grappDir)
+	}
+	defer watcher.Close()
+
+	if err = watchTree(watcher, grappDir); err != nil {
+		return err
+	}
+	// CONFIG CHANGES AFFECT EVERY PROJECT FILE
+	if err = watcher.Add(filepath.Join(grappDir, file.DgrzDirName)); err != nil {
+		return errors.ExternalError.Wrapf(err, "%s: failed to watch grapp directory", grappDir)
+	}
+
+	w := &grappWatch{
+		grappDir:    grappDir,
+		objectsDir:  objectsDir,
+		out:         out,
+		vw:          vw,
+		diagnostics: make(map[string]error),
+		dependents:  make(map[string]map[string]struct{}),
+	}
+
+	if err = w.validate(ctxt, nil); err != nil {
+		return err
+	}
+
+	debounceTimer := time.NewTimer(debounce)
+	debounceTimer.Stop()
+	defer debounceTimer.Stop()
+
+	changed := make(map[string]struct{})
+
+	for {
+		select {
+		case <-ctxt.Done():
+			// CLEAN SHUTDOWN (I.E. SIGINT)
+			verbose(vw, "Stopped watching %s", grappDir)
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				// WATCH NEW SUBDIRECTORIES
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !skipWatchDir(event.Name) {
+					if err = watchTree(watcher, event.Name); err != nil {
+						return err
+					}
+				}
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			changed[event.Name] = struct{}{}
+			debounceTimer.Reset(debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return errors.ExternalError.Wrapf(err, "%s: watching grapp directory failed", grappDir)
+
+		case <-debounceTimer.C:
+			affected, all := w.affected(changed)
+			changed = make(map[string]struct{})
+			if !all && len(affected) < 1 {
+				continue
+			}
+			if all {
+				affected = nil
+			}
+			if err := w.validate(ctxt, affected); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// affected returns the project files that must be re-validated after
+// changes to paths. all is true if every project file is affected
+func (w *grappWatch) affected(paths map[string]struct{}) (files []string, all bool) {
+
+	configPath := filepath.Join(w.grappDir, file.DgrzDirName, grappConfigFileName)
+	set := make(map[string]struct{})
+
+	for p := range paths {
+
+		if p == configPath {
+			return nil, true
+		}
+		if isProjectFile(w.grappDir, p) {
+			set[p] = struct{}{}
+		}
+		for projectFile := range w.dependents[p] {
+			set[projectFile] = struct{}{}
+		}
+	}
+
+	for p := range set {
+		files = append(files, p)
+	}
+	sort.Strings(files)
+
+	return files, false
+}
+
+// validate validates the project files in files (all project files
+// if nil) and prints the refreshed diagnostics
+func (w *grappWatch) validate(ctxt context.Context, files []string) error {
+
+	projectFiles, err := listProjectFiles(w.grappDir, nil)
+	if err != nil {
+		return err
+	}
+
+	if files == nil {
+		files = projectFiles
+		w.diagnostics = make(map[string]error)
+		w.dependents = make(map[string]map[string]struct{})
+	}
+
+	grappLoader, err := NewGrappDocumentLoader(ctxt, w.grappDir, w.objectsDir)
+	if err != nil {
+		// I.E. INVALID CONFIG. KEEP WATCHING UNTIL IT IS FIXED
+		fmt.Fprintf(w.out, "[%s] %s\n", time.Now().Format("15:04:05"), err)
+		return nil
+	}
+
+	exists := make(map[string]bool)
+	for _, p := range projectFiles {
+		exists[p] = true
+	}
+
+	for _, projectFile := range files {
+
+		w.forget(projectFile)
+
+		if !exists[projectFile] {
+			// REMOVED
+			delete(w.diagnostics, projectFile)
+			continue
+		}
+
+		loader := grappLoader.nested()
+		loader.onLoad = func(dep processedDependency) {
+			if len(dep.Path) > 0 {
+				w.depends(projectFile, dep.Path)
+			}
+		}
+
+		_, err := loader.LoadDocument(projectFile)
+		w.diagnostics[projectFile] = err
+
+		// A FILE THAT FAILED TO PARSE IS STILL A DEPENDENCY OF ITSELF
+		w.depends(projectFile, projectFile)
+	}
+
+	if err = grappLoader.PinResolved(); err != nil {
+		return err
+	}
+
+	w.report(files)
+
+	// ONLY A VALID WORKING TREE IS INDEXED
+	for _, err := range w.diagnostics {
+		if err != nil {
+			return nil
+		}
+	}
+
+	if err = updateIndex(w.grappDir, w.objectsDir, grappLoader, nil, w.vw); err != nil {
+		// I.E. A FILE CHANGED WHILE INDEXING. THE VALIDATION OF THE CHANGE UPDATES THE INDEX
+		verbose(w.vw, "Index update failed: %s", err)
+	}
+
+	return nil
+}
+
+// depends records that projectFile loads the local document p
+func (w *grappWatch) depends(projectFile string, p string) {
+
+	if _, ok := w.dependents[p]; !ok {
+		w.dependents[p] = make(map[string]struct{})
+	}
+	w.dependents[p][projectFile] = struct{}{}
+}
+
+// forget drops the recorded dependencies of projectFile
+func (w *grappWatch) forget(projectFile string) {
+
+	for p, projectFiles := range w.dependents {
+		delete(projectFiles, projectFile)
+		if len(projectFiles) < 1 {
+			delete(w.dependents, p)
+		}
+	}
+}
+
+// report prints the diagnostics of the validated files followed by a
+// summary of the whole grapp
+func (w *grappWatch) report(validated []string) {
+
+	fmt.Fprintf(w.out, "[%s] validated %d file(s)\n", time.Now().Format("15:04:05"), len(validated))
+
+	for _, projectFile := range validated {
+		err, ok := w.diagnostics[projectFile]
+		if !ok {
+			continue
+		}
+		name, _ := filepath.Rel(w.grappDir, projectFile)
+		if err != nil {
+			fmt.Fprintf(w.out, "  ERROR %s: %s\n", filepath.ToSlash(name), err)
+		} else {
+			verbose(w.vw, "  OK    %s", filepath.ToSlash(name))
+		}
+	}
+
+	var failed int
+	for _, err := range w.diagnostics {
+		if err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(w.out, "%d of %d file(s) have errors\n", failed, len(w.diagnostics))
+	} else {
+		fmt.Fprintf(w.out, "all %d file(s) are valid\n", len(w.diagnostics))
+	}
+}
+
+// watchTree adds dir and its subdirectories to watcher
+func watchTree(watcher *fsnotify.Watcher, dir string) error {
+
+	return filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != dir && skipWatchDir(p) {
+			return filepath.SkipDir
+		}
+		if err = watcher.Add(p); err != nil {
+			return errors.ExternalError.Wrapf(err, "%s: failed to watch directory", p)
+		}
+		return nil
+	})
+}
+
+// skipWatchDir returns true for hidden directories (i.e. .dgrz) whose
+// changes are not grapp source changes
+func skipWatchDir(dir string) bool {
+	return strings.HasPrefix(filepath.Base(dir), ".") || filepath.Base(filepath.Dir(dir)) == file.DgrzDirName
+}
+
+// isProjectFile returns true if p is a JSON-LD or YAML-LD project file
+// of the grapp in grappDir
+func isProjectFile(grappDir string, p string) bool {
+	return filepath.Dir(p) == grappDir && isProjectFileName(p)
+}
@@ -0,0 +1,101 @@
+package grapp
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+// waitForOutput waits until s occurs count times in out
+func waitForOutput(t *testing.T, out *syncBuffer, s string, count int) {
+
+	deadline := time.Now().Add(5 * time.Second)
+	for strings.Count(out.String(), s) < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q in watch output:\n%s", s, out.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatch(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	if err := os.Mkdir(filepath.Join(grappDir, "contexts"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	contextFile := filepath.Join(grappDir, "contexts", "person.jsonld")
+	writeTestFile(t, contextFile, testContext)
+	writeTestFile(t, filepath.Join(grappDir, "person.jsonld"),
+		`{"@context": "contexts/person.jsonld", "@type": "Person", "name": "Jane Doe"}`)
+	writeTestFile(t, filepath.Join(grappDir, "place.jsonld"),
+		`{"@context": {"name": "http://example.org/name"}, "@id": "http://example.org/paris", "name": "Paris"}`)
+
+	ctxt, cancel := context.WithCancel(ctxt)
+	defer cancel()
+
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+
+	go func() {
+		done <- watchGrapp(ctxt, grappDir, objectsDir, 50*time.Millisecond, out, nil)
+	}()
+
+	waitForOutput(t, out, "all 2 file(s) are valid", 1)
+
+	// BREAKING THE CONTEXT ONLY RE-VALIDATES THE FILE THAT DEPENDS ON IT
+	writeTestFile(t, contextFile, `{"@context": `)
+
+	waitForOutput(t, out, "1 of 2 file(s) have errors", 1)
+	if !strings.Contains(out.String(), "validated 1 file(s)\n  ERROR person.jsonld") {
+		t.Errorf("expected only person.jsonld to be re-validated:\n%s", out.String())
+	}
+
+	writeTestFile(t, contextFile, testContext)
+	waitForOutput(t, out, "all 2 file(s) are valid", 2)
+
+	// NEW PROJECT FILES ARE PICKED UP
+	writeTestFile(t, filepath.Join(grappDir, "broken.jsonld"), `not json`)
+	waitForOutput(t, out, "1 of 3 file(s) have errors", 1)
+
+	if err := os.Remove(filepath.Join(grappDir, "broken.jsonld")); err != nil {
+		t.Fatal(err)
+	}
+	waitForOutput(t, out, "all 2 file(s) are valid", 3)
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected clean shutdown, got %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop after its context was cancelled")
+	}
+
+}
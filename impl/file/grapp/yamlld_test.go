@@ -0,0 +1,96 @@
+package grapp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	resourcegrapp "github.com/datacequia/go-dogg3rz/resource/grapp"
+)
+
+func TestYAMLLDProjectFiles(t *testing.T) {
+
+	ctxt, grappDir, objectsDir := initTestGrapp(t)
+
+	if err := os.Mkdir(filepath.Join(grappDir, "contexts"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(grappDir, "contexts", "people.yaml-ld"), `
+"@context":
+  name: http://schema.org/name
+  Person: http://schema.org/Person
+`)
+	writeTestFile(t, filepath.Join(grappDir, "people.yamlld"), `
+"@context":
+  - contexts/people.yaml-ld
+  - ex: http://example.org/
+"@id": ex:jane
+"@type": Person
+name: Jane Doe
+`)
+	writeTestFile(t, filepath.Join(grappDir, "reviews.jsonld"),
+		`{"@context": {"ex": "http://example.org/", "body": "http://schema.org/reviewBody"},
+		  "@id": "ex:review1", "body": "Great"}`)
+
+	// YAML-LD FILES ARE PROJECT FILES AND CONTEXTS
+	projectFiles, err := listProjectFiles(grappDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projectFiles) != 2 || filepath.Base(projectFiles[0]) != "people.yamlld" {
+		t.Errorf("expected people.yamlld and reviews.jsonld, got %v", projectFiles)
+	}
+	if !isProjectFile(grappDir, filepath.Join(grappDir, "people.yamlld")) {
+		t.Error("expected people.yamlld to be watched as a project file")
+	}
+	if err = validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// OBJECTS ARE CREATED AND EXPORTED LIKE THOSE OF JSON-LD FILES
+	var buf bytes.Buffer
+	if err = exportGrapp(ctxt, grappDir, objectsDir, &buf, resourcegrapp.ExportOptions{Format: "nquads"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<http://example.org/jane> <http://schema.org/name> "Jane Doe" .`; !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected export to contain %s, got:\n%s", expected, buf.String())
+	}
+
+	snapshot, err := CreateSnapshot(ctxt, grappDir, objectsDir, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files := snapshot.ProjectFiles(); len(files) != 2 || files[0] != "people.yamlld" {
+		t.Errorf("expected people.yamlld in snapshot, got %v", files)
+	}
+	if _, ok := snapshot.Files["contexts/people.yaml-ld"]; !ok {
+		t.Errorf("expected contexts/people.yaml-ld in snapshot, got %v", snapshot.Files)
+	}
+
+	// PARSE ERRORS REPORT THEIR LINE AND COLUMN
+	writeTestFile(t, filepath.Join(grappDir, "people.yamlld"), "\"@id\": ex:jane\n1: Jane Doe\n")
+	err = validateGrappProjectFiles(ctxt, grappDir, objectsDir, nil)
+	if errors.GetType(err) != errors.InvalidValue || !strings.Contains(err.Error(), "people.yamlld:2:1: ") {
+		t.Errorf("expected InvalidValue error at people.yamlld:2:1, got %v", err)
+	}
+	os.Remove(filepath.Join(grappDir, "people.yamlld"))
+
+	// IMPORTS CAN WRITE YAML-LD PROJECT FILES
+	src := filepath.Join(t.TempDir(), "people.ttl")
+	writeTestFile(t, src, `<http://example.org/john> <http://schema.org/name> "John Doe" .`)
+	options := resourcegrapp.ImportOptions{Output: "john.yamlld"}
+	outputPath, err := importRDF(ctxt, grappDir, objectsDir, src, options, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "'@context':") {
+		t.Errorf("expected YAML-LD project file, got:\n%s", data)
+	}
+}
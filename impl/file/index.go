@@ -1,7 +1,5 @@
-//go:build nothing
-
 /*
- * Copyright (c) 2019-2020 Datacequia LLC. All rights reserved.
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
  *
  * This program is licensed to you under the Apache License Version 2.0,
  * and you may not use this file except in compliance with the Apache License Version 2.0.
@@ -15,5 +13,176 @@
 
 package file
 
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	dgrzerr "github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	"github.com/piprate/json-gold/ld"
+)
+
+// IndexWorkingTree is the index scope of the statements of the working
+// tree. Snapshots are indexed under their ids
+const IndexWorkingTree = "worktree"
+
+// file in the index directory recording the documents of each scope
+const indexScopesFileName = "scopes.json"
+
+// Index is the persistent graph index of a grapp in .dgrz/index. The
+// statements of each version of a project file are indexed once as a
+// document of a badger quad store. A scope (the working tree or a
+// snapshot) is the set of documents of its project files, so
+// committing a snapshot of an indexed working tree adds no statements
+// and changing a project file only indexes its new version
 type Index struct {
+	dir    string
+	store  *quadstore.BadgerStore
+	scopes indexScopes
+}
+
+// indexScopes is the content of the scopes file
+type indexScopes struct {
+	Version uint32                       `json:"version"`
+	Scopes  map[string]map[string]string `json:"scopes"` // scope -> grapp relative path -> document
+}
+
+// OpenIndex opens (or creates) the index of the grapp in grappDir. An
+// index of another format version is rebuilt. Only one process can
+// open an index at a time. Others get a TryAgain error
+func OpenIndex(grappDir string) (*Index, error) {
+
+	dir := filepath.Join(grappDir, DgrzDirName, IndexDirName)
+
+	for {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+
+		store, err := quadstore.OpenBadgerStore(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		index := &Index{dir: dir, store: store}
+		data, err := os.ReadFile(filepath.Join(dir, indexScopesFileName))
+		if err == nil {
+			err = json.Unmarshal(data, &index.scopes)
+		}
+		if err == nil && index.scopes.Version == IndexFormatVersion {
+			return index, nil
+		}
+		if docs, _ := store.Documents(); len(docs) < 1 && (err == nil || os.IsNotExist(err)) {
+			// NEW INDEX
+			index.scopes = indexScopes{Version: IndexFormatVersion, Scopes: make(map[string]map[string]string)}
+			return index, nil
+		}
+
+		// UNREADABLE OR OF ANOTHER VERSION. REBUILD
+		store.Close()
+		if err = os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close releases the index
+func (x *Index) Close() error {
+	return x.store.Close()
+}
+
+// Scope returns the documents of scope by grapp relative path or false
+// if scope is not indexed
+func (x *Index) Scope(scope string) (map[string]string, bool) {
+
+	docs, ok := x.scopes.Scopes[scope]
+
+	return docs, ok
+}
+
+// UpdateScope makes docs (grapp relative path -> document) the
+// documents of scope. Documents not yet indexed are added with the
+// statements load returns for their path. Documents no scope refers to
+// anymore are deleted. It returns the number of documents added
+func (x *Index) UpdateScope(scope string, docs map[string]string,
+	load func(relPath string) (*ld.RDFDataset, error)) (int, error) {
+
+	paths := make([]string, 0, len(docs))
+	for relPath := range docs {
+		paths = append(paths, relPath)
+	}
+	sort.Strings(paths)
+
+	added := 0
+	for _, relPath := range paths {
+		indexed, err := x.store.HasDocument(docs[relPath])
+		if err != nil {
+			return added, err
+		}
+		if indexed {
+			continue
+		}
+		dataset, err := load(relPath)
+		if err != nil {
+			return added, err
+		}
+		if err = x.store.PutDocument(docs[relPath], dataset); err != nil {
+			return added, err
+		}
+		added++
+	}
+
+	x.scopes.Scopes[scope] = docs
+
+	encoded, err := json.MarshalIndent(x.scopes, "", "  ")
+	if err != nil {
+		return added, err
+	}
+	if _, err = WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(encoded), nil },
+		filepath.Join(x.dir, indexScopesFileName)); err != nil {
+		return added, err
+	}
+
+	// DROP DOCUMENTS OF REPLACED VERSIONS
+	referenced := make(map[string]struct{})
+	for _, scopeDocs := range x.scopes.Scopes {
+		for _, doc := range scopeDocs {
+			referenced[doc] = struct{}{}
+		}
+	}
+	indexed, err := x.store.Documents()
+	if err != nil {
+		return added, err
+	}
+	for _, doc := range indexed {
+		if _, ok := referenced[doc]; !ok {
+			if err = x.store.DeleteDocument(doc); err != nil {
+				return added, err
+			}
+		}
+	}
+
+	return added, nil
+}
+
+// Store returns a read-only store over the statements of scope. It can
+// be used until the index is closed
+func (x *Index) Store(scope string) (quadstore.Store, error) {
+
+	docs, ok := x.scopes.Scopes[scope]
+	if !ok {
+		return nil, dgrzerr.NotFound.Newf("%s: scope not indexed", scope)
+	}
+
+	names := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		names = append(names, doc)
+	}
+	sort.Strings(names)
+
+	return x.store.View(names)
 }
@@ -0,0 +1,121 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/piprate/json-gold/ld"
+)
+
+func TestIndex(t *testing.T) {
+
+	grappDir := t.TempDir()
+
+	datasets := map[string]string{
+		"a.jsonld@1": `<http://example.org/a> <http://example.org/p> "1" .`,
+		"a.jsonld@2": `<http://example.org/a> <http://example.org/p> "2" .`,
+		"b.jsonld@1": `<http://example.org/b> <http://example.org/p> "1" .
+<http://example.org/b> <http://example.org/q> _:x .`,
+	}
+
+	index, err := OpenIndex(grappDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { index.Close() }()
+
+	var loaded []string
+	update := func(scope string, docs map[string]string) int {
+		t.Helper()
+		added, err := index.UpdateScope(scope, docs, func(relPath string) (*ld.RDFDataset, error) {
+			loaded = append(loaded, docs[relPath])
+			return rdf.Parse(strings.NewReader(datasets[docs[relPath]]), rdf.NQuads, "", relPath)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return added
+	}
+	count := func(scope string) int {
+		t.Helper()
+		store, err := index.Store(scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := store.Count(quadstore.Pattern{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if added := update(IndexWorkingTree, map[string]string{"a.jsonld": "a.jsonld@1", "b.jsonld": "b.jsonld@1"}); added != 2 {
+		t.Errorf("expected 2 documents to be indexed, got %d", added)
+	}
+	// A SNAPSHOT OF THE WORKING TREE INDEXES NOTHING
+	if added := update("snapshot1", map[string]string{"a.jsonld": "a.jsonld@1", "b.jsonld": "b.jsonld@1"}); added != 0 {
+		t.Errorf("expected no documents to be indexed, got %d", added)
+	}
+	// ONLY THE CHANGED FILE IS INDEXED
+	if added := update(IndexWorkingTree, map[string]string{"a.jsonld": "a.jsonld@2"}); added != 1 {
+		t.Errorf("expected 1 document to be indexed, got %d", added)
+	}
+
+	if n := count(IndexWorkingTree); n != 1 {
+		t.Errorf("expected 1 statement in the working tree, got %d", n)
+	}
+	if n := count("snapshot1"); n != 3 {
+		t.Errorf("expected 3 statements in the snapshot, got %d", n)
+	}
+	if _, err = index.Store("unknown"); errors.GetType(err) != errors.NotFound {
+		t.Errorf("expected NotFound for an unknown scope, got %v", err)
+	}
+
+	// SCOPES PERSIST. DOCUMENTS NO SCOPE REFERS TO ARE DROPPED
+	if err = index.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if index, err = OpenIndex(grappDir); err != nil {
+		t.Fatal(err)
+	}
+	if docs, ok := index.Scope("snapshot1"); !ok || len(docs) != 2 {
+		t.Errorf("expected snapshot scope with 2 documents, got %v", docs)
+	}
+	index.scopes.Scopes = map[string]map[string]string{}
+	if added := update(IndexWorkingTree, map[string]string{"a.jsonld": "a.jsonld@2"}); added != 0 {
+		t.Errorf("expected no documents to be indexed, got %d", added)
+	}
+	if docs, err := index.store.Documents(); err != nil || strings.Join(docs, " ") != "a.jsonld@2" {
+		t.Errorf("expected unreferenced documents to be dropped, got %v (%v)", docs, err)
+	}
+
+	// INDEXES OF OTHER VERSIONS ARE REBUILT
+	if err = index.Close(); err != nil {
+		t.Fatal(err)
+	}
+	scopes := `{"version": 0, "scopes": {"worktree": {"a.jsonld": "a.jsonld@2"}}}`
+	if err = os.WriteFile(filepath.Join(grappDir, DgrzDirName, IndexDirName, indexScopesFileName), []byte(scopes), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if index, err = OpenIndex(grappDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index.Scope(IndexWorkingTree); ok {
+		t.Errorf("expected index of another version to be rebuilt")
+	}
+	if docs, err := index.store.Documents(); err != nil || len(docs) > 0 {
+		t.Errorf("expected rebuilt index to be empty, got %v (%v)", docs, err)
+	}
+
+	if _, err := OpenIndex(grappDir); errors.GetType(err) != errors.TryAgain {
+		t.Errorf("expected TryAgain opening an index in use, got %v", err)
+	}
+	if len(loaded) != 3 {
+		t.Errorf("expected 3 documents to be loaded, got %v", loaded)
+	}
+}
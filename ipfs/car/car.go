@@ -0,0 +1,357 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package car reads and writes IPFS Content Addressable aRchives (CAR)
+// so that content published to IPFS can be resolved from a local file
+package car
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/fxamacker/cbor/v2"
+	cid "github.com/ipfs/go-cid"
+	merkledag "github.com/ipfs/go-merkledag"
+	unixfs "github.com/ipfs/go-unixfs"
+	unixfspb "github.com/ipfs/go-unixfs/pb"
+)
+
+// CBOR tag of a CID in dag-cbor
+const cidTag = 42
+
+// CARv2 files start with this pragma followed by a fixed size header
+var carV2Pragma = []byte{0x0a, 0xa1, 0x67, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x02}
+
+const carV2HeaderSize = 40
+
+// Archive is the verified content of a CAR file held in memory
+type Archive struct {
+	roots  []cid.Cid
+	blocks map[string][]byte // block data keyed by cid.KeyString()
+}
+
+type carHeader struct {
+	Roots   []cbor.Tag `cbor:"roots"`
+	Version uint64     `cbor:"version"`
+}
+
+// Open reads the CAR (v1 or v2) file at path
+func Open(path string) (*Archive, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	archive, err := Read(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", path)
+	}
+
+	return archive, nil
+}
+
+// Read reads a CAR (v1 or v2) from r. Every block is verified
+// against the hash in its CID
+func Read(r io.Reader) (*Archive, error) {
+
+	br := bufio.NewReader(r)
+
+	pragma, err := br.Peek(len(carV2Pragma))
+	if err == nil && bytes.Equal(pragma, carV2Pragma) {
+		if br, err = carV2Payload(br); err != nil {
+			return nil, err
+		}
+	}
+
+	headerData, err := readSection(br)
+	if err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "reading CAR header")
+	}
+
+	var header carHeader
+	if err = cbor.Unmarshal(headerData, &header); err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "decoding CAR header")
+	}
+	if header.Version != 1 {
+		return nil, errors.NotImplemented.Newf("unsupported CAR version %d", header.Version)
+	}
+
+	archive := &Archive{blocks: make(map[string][]byte)}
+
+	for _, tag := range header.Roots {
+		root, err := tagToCid(tag)
+		if err != nil {
+			return nil, err
+		}
+		archive.roots = append(archive.roots, root)
+	}
+
+	for {
+		section, err := readSection(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.InvalidValue.Wrapf(err, "reading CAR block")
+		}
+
+		n, c, err := cid.CidFromBytes(section)
+		if err != nil {
+			return nil, errors.InvalidValue.Wrapf(err, "decoding CAR block CID")
+		}
+		data := section[n:]
+
+		// CONTENT ADDRESSED INTEGRITY
+		sum, err := c.Prefix().Sum(data)
+		if err != nil {
+			return nil, err
+		}
+		if !sum.Equals(c) {
+			return nil, errors.UnexpectedValue.Newf("block %s: content does not match its CID", c)
+		}
+
+		archive.blocks[c.KeyString()] = data
+	}
+
+	return archive, nil
+}
+
+// carV2Payload returns a reader positioned at the CARv1 payload
+// of the CARv2 in br
+func carV2Payload(br *bufio.Reader) (*bufio.Reader, error) {
+
+	if _, err := br.Discard(len(carV2Pragma)); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, carV2HeaderSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "reading CARv2 header")
+	}
+
+	// CHARACTERISTICS (16 BYTES), DATA OFFSET, DATA SIZE, INDEX OFFSET
+	dataOffset := binary.LittleEndian.Uint64(header[16:24])
+	dataSize := binary.LittleEndian.Uint64(header[24:32])
+
+	skip := int64(dataOffset) - int64(len(carV2Pragma)+carV2HeaderSize)
+	if skip < 0 {
+		return nil, errors.InvalidValue.Newf("invalid CARv2 data offset %d", dataOffset)
+	}
+	if _, err := io.CopyN(io.Discard, br, skip); err != nil {
+		return nil, err
+	}
+
+	return bufio.NewReader(io.LimitReader(br, int64(dataSize))), nil
+}
+
+// readSection reads a varint length prefixed section
+func readSection(br *bufio.Reader) ([]byte, error) {
+
+	length, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+
+	section := make([]byte, length)
+	if _, err = io.ReadFull(br, section); err != nil {
+		return nil, err
+	}
+
+	return section, nil
+}
+
+func tagToCid(tag cbor.Tag) (cid.Cid, error) {
+
+	data, ok := tag.Content.([]byte)
+	if tag.Number != cidTag || !ok || len(data) < 1 || data[0] != 0 {
+		return cid.Undef, errors.InvalidValue.Newf("CAR root is not a CID")
+	}
+
+	return cid.Cast(data[1:])
+}
+
+// Roots returns the root CIDs declared by the archive
+func (a *Archive) Roots() []cid.Cid {
+	return append([]cid.Cid(nil), a.roots...)
+}
+
+// Block returns the data of the block c
+func (a *Archive) Block(c cid.Cid) ([]byte, bool) {
+
+	data, ok := a.blocks[c.KeyString()]
+
+	return data, ok
+}
+
+// Cat returns the content at the IPFS path ipfsPath
+// (i.e. /ipfs/<cid>[/<name>...]) held in the archive. UnixFS
+// directories and files are traversed. Blocks of other codecs are
+// returned as is when addressed directly
+func (a *Archive) Cat(ipfsPath string) ([]byte, error) {
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(ipfsPath, "/ipfs/"), "/"), "/")
+
+	c, err := cid.Decode(segments[0])
+	if err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "%s", ipfsPath)
+	}
+
+	for _, name := range segments[1:] {
+		if c, err = a.link(c, name); err != nil {
+			return nil, errors.Wrapf(err, "%s", ipfsPath)
+		}
+	}
+
+	data, err := a.fileContent(c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", ipfsPath)
+	}
+
+	return data, nil
+}
+
+// link returns the CID of the entry 'name' in the UnixFS directory c
+func (a *Archive) link(c cid.Cid, name string) (cid.Cid, error) {
+
+	node, fsNode, err := a.unixfsNode(c)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	switch fsNode.Type() {
+	case unixfspb.Data_Directory:
+	case unixfspb.Data_HAMTShard:
+		return cid.Undef, errors.NotImplemented.Newf("%s: sharded directories are not supported", c)
+	default:
+		return cid.Undef, errors.NotFound.Newf("%s: not a directory", c)
+	}
+
+	for _, l := range node.Links() {
+		if l.Name == name {
+			return l.Cid, nil
+		}
+	}
+
+	return cid.Undef, errors.NotFound.Newf("%s: no entry named %s", c, name)
+}
+
+// fileContent returns the content of the UnixFS file (or plain block) c
+func (a *Archive) fileContent(c cid.Cid) ([]byte, error) {
+
+	if c.Prefix().Codec != cid.DagProtobuf {
+		data, ok := a.Block(c)
+		if !ok {
+			return nil, errors.NotFound.Newf("block %s not found in archive", c)
+		}
+		return data, nil
+	}
+
+	node, fsNode, err := a.unixfsNode(c)
+	if err != nil {
+		return nil, err
+	}
+
+	switch fsNode.Type() {
+	case unixfspb.Data_File, unixfspb.Data_Raw:
+	default:
+		return nil, errors.UnexpectedType.Newf("%s: not a file", c)
+	}
+
+	content := append([]byte(nil), fsNode.Data()...)
+
+	for _, l := range node.Links() {
+		chunk, err := a.fileContent(l.Cid)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, chunk...)
+	}
+
+	return content, nil
+}
+
+func (a *Archive) unixfsNode(c cid.Cid) (*merkledag.ProtoNode, *unixfs.FSNode, error) {
+
+	data, ok := a.Block(c)
+	if !ok {
+		return nil, nil, errors.NotFound.Newf("block %s not found in archive", c)
+	}
+
+	if c.Prefix().Codec != cid.DagProtobuf {
+		return nil, nil, errors.UnexpectedType.Newf("%s: not a UnixFS node", c)
+	}
+
+	node, err := merkledag.DecodeProtobuf(data)
+	if err != nil {
+		return nil, nil, errors.InvalidValue.Wrapf(err, "%s", c)
+	}
+
+	fsNode, err := unixfs.FSNodeFromBytes(node.Data())
+	if err != nil {
+		return nil, nil, errors.InvalidValue.Wrapf(err, "%s", c)
+	}
+
+	return node, fsNode, nil
+}
+
+// Block is a single block written to a CAR
+type Block struct {
+	Cid  cid.Cid
+	Data []byte
+}
+
+// Write writes a CARv1 with roots and blocks to w
+func Write(w io.Writer, roots []cid.Cid, blocks []Block) error {
+
+	header := carHeader{Version: 1, Roots: make([]cbor.Tag, len(roots))}
+	for i, root := range roots {
+		header.Roots[i] = cbor.Tag{Number: cidTag, Content: append([]byte{0}, root.Bytes()...)}
+	}
+
+	headerData, err := cbor.Marshal(header)
+	if err != nil {
+		return err
+	}
+
+	if err = writeSection(w, headerData); err != nil {
+		return err
+	}
+
+	for _, block := range blocks {
+		if err = writeSection(w, append(block.Cid.Bytes(), block.Data...)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeSection(w io.Writer, section []byte) error {
+
+	var length [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(length[:], uint64(len(section)))
+
+	if _, err := w.Write(length[:n]); err != nil {
+		return err
+	}
+	_, err := w.Write(section)
+
+	return err
+}
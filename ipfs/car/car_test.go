@@ -0,0 +1,103 @@
+package car
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	cid "github.com/ipfs/go-cid"
+	merkledag "github.com/ipfs/go-merkledag"
+	unixfs "github.com/ipfs/go-unixfs"
+	mh "github.com/multiformats/go-multihash"
+)
+
+const testContext = `{"@context": {"name": "http://example.org/name"}}`
+
+// testArchive returns a CAR holding a UnixFS directory with the file
+// 'context.jsonld' split into two chunks and a raw block holding
+// the same content
+func testArchive(t *testing.T) ([]byte, cid.Cid, cid.Cid) {
+
+	half := len(testContext) / 2
+
+	chunk1 := merkledag.NodeWithData(unixfs.FilePBData([]byte(testContext[:half]), uint64(half)))
+	chunk2 := merkledag.NodeWithData(unixfs.FilePBData([]byte(testContext[half:]), uint64(len(testContext)-half)))
+
+	fileNode := merkledag.NodeWithData(unixfs.FilePBData(nil, uint64(len(testContext))))
+	if err := fileNode.AddNodeLink("", chunk1); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileNode.AddNodeLink("", chunk2); err != nil {
+		t.Fatal(err)
+	}
+
+	dirNode := unixfs.EmptyDirNode()
+	if err := dirNode.AddNodeLink("context.jsonld", fileNode); err != nil {
+		t.Fatal(err)
+	}
+
+	rawCid, err := cid.Prefix{Version: 1, Codec: cid.Raw, MhType: mh.SHA2_256, MhLength: -1}.Sum([]byte(testContext))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := []Block{
+		{dirNode.Cid(), dirNode.RawData()},
+		{fileNode.Cid(), fileNode.RawData()},
+		{chunk1.Cid(), chunk1.RawData()},
+		{chunk2.Cid(), chunk2.RawData()},
+		{rawCid, []byte(testContext)},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, []cid.Cid{dirNode.Cid()}, blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes(), dirNode.Cid(), rawCid
+}
+
+func TestArchiveCat(t *testing.T) {
+
+	data, dirCid, rawCid := testArchive(t)
+
+	archive, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if roots := archive.Roots(); len(roots) != 1 || !roots[0].Equals(dirCid) {
+		t.Errorf("expected root %s, got %v", dirCid, roots)
+	}
+
+	for _, ipfsPath := range []string{
+		"/ipfs/" + dirCid.String() + "/context.jsonld",
+		"/ipfs/" + rawCid.String(),
+	} {
+		content, err := archive.Cat(ipfsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != testContext {
+			t.Errorf("%s: expected %s, got %s", ipfsPath, testContext, content)
+		}
+	}
+
+	if _, err := archive.Cat("/ipfs/" + dirCid.String() + "/missing.jsonld"); errors.GetType(err) != errors.NotFound {
+		t.Errorf("expected NotFound error for missing entry, got %v", err)
+	}
+
+}
+
+func TestArchiveIntegrity(t *testing.T) {
+
+	data, _, _ := testArchive(t)
+
+	// CORRUPT THE RAW BLOCK AT THE END OF THE ARCHIVE
+	data[len(data)-2] ^= 0xff
+
+	if _, err := Read(bytes.NewReader(data)); errors.GetType(err) != errors.UnexpectedValue {
+		t.Errorf("expected UnexpectedValue error for corrupted block, got %v", err)
+	}
+
+}
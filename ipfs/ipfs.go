@@ -55,6 +55,15 @@ func DagPut(data interface{}) (string, error) {
 
 }
 
+// BlockPut commits the raw bytes 'block' (e.g. a CBOR-LD encoded
+// object) to IPFS as a block. Returns CID
+func BlockPut(block []byte) (string, error) {
+
+	sh := newShellDefault()
+
+	return sh.BlockPut(block, "raw", "sha2-256", -1)
+}
+
 
 
 
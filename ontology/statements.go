@@ -64,6 +64,9 @@ type DataFile struct {
 type ParquetFile struct {
 	DataFile
 }
+type HDTFile struct {
+	DataFile
+}
 
 type Namespace struct {
 }
@@ -91,6 +94,7 @@ var graph = []any{
 	metadataPropertyDecl(),
 	dataFileClassDecl(),
 	parquetFileClassDecl(),
+	hdtFileClassDecl(),
 	grapplicationMetadataClassDecl(),
 	namespacePropertyDecl(),
 	namespaceClassDecl(),
@@ -415,6 +419,28 @@ func parquetFileClassDecl() *RDFSClass {
 
 }
 
+func hdtFileClassDecl() *RDFSClass {
+
+	c := RDFSClass{
+		RDFSResource: RDFSResource{
+			ResourceIdentifier: ResourceIdentifier{
+				Id: reflect.TypeOf(HDTFile{}).Name(),
+			},
+			Type:        "rdfs:Class",
+			Comment:     "Where a Grapplication's RDF triples are stored and retrieved using the read-only HDT (Header-Dictionary-Triples) file format.",
+			IsDefinedBy: "",
+			Label:       "",
+			Member:      reflect.TypeOf(HDTFile{}).Name(),
+			SeeAlso:     "https://www.rdfhdt.org/",
+		},
+		//SubClassOf: &ResourceIdentifier{},
+	}
+	c.SubClassOf = resourceId(reflect.TypeOf(DataFile{}).Name())
+
+	return &c
+
+}
+
 // ////////////////////////////////////////////////////////////////////////
 // GrapplicationMetadata Class and its properties
 // ////////////////////////////////////////////////////////////////////////
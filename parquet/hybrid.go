@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package parquet
+
+import (
+	"encoding/binary"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// appendHybrid appends values encoded with the RLE / bit-packing
+// hybrid encoding using bitWidth bits per value to dst. Runs of eight
+// or more equal values are run length encoded, everything else is bit
+// packed in groups of eight
+func appendHybrid(dst []byte, values []uint32, bitWidth int) []byte {
+
+	runLength := func(i int) int {
+		n := 1
+		for i+n < len(values) && values[i+n] == values[i] {
+			n++
+		}
+		return n
+	}
+
+	byteWidth := (bitWidth + 7) / 8
+	var header [binary.MaxVarintLen64]byte
+
+	for i := 0; i < len(values); {
+
+		if n := runLength(i); n >= 8 {
+			dst = append(dst, header[:binary.PutUvarint(header[:], uint64(n)<<1)]...)
+			for b := 0; b < byteWidth; b++ {
+				dst = append(dst, byte(values[i]>>(8*b)))
+			}
+			i += n
+			continue
+		}
+
+		// BIT PACK GROUPS OF 8 VALUES UNTIL THE NEXT LONG RUN. THE
+		// LAST GROUP IS PADDED WITH ZEROS
+		start, groups := i, 0
+		for i < len(values) && groups < 63 && (groups == 0 || runLength(i) < 8) {
+			i += 8
+			groups++
+		}
+		dst = append(dst, header[:binary.PutUvarint(header[:], uint64(groups)<<1|1)]...)
+
+		packed := make([]byte, groups*bitWidth)
+		for j := 0; j < groups*8 && start+j < len(values); j++ {
+			v := values[start+j]
+			for b := 0; b < bitWidth; b++ {
+				if v&(1<<b) != 0 {
+					bit := j*bitWidth + b
+					packed[bit/8] |= 1 << (bit % 8)
+				}
+			}
+		}
+		dst = append(dst, packed...)
+
+		if i > len(values) {
+			i = len(values)
+		}
+	}
+
+	return dst
+}
+
+// readHybrid decodes n values encoded with the RLE / bit-packing
+// hybrid encoding using bitWidth bits per value from data. It returns
+// the values and the number of bytes read
+func readHybrid(data []byte, n int, bitWidth int) ([]uint32, int, error) {
+
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, 0, errors.InvalidValue.Newf("invalid parquet bit width %d", bitWidth)
+	}
+
+	values := make([]uint32, 0, n)
+	byteWidth := (bitWidth + 7) / 8
+	pos := 0
+
+	for len(values) < n {
+
+		header, k := binary.Uvarint(data[pos:])
+		if k <= 0 {
+			return nil, 0, errors.InvalidValue.New("invalid parquet RLE header")
+		}
+		pos += k
+
+		if header&1 == 0 {
+			count := header >> 1
+			if count == 0 || len(data)-pos < byteWidth {
+				return nil, 0, errors.InvalidValue.Newf("invalid parquet RLE run of %d values", count)
+			}
+			if count > uint64(n-len(values)) {
+				count = uint64(n - len(values))
+			}
+			var v uint32
+			for b := 0; b < byteWidth; b++ {
+				v |= uint32(data[pos+b]) << (8 * b)
+			}
+			pos += byteWidth
+			for i := uint64(0); i < count; i++ {
+				values = append(values, v)
+			}
+			continue
+		}
+
+		groups := header >> 1
+		if groups == 0 || groups*uint64(bitWidth) > uint64(len(data)-pos) {
+			return nil, 0, errors.InvalidValue.Newf("invalid parquet bit packed run of %d groups", groups)
+		}
+		packed := data[pos : pos+int(groups)*bitWidth]
+		pos += len(packed)
+
+		for j := 0; j < int(groups)*8 && len(values) < n; j++ {
+			var v uint32
+			for b := 0; b < bitWidth; b++ {
+				bit := j*bitWidth + b
+				if packed[bit/8]&(1<<(bit%8)) != 0 {
+					v |= 1 << b
+				}
+			}
+			values = append(values, v)
+		}
+	}
+
+	return values, pos, nil
+}
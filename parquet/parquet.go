@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package parquet stores RDF statements in Apache Parquet files with
+// one row per statement. Columns are dictionary encoded and every row
+// group records the minimum, maximum, null and distinct counts of its
+// columns so query engines (e.g. DuckDB or Spark) can skip row groups
+package parquet
+
+// magic number at the start and end of a parquet file
+const magic = "PAR1"
+
+// created_by value of files written by this package
+const createdBy = "dogg3rz"
+
+// default number of rows in a row group
+const DefaultRowGroupSize = 1 << 16
+
+// parquet physical types
+const (
+	typeByteArray = 6
+)
+
+// parquet field repetition types
+const (
+	repetitionRequired = 0
+	repetitionOptional = 1
+)
+
+// parquet converted type of UTF-8 strings
+const convertedTypeUTF8 = 0
+
+// parquet encodings
+const (
+	encodingPlain           = 0
+	encodingPlainDictionary = 2
+	encodingRLE             = 3
+	encodingRLEDictionary   = 8
+)
+
+// parquet compression codecs
+const (
+	codecUncompressed = 0
+	codecGzip         = 2
+)
+
+// parquet page types
+const (
+	pageData       = 0
+	pageIndex      = 1
+	pageDictionary = 2
+	pageDataV2     = 3
+)
+
+// Row is a statement stored in a parquet file. An object is a literal
+// if and only if it has a datatype, otherwise it is an IRI or a blank
+// node (labelled "_:...")
+type Row struct {
+	Subject   string // IRI or blank node
+	Predicate string // IRI
+	Object    string // IRI, blank node or lexical form of a literal
+	Datatype  string // datatype IRI of a literal object
+	Language  string // language tag of a literal object
+	Graph     string // name of the graph. empty for the default graph
+}
+
+// column describes a column of the parquet schema
+type column struct {
+	name     string
+	optional bool // null when empty
+	value    func(r *Row) *string
+}
+
+// columns of the parquet schema in file order
+var columns = []column{
+	{"subject", false, func(r *Row) *string { return &r.Subject }},
+	{"predicate", false, func(r *Row) *string { return &r.Predicate }},
+	{"object", false, func(r *Row) *string { return &r.Object }},
+	{"datatype", true, func(r *Row) *string { return &r.Datatype }},
+	{"language", true, func(r *Row) *string { return &r.Language }},
+	{"graph", true, func(r *Row) *string { return &r.Graph }},
+}
+
+// Statistics summarizes the values of a column in a row group
+type Statistics struct {
+	Min           string // smallest value (byte order)
+	Max           string // largest value (byte order)
+	NullCount     int64
+	DistinctCount int64
+}
+
+// RowGroup describes a row group of a parquet file
+type RowGroup struct {
+	NumRows    int64
+	Statistics map[string]Statistics // column name -> statistics
+}
@@ -0,0 +1,164 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+func testRows(n int) []Row {
+
+	rows := make([]Row, n)
+	for i := range rows {
+		rows[i] = Row{
+			Subject:   fmt.Sprintf("http://example.org/s%d", i/4),
+			Predicate: "http://example.org/p",
+			Object:    fmt.Sprintf("value %d", i),
+		}
+		switch i % 3 {
+		case 0:
+			rows[i].Datatype = "http://www.w3.org/2001/XMLSchema#string"
+		case 1:
+			rows[i].Datatype = "http://www.w3.org/1999/02/22-rdf-syntax-ns#langString"
+			rows[i].Language = "en"
+		default:
+			rows[i].Object = "_:b" + fmt.Sprint(i)
+		}
+		if i%5 == 0 {
+			rows[i].Graph = "http://example.org/g"
+		}
+	}
+
+	return rows
+}
+
+func writeTestFile(t *testing.T, rows []Row, options WriterOptions) []byte {
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, options)
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func readTestFile(t *testing.T, data []byte) (*Reader, []Row) {
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []Row
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+
+	return r, rows
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	for _, n := range []int{0, 1, 7, 100, 1000} {
+		rows := testRows(n)
+		data := writeTestFile(t, rows, WriterOptions{RowGroupSize: 64})
+
+		r, read := readTestFile(t, data)
+		if r.NumRows() != int64(n) {
+			t.Errorf("%d rows: NumRows returned %d", n, r.NumRows())
+		}
+		if len(rows) > 0 && !reflect.DeepEqual(rows, read) {
+			t.Errorf("%d rows: rows changed in round trip", n)
+		}
+		if expected := (n + 63) / 64; len(r.RowGroups()) != expected {
+			t.Errorf("%d rows: expected %d row groups, got %d", n, expected, len(r.RowGroups()))
+		}
+	}
+}
+
+func TestRowGroupStatistics(t *testing.T) {
+
+	rows := testRows(10)
+	r, _ := readTestFile(t, writeTestFile(t, rows, WriterOptions{RowGroupSize: 8}))
+
+	rowGroups := r.RowGroups()
+	if len(rowGroups) != 2 || rowGroups[0].NumRows != 8 || rowGroups[1].NumRows != 2 {
+		t.Fatalf("unexpected row groups %+v", rowGroups)
+	}
+
+	expected := map[string]Statistics{
+		"subject":   {Min: "http://example.org/s0", Max: "http://example.org/s1", DistinctCount: 2},
+		"predicate": {Min: "http://example.org/p", Max: "http://example.org/p", DistinctCount: 1},
+		"object":    {Min: "_:b2", Max: "value 7", DistinctCount: 8},
+		"language":  {Min: "en", Max: "en", NullCount: 5, DistinctCount: 1},
+		"graph":     {Min: "http://example.org/g", Max: "http://example.org/g", NullCount: 6, DistinctCount: 1},
+	}
+	for column, stats := range expected {
+		if actual := rowGroups[0].Statistics[column]; actual != stats {
+			t.Errorf("%s: expected statistics %+v, got %+v", column, stats, actual)
+		}
+	}
+}
+
+func TestHybrid(t *testing.T) {
+
+	for _, bitWidth := range []int{1, 3, 8, 13, 32} {
+		var values []uint32
+		for i := 0; i < 300; i++ {
+			switch {
+			case i < 20 || (i > 100 && i < 150):
+				values = append(values, 1) // RUNS
+			default:
+				values = append(values, uint32(i*7919)&(1<<bitWidth-1))
+			}
+		}
+
+		encoded := appendHybrid(nil, values, bitWidth)
+		decoded, n, err := readHybrid(encoded, len(values), bitWidth)
+		if err != nil {
+			t.Fatalf("bit width %d: %v", bitWidth, err)
+		}
+		if n != len(encoded) || !reflect.DeepEqual(values, decoded) {
+			t.Errorf("bit width %d: values changed in round trip", bitWidth)
+		}
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+
+	data := writeTestFile(t, testRows(20), WriterOptions{})
+
+	for name, invalid := range map[string][]byte{
+		"empty":        {},
+		"no magic":     append([]byte("JUNK"), data[4:]...),
+		"truncated":    data[:len(data)-20],
+		"short footer": append(append([]byte(nil), data[:len(data)-8]...), 0xff, 0xff, 0, 0, 'P', 'A', 'R', '1'),
+	} {
+		if _, err := NewReader(bytes.NewReader(invalid), int64(len(invalid))); errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("%s: expected InvalidValue, got %v", name, err)
+		}
+	}
+
+	w := NewWriter(io.Discard, WriterOptions{})
+	w.Close()
+	if err := w.Write(Row{}); errors.GetType(err) != errors.InvalidState {
+		t.Errorf("expected InvalidState writing to a closed writer, got %v", err)
+	}
+}
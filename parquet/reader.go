@@ -0,0 +1,484 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// Reader reads the rows of a parquet file written by Writer or by
+// other writers using the same schema. Only flat schemas with plain or
+// dictionary encoded, uncompressed or gzip compressed pages are supported
+type Reader struct {
+	r         io.ReaderAt
+	numRows   int64
+	rowGroups []rowGroupMeta
+	leaves    map[string]int // column name -> index of the column in a row group
+	optional  map[string]bool
+	next      int   // next row group to decode
+	rows      []Row // decoded rows of the current row group
+}
+
+type rowGroupMeta struct {
+	numRows int64
+	columns []columnMeta
+}
+
+type columnMeta struct {
+	codec            int64
+	numValues        int64
+	size             int64
+	dataPageOffset   int64
+	dictionaryOffset int64
+	stats            Statistics
+}
+
+// NewReader reads the footer of the parquet file of size bytes in r
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+
+	if size < int64(2*len(magic)+4) {
+		return nil, errors.InvalidValue.New("not a parquet file: too short")
+	}
+
+	var head, tail [8]byte
+	if _, err := r.ReadAt(head[:len(magic)], 0); err != nil {
+		return nil, err
+	}
+	if _, err := r.ReadAt(tail[:], size-8); err != nil {
+		return nil, err
+	}
+	if string(head[:len(magic)]) != magic || string(tail[4:]) != magic {
+		return nil, errors.InvalidValue.New("not a parquet file: missing magic number")
+	}
+
+	footerLength := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if footerLength > size-int64(2*len(magic)+4) {
+		return nil, errors.InvalidValue.Newf("invalid parquet footer length %d", footerLength)
+	}
+	footer := make([]byte, footerLength)
+	if _, err := r.ReadAt(footer, size-8-footerLength); err != nil {
+		return nil, err
+	}
+
+	meta, err := (&thriftReader{data: footer}).readStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &Reader{r: r, leaves: make(map[string]int), optional: make(map[string]bool)}
+	if reader.numRows, _ = meta.i64(3); reader.numRows < 0 {
+		return nil, errors.InvalidValue.Newf("invalid parquet row count %d", reader.numRows)
+	}
+	if err = reader.readSchema(meta.list(2)); err != nil {
+		return nil, err
+	}
+	if err = reader.readRowGroups(meta.list(4), size); err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// readSchema locates the columns of Row in the flat schema elements
+func (r *Reader) readSchema(elements []interface{}) error {
+
+	if len(elements) < 1 {
+		return errors.InvalidValue.New("parquet file has no schema")
+	}
+
+	for i, e := range elements[1:] {
+		element, ok := e.(thriftFields)
+		if !ok {
+			return errors.InvalidValue.New("invalid parquet schema element")
+		}
+		name, _ := element.bytes(4)
+		if children, _ := element.i64(5); children > 0 {
+			return errors.NotImplemented.Newf("%s: nested parquet columns are not supported", name)
+		}
+		typ, _ := element.i64(1)
+		repetition, _ := element.i64(3)
+		for _, c := range columns {
+			if c.name != string(name) {
+				continue
+			}
+			if typ != typeByteArray || repetition > repetitionOptional {
+				return errors.UnexpectedType.Newf("%s: parquet column must be a string", name)
+			}
+			r.leaves[c.name] = i
+			r.optional[c.name] = repetition == repetitionOptional
+		}
+	}
+
+	for _, c := range columns {
+		if _, ok := r.leaves[c.name]; !ok && !c.optional {
+			return errors.NotFound.Newf("%s: parquet file has no such column", c.name)
+		}
+	}
+
+	return nil
+}
+
+func (r *Reader) readRowGroups(rowGroups []interface{}, size int64) error {
+
+	for _, rg := range rowGroups {
+		fields, ok := rg.(thriftFields)
+		if !ok {
+			return errors.InvalidValue.New("invalid parquet row group")
+		}
+
+		meta := rowGroupMeta{}
+		if meta.numRows, _ = fields.i64(3); meta.numRows < 0 || meta.numRows > r.numRows {
+			return errors.InvalidValue.Newf("invalid parquet row group row count %d", meta.numRows)
+		}
+
+		for _, cc := range fields.list(1) {
+			chunk, _ := cc.(thriftFields)
+			cm, ok := chunk.structField(3)
+			if !ok {
+				return errors.NotImplemented.New("parquet column chunks in separate files are not supported")
+			}
+
+			column := columnMeta{}
+			column.codec, _ = cm.i64(4)
+			column.numValues, _ = cm.i64(5)
+			column.size, _ = cm.i64(7)
+			column.dataPageOffset, _ = cm.i64(9)
+			column.dictionaryOffset, _ = cm.i64(11)
+			if stats, ok := cm.structField(12); ok {
+				column.stats.NullCount, _ = stats.i64(3)
+				column.stats.DistinctCount, _ = stats.i64(4)
+				if max, ok := stats.bytes(5); ok {
+					column.stats.Max = string(max)
+				}
+				if min, ok := stats.bytes(6); ok {
+					column.stats.Min = string(min)
+				}
+			}
+
+			if start := column.start(); start < int64(len(magic)) || column.size < 0 || start+column.size > size {
+				return errors.InvalidValue.Newf("parquet column chunk at %d exceeds file", start)
+			}
+			meta.columns = append(meta.columns, column)
+		}
+
+		for name, leaf := range r.leaves {
+			if leaf >= len(meta.columns) {
+				return errors.InvalidValue.Newf("%s: parquet row group is missing the column", name)
+			}
+			if meta.columns[leaf].numValues != meta.numRows {
+				return errors.InvalidValue.Newf("%s: parquet column has %d values in a row group of %d rows",
+					name, meta.columns[leaf].numValues, meta.numRows)
+			}
+		}
+		r.rowGroups = append(r.rowGroups, meta)
+	}
+
+	return nil
+}
+
+// start returns the offset of the first page of the column chunk
+func (c columnMeta) start() int64 {
+
+	if c.dictionaryOffset > 0 && c.dictionaryOffset < c.dataPageOffset {
+		return c.dictionaryOffset
+	}
+
+	return c.dataPageOffset
+}
+
+// NumRows returns the number of rows in the file
+func (r *Reader) NumRows() int64 {
+	return r.numRows
+}
+
+// RowGroups describes the row groups of the file
+func (r *Reader) RowGroups() []RowGroup {
+
+	rowGroups := make([]RowGroup, len(r.rowGroups))
+	for i, rg := range r.rowGroups {
+		rowGroups[i] = RowGroup{NumRows: rg.numRows, Statistics: make(map[string]Statistics)}
+		for name, leaf := range r.leaves {
+			rowGroups[i].Statistics[name] = rg.columns[leaf].stats
+		}
+	}
+
+	return rowGroups
+}
+
+// Read returns the next row. io.EOF is returned after the last row
+func (r *Reader) Read() (Row, error) {
+
+	for len(r.rows) < 1 {
+		if r.next >= len(r.rowGroups) {
+			return Row{}, io.EOF
+		}
+		if err := r.readRowGroup(r.rowGroups[r.next]); err != nil {
+			return Row{}, err
+		}
+		r.next++
+	}
+
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+
+	return row, nil
+}
+
+// readRowGroup decodes the rows of rg
+func (r *Reader) readRowGroup(rg rowGroupMeta) error {
+
+	rows := make([]Row, rg.numRows)
+
+	for _, c := range columns {
+		leaf, ok := r.leaves[c.name]
+		if !ok {
+			continue
+		}
+		values, err := r.readColumnChunk(rg.columns[leaf], int(rg.numRows), r.optional[c.name])
+		if err != nil {
+			return errors.Wrapf(err, "%s", c.name)
+		}
+		for i := range rows {
+			*c.value(&rows[i]) = values[i]
+		}
+	}
+
+	r.rows = rows
+
+	return nil
+}
+
+// readColumnChunk returns the n values of the column chunk c. Nulls
+// are returned as empty strings
+func (r *Reader) readColumnChunk(c columnMeta, n int, optional bool) ([]string, error) {
+
+	data := make([]byte, c.size)
+	if _, err := r.r.ReadAt(data, c.start()); err != nil {
+		return nil, err
+	}
+
+	var dictionary []string
+	values := make([]string, 0, n)
+
+	for pos := 0; len(values) < n; {
+
+		if pos >= len(data) {
+			return nil, errors.InvalidValue.Newf("parquet column chunk ends after %d of %d values", len(values), n)
+		}
+
+		tr := &thriftReader{data: data[pos:]}
+		header, err := tr.readStruct()
+		if err != nil {
+			return nil, err
+		}
+		pos += tr.pos
+
+		pageType, _ := header.i64(1)
+		uncompressedSize, _ := header.i64(2)
+		compressedSize, _ := header.i64(3)
+		if compressedSize < 0 || compressedSize > int64(len(data)-pos) {
+			return nil, errors.InvalidValue.Newf("parquet page size %d exceeds column chunk", compressedSize)
+		}
+		page := data[pos : pos+int(compressedSize)]
+		pos += int(compressedSize)
+
+		switch pageType {
+		case pageDictionary:
+			if page, err = decompress(page, c.codec, uncompressedSize); err != nil {
+				return nil, err
+			}
+			dph, _ := header.structField(7)
+			count, _ := dph.i64(1)
+			if dictionary, _, err = readPlain(page, count); err != nil {
+				return nil, err
+			}
+
+		case pageData:
+			if page, err = decompress(page, c.codec, uncompressedSize); err != nil {
+				return nil, err
+			}
+			dph, _ := header.structField(5)
+			count, _ := dph.i64(1)
+			encoding, _ := dph.i64(2)
+			if count < 0 || count > int64(n-len(values)) {
+				return nil, errors.InvalidValue.Newf("parquet data page has %d values", count)
+			}
+			var levels []byte
+			if optional {
+				// DEFINITION LEVELS ARE PREFIXED WITH THEIR LENGTH
+				if len(page) < 4 || uint64(binary.LittleEndian.Uint32(page)) > uint64(len(page)-4) {
+					return nil, errors.InvalidValue.New("parquet data page has invalid definition levels")
+				}
+				length := binary.LittleEndian.Uint32(page)
+				levels, page = page[4:4+length], page[4+length:]
+			}
+			if values, err = readDataPage(levels, page, int(count), encoding, optional, dictionary, values); err != nil {
+				return nil, err
+			}
+
+		case pageDataV2:
+			dph, _ := header.structField(8)
+			count, _ := dph.i64(1)
+			encoding, _ := dph.i64(4)
+			levelsLength, _ := dph.i64(5)
+			repetitionLength, _ := dph.i64(6)
+			if count < 0 || count > int64(n-len(values)) {
+				return nil, errors.InvalidValue.Newf("parquet data page has %d values", count)
+			}
+			if levelsLength < 0 || repetitionLength != 0 || levelsLength > int64(len(page)) {
+				return nil, errors.InvalidValue.New("parquet data page has invalid levels")
+			}
+			// LEVELS ARE NEVER COMPRESSED
+			levels, page := page[:levelsLength], page[levelsLength:]
+			if compressed, ok := dph[7].(bool); !ok || compressed {
+				if page, err = decompress(page, c.codec, uncompressedSize-levelsLength); err != nil {
+					return nil, err
+				}
+			}
+			if values, err = readDataPage(levels, page, int(count), encoding, optional, dictionary, values); err != nil {
+				return nil, err
+			}
+
+		case pageIndex:
+		default:
+			return nil, errors.NotImplemented.Newf("parquet page type %d is not supported", pageType)
+		}
+	}
+
+	return values, nil
+}
+
+// readDataPage appends the count values of a data page with the
+// encoded definition levels (optional columns only) to values
+func readDataPage(levelData []byte, page []byte, count int, encoding int64, optional bool, dictionary []string,
+	values []string) ([]string, error) {
+
+	levels := make([]uint32, count)
+	present := count
+
+	if optional {
+		var err error
+		if levels, _, err = readHybrid(levelData, count, 1); err != nil {
+			return nil, err
+		}
+
+		present = 0
+		for _, level := range levels {
+			present += int(level)
+		}
+	} else {
+		for i := range levels {
+			levels[i] = 1
+		}
+	}
+
+	var decoded []string
+	var err error
+
+	switch encoding {
+	case encodingPlain:
+		decoded, _, err = readPlain(page, int64(present))
+
+	case encodingPlainDictionary, encodingRLEDictionary:
+		if present == 0 {
+			break
+		}
+		if len(page) < 1 {
+			return nil, errors.InvalidValue.New("parquet data page is missing the bit width")
+		}
+		var indexes []uint32
+		if indexes, _, err = readHybrid(page[1:], present, int(page[0])); err != nil {
+			return nil, err
+		}
+		decoded = make([]string, present)
+		for i, index := range indexes {
+			if int(index) >= len(dictionary) {
+				return nil, errors.InvalidValue.Newf("parquet dictionary index %d out of range", index)
+			}
+			decoded[i] = dictionary[index]
+		}
+
+	default:
+		return nil, errors.NotImplemented.Newf("parquet encoding %d is not supported", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, level := range levels {
+		if level == 0 {
+			values = append(values, "")
+			continue
+		}
+		values = append(values, decoded[0])
+		decoded = decoded[1:]
+	}
+
+	return values, nil
+}
+
+// readPlain decodes count plain encoded byte arrays from data
+func readPlain(data []byte, count int64) ([]string, int, error) {
+
+	if count < 0 || count > int64(len(data))/4 {
+		return nil, 0, errors.InvalidValue.Newf("invalid parquet value count %d", count)
+	}
+
+	values := make([]string, 0, count)
+	pos := 0
+
+	for i := int64(0); i < count; i++ {
+		if len(data)-pos < 4 {
+			return nil, 0, errors.InvalidValue.New("truncated parquet byte array")
+		}
+		length := binary.LittleEndian.Uint32(data[pos:])
+		pos += 4
+		if uint64(length) > uint64(len(data)-pos) {
+			return nil, 0, errors.InvalidValue.New("truncated parquet byte array")
+		}
+		values = append(values, string(data[pos:pos+int(length)]))
+		pos += int(length)
+	}
+
+	return values, pos, nil
+}
+
+// decompress returns the uncompressed content of a page
+func decompress(page []byte, codec int64, uncompressedSize int64) ([]byte, error) {
+
+	switch codec {
+	case codecUncompressed:
+		return page, nil
+
+	case codecGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(page))
+		if err != nil {
+			return nil, errors.InvalidValue.Wrapf(err, "parquet page")
+		}
+		defer zr.Close()
+		// NO MORE THAN THE DECLARED SIZE IS READ
+		data, err := io.ReadAll(io.LimitReader(zr, uncompressedSize))
+		if err != nil {
+			return nil, errors.InvalidValue.Wrapf(err, "parquet page")
+		}
+		return data, nil
+	}
+
+	return nil, errors.NotImplemented.Newf("parquet compression codec %d is not supported", codec)
+}
@@ -0,0 +1,357 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// thrift compact protocol field types
+const (
+	thriftBoolTrue  = 1
+	thriftBoolFalse = 2
+	thriftByte      = 3
+	thriftI16       = 4
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftDouble    = 7
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftSet       = 10
+	thriftMap       = 11
+	thriftStruct    = 12
+)
+
+// maximum nesting of structs and containers accepted when decoding
+const thriftMaxDepth = 32
+
+// thriftWriter encodes the parquet metadata structures with the thrift
+// compact protocol
+type thriftWriter struct {
+	buf     bytes.Buffer
+	lastID  int16   // id of the previous field of the current struct
+	idStack []int16 // lastID of the enclosing structs
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (w *thriftWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftWriter) fieldHeader(id int16, typ byte) {
+
+	if delta := id - w.lastID; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta<<4) | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.zigzag(int64(id))
+	}
+	w.lastID = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.zigzag(v)
+}
+
+func (w *thriftWriter) bool(id int16, v bool) {
+	if v {
+		w.fieldHeader(id, thriftBoolTrue)
+	} else {
+		w.fieldHeader(id, thriftBoolFalse)
+	}
+}
+
+func (w *thriftWriter) binary(id int16, v []byte) {
+	w.fieldHeader(id, thriftBinary)
+	w.varint(uint64(len(v)))
+	w.buf.Write(v)
+}
+
+func (w *thriftWriter) string(id int16, v string) {
+	w.binary(id, []byte(v))
+}
+
+// structField writes field id as a struct whose fields are written by f
+func (w *thriftWriter) structField(id int16, f func()) {
+	w.fieldHeader(id, thriftStruct)
+	w.structValue(f)
+}
+
+func (w *thriftWriter) structValue(f func()) {
+
+	w.idStack = append(w.idStack, w.lastID)
+	w.lastID = 0
+	f()
+	w.buf.WriteByte(0) // STOP
+	w.lastID = w.idStack[len(w.idStack)-1]
+	w.idStack = w.idStack[:len(w.idStack)-1]
+}
+
+func (w *thriftWriter) listHeader(id int16, elemType byte, n int) {
+
+	w.fieldHeader(id, thriftList)
+	if n < 15 {
+		w.buf.WriteByte(byte(n<<4) | elemType)
+	} else {
+		w.buf.WriteByte(0xf0 | elemType)
+		w.varint(uint64(n))
+	}
+}
+
+func (w *thriftWriter) i32List(id int16, values []int32) {
+	w.listHeader(id, thriftI32, len(values))
+	for _, v := range values {
+		w.zigzag(int64(v))
+	}
+}
+
+func (w *thriftWriter) stringList(id int16, values []string) {
+	w.listHeader(id, thriftBinary, len(values))
+	for _, v := range values {
+		w.varint(uint64(len(v)))
+		w.buf.WriteString(v)
+	}
+}
+
+// structList writes field id as a list of n structs whose fields are
+// written by f
+func (w *thriftWriter) structList(id int16, n int, f func(i int)) {
+	w.listHeader(id, thriftStruct, n)
+	for i := 0; i < n; i++ {
+		w.structValue(func() { f(i) })
+	}
+}
+
+// thriftFields is a decoded struct: field id -> value. Values are
+// bool, int64 (all integer types), float64, []byte, []interface{}
+// (lists and sets) or thriftFields. Maps are skipped
+type thriftFields map[int16]interface{}
+
+func (s thriftFields) i64(id int16) (int64, bool) {
+	v, ok := s[id].(int64)
+	return v, ok
+}
+
+func (s thriftFields) bytes(id int16) ([]byte, bool) {
+	v, ok := s[id].([]byte)
+	return v, ok
+}
+
+func (s thriftFields) structField(id int16) (thriftFields, bool) {
+	v, ok := s[id].(thriftFields)
+	return v, ok
+}
+
+func (s thriftFields) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+// thriftReader decodes thrift compact protocol structures
+type thriftReader struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func (r *thriftReader) errorf(format string, args ...interface{}) error {
+	return errors.InvalidValue.Newf("invalid parquet metadata at offset %d: "+format, append([]interface{}{r.pos}, args...)...)
+}
+
+func (r *thriftReader) byte() (byte, error) {
+
+	if r.pos >= len(r.data) {
+		return 0, r.errorf("unexpected end of data")
+	}
+	b := r.data[r.pos]
+	r.pos++
+
+	return b, nil
+}
+
+func (r *thriftReader) varint() (uint64, error) {
+
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, r.errorf("invalid varint")
+	}
+	r.pos += n
+
+	return v, nil
+}
+
+func (r *thriftReader) zigzag() (int64, error) {
+
+	v, err := r.varint()
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(v>>1) ^ -int64(v&1), nil
+}
+
+func (r *thriftReader) readStruct() (thriftFields, error) {
+
+	if r.depth++; r.depth > thriftMaxDepth {
+		return nil, r.errorf("structures nested too deeply")
+	}
+	defer func() { r.depth-- }()
+
+	s := make(thriftFields)
+	var lastID int16
+
+	for {
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return s, nil
+		}
+
+		typ := header & 0x0f
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			v, err := r.zigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		lastID = id
+
+		var value interface{}
+		switch typ {
+		case thriftBoolTrue:
+			value = true
+		case thriftBoolFalse:
+			value = false
+		default:
+			if value, err = r.readValue(typ); err != nil {
+				return nil, err
+			}
+		}
+		if value != nil {
+			s[id] = value
+		}
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) (interface{}, error) {
+
+	switch typ {
+	case thriftBoolTrue, thriftBoolFalse:
+		// ONLY IN CONTAINERS WHERE BOOLEANS TAKE A BYTE
+		b, err := r.byte()
+		return b == thriftBoolTrue, err
+
+	case thriftByte:
+		b, err := r.byte()
+		return int64(int8(b)), err
+
+	case thriftI16, thriftI32, thriftI64:
+		return r.zigzag()
+
+	case thriftDouble:
+		if len(r.data)-r.pos < 8 {
+			return nil, r.errorf("unexpected end of data")
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+		r.pos += 8
+		return v, nil
+
+	case thriftBinary:
+		n, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, r.errorf("binary length %d exceeds data", n)
+		}
+		v := r.data[r.pos : r.pos+int(n)]
+		r.pos += int(n)
+		return v, nil
+
+	case thriftList, thriftSet:
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(header >> 4)
+		if n == 15 {
+			if n, err = r.varint(); err != nil {
+				return nil, err
+			}
+		}
+		// EVERY ELEMENT TAKES AT LEAST A BYTE
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, r.errorf("list length %d exceeds data", n)
+		}
+		if r.depth++; r.depth > thriftMaxDepth {
+			return nil, r.errorf("structures nested too deeply")
+		}
+		defer func() { r.depth-- }()
+
+		values := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			v, err := r.readValue(header & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+
+	case thriftMap:
+		n, err := r.varint()
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, r.errorf("map size %d exceeds data", n)
+		}
+		types, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < n; i++ {
+			if _, err = r.readValue(types >> 4); err != nil {
+				return nil, err
+			}
+			if _, err = r.readValue(types & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+
+	case thriftStruct:
+		return r.readStruct()
+	}
+
+	return nil, r.errorf("unknown field type %d", typ)
+}
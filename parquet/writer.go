@@ -0,0 +1,309 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/bits"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// WriterOptions controls the layout of the files a Writer produces
+type WriterOptions struct {
+	RowGroupSize int // rows per row group (default: DefaultRowGroupSize)
+}
+
+// Writer writes rows to a parquet file. Rows are buffered until a row
+// group is complete. Close must be called to write the file footer
+type Writer struct {
+	w         io.Writer
+	offset    int64 // bytes written to w
+	options   WriterOptions
+	rows      []Row
+	numRows   int64
+	rowGroups []*thriftWriter // encoded row group metadata
+	err       error           // first write error
+	closed    bool
+}
+
+// columnChunk is the metadata of a column of a row group
+type columnChunk struct {
+	encodings        []int32
+	numValues        int64
+	size             int64
+	dataPageOffset   int64
+	dictionaryOffset int64 // 0 if the column has no dictionary page
+	stats            Statistics
+}
+
+func NewWriter(w io.Writer, options WriterOptions) *Writer {
+
+	if options.RowGroupSize < 1 {
+		options.RowGroupSize = DefaultRowGroupSize
+	}
+
+	return &Writer{w: w, options: options}
+}
+
+// Write adds row to the file
+func (w *Writer) Write(row Row) error {
+
+	if w.closed {
+		return errors.InvalidState.New("parquet writer is closed")
+	}
+	if w.err != nil {
+		return w.err
+	}
+
+	w.rows = append(w.rows, row)
+	if len(w.rows) >= w.options.RowGroupSize {
+		w.flushRowGroup()
+	}
+
+	return w.err
+}
+
+// Close writes the buffered rows and the file footer. The underlying
+// writer is not closed
+func (w *Writer) Close() error {
+
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+
+	if len(w.rows) > 0 {
+		w.flushRowGroup()
+	}
+	if w.write(nil); w.err != nil {
+		return w.err
+	}
+
+	meta := &thriftWriter{}
+	meta.i32(1, 2) // VERSION
+	meta.structList(2, len(columns)+1, func(i int) {
+		if i == 0 {
+			meta.string(4, "schema")
+			meta.i32(5, int32(len(columns)))
+			return
+		}
+		c := columns[i-1]
+		meta.i32(1, typeByteArray)
+		if c.optional {
+			meta.i32(3, repetitionOptional)
+		} else {
+			meta.i32(3, repetitionRequired)
+		}
+		meta.string(4, c.name)
+		meta.i32(6, convertedTypeUTF8)
+		meta.structField(10, func() { meta.structField(1, func() {}) }) // LOGICAL TYPE STRING
+	})
+	meta.i64(3, w.numRows)
+	meta.structList(4, len(w.rowGroups), func(i int) { meta.buf.Write(w.rowGroups[i].buf.Bytes()) })
+	meta.string(6, createdBy)
+	// VALUES ARE ORDERED BY UNSIGNED BYTE COMPARISON
+	meta.structList(7, len(columns), func(int) { meta.structField(1, func() {}) })
+	meta.buf.WriteByte(0)
+
+	footer := meta.buf.Bytes()
+	w.write(footer)
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	w.write(length[:])
+	w.write([]byte(magic))
+
+	return w.err
+}
+
+func (w *Writer) write(data []byte) {
+
+	if w.err != nil {
+		return
+	}
+	if w.offset == 0 {
+		if _, w.err = io.WriteString(w.w, magic); w.err != nil {
+			return
+		}
+		w.offset = int64(len(magic))
+	}
+
+	var n int
+	n, w.err = w.w.Write(data)
+	w.offset += int64(n)
+}
+
+// flushRowGroup writes the buffered rows as a row group
+func (w *Writer) flushRowGroup() {
+
+	w.write(nil)
+	start := w.offset
+
+	chunks := make([]*columnChunk, len(columns))
+	for i, c := range columns {
+		chunks[i] = w.writeColumnChunk(c)
+	}
+	if w.err != nil {
+		return
+	}
+
+	// ROW GROUP FIELDS. THE ENCLOSING STRUCT IS WRITTEN IN Close
+	rg := &thriftWriter{}
+	rg.structList(1, len(chunks), func(i int) {
+		chunk := chunks[i]
+		fileOffset := chunk.dataPageOffset
+		if chunk.dictionaryOffset > 0 {
+			fileOffset = chunk.dictionaryOffset
+		}
+		rg.i64(2, fileOffset)
+		rg.structField(3, func() {
+			rg.i32(1, typeByteArray)
+			rg.i32List(2, chunk.encodings)
+			rg.stringList(3, []string{columns[i].name})
+			rg.i32(4, codecUncompressed)
+			rg.i64(5, chunk.numValues)
+			rg.i64(6, chunk.size)
+			rg.i64(7, chunk.size)
+			rg.i64(9, chunk.dataPageOffset)
+			if chunk.dictionaryOffset > 0 {
+				rg.i64(11, chunk.dictionaryOffset)
+			}
+			rg.structField(12, func() {
+				rg.i64(3, chunk.stats.NullCount)
+				rg.i64(4, chunk.stats.DistinctCount)
+				if chunk.stats.DistinctCount > 0 {
+					rg.string(5, chunk.stats.Max)
+					rg.string(6, chunk.stats.Min)
+				}
+			})
+		})
+	})
+	rg.i64(2, w.offset-start)
+	rg.i64(3, int64(len(w.rows)))
+	rg.i64(5, start)
+	rg.i64(6, w.offset-start)
+
+	w.rowGroups = append(w.rowGroups, rg)
+	w.numRows += int64(len(w.rows))
+	w.rows = w.rows[:0]
+}
+
+// writeColumnChunk writes the values of column c of the buffered rows
+// as a dictionary page followed by a data page of dictionary indexes
+func (w *Writer) writeColumnChunk(c column) *columnChunk {
+
+	chunk := &columnChunk{numValues: int64(len(w.rows))}
+
+	dictionary := make(map[string]uint32)
+	var entries []string
+	var indexes []uint32
+	var levels []uint32
+
+	for i := range w.rows {
+		v := *c.value(&w.rows[i])
+		if c.optional && len(v) < 1 {
+			levels = append(levels, 0)
+			chunk.stats.NullCount++
+			continue
+		}
+		levels = append(levels, 1)
+
+		index, ok := dictionary[v]
+		if !ok {
+			index = uint32(len(entries))
+			dictionary[v] = index
+			entries = append(entries, v)
+			if len(entries) == 1 || v < chunk.stats.Min {
+				chunk.stats.Min = v
+			}
+			if len(entries) == 1 || v > chunk.stats.Max {
+				chunk.stats.Max = v
+			}
+		}
+		indexes = append(indexes, index)
+	}
+	chunk.stats.DistinctCount = int64(len(entries))
+
+	start := w.offset
+
+	// DICTIONARY PAGE
+	if len(entries) > 0 {
+		var page bytes.Buffer
+		for _, e := range entries {
+			var length [4]byte
+			binary.LittleEndian.PutUint32(length[:], uint32(len(e)))
+			page.Write(length[:])
+			page.WriteString(e)
+		}
+
+		header := &thriftWriter{}
+		header.i32(1, pageDictionary)
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(page.Len()))
+		header.structField(7, func() {
+			header.i32(1, int32(len(entries)))
+			header.i32(2, encodingPlain)
+		})
+		header.buf.WriteByte(0)
+
+		chunk.dictionaryOffset = w.offset
+		w.write(header.buf.Bytes())
+		w.write(page.Bytes())
+	}
+
+	// DATA PAGE: DEFINITION LEVELS OF OPTIONAL COLUMNS FOLLOWED BY
+	// THE DICTIONARY INDEXES OF NON-NULL VALUES
+	var page []byte
+	if c.optional {
+		encoded := appendHybrid(nil, levels, 1)
+		page = binary.LittleEndian.AppendUint32(page, uint32(len(encoded)))
+		page = append(page, encoded...)
+	}
+	encoding := int32(encodingPlain)
+	if len(entries) > 0 {
+		encoding = encodingRLEDictionary
+		bitWidth := bits.Len32(uint32(len(entries) - 1))
+		if bitWidth < 1 {
+			bitWidth = 1
+		}
+		page = append(page, byte(bitWidth))
+		page = appendHybrid(page, indexes, bitWidth)
+	}
+
+	header := &thriftWriter{}
+	header.i32(1, pageData)
+	header.i32(2, int32(len(page)))
+	header.i32(3, int32(len(page)))
+	header.structField(5, func() {
+		header.i32(1, int32(len(w.rows)))
+		header.i32(2, encoding)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+	})
+	header.buf.WriteByte(0)
+
+	chunk.dataPageOffset = w.offset
+	w.write(header.buf.Bytes())
+	w.write(page)
+
+	chunk.size = w.offset - start
+	chunk.encodings = []int32{encodingPlain, encodingRLE}
+	if len(entries) > 0 {
+		chunk.encodings = append(chunk.encodings, encodingRLEDictionary)
+	}
+
+	return chunk
+}
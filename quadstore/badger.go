@@ -0,0 +1,676 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package quadstore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	badger "github.com/dgraph-io/badger"
+	"github.com/piprate/json-gold/ld"
+)
+
+// key prefixes of a badger store
+const (
+	termKeyPrefix  = 't' // term -> term id
+	idKeyPrefix    = 'i' // term id -> term
+	docKeyPrefix   = 'd' // document -> document id
+	graphKeyPrefix = 'g' // document id, graph id -> number of quads
+	spoKeyPrefix   = 's' // document id, subject, predicate, object, graph
+	posKeyPrefix   = 'p' // document id, predicate, object, subject, graph
+	ospKeyPrefix   = 'o' // document id, object, subject, predicate, graph
+)
+
+// keys of the id sequences of a badger store
+var (
+	termSequenceKey = []byte("#term")
+	docSequenceKey  = []byte("#doc")
+)
+
+// id of the default graph in a badger store. Term ids start at 1
+const badgerDefaultGraph = 0
+
+// length of the keys of a permutation index: prefix, document id and
+// four term ids
+const quadKeyLength = 1 + 8*5
+
+// BadgerStore is a persistent quad store in a badger database. Quads
+// are added and removed as documents: named datasets whose blank nodes
+// are not shared with other documents. Terms are dictionary encoded
+// and the quads of each document are kept in subject-predicate-object,
+// predicate-object-subject and object-subject-predicate key orders so
+// patterns are answered by prefix scans. Views (see View) match the
+// union of a set of documents
+type BadgerStore struct {
+	mutex sync.Mutex // serializes changes to documents
+	db    *badger.DB
+	terms *badger.Sequence
+	docs  *badger.Sequence
+}
+
+// OpenBadgerStore opens (or creates) the badger store in directory dir.
+// Only one process can open a store at a time
+func OpenBadgerStore(dir string) (*BadgerStore, error) {
+
+	db, err := badger.Open(badger.LSMOnlyOptions(dir).
+		WithLogger(nil).
+		WithEventLogging(false).
+		WithTruncate(true).
+		WithMaxTableSize(16 << 20).
+		WithNumMemtables(2).
+		WithValueLogFileSize(64 << 20))
+	if err != nil {
+		if strings.Contains(err.Error(), "Cannot acquire directory lock") {
+			return nil, errors.TryAgain.Wrapf(err, "%s: store is in use", dir)
+		}
+		return nil, errors.Wrapf(err, "%s: failed to open store", dir)
+	}
+
+	store := &BadgerStore{db: db}
+
+	if store.terms, err = db.GetSequence(termSequenceKey, 1000); err == nil {
+		store.docs, err = db.GetSequence(docSequenceKey, 10)
+	}
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// Close releases the store. Views of the store can no longer be used
+func (b *BadgerStore) Close() error {
+
+	for _, seq := range []*badger.Sequence{b.terms, b.docs} {
+		if seq != nil {
+			seq.Release()
+		}
+	}
+
+	return b.db.Close()
+}
+
+func uint64Bytes(n uint64) []byte {
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, n)
+
+	return buf
+}
+
+// prefixKey returns prefix followed by the big endian encoding of ids
+func prefixKey(prefix byte, ids ...uint64) []byte {
+
+	key := make([]byte, 1, 1+8*len(ids))
+	key[0] = prefix
+	for _, id := range ids {
+		key = append(key, uint64Bytes(id)...)
+	}
+
+	return key
+}
+
+// get returns the value of key or nil if it is not present
+func get(txn *badger.Txn, key []byte) ([]byte, error) {
+
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return item.ValueCopy(nil)
+}
+
+// documentID returns the id of doc or 0 if it is not in the store
+func (b *BadgerStore) documentID(txn *badger.Txn, doc string) (uint64, error) {
+
+	value, err := get(txn, append([]byte{docKeyPrefix}, doc...))
+	if err != nil || value == nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(value), nil
+}
+
+// HasDocument returns true if doc is in the store
+func (b *BadgerStore) HasDocument(doc string) (bool, error) {
+
+	var id uint64
+	err := b.db.View(func(txn *badger.Txn) (err error) {
+		id, err = b.documentID(txn, doc)
+		return err
+	})
+
+	return id > 0, err
+}
+
+// Documents returns the sorted names of the documents in the store
+func (b *BadgerStore) Documents() ([]string, error) {
+
+	var docs []string
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: []byte{docKeyPrefix}})
+		defer it.Close()
+		for it.Rewind(); it.ValidForPrefix([]byte{docKeyPrefix}); it.Next() {
+			docs = append(docs, string(it.Item().Key()[1:]))
+		}
+		return nil
+	})
+	sort.Strings(docs)
+
+	return docs, err
+}
+
+// PutDocument adds the quads of dataset to the store as document doc
+// replacing any quads doc had
+func (b *BadgerStore) PutDocument(doc string, dataset *ld.RDFDataset) error {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err := b.deleteDocument(doc); err != nil {
+		return err
+	}
+
+	next, err := b.docs.Next()
+	if err != nil {
+		return err
+	}
+	docID := next + 1
+
+	wb := b.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	txn := b.db.NewTransaction(false)
+	defer txn.Discard()
+
+	ids := make(map[string]uint64)
+	intern := func(n ld.Node) (uint64, error) {
+
+		key := termKey(n)
+		if ld.IsBlankNode(n) {
+			// BLANK NODES ARE LOCAL TO THE DOCUMENT
+			key = fmt.Sprintf("_:d%d_%s", docID, strings.TrimPrefix(key, "_:"))
+		}
+		if id, ok := ids[key]; ok {
+			return id, nil
+		}
+
+		value, err := get(txn, append([]byte{termKeyPrefix}, key...))
+		if err != nil {
+			return 0, err
+		}
+		var id uint64
+		if value != nil {
+			id = binary.BigEndian.Uint64(value)
+		} else {
+			if id, err = b.terms.Next(); err != nil {
+				return 0, err
+			}
+			id++
+			if err = wb.Set(append([]byte{termKeyPrefix}, key...), uint64Bytes(id)); err != nil {
+				return 0, err
+			}
+			if err = wb.Set(prefixKey(idKeyPrefix, id), []byte(key)); err != nil {
+				return 0, err
+			}
+		}
+		ids[key] = id
+
+		return id, nil
+	}
+
+	graphs := make(map[uint64]uint64)
+	seen := make(map[[4]uint64]struct{})
+
+	for graph, quads := range dataset.Graphs {
+
+		var g uint64 = badgerDefaultGraph
+		if graph != DefaultGraph {
+			if g, err = intern(graphNode(graph)); err != nil {
+				return err
+			}
+		}
+
+		for _, quad := range quads {
+			if err := checkQuad(quad); err != nil {
+				return err
+			}
+			var q [4]uint64
+			q[3] = g
+			for i, n := range []ld.Node{quad.Subject, quad.Predicate, quad.Object} {
+				if q[i], err = intern(n); err != nil {
+					return err
+				}
+			}
+			if _, dup := seen[q]; dup {
+				continue
+			}
+			seen[q] = struct{}{}
+			graphs[g]++
+
+			s, p, o := q[0], q[1], q[2]
+			for _, key := range [][]byte{
+				prefixKey(spoKeyPrefix, docID, s, p, o, g),
+				prefixKey(posKeyPrefix, docID, p, o, s, g),
+				prefixKey(ospKeyPrefix, docID, o, s, p, g),
+			} {
+				if err := wb.Set(key, nil); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for g, n := range graphs {
+		if err := wb.Set(prefixKey(graphKeyPrefix, docID, g), uint64Bytes(n)); err != nil {
+			return err
+		}
+	}
+	if err := wb.Set(append([]byte{docKeyPrefix}, doc...), uint64Bytes(docID)); err != nil {
+		return err
+	}
+
+	return wb.Flush()
+}
+
+// DeleteDocument removes document doc and its quads from the store.
+// Deleting an absent document is a no-op. Terms stay in the dictionary
+func (b *BadgerStore) DeleteDocument(doc string) error {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.deleteDocument(doc)
+}
+
+func (b *BadgerStore) deleteDocument(doc string) error {
+
+	txn := b.db.NewTransaction(false)
+	defer txn.Discard()
+
+	docID, err := b.documentID(txn, doc)
+	if err != nil || docID == 0 {
+		return err
+	}
+
+	wb := b.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	// THE DOCUMENT KEY GOES FIRST SO AN INTERRUPTED DELETE LEAVES NO
+	// REFERENCE TO PARTIALLY DELETED QUADS
+	if err = wb.Delete(append([]byte{docKeyPrefix}, doc...)); err != nil {
+		return err
+	}
+
+	for _, prefix := range []byte{graphKeyPrefix, spoKeyPrefix, posKeyPrefix, ospKeyPrefix} {
+		keyPrefix := prefixKey(prefix, docID)
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: keyPrefix})
+		for it.Seek(keyPrefix); it.ValidForPrefix(keyPrefix); it.Next() {
+			if err = wb.Delete(it.Item().KeyCopy(nil)); err != nil {
+				it.Close()
+				return err
+			}
+		}
+		it.Close()
+	}
+
+	return wb.Flush()
+}
+
+// View returns a read-only store matching the union of the quads of
+// docs. The view can be used until the badger store is closed
+func (b *BadgerStore) View(docs []string) (Store, error) {
+
+	view := &badgerView{store: b}
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		for _, doc := range docs {
+			docID, err := b.documentID(txn, doc)
+			if err != nil {
+				return err
+			}
+			if docID == 0 {
+				return errors.NotFound.Newf("%s: document not found in store", doc)
+			}
+			view.docs = append(view.docs, docID)
+		}
+		return nil
+	})
+
+	return view, err
+}
+
+// badgerView is a read-only store over documents of a badger store
+type badgerView struct {
+	store *BadgerStore
+	docs  []uint64
+}
+
+// badgerPattern is a pattern resolved to term ids. Unbound subjects,
+// predicates and objects are 0
+type badgerPattern struct {
+	ids        [3]uint64
+	graph      uint64
+	graphBound bool
+}
+
+// resolve returns the term ids of pattern or false if one of its bound
+// terms is not in the dictionary, in which case nothing matches
+func (v *badgerView) resolve(txn *badger.Txn, pattern Pattern) (badgerPattern, bool, error) {
+
+	var resolved badgerPattern
+
+	lookup := func(key string) (uint64, bool, error) {
+		value, err := get(txn, append([]byte{termKeyPrefix}, key...))
+		if err != nil || value == nil {
+			return 0, false, err
+		}
+		return binary.BigEndian.Uint64(value), true, nil
+	}
+
+	for i, n := range []ld.Node{pattern.Subject, pattern.Predicate, pattern.Object} {
+		if n == nil {
+			continue
+		}
+		id, ok, err := lookup(termKey(n))
+		if !ok || err != nil {
+			return resolved, false, err
+		}
+		resolved.ids[i] = id
+	}
+
+	if len(pattern.Graph) > 0 {
+		resolved.graphBound = true
+		if pattern.Graph != DefaultGraph {
+			id, ok, err := lookup(pattern.Graph)
+			if !ok || err != nil {
+				return resolved, false, err
+			}
+			resolved.graph = id
+		}
+	}
+
+	return resolved, true, nil
+}
+
+func (v *badgerView) Add(quad *ld.Quad) error {
+	return errors.NotImplemented.New("store view is read-only")
+}
+
+func (v *badgerView) Remove(quad *ld.Quad) error {
+	return errors.NotImplemented.New("store view is read-only")
+}
+
+// Match returns the quads matching pattern grouped by document. The
+// iterator reads a consistent snapshot of the store and must be closed
+func (v *badgerView) Match(pattern Pattern) Iterator {
+
+	txn := v.store.db.NewTransaction(false)
+	it := &badgerIterator{view: v, txn: txn, nodes: make(map[uint64]ld.Node)}
+
+	resolved, ok, err := v.resolve(txn, pattern)
+	switch {
+	case err != nil:
+		it.err = err
+	case !ok:
+		it.doc = len(v.docs)
+	}
+	it.pattern = resolved
+
+	// CHOOSE THE INDEX WHOSE LEADING TERMS THE PATTERN BINDS
+	s, p, o := resolved.ids[0], resolved.ids[1], resolved.ids[2]
+	switch {
+	case s != 0 && (p != 0 || o == 0):
+		it.prefix, it.leading, it.order = spoKeyPrefix, []uint64{s, p, o}, [3]int{0, 1, 2}
+	case p != 0:
+		it.prefix, it.leading, it.order = posKeyPrefix, []uint64{p, o, s}, [3]int{2, 0, 1}
+	case o != 0:
+		it.prefix, it.leading, it.order = ospKeyPrefix, []uint64{o, s, p}, [3]int{1, 2, 0}
+	default:
+		it.prefix, it.leading, it.order = spoKeyPrefix, nil, [3]int{0, 1, 2}
+	}
+	for i, id := range it.leading {
+		if id == 0 {
+			it.leading = it.leading[:i]
+			break
+		}
+	}
+
+	return it
+}
+
+func (v *badgerView) Count(pattern Pattern) (int, error) {
+
+	if pattern.Subject == nil && pattern.Predicate == nil && pattern.Object == nil {
+		// COUNTED BY GRAPH
+		n := 0
+		err := v.graphs(func(g uint64, name string, count uint64) {
+			if len(pattern.Graph) < 1 || pattern.Graph == name {
+				n += int(count)
+			}
+		})
+		return n, err
+	}
+
+	it := v.Match(pattern)
+	defer it.Close()
+
+	n := 0
+	for {
+		_, err := it.Next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		n++
+	}
+}
+
+// graphs calls fn with the id, name and number of quads of each
+// graph of each document of the view
+func (v *badgerView) graphs(fn func(g uint64, name string, count uint64)) error {
+
+	return v.store.db.View(func(txn *badger.Txn) error {
+		names := make(map[uint64]string)
+		for _, docID := range v.docs {
+			keyPrefix := prefixKey(graphKeyPrefix, docID)
+			it := txn.NewIterator(badger.IteratorOptions{Prefix: keyPrefix, PrefetchValues: true})
+			for it.Seek(keyPrefix); it.ValidForPrefix(keyPrefix); it.Next() {
+				g := binary.BigEndian.Uint64(it.Item().Key()[9:])
+				value, err := it.Item().ValueCopy(nil)
+				if err != nil {
+					it.Close()
+					return err
+				}
+				name, ok := names[g]
+				if !ok {
+					if name, err = graphTermName(txn, g); err != nil {
+						it.Close()
+						return err
+					}
+					names[g] = name
+				}
+				fn(g, name, binary.BigEndian.Uint64(value))
+			}
+			it.Close()
+		}
+		return nil
+	})
+}
+
+// graphTermName returns the name of the graph with id g
+func graphTermName(txn *badger.Txn, g uint64) (string, error) {
+
+	if g == badgerDefaultGraph {
+		return DefaultGraph, nil
+	}
+
+	value, err := get(txn, prefixKey(idKeyPrefix, g))
+	if err != nil {
+		return "", err
+	}
+	if value == nil {
+		return "", errors.NotFound.Newf("graph term %d not found in store", g)
+	}
+
+	return string(value), nil
+}
+
+func (v *badgerView) Graphs() ([]string, error) {
+
+	seen := make(map[string]struct{})
+	err := v.graphs(func(g uint64, name string, count uint64) {
+		if count > 0 {
+			seen[name] = struct{}{}
+		}
+	})
+
+	graphs := make([]string, 0, len(seen))
+	for name := range seen {
+		graphs = append(graphs, name)
+	}
+	sort.Strings(graphs)
+
+	return graphs, err
+}
+
+func (v *badgerView) Close() error {
+	return nil
+}
+
+// badgerIterator scans one permutation index document by document
+type badgerIterator struct {
+	view    *badgerView
+	txn     *badger.Txn
+	it      *badger.Iterator
+	pattern badgerPattern
+	prefix  byte
+	leading []uint64 // BOUND LEADING TERMS OF THE INDEX
+	order   [3]int   // POSITIONS OF THE SUBJECT, PREDICATE AND OBJECT IN THE INDEX
+	doc     int
+	scan    []byte // KEY PREFIX OF THE CURRENT SCAN
+	nodes   map[uint64]ld.Node
+	err     error
+}
+
+func (it *badgerIterator) Next() (*ld.Quad, error) {
+
+	for it.err == nil {
+
+		if it.it == nil {
+			if it.doc >= len(it.view.docs) {
+				return nil, io.EOF
+			}
+			it.scan = prefixKey(it.prefix, append([]uint64{it.view.docs[it.doc]}, it.leading...)...)
+			it.it = it.txn.NewIterator(badger.IteratorOptions{Prefix: it.scan})
+			it.it.Seek(it.scan)
+		}
+
+		for ; it.it.ValidForPrefix(it.scan); it.it.Next() {
+			key := it.it.Item().Key()
+			if len(key) != quadKeyLength {
+				it.err = errors.InvalidValue.Newf("invalid index key length %d", len(key))
+				break
+			}
+			var terms [3]uint64
+			for i, pos := range it.order {
+				terms[i] = binary.BigEndian.Uint64(key[9+8*pos:])
+			}
+			g := binary.BigEndian.Uint64(key[9+8*3:])
+
+			if !it.matches(terms, g) {
+				continue
+			}
+			it.it.Next()
+
+			return it.quad(terms, g)
+		}
+
+		if it.err == nil {
+			it.it.Close()
+			it.it = nil
+			it.doc++
+		}
+	}
+
+	return nil, it.err
+}
+
+// matches returns true if the terms and graph of a key match the pattern
+func (it *badgerIterator) matches(terms [3]uint64, g uint64) bool {
+
+	for i, id := range it.pattern.ids {
+		if id != 0 && terms[i] != id {
+			return false
+		}
+	}
+
+	return !it.pattern.graphBound || it.pattern.graph == g
+}
+
+// quad returns the quad of the term ids
+func (it *badgerIterator) quad(terms [3]uint64, g uint64) (*ld.Quad, error) {
+
+	var nodes [3]ld.Node
+	for i, id := range terms {
+		n, ok := it.nodes[id]
+		if !ok {
+			value, err := get(it.txn, prefixKey(idKeyPrefix, id))
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				return nil, errors.NotFound.Newf("term %d not found in store", id)
+			}
+			if n, err = rdf.HDTNode(string(value)); err != nil {
+				return nil, err
+			}
+			it.nodes[id] = n
+		}
+		nodes[i] = n
+	}
+
+	graph, err := graphTermName(it.txn, g)
+	if err != nil {
+		return nil, err
+	}
+
+	return ld.NewQuad(nodes[0], nodes[1], nodes[2], graph), nil
+}
+
+func (it *badgerIterator) Close() error {
+
+	if it.it != nil {
+		it.it.Close()
+		it.it = nil
+	}
+	it.txn.Discard()
+	it.doc = len(it.view.docs)
+
+	return nil
+}
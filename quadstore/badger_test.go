@@ -0,0 +1,127 @@
+package quadstore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/piprate/json-gold/ld"
+)
+
+func TestBadgerStore(t *testing.T) {
+
+	dir := t.TempDir()
+
+	store, err := OpenBadgerStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { store.Close() }()
+
+	dataset, err := rdf.Parse(strings.NewReader(testQuads), rdf.NQuads, "", "test.nq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rdf.Parse(strings.NewReader(`_:carol <http://xmlns.com/foaf/0.1/name> "Carol" .
+<http://example.org/alice> <http://xmlns.com/foaf/0.1/name> "Alice" .
+`), rdf.NQuads, "", "other.nq")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = store.PutDocument("a", dataset); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.PutDocument("b", other); err != nil {
+		t.Fatal(err)
+	}
+
+	view, err := store.View([]string{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// THE VIEW OF A DOCUMENT MATCHES A MEMORY STORE WITH THE SAME QUADS
+	memory := testStore(t)
+	alice := ld.NewIRI("http://example.org/alice")
+	name := ld.NewIRI("http://xmlns.com/foaf/0.1/name")
+	for _, pattern := range []Pattern{
+		{},
+		{Subject: alice},
+		{Subject: alice, Object: ld.NewIRI("http://example.org/bob")},
+		{Predicate: name},
+		{Predicate: name, Object: ld.NewLiteral("Alice", rdf.XSDString, "")},
+		{Object: alice},
+		{Graph: DefaultGraph},
+		{Graph: "http://example.org/g"},
+		{Predicate: name, Graph: "http://example.org/g"},
+		{Subject: ld.NewIRI("http://example.org/dave")},
+	} {
+		expected := strings.Join(matches(t, memory, pattern), "\n")
+		actual := strings.ReplaceAll(strings.Join(matches(t, view, pattern), "\n"), "_:d1_", "_:")
+		if actual != expected {
+			t.Errorf("%+v: expected\n%s\ngot\n%s", pattern, expected, actual)
+		}
+		expectedCount, _ := memory.Count(pattern)
+		if n, err := view.Count(pattern); err != nil || n != expectedCount {
+			t.Errorf("%+v: expected count %d, got %d (%v)", pattern, expectedCount, n, err)
+		}
+	}
+	if graphs, err := view.Graphs(); err != nil || strings.Join(graphs, " ") != "@default _:d1_g http://example.org/g" {
+		t.Errorf("unexpected graphs %v (%v)", graphs, err)
+	}
+
+	// BLANK NODES ARE NOT SHARED BETWEEN DOCUMENTS
+	carol := Pattern{Predicate: name, Object: ld.NewLiteral("Carol", rdf.XSDString, "")}
+	if union, err := store.View([]string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	} else if lines := matches(t, union, carol); len(lines) != 2 || !strings.HasPrefix(lines[0], "_:d1_carol") ||
+		!strings.HasPrefix(lines[1], "_:d2_carol") {
+		t.Errorf("expected two distinct blank nodes, got %v", lines)
+	}
+
+	if err = view.Add(ld.NewQuad(alice, name, ld.NewLiteral("A", rdf.XSDString, ""), "")); errors.GetType(err) != errors.NotImplemented {
+		t.Errorf("expected views to be read-only, got %v", err)
+	}
+
+	// DOCUMENTS PERSIST AND CAN BE REPLACED AND DELETED
+	if err = store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if store, err = OpenBadgerStore(dir); err != nil {
+		t.Fatal(err)
+	}
+	if docs, err := store.Documents(); err != nil || strings.Join(docs, " ") != "a b" {
+		t.Errorf("expected documents a and b, got %v (%v)", docs, err)
+	}
+
+	if err = store.PutDocument("a", other); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.DeleteDocument("b"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.HasDocument("b"); err != nil || ok {
+		t.Errorf("expected document b to be deleted (%v)", err)
+	}
+	if _, err = store.View([]string{"b"}); errors.GetType(err) != errors.NotFound {
+		t.Errorf("expected NotFound viewing a deleted document, got %v", err)
+	}
+
+	if view, err = store.View([]string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := matches(t, view, Pattern{}); strings.Join(lines, "\n") !=
+		`_:d3_carol http://xmlns.com/foaf/0.1/name "Carol"
+http://example.org/alice http://xmlns.com/foaf/0.1/name "Alice"` {
+		t.Errorf("expected replaced document quads, got\n%s", strings.Join(lines, "\n"))
+	}
+	if n, err := view.Count(Pattern{Subject: alice}); err != nil || n != 1 {
+		t.Errorf("expected 1 quad of alice, got %d (%v)", n, err)
+	}
+
+	if _, err := OpenBadgerStore(dir); errors.GetType(err) != errors.TryAgain {
+		t.Errorf("expected TryAgain opening a store in use, got %v", err)
+	}
+}
@@ -0,0 +1,380 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package quadstore
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/piprate/json-gold/ld"
+)
+
+// termID identifies a term in the dictionary of a memory store.
+// anyTerm is never issued so it marks unbound pattern terms
+type termID uint64
+
+const (
+	anyTerm          termID = 0
+	defaultGraphTerm termID = 1
+)
+
+// trie is a permutation index. Each level maps the term at one
+// position of a quad to the next level. Leaves are nil
+type trie map[termID]trie
+
+// add inserts keys returning false if they were already present
+func (t trie) add(keys ...termID) bool {
+
+	last := len(keys) - 1
+	for _, k := range keys[:last] {
+		next, ok := t[k]
+		if !ok {
+			next = make(trie)
+			t[k] = next
+		}
+		t = next
+	}
+
+	if _, ok := t[keys[last]]; ok {
+		return false
+	}
+	t[keys[last]] = nil
+
+	return true
+}
+
+// remove deletes keys pruning levels left empty. It returns false if
+// keys were not present
+func (t trie) remove(keys ...termID) bool {
+
+	next, ok := t[keys[0]]
+	if !ok {
+		return false
+	}
+	if len(keys) > 1 {
+		if !next.remove(keys[1:]...) {
+			return false
+		}
+		if len(next) > 0 {
+			return true
+		}
+	}
+	delete(t, keys[0])
+
+	return true
+}
+
+// scan calls fn with the keys matching pattern until fn returns false.
+// fn must not retain keys
+func (t trie) scan(pattern []termID, keys []termID, fn func(keys []termID) bool) bool {
+
+	if len(pattern) < 1 {
+		return fn(keys)
+	}
+
+	if k := pattern[0]; k != anyTerm {
+		next, ok := t[k]
+		return !ok || next.scan(pattern[1:], append(keys, k), fn)
+	}
+
+	for k, next := range t {
+		if !next.scan(pattern[1:], append(keys, k), fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MemoryStore is a Store held in memory. Terms are kept in a
+// dictionary and quads in subject-predicate-object,
+// predicate-object-subject and object-subject-predicate indexes so
+// any pattern is answered from the index whose leading terms it binds.
+// It is safe for concurrent use
+type MemoryStore struct {
+	mutex  sync.RWMutex // guards all fields
+	ids    map[string]termID
+	terms  []ld.Node // BY termID - 1
+	spo    trie
+	pos    trie
+	osp    trie
+	graphs map[termID]int // NUMBER OF QUADS BY GRAPH
+	size   int
+}
+
+// NewMemoryStore returns an empty memory store
+func NewMemoryStore() *MemoryStore {
+
+	return &MemoryStore{
+		ids:    map[string]termID{DefaultGraph: defaultGraphTerm},
+		terms:  []ld.Node{nil},
+		spo:    make(trie),
+		pos:    make(trie),
+		osp:    make(trie),
+		graphs: make(map[termID]int),
+	}
+}
+
+// termKey returns the dictionary key of n
+func termKey(n ld.Node) string {
+	return rdf.HDTTerm(n)
+}
+
+// intern returns the id of n adding it to the dictionary if necessary
+func (m *MemoryStore) intern(n ld.Node) termID {
+
+	key := termKey(n)
+	if id, ok := m.ids[key]; ok {
+		return id
+	}
+
+	m.terms = append(m.terms, n)
+	id := termID(len(m.terms))
+	m.ids[key] = id
+
+	return id
+}
+
+// lookup returns the id of n or false if n is not in the dictionary.
+// A nil n is unbound
+func (m *MemoryStore) lookup(n ld.Node) (termID, bool) {
+
+	if n == nil {
+		return anyTerm, true
+	}
+
+	id, ok := m.ids[termKey(n)]
+
+	return id, ok
+}
+
+// graphNode returns the node naming graph in a quad
+func graphNode(graph string) ld.Node {
+
+	if strings.HasPrefix(graph, "_:") {
+		return ld.NewBlankNode(graph)
+	}
+
+	return ld.NewIRI(graph)
+}
+
+func checkQuad(quad *ld.Quad) error {
+
+	if quad == nil || quad.Subject == nil || quad.Predicate == nil || quad.Object == nil {
+		return errors.InvalidValue.New("quad must have a subject, predicate and object")
+	}
+
+	return nil
+}
+
+func (m *MemoryStore) Add(quad *ld.Quad) error {
+
+	if err := checkQuad(quad); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	g := defaultGraphTerm
+	if quad.Graph != nil {
+		g = m.intern(graphNode(quad.Graph.GetValue()))
+	}
+	s, p, o := m.intern(quad.Subject), m.intern(quad.Predicate), m.intern(quad.Object)
+
+	if !m.spo.add(s, p, o, g) {
+		return nil
+	}
+	m.pos.add(p, o, s, g)
+	m.osp.add(o, s, p, g)
+	m.graphs[g]++
+	m.size++
+
+	return nil
+}
+
+// Remove deletes quad from the store. Its terms stay in the dictionary
+func (m *MemoryStore) Remove(quad *ld.Quad) error {
+
+	if err := checkQuad(quad); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	g := defaultGraphTerm
+	if quad.Graph != nil {
+		g = m.ids[quad.Graph.GetValue()]
+	}
+	s, p, o := m.ids[termKey(quad.Subject)], m.ids[termKey(quad.Predicate)], m.ids[termKey(quad.Object)]
+	if s == anyTerm || p == anyTerm || o == anyTerm || g == anyTerm {
+		return nil
+	}
+
+	if !m.spo.remove(s, p, o, g) {
+		return nil
+	}
+	m.pos.remove(p, o, s, g)
+	m.osp.remove(o, s, p, g)
+	if m.graphs[g]--; m.graphs[g] < 1 {
+		delete(m.graphs, g)
+	}
+	m.size--
+
+	return nil
+}
+
+// resolve returns the term ids of pattern or false if one of its bound
+// terms is not in the dictionary, in which case nothing matches
+func (m *MemoryStore) resolve(pattern Pattern) ([4]termID, bool) {
+
+	var ids [4]termID
+	var ok bool
+
+	for i, n := range []ld.Node{pattern.Subject, pattern.Predicate, pattern.Object} {
+		if ids[i], ok = m.lookup(n); !ok {
+			return ids, false
+		}
+	}
+	if len(pattern.Graph) > 0 {
+		if ids[3], ok = m.ids[pattern.Graph]; !ok {
+			return ids, false
+		}
+	}
+
+	return ids, true
+}
+
+// walk calls fn with the subject, predicate, object and graph ids of
+// the quads matching the resolved pattern until fn returns false
+func (m *MemoryStore) walk(pattern [4]termID, fn func(s, p, o, g termID) bool) {
+
+	s, p, o, g := pattern[0], pattern[1], pattern[2], pattern[3]
+	keys := make([]termID, 0, 4)
+
+	switch {
+	case s != anyTerm && (p != anyTerm || o == anyTerm):
+		m.spo.scan([]termID{s, p, o, g}, keys, func(k []termID) bool { return fn(k[0], k[1], k[2], k[3]) })
+	case p != anyTerm:
+		m.pos.scan([]termID{p, o, s, g}, keys, func(k []termID) bool { return fn(k[2], k[0], k[1], k[3]) })
+	case o != anyTerm:
+		m.osp.scan([]termID{o, s, p, g}, keys, func(k []termID) bool { return fn(k[1], k[2], k[0], k[3]) })
+	default:
+		m.spo.scan([]termID{s, p, o, g}, keys, func(k []termID) bool { return fn(k[0], k[1], k[2], k[3]) })
+	}
+}
+
+// Match returns the quads matching pattern in no particular order.
+// The matches are collected when Match is called so the store may be
+// modified while iterating
+func (m *MemoryStore) Match(pattern Pattern) Iterator {
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	it := &memoryIterator{store: m}
+
+	if ids, ok := m.resolve(pattern); ok {
+		m.walk(ids, func(s, p, o, g termID) bool {
+			it.quads = append(it.quads, [4]termID{s, p, o, g})
+			return true
+		})
+	}
+
+	return it
+}
+
+func (m *MemoryStore) Count(pattern Pattern) (int, error) {
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	ids, ok := m.resolve(pattern)
+	switch {
+	case !ok:
+		return 0, nil
+	case ids == [4]termID{}:
+		return m.size, nil
+	case ids == [4]termID{anyTerm, anyTerm, anyTerm, ids[3]}:
+		return m.graphs[ids[3]], nil
+	}
+
+	n := 0
+	m.walk(ids, func(s, p, o, g termID) bool {
+		n++
+		return true
+	})
+
+	return n, nil
+}
+
+func (m *MemoryStore) Graphs() ([]string, error) {
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	graphs := make([]string, 0, len(m.graphs))
+	for g := range m.graphs {
+		graphs = append(graphs, m.graphName(g))
+	}
+	sort.Strings(graphs)
+
+	return graphs, nil
+}
+
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// graphName returns the name of the graph with id g
+func (m *MemoryStore) graphName(g termID) string {
+
+	if g == defaultGraphTerm {
+		return DefaultGraph
+	}
+
+	return m.terms[g-1].GetValue()
+}
+
+// memoryIterator returns the quads collected by MemoryStore.Match
+type memoryIterator struct {
+	store *MemoryStore
+	quads [][4]termID
+	next  int
+}
+
+func (it *memoryIterator) Next() (*ld.Quad, error) {
+
+	if it.next >= len(it.quads) {
+		return nil, io.EOF
+	}
+	ids := it.quads[it.next]
+	it.next++
+
+	it.store.mutex.RLock()
+	defer it.store.mutex.RUnlock()
+
+	return ld.NewQuad(it.store.terms[ids[0]-1], it.store.terms[ids[1]-1], it.store.terms[ids[2]-1],
+		it.store.graphName(ids[3])), nil
+}
+
+func (it *memoryIterator) Close() error {
+	it.quads = nil
+	return nil
+}
@@ -0,0 +1,190 @@
+package quadstore
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/piprate/json-gold/ld"
+)
+
+const testQuads = `<http://example.org/alice> <http://xmlns.com/foaf/0.1/name> "Alice" .
+<http://example.org/alice> <http://xmlns.com/foaf/0.1/knows> <http://example.org/bob> .
+<http://example.org/alice> <http://xmlns.com/foaf/0.1/knows> _:carol .
+<http://example.org/bob> <http://xmlns.com/foaf/0.1/name> "Bob"@en .
+<http://example.org/bob> <http://xmlns.com/foaf/0.1/knows> <http://example.org/alice> <http://example.org/g> .
+_:carol <http://xmlns.com/foaf/0.1/age> "42"^^<http://www.w3.org/2001/XMLSchema#integer> <http://example.org/g> .
+_:carol <http://xmlns.com/foaf/0.1/name> "Carol" _:g .
+`
+
+func testStore(t *testing.T) *MemoryStore {
+
+	t.Helper()
+
+	dataset, err := rdf.Parse(strings.NewReader(testQuads), rdf.NQuads, "", "test.nq")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewMemoryStore()
+	if err = AddDataset(store, dataset); err != nil {
+		t.Fatal(err)
+	}
+	// DUPLICATES ARE IGNORED
+	if err = AddDataset(store, dataset); err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+// matches returns the sorted N-Quads of the quads of store matching pattern
+func matches(t *testing.T, store Store, pattern Pattern) []string {
+
+	t.Helper()
+
+	it := store.Match(pattern)
+	defer it.Close()
+
+	var lines []string
+	for {
+		quad, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		line := rdf.HDTTerm(quad.Subject) + " " + rdf.HDTTerm(quad.Predicate) + " " + rdf.HDTTerm(quad.Object)
+		if quad.Graph != nil {
+			line += " " + quad.Graph.GetValue()
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+
+	return lines
+}
+
+func TestMemoryStoreMatch(t *testing.T) {
+
+	store := testStore(t)
+
+	alice, bob := ld.NewIRI("http://example.org/alice"), ld.NewIRI("http://example.org/bob")
+	knows, name := ld.NewIRI("http://xmlns.com/foaf/0.1/knows"), ld.NewIRI("http://xmlns.com/foaf/0.1/name")
+
+	for _, test := range []struct {
+		name     string
+		pattern  Pattern
+		expected []string
+	}{
+		{"subject", Pattern{Subject: alice}, []string{
+			`http://example.org/alice http://xmlns.com/foaf/0.1/knows _:carol`,
+			`http://example.org/alice http://xmlns.com/foaf/0.1/knows http://example.org/bob`,
+			`http://example.org/alice http://xmlns.com/foaf/0.1/name "Alice"`}},
+		{"subject predicate", Pattern{Subject: bob, Predicate: knows}, []string{
+			`http://example.org/bob http://xmlns.com/foaf/0.1/knows http://example.org/alice http://example.org/g`}},
+		{"subject object", Pattern{Subject: alice, Object: bob}, []string{
+			`http://example.org/alice http://xmlns.com/foaf/0.1/knows http://example.org/bob`}},
+		{"predicate", Pattern{Predicate: name}, []string{
+			`_:carol http://xmlns.com/foaf/0.1/name "Carol" _:g`,
+			`http://example.org/alice http://xmlns.com/foaf/0.1/name "Alice"`,
+			`http://example.org/bob http://xmlns.com/foaf/0.1/name "Bob"@en`}},
+		{"predicate object", Pattern{Predicate: name, Object: ld.NewLiteral("Alice", rdf.XSDString, "")}, []string{
+			`http://example.org/alice http://xmlns.com/foaf/0.1/name "Alice"`}},
+		{"object", Pattern{Object: alice}, []string{
+			`http://example.org/bob http://xmlns.com/foaf/0.1/knows http://example.org/alice http://example.org/g`}},
+		{"typed literal", Pattern{Object: ld.NewLiteral("42", rdf.XSDInteger, "")}, []string{
+			`_:carol http://xmlns.com/foaf/0.1/age "42"^^<http://www.w3.org/2001/XMLSchema#integer> http://example.org/g`}},
+		{"bound", Pattern{Subject: alice, Predicate: knows, Object: bob, Graph: DefaultGraph}, []string{
+			`http://example.org/alice http://xmlns.com/foaf/0.1/knows http://example.org/bob`}},
+		{"graph", Pattern{Graph: "http://example.org/g"}, []string{
+			`_:carol http://xmlns.com/foaf/0.1/age "42"^^<http://www.w3.org/2001/XMLSchema#integer> http://example.org/g`,
+			`http://example.org/bob http://xmlns.com/foaf/0.1/knows http://example.org/alice http://example.org/g`}},
+		{"blank node graph", Pattern{Subject: ld.NewBlankNode("_:carol"), Graph: "_:g"}, []string{
+			`_:carol http://xmlns.com/foaf/0.1/name "Carol" _:g`}},
+		{"other graph", Pattern{Subject: alice, Graph: "http://example.org/g"}, nil},
+		{"unknown term", Pattern{Subject: ld.NewIRI("http://example.org/dave")}, nil},
+		{"language", Pattern{Object: ld.NewLiteral("Bob", rdf.XSDString, "")}, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			actual := matches(t, store, test.pattern)
+			if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("expected\n%s\ngot\n%s", strings.Join(test.expected, "\n"), strings.Join(actual, "\n"))
+			}
+
+			n, err := store.Count(test.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(test.expected) {
+				t.Errorf("expected count %d, got %d", len(test.expected), n)
+			}
+		})
+	}
+
+	if n, err := store.Count(Pattern{}); err != nil || n != 7 {
+		t.Errorf("expected 7 quads, got %d (%v)", n, err)
+	}
+	if n, err := store.Count(Pattern{Graph: DefaultGraph}); err != nil || n != 4 {
+		t.Errorf("expected 4 quads in the default graph, got %d (%v)", n, err)
+	}
+	if graphs, err := store.Graphs(); err != nil || strings.Join(graphs, " ") != "@default _:g http://example.org/g" {
+		t.Errorf("unexpected graphs %v (%v)", graphs, err)
+	}
+}
+
+func TestMemoryStoreRemove(t *testing.T) {
+
+	store := testStore(t)
+
+	// THE MATCHES OF AN ITERATOR ARE NOT AFFECTED BY CHANGES TO THE STORE
+	it := store.Match(Pattern{Graph: "http://example.org/g"})
+	defer it.Close()
+
+	dataset, err := Dataset(store, Pattern{Graph: "http://example.org/g"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, quad := range dataset.Graphs["http://example.org/g"] {
+		if err := store.Remove(quad); err != nil {
+			t.Fatal(err)
+		}
+		// REMOVING TWICE IS A NO-OP
+		if err := store.Remove(quad); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Remove(ld.NewQuad(ld.NewIRI("http://example.org/dave"), ld.NewIRI("http://xmlns.com/foaf/0.1/name"),
+		ld.NewLiteral("Dave", rdf.XSDString, ""), "")); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := store.Count(Pattern{}); err != nil || n != 5 {
+		t.Errorf("expected 5 quads after removal, got %d (%v)", n, err)
+	}
+	if n, err := store.Count(Pattern{Subject: ld.NewBlankNode("_:carol")}); err != nil || n != 1 {
+		t.Errorf("expected 1 quad of _:carol after removal, got %d (%v)", n, err)
+	}
+	if graphs, err := store.Graphs(); err != nil || strings.Join(graphs, " ") != "@default _:g" {
+		t.Errorf("unexpected graphs %v (%v)", graphs, err)
+	}
+
+	n := 0
+	for _, err := it.Next(); err != io.EOF; _, err = it.Next() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected iterator to return 2 quads, got %d", n)
+	}
+
+	if err := store.Add(&ld.Quad{Subject: ld.NewIRI("http://example.org/alice")}); err == nil {
+		t.Errorf("expected an error adding an incomplete quad")
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package quadstore holds RDF statements for pattern matching. Stores
+// are filled from the datasets of the JSON-LD processor and answer
+// which quads match a pattern of bound and unbound terms
+package quadstore
+
+import (
+	"io"
+	"sort"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// DefaultGraph names the default graph in patterns and in the graphs of a store
+const DefaultGraph = "@default"
+
+// Pattern selects quads. Nil terms and an empty graph match any
+// term or graph
+type Pattern struct {
+	Subject   ld.Node
+	Predicate ld.Node
+	Object    ld.Node
+	Graph     string // graph name or DefaultGraph
+}
+
+// Iterator returns the quads matching a pattern. Next returns io.EOF
+// once all quads have been returned
+type Iterator interface {
+	Next() (*ld.Quad, error)
+	Close() error
+}
+
+// Store is a set of quads indexed for pattern matching
+type Store interface {
+	// ADD quad TO THE STORE. ADDING A QUAD ALREADY PRESENT IS A NO-OP
+	Add(quad *ld.Quad) error
+	// REMOVE quad FROM THE STORE. REMOVING AN ABSENT QUAD IS A NO-OP
+	Remove(quad *ld.Quad) error
+	// RETURN THE QUADS MATCHING pattern
+	Match(pattern Pattern) Iterator
+	// RETURN THE NUMBER OF QUADS MATCHING pattern
+	Count(pattern Pattern) (int, error)
+	// RETURN THE SORTED NAMES OF THE NON-EMPTY GRAPHS
+	Graphs() ([]string, error)
+	// RELEASE THE RESOURCES HELD BY THE STORE
+	Close() error
+}
+
+// AddDataset adds the quads of dataset to s
+func AddDataset(s Store, dataset *ld.RDFDataset) error {
+
+	graphs := make([]string, 0, len(dataset.Graphs))
+	for graph := range dataset.Graphs {
+		graphs = append(graphs, graph)
+	}
+	sort.Strings(graphs)
+
+	for _, graph := range graphs {
+		for _, quad := range dataset.Graphs[graph] {
+			if err := s.Add(ld.NewQuad(quad.Subject, quad.Predicate, quad.Object, graph)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Dataset returns the quads of s matching pattern as a dataset
+func Dataset(s Store, pattern Pattern) (*ld.RDFDataset, error) {
+
+	it := s.Match(pattern)
+	defer it.Close()
+
+	dataset := ld.NewRDFDataset()
+	for {
+		quad, err := it.Next()
+		if err == io.EOF {
+			return dataset, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		graph := GraphName(quad)
+		dataset.Graphs[graph] = append(dataset.Graphs[graph], quad)
+	}
+}
+
+// GraphName returns the name of the graph of quad
+func GraphName(quad *ld.Quad) string {
+
+	if quad.Graph == nil {
+		return DefaultGraph
+	}
+
+	return quad.Graph.GetValue()
+}
@@ -0,0 +1,435 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package rdf
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+// MaxCanonicalizationCalls bounds the work (hashing steps and
+// permutations tried) spent on blank nodes that cannot be told apart
+// by their direct neighbourhood. Datasets
+// exceeding it (e.g. crafted "poison" graphs) fail to canonicalize
+const MaxCanonicalizationCalls = 1 << 16
+
+// Canonicalize returns the canonical N-Quads of dataset as specified
+// by RDF Dataset Canonicalization (RDFC-1.0, https://www.w3.org/TR/rdf-canon/),
+// which is URDNA2015 with SHA-256. Blank nodes are labeled _:c14n<n>
+// and lines are sorted, so isomorphic datasets produce the same output
+func Canonicalize(dataset *ld.RDFDataset) (string, error) {
+
+	c := newCanonicalizer(dataset)
+	if err := c.label(); err != nil {
+		return "", err
+	}
+
+	lines := make([]string, 0, len(c.quads))
+	seen := make(map[string]struct{}, len(c.quads))
+	for _, q := range c.quads {
+		line := q.nquad(func(label string) string { return c.canonical.issued[label] })
+		if _, dup := seen[line]; !dup {
+			seen[line] = struct{}{}
+			lines = append(lines, line)
+		}
+	}
+	sort.Strings(lines)
+
+	return strings.Join(lines, ""), nil
+}
+
+// CanonicalHash returns the hex encoded SHA-256 hash of the canonical
+// N-Quads of dataset
+func CanonicalHash(dataset *ld.RDFDataset) (string, error) {
+
+	nquads, err := Canonicalize(dataset)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(nquads))), nil
+}
+
+// canonicalQuad is a quad with blank nodes held by their labels
+type canonicalQuad struct {
+	terms [4]ld.Node // subject, predicate, object, graph (nil for the default graph)
+}
+
+// nquad serializes the quad as a canonical N-Quads line with blank
+// node labels mapped by label
+func (q *canonicalQuad) nquad(label func(string) string) string {
+
+	var b strings.Builder
+	for i, term := range q.terms {
+		if term == nil {
+			continue
+		}
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if bn, ok := term.(*ld.BlankNode); ok {
+			b.WriteString("_:" + label(bn.Attribute))
+			continue
+		}
+		b.WriteString(canonicalTerm(term))
+	}
+	b.WriteString(" .\n")
+
+	return b.String()
+}
+
+// canonicalTerm returns the canonical N-Quads form of an IRI or literal
+func canonicalTerm(n ld.Node) string {
+
+	literal, ok := n.(*ld.Literal)
+	if !ok {
+		return "<" + n.GetValue() + ">"
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range literal.Value {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\b':
+			b.WriteString(`\b`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+
+	switch {
+	case len(literal.Language) > 0:
+		b.WriteString("@" + literal.Language)
+	case literal.Datatype != "" && literal.Datatype != XSDString:
+		b.WriteString("^^<" + literal.Datatype + ">")
+	}
+
+	return b.String()
+}
+
+// identifierIssuer issues identifiers with a prefix and a counter
+type identifierIssuer struct {
+	prefix string
+	issued map[string]string // existing -> issued identifier
+	order  []string          // existing identifiers in issue order
+}
+
+func newIdentifierIssuer(prefix string) *identifierIssuer {
+	return &identifierIssuer{prefix: prefix, issued: make(map[string]string)}
+}
+
+func (i *identifierIssuer) issue(existing string) string {
+
+	if id, ok := i.issued[existing]; ok {
+		return id
+	}
+	id := fmt.Sprintf("%s%d", i.prefix, len(i.order))
+	i.issued[existing] = id
+	i.order = append(i.order, existing)
+
+	return id
+}
+
+func (i *identifierIssuer) copy() *identifierIssuer {
+
+	c := &identifierIssuer{prefix: i.prefix, issued: make(map[string]string, len(i.issued)),
+		order: append([]string(nil), i.order...)}
+	for k, v := range i.issued {
+		c.issued[k] = v
+	}
+
+	return c
+}
+
+type canonicalizer struct {
+	quads     []*canonicalQuad
+	blanks    map[string][]*canonicalQuad // blank node label -> quads it occurs in
+	canonical *identifierIssuer
+	firstHash map[string]string // memoized first degree hashes
+	calls     int               // hash n-degree quads invocations
+}
+
+func newCanonicalizer(dataset *ld.RDFDataset) *canonicalizer {
+
+	c := &canonicalizer{
+		blanks:    make(map[string][]*canonicalQuad),
+		canonical: newIdentifierIssuer("c14n"),
+		firstHash: make(map[string]string),
+	}
+
+	graphs := make([]string, 0, len(dataset.Graphs))
+	for graph := range dataset.Graphs {
+		graphs = append(graphs, graph)
+	}
+	sort.Strings(graphs)
+
+	for _, graph := range graphs {
+		var name ld.Node
+		if graph != defaultGraph && graph != "" {
+			name = graphNode(graph)
+		}
+		for _, quad := range dataset.Graphs[graph] {
+			q := &canonicalQuad{terms: [4]ld.Node{quad.Subject, quad.Predicate, quad.Object, name}}
+			c.quads = append(c.quads, q)
+			// A QUAD IS LISTED ONCE PER BLANK NODE, WHATEVER THE NUMBER OF POSITIONS IT TAKES
+			for _, term := range q.terms {
+				if bn, ok := term.(*ld.BlankNode); ok {
+					if quads := c.blanks[bn.Attribute]; len(quads) < 1 || quads[len(quads)-1] != q {
+						c.blanks[bn.Attribute] = append(quads, q)
+					}
+				}
+			}
+		}
+	}
+
+	return c
+}
+
+// label issues canonical labels to all blank nodes
+func (c *canonicalizer) label() error {
+
+	// GROUP BLANK NODES BY FIRST DEGREE HASH
+	byHash := make(map[string][]string)
+	for label := range c.blanks {
+		h := c.hashFirstDegree(label)
+		byHash[h] = append(byHash[h], label)
+	}
+	hashes := make([]string, 0, len(byHash))
+	for h := range byHash {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes)
+
+	// UNIQUE HASHES ARE LABELED FIRST
+	var shared []string
+	for _, h := range hashes {
+		if len(byHash[h]) == 1 {
+			c.canonical.issue(byHash[h][0])
+		} else {
+			shared = append(shared, h)
+		}
+	}
+
+	// THE OTHERS BY THEIR N-DEGREE HASHES
+	for _, h := range shared {
+		type result struct {
+			hash   string
+			issuer *identifierIssuer
+		}
+		var results []result
+		labels := append([]string(nil), byHash[h]...)
+		sort.Strings(labels)
+		for _, label := range labels {
+			if _, done := c.canonical.issued[label]; done {
+				continue
+			}
+			issuer := newIdentifierIssuer("b")
+			issuer.issue(label)
+			hash, issuer, err := c.hashNDegree(label, issuer)
+			if err != nil {
+				return err
+			}
+			results = append(results, result{hash, issuer})
+		}
+		sort.SliceStable(results, func(i, j int) bool { return results[i].hash < results[j].hash })
+		for _, r := range results {
+			for _, existing := range r.issuer.order {
+				c.canonical.issue(existing)
+			}
+		}
+	}
+
+	return nil
+}
+
+// hashFirstDegree hashes the quads of a blank node with the node
+// itself labeled _:a and other blank nodes _:z
+func (c *canonicalizer) hashFirstDegree(label string) string {
+
+	if h, ok := c.firstHash[label]; ok {
+		return h
+	}
+
+	nquads := make([]string, 0, len(c.blanks[label]))
+	for _, q := range c.blanks[label] {
+		nquads = append(nquads, q.nquad(func(l string) string {
+			if l == label {
+				return "a"
+			}
+			return "z"
+		}))
+	}
+	sort.Strings(nquads)
+
+	h := sha256hex(strings.Join(nquads, ""))
+	c.firstHash[label] = h
+
+	return h
+}
+
+// hashRelated hashes a blank node related to another by quad in
+// position s, o or g
+func (c *canonicalizer) hashRelated(related string, quad *canonicalQuad, issuer *identifierIssuer, position string) string {
+
+	var id string
+	if canonical, ok := c.canonical.issued[related]; ok {
+		id = "_:" + canonical
+	} else if temporary, ok := issuer.issued[related]; ok {
+		id = "_:" + temporary
+	} else {
+		id = c.hashFirstDegree(related)
+	}
+
+	input := position
+	if position != "g" {
+		input += "<" + quad.terms[1].GetValue() + ">"
+	}
+
+	return sha256hex(input + id)
+}
+
+// hashNDegree hashes a blank node by the paths to the blank nodes
+// related to it
+func (c *canonicalizer) hashNDegree(label string, issuer *identifierIssuer) (string, *identifierIssuer, error) {
+
+	if c.calls++; c.calls > MaxCanonicalizationCalls {
+		return "", nil, errors.OutOfRange.Newf("dataset canonicalization exceeded %d steps", MaxCanonicalizationCalls)
+	}
+
+	// RELATED HASH -> RELATED BLANK NODES
+	related := make(map[string][]string)
+	for _, q := range c.blanks[label] {
+		for i, position := range []string{"s", "", "o", "g"} {
+			bn, ok := q.terms[i].(*ld.BlankNode)
+			if !ok || len(position) < 1 || bn.Attribute == label {
+				continue
+			}
+			h := c.hashRelated(bn.Attribute, q, issuer, position)
+			related[h] = append(related[h], bn.Attribute)
+		}
+	}
+	hashes := make([]string, 0, len(related))
+	for h := range related {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes)
+
+	var data strings.Builder
+	for _, h := range hashes {
+		data.WriteString(h)
+
+		var chosenPath string
+		var chosenIssuer *identifierIssuer
+
+		permutation := append([]string(nil), related[h]...)
+		sort.Strings(permutation)
+		for more := true; more; more = nextPermutation(permutation) {
+			if c.calls++; c.calls > MaxCanonicalizationCalls {
+				return "", nil, errors.OutOfRange.Newf("dataset canonicalization exceeded %d steps", MaxCanonicalizationCalls)
+			}
+
+			issuerCopy := issuer.copy()
+			var path strings.Builder
+			var recursion []string
+			skip := false
+
+			for _, r := range permutation {
+				if canonical, ok := c.canonical.issued[r]; ok {
+					path.WriteString("_:" + canonical)
+				} else {
+					if _, ok := issuerCopy.issued[r]; !ok {
+						recursion = append(recursion, r)
+					}
+					path.WriteString("_:" + issuerCopy.issue(r))
+				}
+				if len(chosenPath) > 0 && path.Len() >= len(chosenPath) && path.String() > chosenPath {
+					skip = true
+					break
+				}
+			}
+
+			for i := 0; !skip && i < len(recursion); i++ {
+				r := recursion[i]
+				hash, resultIssuer, err := c.hashNDegree(r, issuerCopy)
+				if err != nil {
+					return "", nil, err
+				}
+				path.WriteString("_:" + issuerCopy.issue(r))
+				path.WriteString("<" + hash + ">")
+				issuerCopy = resultIssuer
+				if len(chosenPath) > 0 && path.Len() >= len(chosenPath) && path.String() > chosenPath {
+					skip = true
+				}
+			}
+
+			if !skip && (len(chosenPath) < 1 || path.String() < chosenPath) {
+				chosenPath = path.String()
+				chosenIssuer = issuerCopy
+			}
+		}
+
+		data.WriteString(chosenPath)
+		issuer = chosenIssuer
+	}
+
+	return sha256hex(data.String()), issuer, nil
+}
+
+// nextPermutation rearranges items into the lexicographically next
+// permutation, returning false once items are in descending order
+func nextPermutation(items []string) bool {
+
+	i := len(items) - 2
+	for i >= 0 && items[i] >= items[i+1] {
+		i--
+	}
+	if i < 0 {
+		return false
+	}
+
+	j := len(items) - 1
+	for items[j] <= items[i] {
+		j--
+	}
+	items[i], items[j] = items[j], items[i]
+	for l, r := i+1, len(items)-1; l < r; l, r = l+1, r-1 {
+		items[l], items[r] = items[r], items[l]
+	}
+
+	return true
+}
+
+func sha256hex(s string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+}
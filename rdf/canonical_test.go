@@ -0,0 +1,209 @@
+package rdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+func parseNQuads(t *testing.T, input string) *ld.RDFDataset {
+
+	dataset, err := Parse(strings.NewReader(input), NQuads, "", "input.nq")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dataset
+}
+
+func TestCanonicalize(t *testing.T) {
+
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"no blank nodes",
+			`<http://example.org/b> <http://example.org/p> "x" .
+<http://example.org/a> <http://example.org/p> "y"^^<http://www.w3.org/2001/XMLSchema#string> .
+<http://example.org/a> <http://example.org/p> "y" .
+`,
+			`<http://example.org/a> <http://example.org/p> "y" .
+<http://example.org/b> <http://example.org/p> "x" .
+`},
+		{"unique first degree hashes",
+			`_:x <http://example.org/p> _:y .
+_:y <http://example.org/q> "1" .
+`,
+			`_:c14n0 <http://example.org/q> "1" .
+_:c14n1 <http://example.org/p> _:c14n0 .
+`},
+		{"named graph",
+			`_:e0 <http://example.org/p> "x" _:g .
+`,
+			`_:c14n0 <http://example.org/p> "x" _:c14n1 .
+`},
+	}
+
+	for _, c := range cases {
+		actual, err := Canonicalize(parseNQuads(t, c.input))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if actual != c.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestCanonicalizeEscaping(t *testing.T) {
+
+	dataset := ld.NewRDFDataset()
+	dataset.Graphs[defaultGraph] = []*ld.Quad{
+		ld.NewQuad(ld.NewIRI("http://example.org/a"), ld.NewIRI("http://example.org/p"),
+			ld.NewLiteral("tab\tquote\"back\\nl\ncr\rdel\x7fbs\bff\fvt\v", ld.RDFLangString, "en"), defaultGraph),
+	}
+
+	actual, err := Canonicalize(dataset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<http://example.org/a> <http://example.org/p> "tab\tquote\"back\\nl\ncr\rdel\u007Fbs\bff\fvt\u000B"@en .` + "\n"
+	if actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+}
+
+// THE RESULT MUST NOT DEPEND ON BLANK NODE LABELS OR STATEMENT ORDER
+func TestCanonicalizeIsomorphic(t *testing.T) {
+
+	// TWO INDISTINGUISHABLE CYCLES NEED N-DEGREE HASHING
+	inputs := []string{
+		`_:a <http://example.org/next> _:b .
+_:b <http://example.org/next> _:c .
+_:c <http://example.org/next> _:a .
+_:d <http://example.org/next> _:e .
+_:e <http://example.org/next> _:d .
+_:a <http://example.org/name> "x" .
+`,
+		`_:q <http://example.org/next> _:p .
+_:z3 <http://example.org/next> _:z1 .
+_:p <http://example.org/next> _:q .
+_:z1 <http://example.org/name> "x" .
+_:z2 <http://example.org/next> _:z3 .
+_:z1 <http://example.org/next> _:z2 .
+`,
+	}
+
+	var expected string
+	for i, input := range inputs {
+		actual, err := Canonicalize(parseNQuads(t, input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			expected = actual
+		} else if actual != expected {
+			t.Errorf("input %d: expected\n%s\ngot\n%s", i, expected, actual)
+		}
+	}
+
+	if n := strings.Count(expected, "\n"); n != 6 {
+		t.Errorf("expected 6 statements, got %d:\n%s", n, expected)
+	}
+}
+
+// WITHOUT CHARACTERS ESCAPED DIFFERENTLY THE OUTPUT MATCHES JSON-GOLD'S URDNA2015
+func TestCanonicalizeMatchesURDNA2015(t *testing.T) {
+
+	input := `_:a <http://example.org/p> _:b .
+_:b <http://example.org/p> _:a .
+_:c <http://example.org/p> _:d .
+_:d <http://example.org/p> _:c .
+_:a <http://example.org/q> "v"@en _:g .
+_:b <http://example.org/r> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .
+_:g <http://example.org/label> <http://example.org/x> .
+`
+	dataset := parseNQuads(t, input)
+
+	actual, err := Canonicalize(dataset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := CanonicalHash(dataset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != sha256hex(actual) {
+		t.Errorf("unexpected canonical hash %s", hash)
+	}
+
+	// JSON-GOLD RELABELS THE DATASET IN PLACE
+	opts := ld.NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	normalized, err := ld.NewNormalisationAlgorithm(ld.AlgorithmURDNA2015).Main(dataset, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := normalized.(string); actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+}
+
+// JSON-GOLD ONLY ESCAPES TAB, NEWLINE AND CARRIAGE RETURN. OTHER CONTROL
+// CHARACTERS ARE WRITTEN AS THEY ARE
+func TestCanonicalizeControlCharactersMatchURDNA2015(t *testing.T) {
+
+	dataset := ld.NewRDFDataset()
+	dataset.Graphs[defaultGraph] = []*ld.Quad{
+		ld.NewQuad(ld.NewBlankNode("_:a"), ld.NewIRI("http://example.org/p"),
+			ld.NewLiteral("tab\tnl\ncr\r", XSDString, ""), defaultGraph),
+		ld.NewQuad(ld.NewIRI("http://example.org/b"), ld.NewIRI("http://example.org/p"),
+			ld.NewLiteral("bs\bff\f", XSDString, ""), defaultGraph),
+		ld.NewQuad(ld.NewBlankNode("_:a"), ld.NewIRI("http://example.org/q"), ld.NewBlankNode("_:c"), defaultGraph),
+	}
+
+	actual, err := Canonicalize(dataset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ld.NewJsonLdOptions("")
+	opts.Format = "application/n-quads"
+	normalized, err := ld.NewNormalisationAlgorithm(ld.AlgorithmURDNA2015).Main(dataset, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.NewReplacer("\b", `\b`, "\f", `\f`).Replace(normalized.(string))
+	if actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+	if !strings.Contains(actual, `"tab\tnl\ncr\r"`) || !strings.Contains(actual, `"bs\bff\f"`) {
+		t.Errorf("expected control characters to be escaped, got\n%s", actual)
+	}
+}
+
+func TestCanonicalizeLimit(t *testing.T) {
+
+	// A LARGE CLIQUE OF INDISTINGUISHABLE BLANK NODES
+	var b strings.Builder
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			if i != j {
+				fmt.Fprintf(&b, "_:n%d <http://example.org/p> _:n%d .\n", i, j)
+			}
+		}
+	}
+
+	if _, err := Canonicalize(parseNQuads(t, b.String())); errors.GetType(err) != errors.OutOfRange {
+		t.Errorf("expected OutOfRange error, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package rdf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/hdt"
+	"github.com/piprate/json-gold/ld"
+)
+
+// writeHDT writes the distinct statements of all graphs of dataset to
+// w as an HDT file
+func writeHDT(w io.Writer, dataset *ld.RDFDataset) error {
+
+	hw := hdt.NewWriter(w, hdt.WriterOptions{})
+
+	for _, quad := range mergedTriples(dataset) {
+		if err := hw.Write(hdt.Triple{
+			Subject:   HDTTerm(quad.Subject),
+			Predicate: HDTTerm(quad.Predicate),
+			Object:    HDTTerm(quad.Object),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return hw.Close()
+}
+
+// parseHDT reads a dataset from the HDT file data
+func parseHDT(data []byte, src string) (*ld.RDFDataset, error) {
+
+	doc, err := hdt.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", src)
+	}
+
+	dataset := ld.NewRDFDataset()
+
+	it := doc.Search("", "", "")
+	for {
+		triple, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s", src)
+		}
+		object, err := HDTNode(triple.Object)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s", src)
+		}
+		dataset.Graphs[defaultGraph] = append(dataset.Graphs[defaultGraph],
+			ld.NewQuad(hdtResource(triple.Subject), ld.NewIRI(triple.Predicate), object, defaultGraph))
+	}
+
+	return dataset, nil
+}
+
+// HDTTerm returns the form of n in an HDT dictionary
+func HDTTerm(n ld.Node) string {
+
+	literal, ok := n.(*ld.Literal)
+	if !ok {
+		return n.GetValue()
+	}
+
+	term := `"` + literal.Value + `"`
+	if len(literal.Language) > 0 {
+		return term + "@" + literal.Language
+	}
+	if len(literal.Datatype) > 0 && literal.Datatype != XSDString {
+		return term + "^^<" + literal.Datatype + ">"
+	}
+
+	return term
+}
+
+// HDTNode returns the node of the HDT dictionary term
+func HDTNode(term string) (ld.Node, error) {
+
+	if !strings.HasPrefix(term, `"`) {
+		return hdtResource(term), nil
+	}
+
+	end := strings.LastIndexByte(term, '"')
+	if end < 1 {
+		return nil, errors.InvalidValue.Newf("invalid HDT literal %s", term)
+	}
+	value, suffix := term[1:end], term[end+1:]
+
+	switch {
+	case len(suffix) < 1:
+		return ld.NewLiteral(value, XSDString, ""), nil
+	case strings.HasPrefix(suffix, "@"):
+		return ld.NewLiteral(value, ld.RDFLangString, suffix[1:]), nil
+	case strings.HasPrefix(suffix, "^^<") && strings.HasSuffix(suffix, ">"):
+		return ld.NewLiteral(value, suffix[3:len(suffix)-1], ""), nil
+	}
+
+	return nil, errors.InvalidValue.Newf("invalid HDT literal %s", term)
+}
+
+// hdtResource returns the IRI or blank node of an HDT dictionary term
+func hdtResource(term string) ld.Node {
+
+	if strings.HasPrefix(term, "_:") {
+		return ld.NewBlankNode(term)
+	}
+
+	return ld.NewIRI(term)
+}
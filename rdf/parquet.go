@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package rdf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/parquet"
+	"github.com/piprate/json-gold/ld"
+)
+
+// writeParquet writes the statements of dataset to w as a parquet file
+// with one row per statement
+func writeParquet(w io.Writer, dataset *ld.RDFDataset) error {
+
+	pw := parquet.NewWriter(w, parquet.WriterOptions{})
+
+	for _, graph := range graphNames(dataset) {
+		for _, quad := range sortedTriples(dataset.Graphs[graph], false) {
+			row := parquet.Row{
+				Subject:   quad.Subject.GetValue(),
+				Predicate: quad.Predicate.GetValue(),
+				Object:    quad.Object.GetValue(),
+			}
+			if literal, ok := quad.Object.(*ld.Literal); ok {
+				row.Datatype = literal.Datatype
+				row.Language = literal.Language
+				if len(row.Datatype) < 1 {
+					row.Datatype = XSDString
+					if len(row.Language) > 0 {
+						row.Datatype = ld.RDFLangString
+					}
+				}
+			}
+			if graph != defaultGraph {
+				row.Graph = graph
+			}
+			if err := pw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	return pw.Close()
+}
+
+// parseParquet reads a dataset from the parquet file data
+func parseParquet(data []byte, src string) (*ld.RDFDataset, error) {
+
+	pr, err := parquet.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s", src)
+	}
+
+	resource := func(v string) ld.Node {
+		if strings.HasPrefix(v, "_:") {
+			return ld.NewBlankNode(v)
+		}
+		return ld.NewIRI(v)
+	}
+
+	dataset := ld.NewRDFDataset()
+	seen := make(map[string]map[string]struct{})
+
+	for i := 1; ; i++ {
+		row, err := pr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s", src)
+		}
+		if len(row.Subject) < 1 || len(row.Predicate) < 1 {
+			return nil, errors.InvalidValue.Newf("%s: row %d: missing subject or predicate", src, i)
+		}
+
+		object := resource(row.Object)
+		if len(row.Datatype) > 0 {
+			object = ld.NewLiteral(row.Object, row.Datatype, row.Language)
+		}
+
+		graph := defaultGraph
+		if len(row.Graph) > 0 {
+			graph = row.Graph
+		}
+
+		quad := ld.NewQuad(resource(row.Subject), ld.NewIRI(row.Predicate), object, graph)
+		if _, ok := seen[graph]; !ok {
+			seen[graph] = make(map[string]struct{})
+		}
+		key := quadKey(quad)
+		if _, dup := seen[graph][key]; dup {
+			continue
+		}
+		seen[graph][key] = struct{}{}
+		dataset.Graphs[graph] = append(dataset.Graphs[graph], quad)
+	}
+
+	return dataset, nil
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package rdf reads and writes RDF datasets in the standard RDF
+// serializations (N-Triples, N-Quads, Turtle and TriG) and in Parquet
+// and HDT files using the dataset model of the JSON-LD processor
+package rdf
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+// Format is an RDF serialization
+type Format string
+
+const (
+	NTriples Format = "nt"
+	NQuads   Format = "nq"
+	Turtle   Format = "ttl"
+	TriG     Format = "trig"
+	Parquet  Format = "parquet"
+	HDT      Format = "hdt"
+)
+
+// well-known IRIs
+const (
+	RDFType  = ld.RDFType
+	RDFFirst = ld.RDFFirst
+	RDFRest  = ld.RDFRest
+	RDFNil   = ld.RDFNil
+
+	XSDBoolean  = ld.XSDBoolean
+	XSDDateTime = "http://www.w3.org/2001/XMLSchema#dateTime"
+	XSDInteger  = ld.XSDInteger
+	XSDDecimal  = "http://www.w3.org/2001/XMLSchema#decimal"
+	XSDDouble   = ld.XSDDouble
+	XSDString   = ld.XSDString
+)
+
+// Formats returns the supported serializations
+func Formats() []Format {
+	return []Format{NTriples, NQuads, Turtle, TriG, Parquet, HDT}
+}
+
+// ParseFormat returns the serialization named s. File extensions and
+// common aliases are accepted
+func ParseFormat(s string) (Format, error) {
+
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "nt", "ntriples", "n-triples":
+		return NTriples, nil
+	case "nq", "nquads", "n-quads":
+		return NQuads, nil
+	case "ttl", "turtle":
+		return Turtle, nil
+	case "trig":
+		return TriG, nil
+	case "parquet":
+		return Parquet, nil
+	case "hdt":
+		return HDT, nil
+	}
+
+	return "", errors.InvalidValue.Newf("%s: unknown RDF format. expected one of %v", s, Formats())
+}
+
+// FormatOf returns the serialization of the file at path by its extension
+func FormatOf(path string) (Format, error) {
+
+	ext := filepath.Ext(path)
+	if len(ext) < 1 {
+		return "", errors.InvalidValue.Newf("%s: cannot determine RDF format without a file extension", path)
+	}
+
+	return ParseFormat(ext)
+}
+
+// MediaType returns the media type of the serialization
+func (f Format) MediaType() string {
+
+	switch f {
+	case NTriples:
+		return "application/n-triples"
+	case NQuads:
+		return "application/n-quads"
+	case Turtle:
+		return "text/turtle"
+	case TriG:
+		return "application/trig"
+	case Parquet:
+		return "application/vnd.apache.parquet"
+	case HDT:
+		return "application/vnd.hdt"
+	}
+
+	return ""
+}
+
+// Parse reads a dataset in format f from r. Relative IRIs resolve
+// against base. Prefixes declared in Turtle and TriG documents are
+// available from the dataset's GetContext(). src names the input in errors
+func Parse(r io.Reader, f Format, base string, src string) (*ld.RDFDataset, error) {
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch f {
+	case NTriples, NQuads:
+		dataset, err := ld.ParseNQuads(string(data))
+		if err != nil {
+			return nil, errors.InvalidValue.Wrapf(err, "%s", src)
+		}
+		if f == NTriples && len(dataset.Graphs) > 1 {
+			return nil, errors.InvalidValue.Newf("%s: N-Triples document contains named graphs", src)
+		}
+		return dataset, nil
+
+	case Turtle, TriG:
+		p := newTurtleParser(string(data), base, src, f == TriG)
+		if err := p.parse(); err != nil {
+			return nil, err
+		}
+		return p.dataset, nil
+
+	case Parquet:
+		return parseParquet(data, src)
+
+	case HDT:
+		return parseHDT(data, src)
+	}
+
+	return nil, errors.InvalidValue.Newf("%s: unknown RDF format '%s'", src, f)
+}
+
+// Union returns a dataset with the distinct statements of datasets.
+// Blank node labels are prefixed with the index of the dataset they came
+// from so blank nodes of different datasets are never merged
+func Union(datasets []*ld.RDFDataset) *ld.RDFDataset {
+
+	union := ld.NewRDFDataset()
+	seen := make(map[string]map[string]struct{})
+
+	for i, dataset := range datasets {
+
+		relabel := func(n ld.Node) ld.Node {
+			if b, ok := n.(*ld.BlankNode); ok {
+				return ld.NewBlankNode(fmt.Sprintf("_:d%d_%s", i, strings.TrimPrefix(b.Attribute, "_:")))
+			}
+			return n
+		}
+
+		for graph, quads := range dataset.Graphs {
+			if strings.HasPrefix(graph, "_:") {
+				graph = relabel(ld.NewBlankNode(graph)).GetValue()
+			}
+			if _, ok := seen[graph]; !ok {
+				seen[graph] = make(map[string]struct{})
+			}
+			for _, quad := range quads {
+				q := ld.NewQuad(relabel(quad.Subject), quad.Predicate, relabel(quad.Object), graph)
+				key := quadKey(q)
+				if _, dup := seen[graph][key]; dup {
+					continue
+				}
+				seen[graph][key] = struct{}{}
+				union.Graphs[graph] = append(union.Graphs[graph], q)
+			}
+		}
+	}
+
+	return union
+}
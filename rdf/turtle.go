@@ -0,0 +1,939 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package rdf
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+// turtleParser is a recursive descent parser for Turtle and TriG
+// documents (https://www.w3.org/TR/turtle/, https://www.w3.org/TR/trig/)
+type turtleParser struct {
+	input string
+	pos   int
+	src   string
+	trig  bool
+
+	base     *url.URL
+	prefixes map[string]string
+	bnodes   map[string]string // document blank node label -> dataset label
+	bnodeSeq int
+
+	graph   string // graph triples are added to
+	dataset *ld.RDFDataset
+	seen    map[string]map[string]struct{} // graph -> N-Quads form of added quads
+}
+
+func newTurtleParser(input string, base string, src string, trig bool) *turtleParser {
+
+	p := &turtleParser{
+		input:    strings.TrimPrefix(input, "\uFEFF"),
+		src:      src,
+		trig:     trig,
+		prefixes: make(map[string]string),
+		bnodes:   make(map[string]string),
+		graph:    "@default",
+		dataset:  ld.NewRDFDataset(),
+		seen:     make(map[string]map[string]struct{}),
+	}
+	p.base, _ = url.Parse(base)
+
+	return p
+}
+
+func (p *turtleParser) parse() error {
+
+	for {
+		p.skipWS()
+		if p.eof() {
+			return nil
+		}
+
+		if ok, err := p.directive(); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+
+		var err error
+		if p.trig {
+			err = p.block()
+		} else {
+			err = p.triples(true)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// directive parses a prefix or base declaration
+func (p *turtleParser) directive() (bool, error) {
+
+	var sparql bool
+
+	switch {
+	case p.hasPrefix("@prefix"):
+		p.pos += len("@prefix")
+	case p.hasKeyword("PREFIX"):
+		p.pos += len("PREFIX")
+		sparql = true
+	case p.hasPrefix("@base"):
+		p.pos += len("@base")
+		p.skipWS()
+		iri, err := p.iriRef()
+		if err != nil {
+			return false, err
+		}
+		if p.base, err = url.Parse(iri); err != nil {
+			return false, p.errorf("invalid base IRI <%s>", iri)
+		}
+		return true, p.expect('.')
+	case p.hasKeyword("BASE"):
+		p.pos += len("BASE")
+		p.skipWS()
+		iri, err := p.iriRef()
+		if err != nil {
+			return false, err
+		}
+		if p.base, err = url.Parse(iri); err != nil {
+			return false, p.errorf("invalid base IRI <%s>", iri)
+		}
+		return true, nil
+	default:
+		return false, nil
+	}
+
+	p.skipWS()
+	prefix, err := p.pnPrefix()
+	if err != nil {
+		return false, err
+	}
+	if err = p.expect(':'); err != nil {
+		return false, err
+	}
+	p.skipWS()
+	iri, err := p.iriRef()
+	if err != nil {
+		return false, err
+	}
+	p.prefixes[prefix] = iri
+	// RDFDataset.GetContext() EXPECTS PREFIXES AS KEYS
+	p.dataset.SetNamespace(prefix, iri)
+
+	if sparql {
+		return true, nil
+	}
+
+	return true, p.expect('.')
+}
+
+// block parses a TriG block: triples, a graph or a wrapped default graph
+func (p *turtleParser) block() error {
+
+	if p.hasKeyword("GRAPH") {
+		p.pos += len("GRAPH")
+		p.skipWS()
+		label, err := p.graphLabel()
+		if err != nil {
+			return err
+		}
+		return p.wrappedGraph(label)
+	}
+
+	switch p.peek() {
+	case '{':
+		return p.wrappedGraph("@default")
+	case '[', '(':
+		// triples2
+		return p.triples(true)
+	}
+
+	// labelOrSubject (wrappedGraph | predicateObjectList '.')
+	start := p.pos
+	label, err := p.graphLabel()
+	if err != nil {
+		return err
+	}
+	p.skipWS()
+	if p.peek() == '{' {
+		return p.wrappedGraph(label)
+	}
+	p.pos = start
+
+	return p.triples(true)
+}
+
+// graphLabel parses an IRI or blank node label naming a graph
+func (p *turtleParser) graphLabel() (string, error) {
+
+	term, err := p.term(false)
+	if err != nil {
+		return "", err
+	}
+	switch node := term.(type) {
+	case *ld.IRI:
+		return node.Value, nil
+	case *ld.BlankNode:
+		return node.Attribute, nil
+	}
+
+	return "", p.errorf("expected graph name")
+}
+
+// wrappedGraph parses '{' triplesBlock? '}' adding triples to graph
+func (p *turtleParser) wrappedGraph(graph string) error {
+
+	if err := p.expect('{'); err != nil {
+		return err
+	}
+
+	outer := p.graph
+	p.graph = graph
+	defer func() { p.graph = outer }()
+
+	if _, ok := p.dataset.Graphs[graph]; !ok {
+		p.dataset.Graphs[graph] = make([]*ld.Quad, 0)
+	}
+
+	for {
+		p.skipWS()
+		if p.peek() == '}' {
+			p.pos++
+			return nil
+		}
+		if p.eof() {
+			return p.errorf("expected '}'")
+		}
+		if err := p.triples(false); err != nil {
+			return err
+		}
+		p.skipWS()
+		if p.peek() == '.' {
+			p.pos++
+		} else if p.peek() != '}' {
+			return p.errorf("expected '.' or '}'")
+		}
+	}
+}
+
+// triples parses subject predicateObjectList (or a blank node property
+// list with an optional predicateObjectList) optionally followed by '.'
+func (p *turtleParser) triples(terminated bool) error {
+
+	p.skipWS()
+
+	var subject ld.Node
+	var err error
+	optionalPredicates := false
+
+	switch p.peek() {
+	case '[':
+		// ONLY A NON-EMPTY BLANK NODE PROPERTY LIST MAY STAND ALONE
+		optionalPredicates = !p.anon()
+		if subject, err = p.blankNodePropertyList(); err != nil {
+			return err
+		}
+	case '(':
+		if subject, err = p.collection(); err != nil {
+			return err
+		}
+	default:
+		if subject, err = p.term(false); err != nil {
+			return err
+		}
+		if _, ok := subject.(*ld.Literal); ok {
+			return p.errorf("literal cannot be a subject")
+		}
+	}
+
+	p.skipWS()
+	if !(optionalPredicates && (p.peek() == '.' || p.peek() == '}')) {
+		if err = p.predicateObjectList(subject); err != nil {
+			return err
+		}
+	}
+
+	if terminated {
+		return p.expect('.')
+	}
+
+	return nil
+}
+
+// predicateObjectList parses verb objectList (';' (verb objectList)?)*
+func (p *turtleParser) predicateObjectList(subject ld.Node) error {
+
+	for {
+		p.skipWS()
+
+		predicate, err := p.verb()
+		if err != nil {
+			return err
+		}
+
+		for {
+			p.skipWS()
+			object, err := p.object()
+			if err != nil {
+				return err
+			}
+			p.add(subject, predicate, object)
+
+			p.skipWS()
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+
+		if p.peek() != ';' {
+			return nil
+		}
+		// ANY NUMBER OF ';' MAY FOLLOW
+		for p.peek() == ';' {
+			p.pos++
+			p.skipWS()
+		}
+		if c := p.peek(); c == '.' || c == ']' || c == '}' || p.eof() {
+			return nil
+		}
+	}
+}
+
+// verb parses a predicate or 'a'
+func (p *turtleParser) verb() (ld.Node, error) {
+
+	if p.peek() == 'a' {
+		if next, _ := utf8.DecodeRuneInString(p.input[p.pos+1:]); p.pos+1 >= len(p.input) || !isPNChars(next) && next != ':' {
+			p.pos++
+			return ld.NewIRI(RDFType), nil
+		}
+	}
+
+	term, err := p.term(false)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := term.(*ld.IRI); !ok {
+		return nil, p.errorf("predicate must be an IRI")
+	}
+
+	return term, nil
+}
+
+// object parses an object of a triple
+func (p *turtleParser) object() (ld.Node, error) {
+
+	switch p.peek() {
+	case '[':
+		return p.blankNodePropertyList()
+	case '(':
+		return p.collection()
+	}
+
+	return p.term(true)
+}
+
+// blankNodePropertyList parses '[' predicateObjectList? ']'
+func (p *turtleParser) blankNodePropertyList() (ld.Node, error) {
+
+	p.pos++ // '['
+	node := p.newBlankNode()
+
+	p.skipWS()
+	if p.peek() == ']' {
+		p.pos++
+		return node, nil
+	}
+
+	if err := p.predicateObjectList(node); err != nil {
+		return nil, err
+	}
+	p.skipWS()
+	if err := p.expect(']'); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// collection parses '(' object* ')' into an RDF list
+func (p *turtleParser) collection() (ld.Node, error) {
+
+	p.pos++ // '('
+
+	var items []ld.Node
+	for {
+		p.skipWS()
+		if p.peek() == ')' {
+			p.pos++
+			break
+		}
+		if p.eof() {
+			return nil, p.errorf("expected ')'")
+		}
+		item, err := p.object()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if len(items) < 1 {
+		return ld.NewIRI(RDFNil), nil
+	}
+
+	head := p.newBlankNode()
+	var node ld.Node = head
+	for i, item := range items {
+		p.add(node, ld.NewIRI(RDFFirst), item)
+		var rest ld.Node = ld.NewIRI(RDFNil)
+		if i < len(items)-1 {
+			rest = p.newBlankNode()
+		}
+		p.add(node, ld.NewIRI(RDFRest), rest)
+		node = rest
+	}
+
+	return head, nil
+}
+
+// term parses an IRI, prefixed name, blank node label or (if literals
+// is true) a literal
+func (p *turtleParser) term(literals bool) (ld.Node, error) {
+
+	c := p.peek()
+
+	switch {
+	case c == '<':
+		iri, err := p.iriRef()
+		if err != nil {
+			return nil, err
+		}
+		return ld.NewIRI(iri), nil
+
+	case c == '_' && p.hasPrefix("_:"):
+		return p.blankNodeLabel()
+
+	case literals && (c == '"' || c == '\''):
+		return p.rdfLiteral()
+
+	case literals && (c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9')):
+		return p.numericLiteral()
+
+	case literals && (p.hasKeyword("true") || p.hasKeyword("false")):
+		value := "true"
+		if p.hasKeyword("false") {
+			value = "false"
+		}
+		p.pos += len(value)
+		return ld.NewLiteral(value, XSDBoolean, ""), nil
+	}
+
+	return p.prefixedName()
+}
+
+// iriRef parses '<' IRI '>' and resolves it against the base IRI
+func (p *turtleParser) iriRef() (string, error) {
+
+	if err := p.expect('<'); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated IRI")
+		}
+		c, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		switch {
+		case c == '>':
+			p.pos++
+			return p.resolve(b.String())
+		case c == '\\':
+			r, err := p.unicodeEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		case c <= 0x20 || strings.ContainsRune("<\"{}|^`", c):
+			return "", p.errorf("invalid character %q in IRI", c)
+		default:
+			b.WriteRune(c)
+			p.pos += size
+		}
+	}
+}
+
+// resolve resolves a (possibly relative) IRI against the base IRI
+func (p *turtleParser) resolve(iri string) (string, error) {
+
+	ref, err := url.Parse(iri)
+	if err != nil {
+		return "", p.errorf("invalid IRI <%s>", iri)
+	}
+	if ref.IsAbs() || p.base == nil {
+		return iri, nil
+	}
+
+	return p.base.ResolveReference(ref).String(), nil
+}
+
+// prefixedName parses PNAME_NS or PNAME_LN into an IRI
+func (p *turtleParser) prefixedName() (ld.Node, error) {
+
+	prefix, err := p.pnPrefix()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ':' {
+		if p.eof() {
+			return nil, p.errorf("unexpected end of input")
+		}
+		return nil, p.errorf("unexpected '%c'", p.peek())
+	}
+	p.pos++
+
+	ns, ok := p.prefixes[prefix]
+	if !ok {
+		return nil, p.errorf("undeclared prefix '%s'", prefix)
+	}
+
+	local, err := p.pnLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	return ld.NewIRI(ns + local), nil
+}
+
+// pnPrefix parses an (optionally empty) PN_PREFIX
+func (p *turtleParser) pnPrefix() (string, error) {
+
+	start := p.pos
+	c, size := utf8.DecodeRuneInString(p.input[p.pos:])
+	if p.eof() || !isPNCharsBase(c) {
+		return "", nil
+	}
+	p.pos += size
+
+	for !p.eof() {
+		c, size = utf8.DecodeRuneInString(p.input[p.pos:])
+		if !isPNChars(c) && c != '.' {
+			break
+		}
+		p.pos += size
+	}
+	p.unreadTrailingDots(start)
+
+	return p.input[start:p.pos], nil
+}
+
+// pnLocal parses an (optionally empty) PN_LOCAL with escapes removed
+func (p *turtleParser) pnLocal() (string, error) {
+
+	var b strings.Builder
+	start := p.pos
+	first := true
+
+	for !p.eof() {
+		c, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		switch {
+		case c == '%':
+			if p.pos+2 >= len(p.input) || !isHexDigit(p.input[p.pos+1]) || !isHexDigit(p.input[p.pos+2]) {
+				return "", p.errorf("invalid percent encoding in local name")
+			}
+			b.WriteString(p.input[p.pos : p.pos+3])
+			p.pos += 3
+		case c == '\\':
+			if p.pos+1 >= len(p.input) || !strings.ContainsRune("_~.-!$&'()*+,;=/?#@%", rune(p.input[p.pos+1])) {
+				return "", p.errorf("invalid escape in local name")
+			}
+			b.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+		case isPNCharsU(c) || c == ':' || (c >= '0' && c <= '9') || (!first && (isPNChars(c) || c == '.')):
+			b.WriteRune(c)
+			p.pos += size
+		default:
+			return p.trimLocal(b.String(), start), nil
+		}
+		first = false
+	}
+
+	return p.trimLocal(b.String(), start), nil
+}
+
+// trimLocal drops trailing dots (statement terminators) from a local name
+func (p *turtleParser) trimLocal(local string, start int) string {
+
+	for strings.HasSuffix(local, ".") && !strings.HasSuffix(local, "\\.") && p.pos > start {
+		local = local[:len(local)-1]
+		p.pos--
+	}
+
+	return local
+}
+
+// blankNodeLabel parses '_:' label
+func (p *turtleParser) blankNodeLabel() (ld.Node, error) {
+
+	p.pos += 2 // '_:'
+	start := p.pos
+
+	c, size := utf8.DecodeRuneInString(p.input[p.pos:])
+	if p.eof() || !(isPNCharsU(c) || (c >= '0' && c <= '9')) {
+		return nil, p.errorf("invalid blank node label")
+	}
+	p.pos += size
+
+	for !p.eof() {
+		c, size = utf8.DecodeRuneInString(p.input[p.pos:])
+		if !isPNChars(c) && c != '.' {
+			break
+		}
+		p.pos += size
+	}
+	p.unreadTrailingDots(start)
+
+	label := p.input[start:p.pos]
+	id, ok := p.bnodes[label]
+	if !ok {
+		id = p.newBlankNode().Attribute
+		p.bnodes[label] = id
+	}
+
+	return ld.NewBlankNode(id), nil
+}
+
+// anon returns true if an empty blank node '[' ']' is next
+func (p *turtleParser) anon() bool {
+	return strings.HasPrefix(strings.TrimLeft(p.input[p.pos+1:], " \t\r\n"), "]")
+}
+
+func (p *turtleParser) newBlankNode() *ld.BlankNode {
+	id := fmt.Sprintf("_:b%d", p.bnodeSeq)
+	p.bnodeSeq++
+	return ld.NewBlankNode(id)
+}
+
+// rdfLiteral parses a string with an optional language tag or datatype
+func (p *turtleParser) rdfLiteral() (ld.Node, error) {
+
+	value, err := p.stringLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.peek() == '@':
+		p.pos++
+		start := p.pos
+		for !p.eof() {
+			c := p.input[p.pos]
+			if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c == '-' && p.pos > start) ||
+				(c >= '0' && c <= '9' && p.pos > start)) {
+				break
+			}
+			p.pos++
+		}
+		if p.pos == start {
+			return nil, p.errorf("invalid language tag")
+		}
+		return ld.NewLiteral(value, ld.RDFLangString, strings.ToLower(p.input[start:p.pos])), nil
+
+	case p.hasPrefix("^^"):
+		p.pos += 2
+		datatype, err := p.term(false)
+		if err != nil {
+			return nil, err
+		}
+		iri, ok := datatype.(*ld.IRI)
+		if !ok {
+			return nil, p.errorf("datatype must be an IRI")
+		}
+		return ld.NewLiteral(value, iri.Value, ""), nil
+	}
+
+	return ld.NewLiteral(value, XSDString, ""), nil
+}
+
+// stringLiteral parses any of the four Turtle string forms
+func (p *turtleParser) stringLiteral() (string, error) {
+
+	quote := p.input[p.pos : p.pos+1]
+	long := p.hasPrefix(strings.Repeat(quote, 3))
+	if long {
+		quote = strings.Repeat(quote, 3)
+	}
+	p.pos += len(quote)
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		if p.hasPrefix(quote) {
+			p.pos += len(quote)
+			return b.String(), nil
+		}
+
+		c, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		switch {
+		case c == '\\':
+			r, err := p.stringEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		case !long && (c == '\n' || c == '\r'):
+			return "", p.errorf("line break in short string")
+		default:
+			b.WriteRune(c)
+			p.pos += size
+		}
+	}
+}
+
+// stringEscape parses an ECHAR or UCHAR escape sequence
+func (p *turtleParser) stringEscape() (rune, error) {
+
+	if p.pos+1 >= len(p.input) {
+		return 0, p.errorf("invalid escape sequence")
+	}
+
+	echars := map[byte]rune{'t': '\t', 'b': '\b', 'n': '\n', 'r': '\r', 'f': '\f', '"': '"', '\'': '\'', '\\': '\\'}
+	if r, ok := echars[p.input[p.pos+1]]; ok {
+		p.pos += 2
+		return r, nil
+	}
+
+	return p.unicodeEscape()
+}
+
+// unicodeEscape parses \uXXXX or \UXXXXXXXX
+func (p *turtleParser) unicodeEscape() (rune, error) {
+
+	if p.pos+1 >= len(p.input) {
+		return 0, p.errorf("invalid escape sequence")
+	}
+
+	var digits int
+	switch p.input[p.pos+1] {
+	case 'u':
+		digits = 4
+	case 'U':
+		digits = 8
+	default:
+		return 0, p.errorf("invalid escape sequence '\\%c'", p.input[p.pos+1])
+	}
+
+	if p.pos+2+digits > len(p.input) {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	code, err := strconv.ParseUint(p.input[p.pos+2:p.pos+2+digits], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	p.pos += 2 + digits
+
+	return rune(code), nil
+}
+
+// numericLiteral parses an integer, decimal or double
+func (p *turtleParser) numericLiteral() (ld.Node, error) {
+
+	start := p.pos
+	if c := p.peek(); c == '+' || c == '-' {
+		p.pos++
+	}
+
+	intDigits := p.digits()
+	datatype := XSDInteger
+
+	// A '.' NOT FOLLOWED BY A DIGIT (OR EXPONENT) TERMINATES THE STATEMENT
+	if p.peek() == '.' && p.pos+1 < len(p.input) && (isDigit(p.input[p.pos+1]) ||
+		(intDigits > 0 && (p.input[p.pos+1] == 'e' || p.input[p.pos+1] == 'E'))) {
+		p.pos++
+		p.digits()
+		datatype = XSDDecimal
+	}
+
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		if p.digits() < 1 {
+			return nil, p.errorf("invalid exponent")
+		}
+		datatype = XSDDouble
+	}
+
+	lexical := p.input[start:p.pos]
+	if strings.Trim(lexical, "+-.") == "" || (datatype == XSDInteger && intDigits < 1) {
+		p.pos = start
+		return nil, p.errorf("invalid number")
+	}
+
+	return ld.NewLiteral(lexical, datatype, ""), nil
+}
+
+func (p *turtleParser) digits() int {
+	n := 0
+	for !p.eof() && isDigit(p.input[p.pos]) {
+		p.pos++
+		n++
+	}
+	return n
+}
+
+// add adds a triple to the current graph unless it is already present
+func (p *turtleParser) add(subject ld.Node, predicate ld.Node, object ld.Node) {
+
+	quad := ld.NewQuad(subject, predicate, object, p.graph)
+
+	seen, ok := p.seen[p.graph]
+	if !ok {
+		seen = make(map[string]struct{})
+		p.seen[p.graph] = seen
+	}
+	key := quadKey(quad)
+	if _, dup := seen[key]; dup {
+		return
+	}
+	seen[key] = struct{}{}
+
+	p.dataset.Graphs[p.graph] = append(p.dataset.Graphs[p.graph], quad)
+}
+
+// quadKey returns a key identifying the triple of quad
+func quadKey(quad *ld.Quad) string {
+
+	key := func(n ld.Node) string {
+		switch node := n.(type) {
+		case *ld.Literal:
+			return "\"" + node.Value + "\"" + node.Datatype + "@" + node.Language
+		case *ld.BlankNode:
+			return node.Attribute
+		}
+		return "<" + n.GetValue() + ">"
+	}
+
+	return key(quad.Subject) + " " + key(quad.Predicate) + " " + key(quad.Object)
+}
+
+func (p *turtleParser) skipWS() {
+
+	for !p.eof() {
+		c := p.input[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case c == '#':
+			for !p.eof() && p.input[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *turtleParser) expect(c byte) error {
+
+	p.skipWS()
+	if p.peek() != c {
+		if p.eof() {
+			return p.errorf("expected '%c' but reached end of input", c)
+		}
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+
+	return nil
+}
+
+func (p *turtleParser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *turtleParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *turtleParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.input[p.pos:], s)
+}
+
+// hasKeyword returns true if the case insensitive keyword kw (not
+// followed by a name character) is next
+func (p *turtleParser) hasKeyword(kw string) bool {
+
+	if len(p.input)-p.pos < len(kw) || !strings.EqualFold(p.input[p.pos:p.pos+len(kw)], kw) {
+		return false
+	}
+	next, _ := utf8.DecodeRuneInString(p.input[p.pos+len(kw):])
+
+	return p.pos+len(kw) == len(p.input) || !(isPNChars(next) || next == ':' || next == '.')
+}
+
+// unreadTrailingDots moves back over dots at the end of a name
+func (p *turtleParser) unreadTrailingDots(start int) {
+	for p.pos > start && p.input[p.pos-1] == '.' {
+		p.pos--
+	}
+}
+
+// errorf returns an InvalidValue error at the current line and column
+func (p *turtleParser) errorf(format string, args ...interface{}) error {
+
+	consumed := p.input[:p.pos]
+	line := strings.Count(consumed, "\n") + 1
+	column := utf8.RuneCountInString(consumed[strings.LastIndex(consumed, "\n")+1:]) + 1
+
+	return errors.InvalidValue.Newf("%s:%d:%d: %s", p.src, line, column, fmt.Sprintf(format, args...))
+}
+
+func isPNCharsBase(c rune) bool {
+	return unicode.IsLetter(c) && c != '_'
+}
+
+func isPNCharsU(c rune) bool {
+	return isPNCharsBase(c) || c == '_'
+}
+
+func isPNChars(c rune) bool {
+	return isPNCharsU(c) || c == '-' || unicode.IsDigit(c) || c == 0xB7 || unicode.Is(unicode.Mn, c) ||
+		(c >= 0x203F && c <= 0x2040)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,146 @@
+package rdf
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+// nquads returns the sorted N-Quads lines of dataset
+func nquads(t *testing.T, dataset *ld.RDFDataset) []string {
+
+	out, err := (&ld.NQuadRDFSerializer{}).Serialize(dataset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.(string)), "\n")
+	sort.Strings(lines)
+
+	return lines
+}
+
+func TestParseTurtle(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		format   Format
+		input    string
+		expected string
+	}{
+		{"prefixes and base", Turtle, `
+@base <http://example.org/> .
+@prefix foaf: <http://xmlns.com/foaf/0.1/> .
+PREFIX ex: <http://example.org/ns#>
+
+<alice> a foaf:Person ;
+    foaf:name "Alice"@en-US , "Alicia"@es ;
+    foaf:knows <#bob> ;
+    ex:age 42 ; ex:height 1.68 ; ex:weight 6.1e1 ; ex:active true .
+`, `
+<http://example.org/alice> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://xmlns.com/foaf/0.1/Person> .
+<http://example.org/alice> <http://xmlns.com/foaf/0.1/name> "Alice"@en-us .
+<http://example.org/alice> <http://xmlns.com/foaf/0.1/name> "Alicia"@es .
+<http://example.org/alice> <http://xmlns.com/foaf/0.1/knows> <http://example.org/#bob> .
+<http://example.org/alice> <http://example.org/ns#age> "42"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://example.org/alice> <http://example.org/ns#height> "1.68"^^<http://www.w3.org/2001/XMLSchema#decimal> .
+<http://example.org/alice> <http://example.org/ns#weight> "6.1e1"^^<http://www.w3.org/2001/XMLSchema#double> .
+<http://example.org/alice> <http://example.org/ns#active> "true"^^<http://www.w3.org/2001/XMLSchema#boolean> .
+`},
+		{"blank nodes and collections", Turtle, `
+@prefix ex: <http://example.org/> .
+ex:s ex:p [ ex:q "nested" ] ; ex:list ( 1 ex:o ) ; ex:empty () .
+_:x ex:p _:x .
+[ ex:q "standalone" ] .
+[] ex:q "anon" .
+`, `
+<http://example.org/s> <http://example.org/p> _:b0 .
+_:b0 <http://example.org/q> "nested" .
+<http://example.org/s> <http://example.org/list> _:b1 .
+_:b1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .
+_:b1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> _:b2 .
+_:b2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> <http://example.org/o> .
+_:b2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .
+<http://example.org/s> <http://example.org/empty> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .
+_:b3 <http://example.org/p> _:b3 .
+_:b4 <http://example.org/q> "standalone" .
+_:b5 <http://example.org/q> "anon" .
+`},
+		{"strings and escapes", Turtle, `
+@prefix ex: <http://example.org/> .
+ex:s ex:p """multi
+line "quoted" string""" , 'single \'quoted\'' , "tab\there é" , "typed"^^ex:type .
+ex:a\.b ex:p ex:c.
+`, `
+<http://example.org/s> <http://example.org/p> "multi\nline \"quoted\" string" .
+<http://example.org/s> <http://example.org/p> "single 'quoted'" .
+<http://example.org/s> <http://example.org/p> "tab\there é" .
+<http://example.org/s> <http://example.org/p> "typed"^^<http://example.org/type> .
+<http://example.org/a.b> <http://example.org/p> <http://example.org/c> .
+`},
+		{"trig graphs", TriG, `
+@prefix ex: <http://example.org/> .
+ex:s ex:p ex:o .
+{ ex:s ex:p "default" }
+ex:g1 { ex:s ex:p ex:o1 . ex:s ex:p ex:o2 }
+GRAPH ex:g2 { ex:s ex:p [ ex:q "nested" ] . }
+_:g { ex:s ex:p ex:o3 }
+`, `
+<http://example.org/s> <http://example.org/p> <http://example.org/o> .
+<http://example.org/s> <http://example.org/p> "default" .
+<http://example.org/s> <http://example.org/p> <http://example.org/o1> <http://example.org/g1> .
+<http://example.org/s> <http://example.org/p> <http://example.org/o2> <http://example.org/g1> .
+<http://example.org/s> <http://example.org/p> _:b0 <http://example.org/g2> .
+_:b0 <http://example.org/q> "nested" <http://example.org/g2> .
+<http://example.org/s> <http://example.org/p> <http://example.org/o3> _:b1 .
+`},
+		{"n-quads", NQuads, `
+<http://example.org/s> <http://example.org/p> "o"@en <http://example.org/g> .
+_:a <http://example.org/p> <http://example.org/o> .
+`, `
+<http://example.org/s> <http://example.org/p> "o"@en <http://example.org/g> .
+_:a <http://example.org/p> <http://example.org/o> .
+`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			dataset, err := Parse(strings.NewReader(test.input), test.format, "http://example.org/doc", test.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := strings.Split(strings.TrimSpace(test.expected), "\n")
+			sort.Strings(expected)
+
+			actual := nquads(t, dataset)
+			if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+				t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseTurtleErrors(t *testing.T) {
+
+	invalid := map[string]string{
+		"undeclared prefix":  `ex:s ex:p ex:o .`,
+		"missing terminator": `<http://example.org/s> <http://example.org/p> <http://example.org/o>`,
+		"literal subject":    `"s" <http://example.org/p> <http://example.org/o> .`,
+		"unterminated":       `<http://example.org/s> <http://example.org/p> "o .`,
+		"graph in turtle":    `<http://example.org/g> { <http://example.org/s> <http://example.org/p> <http://example.org/o> }`,
+	}
+
+	for name, input := range invalid {
+		_, err := Parse(strings.NewReader(input), Turtle, "", "test.ttl")
+		if errors.GetType(err) != errors.InvalidValue {
+			t.Errorf("%s: expected InvalidValue error, got %v", name, err)
+		} else if !strings.Contains(err.Error(), "test.ttl:1:") {
+			t.Errorf("%s: expected error position, got %s", name, err)
+		}
+	}
+}
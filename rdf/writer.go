@@ -0,0 +1,334 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package rdf
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+// name of the default graph in a dataset
+const defaultGraph = "@default"
+
+var (
+	// LOCAL PARTS OF PREFIXED NAMES THE WRITER PRODUCES. A SAFE
+	// SUBSET OF THE PN_LOCAL PRODUCTION THAT NEEDS NO ESCAPES
+	simpleLocalName = regexp.MustCompile(`^([A-Za-z0-9_]([A-Za-z0-9_.-]*[A-Za-z0-9_-])?)?$`)
+	simplePrefix    = regexp.MustCompile(`^([A-Za-z]([A-Za-z0-9_.-]*[A-Za-z0-9_-])?)?$`)
+
+	integerLexical = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	decimalLexical = regexp.MustCompile(`^[+-]?[0-9]*\.[0-9]+$`)
+	doubleLexical  = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)[eE][+-]?[0-9]+$`)
+)
+
+// Write serializes dataset to w in format f with statements in a stable
+// order. Turtle and TriG output abbreviates IRIs using prefixes (prefix ->
+// namespace IRI). N-Triples, Turtle and HDT cannot represent named graphs
+// so the statements of all graphs are merged into one graph
+func Write(w io.Writer, dataset *ld.RDFDataset, f Format, prefixes map[string]string) error {
+
+	bw := bufio.NewWriter(w)
+
+	switch f {
+	case NQuads:
+		for _, graph := range graphNames(dataset) {
+			for _, quad := range sortedTriples(dataset.Graphs[graph], false) {
+				bw.WriteString(NTriplesTerm(quad.Subject) + " " + NTriplesTerm(quad.Predicate) + " " + NTriplesTerm(quad.Object))
+				if graph != defaultGraph {
+					bw.WriteString(" " + NTriplesTerm(graphNode(graph)))
+				}
+				bw.WriteString(" .\n")
+			}
+		}
+
+	case NTriples:
+		for _, quad := range sortedTriples(mergedTriples(dataset), false) {
+			bw.WriteString(NTriplesTerm(quad.Subject) + " " + NTriplesTerm(quad.Predicate) + " " + NTriplesTerm(quad.Object) + " .\n")
+		}
+
+	case Turtle, TriG:
+		tw := newTurtleWriter(bw, prefixes)
+		tw.writePrefixes()
+		if f == Turtle {
+			tw.writeTriples(mergedTriples(dataset), "")
+			break
+		}
+		for _, graph := range graphNames(dataset) {
+			if graph == defaultGraph {
+				tw.writeTriples(dataset.Graphs[graph], "")
+				continue
+			}
+			bw.WriteString("\n" + tw.term(graphNode(graph), false) + " {")
+			tw.writeTriples(dataset.Graphs[graph], "    ")
+			bw.WriteString("}\n")
+		}
+
+	case Parquet:
+		return writeParquet(w, dataset)
+
+	case HDT:
+		return writeHDT(w, dataset)
+
+	default:
+		return errors.InvalidValue.Newf("unknown RDF format '%s'", f)
+	}
+
+	return bw.Flush()
+}
+
+// graphNames returns the names of the graphs in dataset with the
+// default graph first followed by named graphs in lexical order
+func graphNames(dataset *ld.RDFDataset) []string {
+
+	var names []string
+	for name, triples := range dataset.Graphs {
+		if name != defaultGraph && len(triples) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if len(dataset.Graphs[defaultGraph]) > 0 {
+		names = append([]string{defaultGraph}, names...)
+	}
+
+	return names
+}
+
+// graphNode returns the node naming graph
+func graphNode(graph string) ld.Node {
+
+	if strings.HasPrefix(graph, "_:") {
+		return ld.NewBlankNode(graph)
+	}
+
+	return ld.NewIRI(graph)
+}
+
+// mergedTriples returns the distinct triples of all graphs in dataset
+func mergedTriples(dataset *ld.RDFDataset) []*ld.Quad {
+
+	var merged []*ld.Quad
+	seen := make(map[string]struct{})
+
+	for _, graph := range graphNames(dataset) {
+		for _, quad := range dataset.Graphs[graph] {
+			key := quadKey(quad)
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			merged = append(merged, quad)
+		}
+	}
+
+	return merged
+}
+
+// sortedTriples returns a copy of triples ordered by subject,
+// predicate and object. If typeFirst is set rdf:type precedes the
+// other predicates of a subject
+func sortedTriples(triples []*ld.Quad, typeFirst bool) []*ld.Quad {
+
+	sorted := append([]*ld.Quad(nil), triples...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if s1, s2 := NTriplesTerm(a.Subject), NTriplesTerm(b.Subject); s1 != s2 {
+			return s1 < s2
+		}
+		if t1, t2 := a.Predicate.GetValue() == RDFType, b.Predicate.GetValue() == RDFType; typeFirst && t1 != t2 {
+			return t1
+		}
+		if p1, p2 := NTriplesTerm(a.Predicate), NTriplesTerm(b.Predicate); p1 != p2 {
+			return p1 < p2
+		}
+		return NTriplesTerm(a.Object) < NTriplesTerm(b.Object)
+	})
+
+	return sorted
+}
+
+// NTriplesTerm returns the N-Triples form of n
+func NTriplesTerm(n ld.Node) string {
+
+	switch node := n.(type) {
+	case *ld.Literal:
+		s := `"` + escapeString(node.Value) + `"`
+		switch {
+		case node.Datatype == ld.RDFLangString || (len(node.Language) > 0 && node.Datatype == ""):
+			s += "@" + node.Language
+		case node.Datatype != "" && node.Datatype != XSDString:
+			s += "^^<" + node.Datatype + ">"
+		}
+		return s
+	case *ld.BlankNode:
+		return node.Attribute
+	}
+
+	return "<" + n.GetValue() + ">"
+}
+
+// escapeString escapes s for use in a quoted N-Triples or Turtle string
+func escapeString(s string) string {
+
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// turtleWriter writes triples in the Turtle syntax shared by TriG
+type turtleWriter struct {
+	w        *bufio.Writer
+	prefixes map[string]string
+	names    []string // prefixes ordered longest namespace first
+}
+
+func newTurtleWriter(w *bufio.Writer, prefixes map[string]string) *turtleWriter {
+
+	tw := &turtleWriter{w: w, prefixes: prefixes}
+
+	for prefix := range prefixes {
+		if simplePrefix.MatchString(prefix) {
+			tw.names = append(tw.names, prefix)
+		}
+	}
+	sort.Strings(tw.names)
+	sort.SliceStable(tw.names, func(i, j int) bool {
+		return len(prefixes[tw.names[i]]) > len(prefixes[tw.names[j]])
+	})
+
+	return tw
+}
+
+// writePrefixes writes the prefix declarations
+func (tw *turtleWriter) writePrefixes() {
+
+	names := append([]string(nil), tw.names...)
+	sort.Strings(names)
+
+	for _, prefix := range names {
+		tw.w.WriteString("@prefix " + prefix + ": <" + tw.prefixes[prefix] + "> .\n")
+	}
+}
+
+// writeTriples writes triples grouped by subject and predicate with
+// each line preceded by indent
+func (tw *turtleWriter) writeTriples(triples []*ld.Quad, indent string) {
+
+	sorted := sortedTriples(triples, true)
+
+	for i := 0; i < len(sorted); {
+
+		subject := sorted[i].Subject
+		tw.w.WriteString("\n" + indent + tw.term(subject, false))
+
+		for first := true; i < len(sorted) && sorted[i].Subject.Equal(subject); first = false {
+
+			predicate := sorted[i].Predicate
+			if !first {
+				tw.w.WriteString(" ;\n" + indent + "   ")
+			}
+			tw.w.WriteString(" " + tw.term(predicate, true))
+
+			for j := 0; i < len(sorted) && sorted[i].Subject.Equal(subject) && sorted[i].Predicate.Equal(predicate); i, j = i+1, j+1 {
+				if j > 0 {
+					tw.w.WriteString(",")
+				}
+				tw.w.WriteString(" " + tw.term(sorted[i].Object, false))
+			}
+		}
+
+		tw.w.WriteString(" .\n")
+	}
+}
+
+// TurtleTerm returns the Turtle form of n with IRIs abbreviated using
+// prefixes (prefix -> namespace IRI)
+func TurtleTerm(n ld.Node, prefixes map[string]string) string {
+	return newTurtleWriter(nil, prefixes).term(n, false)
+}
+
+// term returns the Turtle form of n. rdf:type is abbreviated to 'a'
+// in the predicate position
+func (tw *turtleWriter) term(n ld.Node, predicate bool) string {
+
+	switch node := n.(type) {
+	case *ld.Literal:
+		switch node.Datatype {
+		case XSDInteger:
+			if integerLexical.MatchString(node.Value) {
+				return node.Value
+			}
+		case XSDDecimal:
+			if decimalLexical.MatchString(node.Value) {
+				return node.Value
+			}
+		case XSDDouble:
+			if doubleLexical.MatchString(node.Value) {
+				return node.Value
+			}
+		case XSDBoolean:
+			if node.Value == "true" || node.Value == "false" {
+				return node.Value
+			}
+		}
+		if node.Datatype != "" && node.Datatype != XSDString && node.Datatype != ld.RDFLangString {
+			return `"` + escapeString(node.Value) + `"^^` + tw.iri(node.Datatype)
+		}
+		return NTriplesTerm(node)
+	case *ld.BlankNode:
+		return node.Attribute
+	}
+
+	if predicate && n.GetValue() == RDFType {
+		return "a"
+	}
+
+	return tw.iri(n.GetValue())
+}
+
+// iri returns iri as a prefixed name if a prefix applies
+func (tw *turtleWriter) iri(iri string) string {
+
+	for _, prefix := range tw.names {
+		ns := tw.prefixes[prefix]
+		if len(ns) > 0 && strings.HasPrefix(iri, ns) && simpleLocalName.MatchString(iri[len(ns):]) {
+			return prefix + ":" + iri[len(ns):]
+		}
+	}
+
+	return "<" + iri + ">"
+}
@@ -0,0 +1,166 @@
+package rdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/piprate/json-gold/ld"
+)
+
+const writerTestInput = `
+@prefix ex: <http://example.org/> .
+@prefix xsd: <http://www.w3.org/2001/XMLSchema#> .
+
+ex:bob ex:name "Bob" ; a ex:Person ; ex:knows ex:alice .
+ex:alice a ex:Person ;
+    ex:name "Alice"@en , "line\nbreak \"quoted\"" ;
+    ex:age 42 ; ex:height 1.68 ; ex:weight 6.1e1 ; ex:active false ;
+    ex:born "2000-01-01"^^xsd:date ;
+    ex:page <http://example.org/pages/alice?x=1> ;
+    ex:address [ ex:city "Paris" ] .
+
+GRAPH ex:g1 { ex:bob ex:name "Bob" . ex:carol a ex:Person . }
+`
+
+func TestWriteRoundTrip(t *testing.T) {
+
+	dataset, err := Parse(strings.NewReader(writerTestInput), TriG, "", "input.trig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefixes := map[string]string{"ex": "http://example.org/", "xsd": "http://www.w3.org/2001/XMLSchema#"}
+
+	for _, f := range []Format{NQuads, TriG, Parquet} {
+		var buf bytes.Buffer
+		if err := Write(&buf, dataset, f, prefixes); err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := Parse(&buf, f, "", "output")
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", f, err, buf.String())
+		}
+
+		if expected, actual := strings.Join(nquads(t, dataset), "\n"), strings.Join(nquads(t, parsed), "\n"); expected != actual {
+			t.Errorf("%s: round trip changed statements: expected\n%s\ngot\n%s", f, expected, actual)
+		}
+	}
+
+	// GRAPHS ARE MERGED IN TRIPLE FORMATS
+	for _, f := range []Format{NTriples, Turtle, HDT} {
+		var buf bytes.Buffer
+		if err := Write(&buf, dataset, f, prefixes); err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := Parse(&buf, f, "", "output")
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", f, err, buf.String())
+		}
+		if len(parsed.Graphs) != 1 {
+			t.Errorf("%s: expected a single graph, got %d", f, len(parsed.Graphs))
+		}
+		// ex:bob ex:name "Bob" APPEARS IN BOTH GRAPHS
+		if expected, actual := len(dataset.Graphs["@default"])+1, len(parsed.Graphs["@default"]); expected != actual {
+			t.Errorf("%s: expected %d triples, got %d", f, expected, actual)
+		}
+	}
+}
+
+func TestWriteTurtle(t *testing.T) {
+
+	dataset, err := Parse(strings.NewReader(writerTestInput), TriG, "", "input.trig")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, dataset, TriG, map[string]string{"ex": "http://example.org/", "xsd": "http://www.w3.org/2001/XMLSchema#"}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, expected := range []string{
+		"@prefix ex: <http://example.org/> .\n",
+		"ex:bob a ex:Person ;\n    ex:knows ex:alice ;\n    ex:name \"Bob\" .\n",
+		"ex:age 42 ;",
+		"ex:height 1.68 ;",
+		"ex:weight 6.1e1",
+		"ex:active false ;",
+		"ex:born \"2000-01-01\"^^xsd:date ;",
+		"ex:name \"Alice\"@en, \"line\\nbreak \\\"quoted\\\"\" ;",
+		"ex:page <http://example.org/pages/alice?x=1> ;",
+		"\nex:g1 {\n    ex:bob ex:name \"Bob\" .\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q. got\n%s", expected, out)
+		}
+	}
+
+	// OUTPUT IS STABLE
+	var again bytes.Buffer
+	if err := Write(&again, dataset, TriG, map[string]string{"ex": "http://example.org/", "xsd": "http://www.w3.org/2001/XMLSchema#"}); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != out {
+		t.Errorf("expected identical output. got\n%s\nthen\n%s", out, again.String())
+	}
+
+	if err := Write(&buf, dataset, Format("rdfxml"), nil); errors.GetType(err) != errors.InvalidValue {
+		t.Errorf("expected InvalidValue for unknown format, got %v", err)
+	}
+}
+
+func TestUnion(t *testing.T) {
+
+	a, err := Parse(strings.NewReader(`<http://example.org/s> <http://example.org/p> _:b0 .
+_:b0 <http://example.org/q> "a" .
+`), NTriples, "", "a.nt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse(strings.NewReader(`<http://example.org/s> <http://example.org/p> _:b0 .
+<http://example.org/s> <http://example.org/r> "b" <http://example.org/g> .
+`), NQuads, "", "b.nq")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	union := Union([]*ld.RDFDataset{a, b, b})
+
+	// BLANK NODES OF a AND b ARE DISTINCT. STATEMENTS REPEATED BY b ARE NOT
+	expected := []string{
+		`<http://example.org/s> <http://example.org/p> _:d0_b0 .`,
+		`<http://example.org/s> <http://example.org/p> _:d1_b0 .`,
+		`<http://example.org/s> <http://example.org/p> _:d2_b0 .`,
+		`<http://example.org/s> <http://example.org/r> "b" <http://example.org/g> .`,
+		`_:d0_b0 <http://example.org/q> "a" .`,
+	}
+	if actual := nquads(t, union); strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
+
+// THE LABEL _:1b OF DATASET 1 AND _:b OF DATASET 11 MUST STAY DISTINCT
+func TestUnionLabels(t *testing.T) {
+
+	datasets := make([]*ld.RDFDataset, 12)
+	for i := range datasets {
+		datasets[i] = ld.NewRDFDataset()
+	}
+	p := ld.NewIRI("http://example.org/p")
+	datasets[1].Graphs[defaultGraph] = []*ld.Quad{ld.NewQuad(ld.NewBlankNode("_:1b"), p, ld.NewLiteral("1", XSDString, ""), defaultGraph)}
+	datasets[11].Graphs[defaultGraph] = []*ld.Quad{ld.NewQuad(ld.NewBlankNode("_:b"), p, ld.NewLiteral("11", XSDString, ""), defaultGraph)}
+
+	union := Union(datasets)
+
+	subjects := make(map[string]bool)
+	for _, quad := range union.Graphs[defaultGraph] {
+		subjects[quad.Subject.GetValue()] = true
+	}
+	if len(subjects) != 2 {
+		t.Errorf("expected blank nodes of datasets 1 and 11 to be distinct, got %v", subjects)
+	}
+}
@@ -16,20 +16,134 @@ package config
 import (
 	"bytes"
 	"context"
+	"strings"
 	"text/template"
+	"time"
 )
 
+// definitions shared by the user and grapp configuration schemas
+const configJSONSchemaDefinitions = `
+    "definitions": {
+        "iriRewrite": {
+            "description": "Maps a vocabulary IRI (or IRI prefix) to an alternate location",
+            "type": "object",
+            "properties": {
+                "match": {
+                    "description": "how 'from' is matched against an IRI. defaults to exact",
+                    "type": "string",
+                    "enum": ["exact", "prefix"]
+                },
+                "from": {
+                    "description": "the IRI (or IRI prefix) to rewrite",
+                    "type": "string",
+                    "minLength": 1
+                },
+                "to": {
+                    "description": "http(s) url or grapp relative file path to load instead",
+                    "type": "string",
+                    "minLength": 1
+                }
+            },
+            "required": ["from", "to"],
+            "additionalProperties": false
+        },
+        "documentLoader": {
+            "description": "JSON-LD Document Loader Configuration Section",
+            "type": "object",
+            "properties": {
+                "rewrites": {
+                    "description": "IRI rewrite rules. the first matching rule is applied",
+                    "type": "array",
+                    "items": { "$ref": "#/definitions/iriRewrite" }
+                },
+                "limits": { "$ref": "#/definitions/documentLoaderLimits" },
+                "ipfs": {
+                    "description": "resolution of ipfs:// and ipns:// IRIs",
+                    "type": "object",
+                    "properties": {
+                        "apiEndpoint": {
+                            "description": "IPFS node REST API endpoint. defaults to the user's ipfs configuration",
+                            "type": "string"
+                        },
+                        "carFiles": {
+                            "description": "CAR files (absolute or grapp relative) searched for ipfs:// content before the IPFS node",
+                            "type": "array",
+                            "items": { "type": "string", "minLength": 1 }
+                        }
+                    },
+                    "additionalProperties": false
+                },
+                "dataProducts": {
+                    "description": "grapp directories (absolute or grapp relative) of data products referenced by dgrz:// IRIs",
+                    "type": "object",
+                    "additionalProperties": { "type": "string", "minLength": 1 }
+                }
+            },
+            "additionalProperties": false
+        },
+        "documentLoaderLimits": {
+            "description": "Safety limits applied when loading documents. grapp settings take precedence over user settings",
+            "type": "object",
+            "properties": {
+                "timeoutSeconds": {
+                    "description": "maximum time allowed for a single http request",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "maxDocumentSize": {
+                    "description": "maximum size in bytes of a loaded document (of a node object in streamed project files)",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "streamThreshold": {
+                    "description": "size in bytes above which JSON-LD project files with a top-level @graph are processed as a stream",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "maxRedirects": {
+                    "description": "maximum number of http redirects followed per request",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "httpsOnly": {
+                    "description": "refuse to load remote documents over plain http",
+                    "type": "boolean"
+                },
+                "allowDomains": {
+                    "description": "if not empty, only load remote documents from these domains (and their subdomains)",
+                    "type": "array",
+                    "items": { "type": "string", "minLength": 1 }
+                },
+                "denyDomains": {
+                    "description": "never load remote documents from these domains (and their subdomains)",
+                    "type": "array",
+                    "items": { "type": "string", "minLength": 1 }
+                },
+                "maxContextDepth": {
+                    "description": "maximum nesting of contexts loaded by other contexts",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "allowFilePaths": {
+                    "description": "directories outside of the grapp (absolute or grapp relative) that local documents may be loaded from. only honoured in the user configuration",
+                    "type": "array",
+                    "items": { "type": "string", "minLength": 1 }
+                }
+            },
+            "additionalProperties": false
+        }
+    },
+`
+
 const CONFIG_JSON_SCHEMA = `
 {
     "$id": "https://www.datacequia.com/dogg3rz.config.schema.json",
     "$schema": "http://json-schema.org/draft-07/schema#",
     "title": "Dogg3rz configuration",
-    "description": "Configuration schema for dogg3rz",
-    "definitions": {
-       
-    },
+    "description": "Configuration schema for dogg3rz",` + configJSONSchemaDefinitions + `
     "type": "object",
     "properties": {
+        "documentLoader": { "$ref": "#/definitions/documentLoader" },
         "ipfs": {
             "description": "IPFS Node Configuration Section",
             "type": "object",
@@ -85,6 +199,22 @@ const CONFIG_JSON_SCHEMA = `
 }
 `
 
+// GRAPP_CONFIG_JSON_SCHEMA validates the optional configuration
+// file stored in a grapp's .dgrz directory
+const GRAPP_CONFIG_JSON_SCHEMA = `
+{
+    "$id": "https://www.datacequia.com/dogg3rz.grapp.config.schema.json",
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "title": "Dogg3rz grapplication configuration",
+    "description": "Configuration schema for a dogg3rz grapplication",` + configJSONSchemaDefinitions + `
+    "type": "object",
+    "properties": {
+        "documentLoader": { "$ref": "#/definitions/documentLoader" }
+    },
+    "additionalProperties": false
+}
+`
+
 // use CONFIG_JSON_DEFAAULT 	with text/template to generraate default
 const CONFIG_JSON_DEFAULT_TEMPLATE = `
 {
@@ -104,9 +234,193 @@ const (
 	IPFSDeploymentEmbedded   = "embedded"
 )
 
+const (
+	IRIRewriteMatchExact  = "exact"
+	IRIRewriteMatchPrefix = "prefix"
+)
+
 type Dogg3rzConfig struct {
-	IPFS IPFSConfig `json:"ipfs"`
-	User UserConfig `json:"user"`
+	IPFS           IPFSConfig           `json:"ipfs"`
+	User           UserConfig           `json:"user"`
+	DocumentLoader DocumentLoaderConfig `json:"documentLoader"`
+}
+
+// GrappConfig is the configuration declared by a single grapplication
+type GrappConfig struct {
+	DocumentLoader DocumentLoaderConfig `json:"documentLoader"`
+}
+
+type DocumentLoaderConfig struct {
+	Rewrites     []IRIRewrite             `json:"rewrites,omitempty"`
+	Limits       DocumentLoaderLimits     `json:"limits"`
+	IPFS         DocumentLoaderIPFSConfig `json:"ipfs"`
+	DataProducts map[string]string        `json:"dataProducts,omitempty"` // data product name -> grapp dir
+}
+
+// DocumentLoaderIPFSConfig configures how ipfs:// and ipns:// IRIs
+// are resolved
+type DocumentLoaderIPFSConfig struct {
+	ApiEndpoint string   `json:"apiEndpoint,omitempty"`
+	CarFiles    []string `json:"carFiles,omitempty"`
+	Deployment  string   `json:"-"` // ipfs node deployment type from the user's configuration
+}
+
+// default document loader limits
+const (
+	DefaultLoaderTimeoutSeconds  = 30
+	DefaultLoaderMaxDocumentSize = 10 * 1024 * 1024
+	DefaultLoaderMaxRedirects    = 10
+	DefaultLoaderMaxContextDepth = 16
+	DefaultLoaderStreamThreshold = 8 * 1024 * 1024
+)
+
+// DocumentLoaderLimits are the safety limits applied when loading
+// documents. Unset (nil) values fall back to their defaults
+type DocumentLoaderLimits struct {
+	TimeoutSeconds  *int     `json:"timeoutSeconds,omitempty"`
+	MaxDocumentSize *int64   `json:"maxDocumentSize,omitempty"`
+	MaxRedirects    *int     `json:"maxRedirects,omitempty"`
+	HTTPSOnly       *bool    `json:"httpsOnly,omitempty"`
+	AllowDomains    []string `json:"allowDomains,omitempty"`
+	DenyDomains     []string `json:"denyDomains,omitempty"`
+	MaxContextDepth *int     `json:"maxContextDepth,omitempty"`
+	AllowFilePaths  []string `json:"allowFilePaths,omitempty"`
+	StreamThreshold *int64   `json:"streamThreshold,omitempty"`
+}
+
+// Merge returns limits l with unset values taken from 'fallback'.
+// Denied domains and allowed file paths of both are combined. Allowed domains of l replace
+// those of 'fallback' if any are set
+func (l DocumentLoaderLimits) Merge(fallback DocumentLoaderLimits) DocumentLoaderLimits {
+
+	if l.TimeoutSeconds == nil {
+		l.TimeoutSeconds = fallback.TimeoutSeconds
+	}
+	if l.MaxDocumentSize == nil {
+		l.MaxDocumentSize = fallback.MaxDocumentSize
+	}
+	if l.MaxRedirects == nil {
+		l.MaxRedirects = fallback.MaxRedirects
+	}
+	if l.HTTPSOnly == nil {
+		l.HTTPSOnly = fallback.HTTPSOnly
+	}
+	if len(l.AllowDomains) == 0 {
+		l.AllowDomains = fallback.AllowDomains
+	}
+	l.DenyDomains = append(append([]string(nil), l.DenyDomains...), fallback.DenyDomains...)
+	if l.MaxContextDepth == nil {
+		l.MaxContextDepth = fallback.MaxContextDepth
+	}
+	l.AllowFilePaths = append(append([]string(nil), l.AllowFilePaths...), fallback.AllowFilePaths...)
+	if l.StreamThreshold == nil {
+		l.StreamThreshold = fallback.StreamThreshold
+	}
+
+	return l
+}
+
+// Timeout returns the configured http request timeout
+func (l DocumentLoaderLimits) Timeout() time.Duration {
+	if l.TimeoutSeconds == nil {
+		return DefaultLoaderTimeoutSeconds * time.Second
+	}
+	return time.Duration(*l.TimeoutSeconds) * time.Second
+}
+
+// DocumentSize returns the configured maximum document size in bytes
+func (l DocumentLoaderLimits) DocumentSize() int64 {
+	if l.MaxDocumentSize == nil {
+		return DefaultLoaderMaxDocumentSize
+	}
+	return *l.MaxDocumentSize
+}
+
+// Redirects returns the configured maximum number of http redirects
+func (l DocumentLoaderLimits) Redirects() int {
+	if l.MaxRedirects == nil {
+		return DefaultLoaderMaxRedirects
+	}
+	return *l.MaxRedirects
+}
+
+// HTTPSRequired returns true if remote documents must be loaded over https
+func (l DocumentLoaderLimits) HTTPSRequired() bool {
+	return l.HTTPSOnly != nil && *l.HTTPSOnly
+}
+
+// StreamSize returns the configured size in bytes above which project
+// files are processed as a stream
+func (l DocumentLoaderLimits) StreamSize() int64 {
+	if l.StreamThreshold == nil {
+		return DefaultLoaderStreamThreshold
+	}
+	return *l.StreamThreshold
+}
+
+// ContextDepth returns the configured maximum context nesting
+func (l DocumentLoaderLimits) ContextDepth() int {
+	if l.MaxContextDepth == nil {
+		return DefaultLoaderMaxContextDepth
+	}
+	return *l.MaxContextDepth
+}
+
+// DomainAllowed returns true if remote documents may be loaded from host
+func (l DocumentLoaderLimits) DomainAllowed(host string) bool {
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for _, domain := range l.DenyDomains {
+		if domainMatches(host, domain) {
+			return false
+		}
+	}
+
+	if len(l.AllowDomains) == 0 {
+		return true
+	}
+
+	for _, domain := range l.AllowDomains {
+		if domainMatches(host, domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// domainMatches returns true if host is domain or one of its subdomains
+func domainMatches(host string, domain string) bool {
+
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// IRIRewrite maps the IRI 'From' (or IRIs starting with 'From' if
+// 'Match' is prefix) to 'To' when loading documents
+type IRIRewrite struct {
+	Match string `json:"match,omitempty"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Rewrite returns the rewritten iri and true if iri matches this rule
+func (r IRIRewrite) Rewrite(iri string) (string, bool) {
+
+	switch r.Match {
+	case IRIRewriteMatchPrefix:
+		if strings.HasPrefix(iri, r.From) {
+			return r.To + strings.TrimPrefix(iri, r.From), true
+		}
+	default:
+		if iri == r.From {
+			return r.To, true
+		}
+	}
+
+	return iri, false
 }
 
 type IPFSConfig struct {
@@ -24,6 +24,32 @@ type GrapplicationResource interface {
 	// CREATE A NEW GRAPPLICATION
 	Init(ctxt context.Context, grappDirPath string) error
 	Validate(ctxt context.Context, verbose io.Writer) error
+	// RE-VALIDATE CHANGED PROJECT FILES AND THEIR DEPENDENTS UNTIL ctxt IS CANCELLED
+	Watch(ctxt context.Context, out io.Writer, verbose io.Writer) error
+	// SNAPSHOT GRAPPLICATION SOURCE FILES ON THE CURRENT BRANCH. RETURNS SNAPSHOT ID
+	Snapshot(ctxt context.Context, message string, verbose io.Writer) (string, error)
+	// RESOLVE A DID (OR DID URL) TO ITS DID DOCUMENT
+	ResolveDID(ctxt context.Context, did string) ([]byte, error)
+	// IMPORT AN RDF FILE AS A JSON-LD PROJECT FILE. RETURNS THE PATH OF THE NEW FILE
+	Import(ctxt context.Context, src string, options ImportOptions, verbose io.Writer) (string, error)
+	// CONVERT A CSV FILE DESCRIBED BY CSVW METADATA TO A JSON-LD PROJECT FILE. RETURNS THE PATH OF THE NEW FILE
+	ImportCSV(ctxt context.Context, src string, options CSVImportOptions, verbose io.Writer) (string, error)
+	// WRITE THE UNION OF THE GRAPPLICATION'S DATA (OR A SNAPSHOT'S) TO w IN AN RDF SERIALIZATION
+	Export(ctxt context.Context, w io.Writer, options ExportOptions, verbose io.Writer) error
+	// WRITE THE UNION OF THE GRAPPLICATION'S DATA (OR A SNAPSHOT'S) TO w SHAPED BY A JSON-LD FRAME
+	Frame(ctxt context.Context, w io.Writer, options FrameOptions, verbose io.Writer) error
+	// EVALUATE A SPARQL 1.1 QUERY OVER THE GRAPPLICATION'S DATA (OR A SNAPSHOT'S) AND WRITE THE RESULT TO w
+	Query(ctxt context.Context, query string, w io.Writer, options QueryOptions, verbose io.Writer) error
+	// WRITE THE SNAPSHOT CHOSEN BY A SELECTOR (BRANCH, TAG, ID OR ID PREFIX, OPTIONALLY WITH ~N) TO w
+	Show(ctxt context.Context, selector string, w io.Writer, verbose io.Writer) error
+	// NAME THE SNAPSHOT CHOSEN BY A SELECTOR WITH A TAG. RETURNS THE SNAPSHOT ID
+	Tag(ctxt context.Context, name string, selector string) (string, error)
+	// REWRITE JSON-LD FILES (DEFAULT: THE PROJECT FILES) IN FORMATTED FORM OR, WITH options.Check, LIST UNFORMATTED FILES ON out
+	Format(ctxt context.Context, files []string, options FormatOptions, out io.Writer, verbose io.Writer) error
+	// WRITE THE STATEMENTS OF AN RDF, JSON-LD OR YAML-LD FILE TO w AS CANONICAL N-QUADS (RDFC-1.0)
+	Canonicalize(ctxt context.Context, src string, w io.Writer, verbose io.Writer) error
+	// PUT THE CBOR-LD OBJECTS OF THE SNAPSHOT CHOSEN BY A SELECTOR AS IPFS BLOCKS AND WRITE THEIR CIDS TO out
+	Publish(ctxt context.Context, selector string, put func(block []byte) (string, error), out io.Writer, verbose io.Writer) error
 	//CreateDataset(ctxt context.Context, grappName string, datasetPath string) error
 
 	//AddNamespaceDataset(ctxt context.Context, grappName string, datasetPath string, term string, iri string) error
@@ -58,6 +84,53 @@ type GrapplicationResource interface {
 	//Add(ctxt context.Context, grappName string, path string) error
 }
 
+// ImportOptions controls how an RDF file is imported into a grapp
+type ImportOptions struct {
+	Format  string // RDF serialization of the source (default: by file extension)
+	Context string // context to compact with (default: prefixes declared by the source)
+	Output  string // name of the project file to write (default: source name with .jsonld extension)
+	Force   bool   // overwrite an existing project file
+	Source  string // IRI recorded as the location of the source (default: its grapp relative path)
+}
+
+// CSVImportOptions controls how a CSV file is converted to RDF and
+// imported into a grapp
+type CSVImportOptions struct {
+	ImportOptions
+	Metadata string // CSVW metadata file (default: <file>-metadata.json or csv-metadata.json next to the file)
+	Mode     string // conversion mode: standard or minimal (default: standard)
+}
+
+// ExportOptions controls which data of a grapp is exported and how
+type ExportOptions struct {
+	Snapshot string // snapshot selector (default: working tree)
+	Format   string // output serialization
+	Graph    string // restrict output to this named graph ("@default" for the default graph)
+	File     string // restrict output to this project file
+	Context  string // context to compact with (default: prefixes declared by the project files)
+}
+
+// FrameOptions controls which data of a grapp is framed and how
+type FrameOptions struct {
+	Snapshot string // snapshot selector (default: working tree)
+	Frame    string // path of the JSON-LD (or YAML-LD) frame document
+}
+
+// QueryOptions controls which data of a grapp is queried and how the
+// result is written
+type QueryOptions struct {
+	Snapshot string // snapshot selector (default: working tree or, with Blame, the current branch head)
+	Format   string // result serialization: table, json, csv or tsv (default: table)
+	Blame    bool   // report the snapshots in which the triples of a CONSTRUCT or DESCRIBE result appeared and disappeared
+	Since    string // with Blame, selector of the oldest snapshot to evaluate (default: the first snapshot)
+}
+
+// FormatOptions controls how JSON-LD files are formatted
+type FormatOptions struct {
+	Compact bool // compact full IRIs against the document's own context
+	Check   bool // report unformatted files instead of rewriting them
+}
+
 // ALLOWS USER TO STAGE/UNSTAGE (i.e. .Add(), Remove() )  EXISTING (JSON-LD) WORKSPACE RESOURCES ITERATIVELY
 // TO GRAPPLICATION IDENTIFIED BY VALUE RETURNED FROM  .Grapplication()
 // BEFORE FLUSHING STAGED RESOURCES USING .Commit()
@@ -0,0 +1,843 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package sparql
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	"github.com/piprate/json-gold/ld"
+)
+
+// Binding maps variable names to their values in a solution
+type Binding map[string]ld.Node
+
+// extend returns a copy of b with variable bound to value
+func (b Binding) extend(variable string, value ld.Node) Binding {
+
+	extended := make(Binding, len(b)+1)
+	for k, v := range b {
+		extended[k] = v
+	}
+	extended[variable] = value
+
+	return extended
+}
+
+// compatible returns true if b and other bind their shared variables
+// to the same terms
+func (b Binding) compatible(other Binding) bool {
+
+	for k, v := range other {
+		if bound, ok := b[k]; ok && !sameTerm(bound, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// merge returns the union of the compatible bindings b and other
+func (b Binding) merge(other Binding) Binding {
+
+	merged := make(Binding, len(b)+len(other))
+	for k, v := range b {
+		merged[k] = v
+	}
+	for k, v := range other {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// key returns a key identifying the values of vars in b or of all
+// variables if vars is nil
+func (b Binding) key(vars []string) string {
+
+	if vars == nil {
+		for k := range b {
+			vars = append(vars, k)
+		}
+		sort.Strings(vars)
+	}
+
+	var key strings.Builder
+	for _, v := range vars {
+		key.WriteString(v + "=" + termKey(b[v]) + "\x00")
+	}
+
+	return key.String()
+}
+
+// Result is the result of a query: solutions of a SELECT query, the
+// boolean of an ASK query or the graph of a CONSTRUCT or DESCRIBE query
+type Result struct {
+	Form      Form
+	Vars      []string
+	Solutions []Binding
+	Boolean   bool
+	Graph     *ld.RDFDataset
+	Prefixes  map[string]string
+}
+
+// Evaluate evaluates q over the quads of store
+func (q *Query) Evaluate(store quadstore.Store) (*Result, error) {
+
+	e := &evaluator{store: store, now: time.Now(), regexps: make(map[string]*regexp.Regexp)}
+	result := &Result{Form: q.Form, Prefixes: q.Prefixes}
+
+	solutions, vars, err := e.solutions(q, Binding{})
+	if err != nil {
+		return nil, err
+	}
+
+	switch q.Form {
+	case Select:
+		result.Vars, result.Solutions = vars, solutions
+	case Ask:
+		result.Boolean = len(solutions) > 0
+	case Construct:
+		result.Graph = e.construct(q.template, solutions)
+	case Describe:
+		if result.Graph, err = e.describe(q, solutions, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// evaluator evaluates queries over a store
+type evaluator struct {
+	store   quadstore.Store
+	now     time.Time
+	regexps map[string]*regexp.Regexp
+	blanks  int
+	err     error // FIRST STORE ERROR RAISED WHILE EVALUATING AN EXPRESSION
+}
+
+// evalContext is the context in which expressions are evaluated
+type evalContext struct {
+	e     *evaluator
+	graph string    // ACTIVE GRAPH
+	group []Binding // SOLUTIONS OF THE GROUP WHEN EVALUATING AGGREGATES
+}
+
+func (e *evaluator) fail(err error) {
+
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+// blankNode returns a new blank node
+func (e *evaluator) blankNode() ld.Node {
+
+	e.blanks++
+
+	return ld.NewBlankNode(fmt.Sprintf("_:q%d", e.blanks))
+}
+
+// solutions returns the solutions of q compatible with input after
+// grouping, ordering, projection and slicing and the projected variables
+func (e *evaluator) solutions(q *Query, input Binding) ([]Binding, []string, error) {
+
+	solutions := []Binding{input}
+	var err error
+
+	if q.where != nil {
+		if solutions, err = e.evalGroup(q.where, quadstore.DefaultGraph, input); err != nil {
+			return nil, nil, err
+		}
+	}
+	if q.values != nil {
+		solutions = e.join(solutions, q.values)
+	}
+
+	// EACH ROW IS A SOLUTION OR A GROUP OF SOLUTIONS
+	type row struct {
+		binding Binding
+		members []Binding
+		order   []ld.Node
+	}
+	var rows []*row
+
+	if q.grouped {
+		groups, err := e.group(q.groupBy, solutions)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, g := range groups {
+			rows = append(rows, &row{binding: g[0], members: g[1:]})
+		}
+	} else {
+		for _, solution := range solutions {
+			rows = append(rows, &row{binding: solution})
+		}
+	}
+
+	if len(q.having) > 0 {
+		kept := rows[:0]
+		for _, r := range rows {
+			if e.test(q.having, &evalContext{e: e, graph: quadstore.DefaultGraph, group: r.members}, r.binding) {
+				kept = append(kept, r)
+			}
+		}
+		rows = kept
+	}
+
+	for _, r := range rows {
+		ctx := &evalContext{e: e, graph: quadstore.DefaultGraph, group: r.members}
+		for _, proj := range q.projection {
+			if proj.expr == nil {
+				continue
+			}
+			if _, bound := r.binding[proj.variable]; bound && !q.grouped {
+				return nil, nil, errors.InvalidValue.Newf("variable ?%s is already bound", proj.variable)
+			}
+			if value := proj.expr.eval(ctx, r.binding); value != nil {
+				r.binding = r.binding.extend(proj.variable, value)
+			}
+		}
+		for _, condition := range q.orderBy {
+			r.order = append(r.order, condition.expr.eval(ctx, r.binding))
+		}
+	}
+	if e.err != nil {
+		return nil, nil, e.err
+	}
+
+	if len(q.orderBy) > 0 {
+		sort.SliceStable(rows, func(i, j int) bool {
+			for k, condition := range q.orderBy {
+				c := orderCompare(rows[i].order[k], rows[j].order[k])
+				if condition.descending {
+					c = -c
+				}
+				if c != 0 {
+					return c < 0
+				}
+			}
+			return false
+		})
+	}
+
+	vars := q.variables()
+	seen := make(map[string]bool)
+	solutions = solutions[:0:0]
+
+	for _, r := range rows {
+		projected := r.binding
+		if vars != nil {
+			projected = make(Binding, len(vars))
+			for _, v := range vars {
+				if value, ok := r.binding[v]; ok {
+					projected[v] = value
+				}
+			}
+		}
+		if q.distinct {
+			key := projected.key(vars)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		solutions = append(solutions, projected)
+	}
+
+	if offset := q.offset; offset > 0 {
+		if offset > len(solutions) {
+			offset = len(solutions)
+		}
+		solutions = solutions[offset:]
+	}
+	if q.limit >= 0 && q.limit < len(solutions) {
+		solutions = solutions[:q.limit]
+	}
+
+	return solutions, vars, nil
+}
+
+// variables returns the projected variables of q or nil if all
+// variables are kept. Those of SELECT * are the visible variables of
+// its pattern in order of appearance
+func (q *Query) variables() []string {
+
+	if q.Form != Select {
+		return nil
+	}
+	if !q.star {
+		vars := make([]string, len(q.projection))
+		for i, proj := range q.projection {
+			vars[i] = proj.variable
+		}
+		return vars
+	}
+
+	vars := []string{}
+	seen := make(map[string]bool)
+	add := func(v string) {
+		if !seen[v] && !strings.HasPrefix(v, internalVariablePrefix) {
+			seen[v] = true
+			vars = append(vars, v)
+		}
+	}
+	var addTerm func(t term)
+	addTerm = func(t term) {
+		if t.isVariable() {
+			add(t.variable)
+		}
+	}
+	var addGroup func(g *group)
+	addGroup = func(g *group) {
+		for _, el := range g.elements {
+			switch x := el.(type) {
+			case *basicPattern:
+				for _, triple := range x.triples {
+					addTerm(triple.subject)
+					if triple.path == nil {
+						addTerm(triple.predicate)
+					}
+					addTerm(triple.object)
+				}
+			case *group:
+				addGroup(x)
+			case *optionalPattern:
+				addGroup(x.group)
+			case *unionPattern:
+				for _, branch := range x.groups {
+					addGroup(branch)
+				}
+			case *graphPattern:
+				addTerm(x.name)
+				addGroup(x.group)
+			case *bindPattern:
+				add(x.variable)
+			case *values:
+				for _, v := range x.variables {
+					add(v)
+				}
+			case *subQuery:
+				for _, v := range x.query.variables() {
+					add(v)
+				}
+			}
+		}
+	}
+	if q.where != nil {
+		addGroup(q.where)
+	}
+	if q.values != nil {
+		for _, v := range q.values.variables {
+			add(v)
+		}
+	}
+
+	return vars
+}
+
+// group partitions solutions by the values of conditions. Each group
+// is returned as the binding of its grouping variables followed by its
+// solutions. Without conditions all solutions form one group
+func (e *evaluator) group(conditions []projection, solutions []Binding) ([][]Binding, error) {
+
+	var groups [][]Binding
+	index := make(map[string]int)
+
+	if len(conditions) < 1 {
+		return [][]Binding{append([]Binding{{}}, solutions...)}, nil
+	}
+
+	ctx := &evalContext{e: e, graph: quadstore.DefaultGraph}
+	for _, solution := range solutions {
+		binding := Binding{}
+		var key strings.Builder
+		for _, condition := range conditions {
+			value := condition.expr.eval(ctx, solution)
+			key.WriteString(termKey(value) + "\x00")
+			if value == nil {
+				continue
+			}
+			if len(condition.variable) > 0 {
+				binding[condition.variable] = value
+			} else if v, ok := condition.expr.(*variableExpr); ok {
+				binding[v.name] = value
+			}
+		}
+		i, ok := index[key.String()]
+		if !ok {
+			i = len(groups)
+			index[key.String()] = i
+			groups = append(groups, []Binding{binding})
+		}
+		groups[i] = append(groups[i], solution)
+	}
+
+	return groups, e.err
+}
+
+// test returns true if the effective boolean values of filters are true
+func (e *evaluator) test(filters []expression, ctx *evalContext, b Binding) bool {
+
+	for _, filter := range filters {
+		if v, ok := ebv(filter.eval(ctx, b)); !ok || !v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// evalGroup returns the solutions of g in graph that extend input
+func (e *evaluator) evalGroup(g *group, graph string, input Binding) ([]Binding, error) {
+
+	solutions := []Binding{input}
+	var err error
+
+	for _, el := range g.elements {
+		if solutions, err = e.evalElement(el, graph, solutions); err != nil {
+			return nil, err
+		}
+		if len(solutions) < 1 {
+			return nil, nil
+		}
+	}
+
+	if len(g.filters) > 0 {
+		ctx := &evalContext{e: e, graph: graph}
+		kept := solutions[:0:0]
+		for _, solution := range solutions {
+			if e.test(g.filters, ctx, solution) {
+				kept = append(kept, solution)
+			}
+		}
+		solutions = kept
+	}
+
+	return solutions, e.err
+}
+
+// evalElement returns the solutions of el in graph that extend solutions
+func (e *evaluator) evalElement(el element, graph string, solutions []Binding) ([]Binding, error) {
+
+	var results []Binding
+
+	switch x := el.(type) {
+	case *basicPattern:
+		for _, solution := range solutions {
+			matched, err := e.matchTriples(x.triples, graph, solution, nil)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, matched...)
+		}
+
+	case *group:
+		for _, solution := range solutions {
+			matched, err := e.evalGroup(x, graph, solution)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, matched...)
+		}
+
+	case *optionalPattern:
+		for _, solution := range solutions {
+			matched, err := e.evalGroup(x.group, graph, solution)
+			if err != nil {
+				return nil, err
+			}
+			if len(matched) < 1 {
+				matched = []Binding{solution}
+			}
+			results = append(results, matched...)
+		}
+
+	case *unionPattern:
+		for _, solution := range solutions {
+			for _, branch := range x.groups {
+				matched, err := e.evalGroup(branch, graph, solution)
+				if err != nil {
+					return nil, err
+				}
+				results = append(results, matched...)
+			}
+		}
+
+	case *minusPattern:
+		removed, err := e.evalGroup(x.group, graph, Binding{})
+		if err != nil {
+			return nil, err
+		}
+		for _, solution := range solutions {
+			if !minus(solution, removed) {
+				results = append(results, solution)
+			}
+		}
+
+	case *graphPattern:
+		return e.evalGraph(x, solutions)
+
+	case *bindPattern:
+		ctx := &evalContext{e: e, graph: graph}
+		for _, solution := range solutions {
+			if _, bound := solution[x.variable]; bound {
+				return nil, errors.InvalidValue.Newf("BIND: variable ?%s is already bound", x.variable)
+			}
+			if value := x.expr.eval(ctx, solution); value != nil {
+				solution = solution.extend(x.variable, value)
+			}
+			results = append(results, solution)
+		}
+
+	case *values:
+		results = e.join(solutions, x)
+
+	case *subQuery:
+		// SUBQUERIES ARE EVALUATED INDEPENDENTLY AND JOINED
+		inner, _, err := e.solutions(x.query, Binding{})
+		if err != nil {
+			return nil, err
+		}
+		for _, solution := range solutions {
+			for _, i := range inner {
+				if solution.compatible(i) {
+					results = append(results, solution.merge(i))
+				}
+			}
+		}
+	}
+
+	return results, e.err
+}
+
+// minus returns true if solution is compatible with and shares a
+// variable with one of removed
+func minus(solution Binding, removed []Binding) bool {
+
+	for _, r := range removed {
+		shared := false
+		for k := range r {
+			if _, ok := solution[k]; ok {
+				shared = true
+				break
+			}
+		}
+		if shared && solution.compatible(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// join returns the solutions extended by the compatible rows of data
+func (e *evaluator) join(solutions []Binding, data *values) []Binding {
+
+	var results []Binding
+
+	for _, solution := range solutions {
+		for _, row := range data.rows {
+			b := Binding{}
+			for i, v := range data.variables {
+				if row[i] != nil {
+					b[v] = row[i]
+				}
+			}
+			if solution.compatible(b) {
+				results = append(results, solution.merge(b))
+			}
+		}
+	}
+
+	return results
+}
+
+// evalGraph evaluates a GRAPH pattern. A variable graph name ranges
+// over the named graphs of the store
+func (e *evaluator) evalGraph(x *graphPattern, solutions []Binding) ([]Binding, error) {
+
+	var names []string
+	if x.name.isVariable() {
+		graphs, err := e.store.Graphs()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range graphs {
+			if name != quadstore.DefaultGraph {
+				names = append(names, name)
+			}
+		}
+	}
+
+	var results []Binding
+
+	for _, solution := range solutions {
+		if !x.name.isVariable() {
+			matched, err := e.evalGroup(x.group, x.name.node.GetValue(), solution)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, matched...)
+			continue
+		}
+		for _, name := range names {
+			node := graphNode(name)
+			if bound, ok := solution[x.name.variable]; ok && !sameTerm(bound, node) {
+				continue
+			}
+			matched, err := e.evalGroup(x.group, name, solution.extend(x.name.variable, node))
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, matched...)
+		}
+	}
+
+	return results, nil
+}
+
+// graphNode returns the node naming graph
+func graphNode(graph string) ld.Node {
+
+	if strings.HasPrefix(graph, "_:") {
+		return ld.NewBlankNode(graph)
+	}
+
+	return ld.NewIRI(graph)
+}
+
+// resolve returns the value of t in b or nil if t is an unbound variable
+func resolve(t term, b Binding) ld.Node {
+
+	if t.isVariable() {
+		return b[t.variable]
+	}
+
+	return t.node
+}
+
+// bind binds the variable t to value in b returning false if it is
+// bound to a different term
+func bind(t term, value ld.Node, b Binding) (Binding, bool) {
+
+	if !t.isVariable() {
+		return b, true
+	}
+	if bound, ok := b[t.variable]; ok {
+		return b, sameTerm(bound, value)
+	}
+
+	return b.extend(t.variable, value), true
+}
+
+// matchTriples appends the solutions of the triple patterns in graph
+// that extend b to results. The pattern with the most bound terms is
+// matched first
+func (e *evaluator) matchTriples(triples []triplePattern, graph string, b Binding, results []Binding) ([]Binding, error) {
+
+	if len(triples) < 1 {
+		return append(results, b), nil
+	}
+
+	best, bestScore := 0, -1
+	for i, triple := range triples {
+		score := 0
+		for _, t := range []term{triple.subject, triple.predicate, triple.object} {
+			if resolve(t, b) != nil {
+				score++
+			}
+		}
+		if triple.path != nil {
+			// PATHS ARE EXPENSIVE TO EVALUATE WITH BOTH ENDS UNBOUND
+			score--
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	triple := triples[best]
+	rest := make([]triplePattern, 0, len(triples)-1)
+	rest = append(append(rest, triples[:best]...), triples[best+1:]...)
+
+	subject, object := resolve(triple.subject, b), resolve(triple.object, b)
+	if ld.IsLiteral(subject) {
+		return results, nil
+	}
+
+	if triple.path != nil {
+		pairs, err := e.pathPairs(triple.path, graph, subject, object)
+		if err != nil {
+			return nil, err
+		}
+		for _, pair := range pairs {
+			extended, ok := bind(triple.subject, pair[0], b)
+			if !ok {
+				continue
+			}
+			if extended, ok = bind(triple.object, pair[1], extended); !ok {
+				continue
+			}
+			if results, err = e.matchTriples(rest, graph, extended, results); err != nil {
+				return nil, err
+			}
+		}
+		return results, nil
+	}
+
+	predicate := resolve(triple.predicate, b)
+	if predicate != nil && !ld.IsIRI(predicate) {
+		return results, nil
+	}
+
+	it := e.store.Match(quadstore.Pattern{Subject: subject, Predicate: predicate, Object: object, Graph: graph})
+	defer it.Close()
+
+	for {
+		quad, err := it.Next()
+		if err == io.EOF {
+			return results, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		extended, ok := bind(triple.subject, quad.Subject, b)
+		if ok {
+			extended, ok = bind(triple.predicate, quad.Predicate, extended)
+		}
+		if ok {
+			extended, ok = bind(triple.object, quad.Object, extended)
+		}
+		if !ok {
+			continue
+		}
+		if results, err = e.matchTriples(rest, graph, extended, results); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// construct instantiates template with each of solutions. Blank nodes
+// of the template are fresh for each solution
+func (e *evaluator) construct(template []triplePattern, solutions []Binding) *ld.RDFDataset {
+
+	dataset := ld.NewRDFDataset()
+	seen := make(map[string]bool)
+
+	for _, solution := range solutions {
+		blanks := make(map[string]ld.Node)
+		instantiate := func(t term) ld.Node {
+			if t.isVariable() {
+				return solution[t.variable]
+			}
+			if ld.IsBlankNode(t.node) {
+				if _, ok := blanks[t.node.GetValue()]; !ok {
+					blanks[t.node.GetValue()] = e.blankNode()
+				}
+				return blanks[t.node.GetValue()]
+			}
+			return t.node
+		}
+		for _, triple := range template {
+			s, p, o := instantiate(triple.subject), instantiate(triple.predicate), instantiate(triple.object)
+			// TRIPLES WITH UNBOUND OR INVALID TERMS ARE LEFT OUT
+			if s == nil || p == nil || o == nil || ld.IsLiteral(s) || !ld.IsIRI(p) {
+				continue
+			}
+			e.addTriple(dataset, seen, s, p, o)
+		}
+	}
+
+	return dataset
+}
+
+func (e *evaluator) addTriple(dataset *ld.RDFDataset, seen map[string]bool, s ld.Node, p ld.Node, o ld.Node) bool {
+
+	key := termKey(s) + " " + termKey(p) + " " + termKey(o)
+	if seen[key] {
+		return false
+	}
+	seen[key] = true
+	dataset.Graphs[quadstore.DefaultGraph] = append(dataset.Graphs[quadstore.DefaultGraph],
+		ld.NewQuad(s, p, o, quadstore.DefaultGraph))
+
+	return true
+}
+
+// describe returns the statements of all graphs about the described
+// resources and, recursively, about the blank nodes they reference
+func (e *evaluator) describe(q *Query, solutions []Binding, vars []string) (*ld.RDFDataset, error) {
+
+	var resources []ld.Node
+	for _, described := range q.describe {
+		if !described.isVariable() {
+			resources = append(resources, described.node)
+			continue
+		}
+		for _, solution := range solutions {
+			if value := solution[described.variable]; value != nil {
+				resources = append(resources, value)
+			}
+		}
+	}
+	if q.star {
+		for _, solution := range solutions {
+			for k, value := range solution {
+				if !strings.HasPrefix(k, internalVariablePrefix) {
+					resources = append(resources, value)
+				}
+			}
+		}
+	}
+
+	dataset := ld.NewRDFDataset()
+	seen := make(map[string]bool)
+	described := make(map[string]bool)
+
+	for len(resources) > 0 {
+		resource := resources[0]
+		resources = resources[1:]
+		if ld.IsLiteral(resource) || described[termKey(resource)] {
+			continue
+		}
+		described[termKey(resource)] = true
+
+		it := e.store.Match(quadstore.Pattern{Subject: resource})
+		for {
+			quad, err := it.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				it.Close()
+				return nil, err
+			}
+			if e.addTriple(dataset, seen, quad.Subject, quad.Predicate, quad.Object) && ld.IsBlankNode(quad.Object) {
+				resources = append(resources, quad.Object)
+			}
+		}
+		it.Close()
+	}
+
+	return dataset, nil
+}
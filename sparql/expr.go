@@ -0,0 +1,903 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package sparql
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/piprate/json-gold/ld"
+)
+
+// expression is a FILTER, BIND, projection, grouping, ordering or
+// HAVING expression. eval returns nil if the expression is in error
+// (e.g. an unbound variable or a type error), which is not fatal
+type expression interface {
+	eval(ctx *evalContext, b Binding) ld.Node
+}
+
+type (
+	variableExpr struct {
+		name string
+	}
+	constantExpr struct {
+		node ld.Node
+	}
+	// || && = != < > <= >= + - * /
+	binaryExpr struct {
+		op          string
+		left, right expression
+	}
+	// ! - +
+	unaryExpr struct {
+		op   string
+		expr expression
+	}
+	inExpr struct {
+		expr    expression
+		list    []expression
+		negated bool
+	}
+	existsExpr struct {
+		group   *group
+		negated bool
+	}
+	// BUILT-IN FUNCTION (UPPER CASE NAME) OR CAST (DATATYPE IRI)
+	callExpr struct {
+		name string
+		args []expression
+	}
+	aggregateExpr struct {
+		name      string
+		distinct  bool
+		arg       expression // NIL FOR COUNT(*)
+		separator string
+	}
+)
+
+func (x *variableExpr) eval(ctx *evalContext, b Binding) ld.Node {
+	return b[x.name]
+}
+
+func (x *constantExpr) eval(ctx *evalContext, b Binding) ld.Node {
+	return x.node
+}
+
+func (x *binaryExpr) eval(ctx *evalContext, b Binding) ld.Node {
+
+	switch x.op {
+	case "||", "&&":
+		// AN ERROR ON ONE SIDE IS MASKED IF THE OTHER SIDE DECIDES THE RESULT
+		left, lok := ebv(x.left.eval(ctx, b))
+		right, rok := ebv(x.right.eval(ctx, b))
+		decisive := x.op == "||"
+		switch {
+		case (lok && left == decisive) || (rok && right == decisive):
+			return booleanNode(decisive)
+		case lok && rok:
+			return booleanNode(!decisive)
+		}
+		return nil
+	}
+
+	left := x.left.eval(ctx, b)
+	right := x.right.eval(ctx, b)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	switch x.op {
+	case "=", "!=":
+		equal, ok := termsEqual(left, right)
+		if !ok {
+			return nil
+		}
+		return booleanNode(equal == (x.op == "="))
+	case "<", ">", "<=", ">=":
+		c, ok := compare(left, right)
+		if !ok {
+			return nil
+		}
+		switch x.op {
+		case "<":
+			return booleanNode(c < 0)
+		case ">":
+			return booleanNode(c > 0)
+		case "<=":
+			return booleanNode(c <= 0)
+		}
+		return booleanNode(c >= 0)
+	}
+
+	l, lok := toNumeric(left)
+	r, rok := toNumeric(right)
+	if !lok || !rok {
+		return nil
+	}
+	result, ok := arithmetic(x.op, l, r)
+	if !ok {
+		return nil
+	}
+
+	return result.node()
+}
+
+func (x *unaryExpr) eval(ctx *evalContext, b Binding) ld.Node {
+
+	value := x.expr.eval(ctx, b)
+	if value == nil {
+		return nil
+	}
+
+	if x.op == "!" {
+		v, ok := ebv(value)
+		if !ok {
+			return nil
+		}
+		return booleanNode(!v)
+	}
+
+	n, ok := toNumeric(value)
+	if !ok {
+		return nil
+	}
+	if x.op == "-" {
+		n.i, n.f = -n.i, -n.f
+	}
+
+	return n.node()
+}
+
+func (x *inExpr) eval(ctx *evalContext, b Binding) ld.Node {
+
+	value := x.expr.eval(ctx, b)
+	if value == nil {
+		return nil
+	}
+
+	failed := false
+	for _, item := range x.list {
+		member := item.eval(ctx, b)
+		if member == nil {
+			failed = true
+			continue
+		}
+		equal, ok := termsEqual(value, member)
+		if !ok {
+			failed = true
+			continue
+		}
+		if equal {
+			return booleanNode(!x.negated)
+		}
+	}
+	if failed {
+		return nil
+	}
+
+	return booleanNode(x.negated)
+}
+
+func (x *existsExpr) eval(ctx *evalContext, b Binding) ld.Node {
+
+	solutions, err := ctx.e.evalGroup(x.group, ctx.graph, b)
+	if err != nil {
+		ctx.e.fail(err)
+		return nil
+	}
+
+	return booleanNode((len(solutions) > 0) != x.negated)
+}
+
+// walkExpression calls fn with expr and its subexpressions depth first.
+// The subexpressions of an expression are skipped if fn returns false
+func walkExpression(expr expression, fn func(expression) bool) {
+
+	if expr == nil || !fn(expr) {
+		return
+	}
+
+	switch x := expr.(type) {
+	case *binaryExpr:
+		walkExpression(x.left, fn)
+		walkExpression(x.right, fn)
+	case *unaryExpr:
+		walkExpression(x.expr, fn)
+	case *inExpr:
+		walkExpression(x.expr, fn)
+		for _, item := range x.list {
+			walkExpression(item, fn)
+		}
+	case *callExpr:
+		for _, arg := range x.args {
+			walkExpression(arg, fn)
+		}
+	case *aggregateExpr:
+		walkExpression(x.arg, fn)
+	}
+}
+
+// hasAggregate returns true if expr contains an aggregate
+func hasAggregate(expr expression) bool {
+
+	found := false
+	walkExpression(expr, func(e expression) bool {
+		if _, ok := e.(*aggregateExpr); ok {
+			found = true
+		}
+		return !found
+	})
+
+	return found
+}
+
+// ungroupedVariable returns a variable used by expr outside of an
+// aggregate that is not in grouped or "" if there is none
+func ungroupedVariable(expr expression, grouped map[string]bool) string {
+
+	var ungrouped string
+	walkExpression(expr, func(e expression) bool {
+		switch x := e.(type) {
+		case *aggregateExpr:
+			return false
+		case *variableExpr:
+			if !grouped[x.name] && len(ungrouped) < 1 {
+				ungrouped = x.name
+			}
+		}
+		return len(ungrouped) < 1
+	})
+
+	return ungrouped
+}
+
+// aggregatesUsed returns true if the projection, HAVING or ORDER BY
+// clauses of q use aggregates
+func (q *Query) aggregatesUsed() bool {
+
+	for _, proj := range q.projection {
+		if hasAggregate(proj.expr) {
+			return true
+		}
+	}
+	for _, condition := range q.orderBy {
+		if hasAggregate(condition.expr) {
+			return true
+		}
+	}
+
+	return len(q.having) > 0
+}
+
+// ebv returns the effective boolean value of value or false if it has none
+func ebv(value ld.Node) (bool, bool) {
+
+	literal, ok := value.(*ld.Literal)
+	if !ok {
+		return false, false
+	}
+
+	switch datatype(literal) {
+	case rdf.XSDBoolean:
+		return literal.Value == "true" || literal.Value == "1", true
+	case rdf.XSDString:
+		return len(literal.Value) > 0, true
+	}
+
+	if n, ok := toNumeric(literal); ok {
+		if n.kind == numericInteger {
+			return n.i != 0, true
+		}
+		return n.f != 0 && !math.IsNaN(n.f), true
+	}
+
+	return false, false
+}
+
+// datatype returns the datatype IRI of literal
+func datatype(literal *ld.Literal) string {
+
+	switch {
+	case len(literal.Language) > 0:
+		return ld.RDFLangString
+	case len(literal.Datatype) < 1:
+		return rdf.XSDString
+	}
+
+	return literal.Datatype
+}
+
+func booleanNode(v bool) ld.Node {
+	return ld.NewLiteral(strconv.FormatBool(v), rdf.XSDBoolean, "")
+}
+
+func stringNode(s string, language string) ld.Node {
+
+	if len(language) > 0 {
+		return ld.NewLiteral(s, ld.RDFLangString, language)
+	}
+
+	return ld.NewLiteral(s, rdf.XSDString, "")
+}
+
+// termKey returns a key identifying the RDF term n. Simple literals
+// and xsd:string literals share their keys
+func termKey(n ld.Node) string {
+
+	if n == nil {
+		return ""
+	}
+
+	return rdf.HDTTerm(n)
+}
+
+// sameTerm returns true if a and b are the same RDF term
+func sameTerm(a ld.Node, b ld.Node) bool {
+	return a != nil && b != nil && termKey(a) == termKey(b)
+}
+
+// termsEqual returns true if a and b are equal values. It returns false
+// for its second value if a and b are literals that cannot be compared
+func termsEqual(a ld.Node, b ld.Node) (bool, bool) {
+
+	if c, ok := compare(a, b); ok {
+		return c == 0, true
+	}
+	if sameTerm(a, b) {
+		return true, true
+	}
+	if _, ok := a.(*ld.Literal); ok {
+		if _, ok := b.(*ld.Literal); ok {
+			return false, false
+		}
+	}
+
+	return false, true
+}
+
+// compare compares the values of the literals a and b. It returns false
+// for its second value if they are not comparable
+func compare(a ld.Node, b ld.Node) (int, bool) {
+
+	la, ok := a.(*ld.Literal)
+	if !ok {
+		return 0, false
+	}
+	lb, ok := b.(*ld.Literal)
+	if !ok {
+		return 0, false
+	}
+
+	if na, ok := toNumeric(la); ok {
+		nb, ok := toNumeric(lb)
+		if !ok {
+			return 0, false
+		}
+		return compareNumeric(na, nb), true
+	}
+
+	da, db := datatype(la), datatype(lb)
+	switch {
+	case da != db:
+		return 0, false
+	case da == ld.RDFLangString:
+		if !strings.EqualFold(la.Language, lb.Language) {
+			return 0, false
+		}
+		return strings.Compare(la.Value, lb.Value), true
+	case da == rdf.XSDBoolean:
+		va, oka := ebv(la)
+		vb, okb := ebv(lb)
+		if !oka || !okb {
+			return 0, false
+		}
+		switch {
+		case va == vb:
+			return 0, true
+		case vb:
+			return -1, true
+		}
+		return 1, true
+	case da == rdf.XSDString || da == xsdDateTime || da == xsdDate || da == xsdTime:
+		// DATES AND TIMES IN THE SAME TIME ZONE COMPARE LEXICALLY
+		return strings.Compare(la.Value, lb.Value), true
+	}
+
+	return 0, false
+}
+
+// orderCompare compares a and b for ORDER BY, MIN and MAX. Unbound
+// values come first, then blank nodes, IRIs and literals
+func orderCompare(a ld.Node, b ld.Node) int {
+
+	rank := func(n ld.Node) int {
+		switch {
+		case n == nil:
+			return 0
+		case ld.IsBlankNode(n):
+			return 1
+		case ld.IsIRI(n):
+			return 2
+		}
+		return 3
+	}
+
+	ra, rb := rank(a), rank(b)
+	switch {
+	case ra != rb:
+		return ra - rb
+	case ra == 0:
+		return 0
+	case ra == 3:
+		if c, ok := compare(a, b); ok && c != 0 {
+			return c
+		}
+	}
+
+	return strings.Compare(termKey(a), termKey(b))
+}
+
+const (
+	xsd         = "http://www.w3.org/2001/XMLSchema#"
+	xsdFloat    = xsd + "float"
+	xsdDateTime = xsd + "dateTime"
+	xsdDate     = xsd + "date"
+	xsdTime     = xsd + "time"
+)
+
+// numeric kinds in order of type promotion
+const (
+	numericInteger = iota
+	numericDecimal
+	numericFloat
+	numericDouble
+)
+
+// numeric is the value of a numeric literal. i holds integers and f the
+// other kinds
+type numeric struct {
+	kind int
+	i    int64
+	f    float64
+}
+
+// integerTypes are xsd:integer and the datatypes derived from it
+var integerTypes = map[string]bool{
+	rdf.XSDInteger: true, xsd + "int": true, xsd + "long": true, xsd + "short": true, xsd + "byte": true,
+	xsd + "nonNegativeInteger": true, xsd + "positiveInteger": true, xsd + "nonPositiveInteger": true,
+	xsd + "negativeInteger": true, xsd + "unsignedLong": true, xsd + "unsignedInt": true,
+	xsd + "unsignedShort": true, xsd + "unsignedByte": true,
+}
+
+// toNumeric returns the value of the numeric literal n
+func toNumeric(n ld.Node) (numeric, bool) {
+
+	literal, ok := n.(*ld.Literal)
+	if !ok {
+		return numeric{}, false
+	}
+
+	value := strings.TrimSpace(literal.Value)
+
+	switch dt := literal.Datatype; {
+	case integerTypes[dt]:
+		i, err := strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64)
+		return numeric{kind: numericInteger, i: i}, err == nil
+	case dt == rdf.XSDDecimal:
+		if strings.ContainsAny(value, "eEnN") {
+			return numeric{}, false
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		return numeric{kind: numericDecimal, f: f}, err == nil
+	case dt == rdf.XSDDouble || dt == xsdFloat:
+		kind := numericDouble
+		if dt == xsdFloat {
+			kind = numericFloat
+		}
+		switch value {
+		case "INF", "+INF":
+			return numeric{kind: kind, f: math.Inf(1)}, true
+		case "-INF":
+			return numeric{kind: kind, f: math.Inf(-1)}, true
+		case "NaN":
+			return numeric{kind: kind, f: math.NaN()}, true
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		return numeric{kind: kind, f: f}, err == nil && !strings.ContainsAny(value, "nN")
+	}
+
+	return numeric{}, false
+}
+
+// promote returns n as a value of kind
+func (n numeric) promote(kind int) numeric {
+
+	if n.kind == numericInteger && kind != numericInteger {
+		n.f = float64(n.i)
+	}
+	n.kind = kind
+
+	return n
+}
+
+func (n numeric) float() float64 {
+
+	if n.kind == numericInteger {
+		return float64(n.i)
+	}
+
+	return n.f
+}
+
+func compareNumeric(a numeric, b numeric) int {
+
+	if a.kind == numericInteger && b.kind == numericInteger {
+		switch {
+		case a.i < b.i:
+			return -1
+		case a.i > b.i:
+			return 1
+		}
+		return 0
+	}
+
+	fa, fb := a.float(), b.float()
+	switch {
+	case fa < fb:
+		return -1
+	case fa > fb:
+		return 1
+	}
+
+	return 0
+}
+
+// arithmetic applies the operator op to a and b. It returns false for
+// its second value on division of an integer or decimal by zero
+func arithmetic(op string, a numeric, b numeric) (numeric, bool) {
+
+	kind := a.kind
+	if b.kind > kind {
+		kind = b.kind
+	}
+	if op == "/" && kind == numericInteger {
+		kind = numericDecimal
+	}
+	a, b = a.promote(kind), b.promote(kind)
+
+	if kind == numericInteger {
+		switch op {
+		case "+":
+			return numeric{kind: kind, i: a.i + b.i}, true
+		case "-":
+			return numeric{kind: kind, i: a.i - b.i}, true
+		}
+		return numeric{kind: kind, i: a.i * b.i}, true
+	}
+
+	switch op {
+	case "+":
+		return numeric{kind: kind, f: a.f + b.f}, true
+	case "-":
+		return numeric{kind: kind, f: a.f - b.f}, true
+	case "*":
+		return numeric{kind: kind, f: a.f * b.f}, true
+	}
+	if b.f == 0 && kind == numericDecimal {
+		return numeric{}, false
+	}
+
+	return numeric{kind: kind, f: a.f / b.f}, true
+}
+
+// node returns the canonical literal of n
+func (n numeric) node() ld.Node {
+
+	switch n.kind {
+	case numericInteger:
+		return ld.NewLiteral(strconv.FormatInt(n.i, 10), rdf.XSDInteger, "")
+	case numericDecimal:
+		s := strconv.FormatFloat(n.f, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return ld.NewLiteral(s, rdf.XSDDecimal, "")
+	case numericFloat:
+		return ld.NewLiteral(formatDouble(n.f), xsdFloat, "")
+	}
+
+	return ld.NewLiteral(formatDouble(n.f), rdf.XSDDouble, "")
+}
+
+// formatDouble returns the canonical lexical form of a double e.g. 1.5E2
+func formatDouble(f float64) string {
+
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	}
+
+	s := strconv.FormatFloat(f, 'E', -1, 64)
+	i := strings.IndexByte(s, 'E')
+	mantissa, exponent := s[:i], s[i+1:]
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	exp, _ := strconv.Atoi(exponent)
+
+	return mantissa + "E" + strconv.Itoa(exp)
+}
+
+// expression parses an expression
+func (p *parser) expression() (expression, error) {
+	return p.binary(0)
+}
+
+// binary operators by precedence, lowest first
+var binaryOperators = [][]string{{"||"}, {"&&"}, {"=", "!=", "<", ">", "<=", ">="}, {"+", "-"}, {"*", "/"}}
+
+// binary parses the operators of precedence level and above
+func (p *parser) binary(level int) (expression, error) {
+
+	if level == len(binaryOperators) {
+		return p.unaryExpression()
+	}
+
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+
+		if level == 2 && (t.is("IN") || (t.is("NOT") && p.peekAt(1).is("IN"))) {
+			negated := p.accept("NOT")
+			p.advance()
+			list, err := p.argList(true)
+			if err != nil {
+				return nil, err
+			}
+			return &inExpr{expr: left, list: list, negated: negated}, nil
+		}
+
+		op := ""
+		for _, candidate := range binaryOperators[level] {
+			if t.is(candidate) {
+				op = candidate
+			}
+		}
+		if len(op) < 1 {
+			return left, nil
+		}
+		p.advance()
+
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, left: left, right: right}
+
+		if level == 2 {
+			// RELATIONAL OPERATORS DO NOT ASSOCIATE
+			return left, nil
+		}
+	}
+}
+
+func (p *parser) unaryExpression() (expression, error) {
+
+	for _, op := range []string{"!", "-", "+"} {
+		if p.accept(op) {
+			expr, err := p.unaryExpression()
+			if err != nil {
+				return nil, err
+			}
+			return &unaryExpr{op: op, expr: expr}, nil
+		}
+	}
+
+	return p.primaryExpression()
+}
+
+func (p *parser) primaryExpression() (expression, error) {
+
+	t := p.peek()
+
+	switch {
+	case t.is("("):
+		p.advance()
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(")")
+
+	case t.kind == tokenVar:
+		p.advance()
+		return &variableExpr{name: t.text}, nil
+
+	case t.kind == tokenWord && !t.is("true") && !t.is("false"):
+		return p.builtInCall()
+
+	case (t.kind == tokenIRI || t.kind == tokenPName) && p.peekAt(1).is("("):
+		p.advance()
+		iri, err := p.iri(t)
+		if err != nil {
+			return nil, err
+		}
+		if !castTypes[iri.GetValue()] {
+			return nil, p.errorf(t, "unsupported function <%s>", iri.GetValue())
+		}
+		args, err := p.argList(false)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) != 1 {
+			return nil, p.errorf(t, "cast to <%s> takes one argument", iri.GetValue())
+		}
+		return &callExpr{name: iri.GetValue(), args: args}, nil
+	}
+
+	value, err := p.varOrTerm()
+	if err != nil {
+		return nil, err
+	}
+	if value.isVariable() || ld.IsBlankNode(value.node) {
+		return nil, p.errorf(t, "blank nodes are not allowed in expressions")
+	}
+
+	return &constantExpr{node: value.node}, nil
+}
+
+// constraint parses a FILTER, HAVING or ORDER BY constraint: a
+// bracketted expression or a function call
+func (p *parser) constraint() (expression, error) {
+
+	if t := p.peek(); !t.is("(") && !p.isCall() {
+		return nil, p.errorf(t, "expected '(' or function call but found '%s'", t.text)
+	}
+
+	return p.primaryExpression()
+}
+
+// isCall returns true if the next tokens start a function call
+func (p *parser) isCall() bool {
+
+	t := p.peek()
+
+	switch t.kind {
+	case tokenIRI, tokenPName:
+		return p.peekAt(1).is("(")
+	case tokenWord:
+		name := strings.ToUpper(t.text)
+		_, builtin := builtins[name]
+		return builtin || aggregates[name] || name == "EXISTS" || (name == "NOT" && p.peekAt(1).is("EXISTS"))
+	}
+
+	return false
+}
+
+// argList parses a bracketted, comma separated list of expressions.
+// An empty list is allowed if empty is true
+func (p *parser) argList(empty bool) ([]expression, error) {
+
+	if t := p.peek(); t.kind != tokenPunct || t.text != "(" {
+		return nil, p.expect("(")
+	}
+	p.advance()
+
+	var args []expression
+	if p.accept(")") {
+		return args, nil
+	}
+	for {
+		arg, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	return args, p.expect(")")
+}
+
+func (p *parser) builtInCall() (expression, error) {
+
+	t := p.advance()
+	name := strings.ToUpper(t.text)
+
+	if name == "EXISTS" || name == "NOT" {
+		negated := name == "NOT"
+		if negated {
+			p.advance()
+		}
+		g, err := p.groupGraphPattern()
+		if err != nil {
+			return nil, err
+		}
+		return &existsExpr{group: g, negated: negated}, nil
+	}
+
+	if aggregates[name] {
+		return p.aggregate(t, name)
+	}
+
+	arity, ok := builtins[name]
+	if !ok {
+		return nil, p.errorf(t, "unknown function '%s'", t.text)
+	}
+
+	args, err := p.argList(true)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < arity[0] || (arity[1] >= 0 && len(args) > arity[1]) {
+		return nil, p.errorf(t, "wrong number of arguments to %s", name)
+	}
+	if name == "BOUND" {
+		if _, ok := args[0].(*variableExpr); !ok {
+			return nil, p.errorf(t, "BOUND takes a variable")
+		}
+	}
+
+	return &callExpr{name: name, args: args}, nil
+}
+
+func (p *parser) aggregate(t token, name string) (expression, error) {
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	agg := &aggregateExpr{name: name, distinct: p.accept("DISTINCT"), separator: " "}
+
+	if name == "COUNT" && p.accept("*") {
+		return agg, p.expect(")")
+	}
+
+	arg, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if hasAggregate(arg) {
+		return nil, p.errorf(t, "aggregates cannot be nested")
+	}
+	agg.arg = arg
+
+	if name == "GROUP_CONCAT" && p.accept(";") {
+		if err = p.expect("SEPARATOR"); err != nil {
+			return nil, err
+		}
+		if err = p.expect("="); err != nil {
+			return nil, err
+		}
+		s := p.advance()
+		if s.kind != tokenString {
+			return nil, p.errorf(s, "expected separator string")
+		}
+		agg.separator = s.text
+	}
+
+	return agg, p.expect(")")
+}
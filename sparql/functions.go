@@ -0,0 +1,694 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package sparql
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/piprate/json-gold/ld"
+)
+
+// builtins maps the built-in functions to their minimum and maximum
+// number of arguments (-1 if unlimited)
+var builtins = map[string][2]int{
+	"BOUND": {1, 1}, "IF": {3, 3}, "COALESCE": {0, -1}, "SAMETERM": {2, 2},
+	"ISIRI": {1, 1}, "ISURI": {1, 1}, "ISBLANK": {1, 1}, "ISLITERAL": {1, 1}, "ISNUMERIC": {1, 1},
+	"STR": {1, 1}, "LANG": {1, 1}, "DATATYPE": {1, 1}, "LANGMATCHES": {2, 2},
+	"IRI": {1, 1}, "URI": {1, 1}, "BNODE": {0, 1}, "STRDT": {2, 2}, "STRLANG": {2, 2},
+	"STRLEN": {1, 1}, "SUBSTR": {2, 3}, "UCASE": {1, 1}, "LCASE": {1, 1},
+	"STRSTARTS": {2, 2}, "STRENDS": {2, 2}, "CONTAINS": {2, 2}, "STRBEFORE": {2, 2}, "STRAFTER": {2, 2},
+	"ENCODE_FOR_URI": {1, 1}, "CONCAT": {0, -1}, "REGEX": {2, 3}, "REPLACE": {3, 4},
+	"ABS": {1, 1}, "ROUND": {1, 1}, "CEIL": {1, 1}, "FLOOR": {1, 1}, "RAND": {0, 0},
+	"NOW": {0, 0}, "YEAR": {1, 1}, "MONTH": {1, 1}, "DAY": {1, 1},
+	"HOURS": {1, 1}, "MINUTES": {1, 1}, "SECONDS": {1, 1},
+	"MD5": {1, 1}, "SHA1": {1, 1}, "SHA256": {1, 1}, "SHA384": {1, 1}, "SHA512": {1, 1},
+}
+
+// aggregates are the names of the aggregate functions
+var aggregates = map[string]bool{
+	"COUNT": true, "SUM": true, "MIN": true, "MAX": true, "AVG": true, "SAMPLE": true, "GROUP_CONCAT": true,
+}
+
+// castTypes are the datatypes whose IRIs may be called as cast functions
+var castTypes = map[string]bool{
+	rdf.XSDString: true, rdf.XSDInteger: true, rdf.XSDDecimal: true, rdf.XSDDouble: true,
+	xsdFloat: true, rdf.XSDBoolean: true, xsdDateTime: true,
+}
+
+func (x *callExpr) eval(ctx *evalContext, b Binding) ld.Node {
+
+	// FUNCTIONS NOT EVALUATING ALL OF THEIR ARGUMENTS
+	switch x.name {
+	case "BOUND":
+		return booleanNode(x.args[0].eval(ctx, b) != nil)
+	case "IF":
+		condition, ok := ebv(x.args[0].eval(ctx, b))
+		switch {
+		case !ok:
+			return nil
+		case condition:
+			return x.args[1].eval(ctx, b)
+		}
+		return x.args[2].eval(ctx, b)
+	case "COALESCE":
+		for _, arg := range x.args {
+			if value := arg.eval(ctx, b); value != nil {
+				return value
+			}
+		}
+		return nil
+	}
+
+	args := make([]ld.Node, len(x.args))
+	for i, arg := range x.args {
+		if args[i] = arg.eval(ctx, b); args[i] == nil {
+			return nil
+		}
+	}
+
+	if castTypes[x.name] {
+		return cast(args[0], x.name)
+	}
+
+	switch x.name {
+	case "SAMETERM":
+		return booleanNode(sameTerm(args[0], args[1]))
+	case "ISIRI", "ISURI":
+		return booleanNode(ld.IsIRI(args[0]))
+	case "ISBLANK":
+		return booleanNode(ld.IsBlankNode(args[0]))
+	case "ISLITERAL":
+		return booleanNode(ld.IsLiteral(args[0]))
+	case "ISNUMERIC":
+		_, ok := toNumeric(args[0])
+		return booleanNode(ok)
+	case "STR":
+		if ld.IsBlankNode(args[0]) {
+			return nil
+		}
+		return stringNode(args[0].GetValue(), "")
+	case "LANG":
+		if literal, ok := args[0].(*ld.Literal); ok {
+			return stringNode(literal.Language, "")
+		}
+		return nil
+	case "DATATYPE":
+		if literal, ok := args[0].(*ld.Literal); ok {
+			return ld.NewIRI(datatype(literal))
+		}
+		return nil
+	case "LANGMATCHES":
+		tag, _, ok1 := stringArg(args[0])
+		pattern, _, ok2 := stringArg(args[1])
+		if !ok1 || !ok2 {
+			return nil
+		}
+		return booleanNode(langMatches(tag, pattern))
+	case "IRI", "URI":
+		if ld.IsIRI(args[0]) {
+			return args[0]
+		}
+		if s, lang, ok := stringArg(args[0]); ok && len(lang) < 1 {
+			return ld.NewIRI(s)
+		}
+		return nil
+	case "BNODE":
+		return ctx.e.blankNode()
+	case "STRDT":
+		s, lang, ok := stringArg(args[0])
+		if !ok || len(lang) > 0 || !ld.IsIRI(args[1]) {
+			return nil
+		}
+		return ld.NewLiteral(s, args[1].GetValue(), "")
+	case "STRLANG":
+		s, lang, ok1 := stringArg(args[0])
+		tag, _, ok2 := stringArg(args[1])
+		if !ok1 || !ok2 || len(lang) > 0 || len(tag) < 1 {
+			return nil
+		}
+		return stringNode(s, strings.ToLower(tag))
+	case "STRLEN":
+		if s, _, ok := stringArg(args[0]); ok {
+			return numeric{kind: numericInteger, i: int64(utf8.RuneCountInString(s))}.node()
+		}
+		return nil
+	case "SUBSTR":
+		return substr(args)
+	case "UCASE", "LCASE":
+		s, lang, ok := stringArg(args[0])
+		if !ok {
+			return nil
+		}
+		if x.name == "UCASE" {
+			return stringNode(strings.ToUpper(s), lang)
+		}
+		return stringNode(strings.ToLower(s), lang)
+	case "STRSTARTS", "STRENDS", "CONTAINS", "STRBEFORE", "STRAFTER":
+		return stringTest(x.name, args[0], args[1])
+	case "ENCODE_FOR_URI":
+		if s, _, ok := stringArg(args[0]); ok {
+			return stringNode(encodeForURI(s), "")
+		}
+		return nil
+	case "CONCAT":
+		return concat(args)
+	case "REGEX", "REPLACE":
+		return ctx.e.regex(x.name, args)
+	case "ABS", "ROUND", "CEIL", "FLOOR":
+		return rounding(x.name, args[0])
+	case "RAND":
+		return numeric{kind: numericDouble, f: rand.Float64()}.node()
+	case "NOW":
+		return ld.NewLiteral(ctx.e.now.Format(time.RFC3339Nano), xsdDateTime, "")
+	case "YEAR", "MONTH", "DAY", "HOURS", "MINUTES", "SECONDS":
+		return dateTimePart(x.name, args[0])
+	case "MD5", "SHA1", "SHA256", "SHA384", "SHA512":
+		return digest(x.name, args[0])
+	}
+
+	return nil
+}
+
+// stringArg returns the lexical form and language of a string literal
+func stringArg(n ld.Node) (string, string, bool) {
+
+	literal, ok := n.(*ld.Literal)
+	if !ok {
+		return "", "", false
+	}
+	if dt := datatype(literal); dt != rdf.XSDString && dt != ld.RDFLangString {
+		return "", "", false
+	}
+
+	return literal.Value, literal.Language, true
+}
+
+// langMatches returns true if the language tag matches the basic language range
+func langMatches(tag string, pattern string) bool {
+
+	if pattern == "*" {
+		return len(tag) > 0
+	}
+	tag, pattern = strings.ToLower(tag), strings.ToLower(pattern)
+
+	return tag == pattern || strings.HasPrefix(tag, pattern+"-")
+}
+
+func substr(args []ld.Node) ld.Node {
+
+	s, lang, ok := stringArg(args[0])
+	start, ok2 := toNumeric(args[1])
+	if !ok || !ok2 {
+		return nil
+	}
+
+	runes := []rune(s)
+	// POSITIONS START AT 1 AND ARE ROUNDED
+	from := math.Round(start.float())
+	to := math.Inf(1)
+	if len(args) > 2 {
+		length, ok := toNumeric(args[2])
+		if !ok {
+			return nil
+		}
+		to = from + math.Round(length.float())
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		if position := float64(i + 1); position >= from && position < to {
+			b.WriteRune(r)
+		}
+	}
+
+	return stringNode(b.String(), lang)
+}
+
+func stringTest(name string, arg1 ld.Node, arg2 ld.Node) ld.Node {
+
+	s, lang1, ok1 := stringArg(arg1)
+	sub, lang2, ok2 := stringArg(arg2)
+	// THE ARGUMENTS MUST BE COMPATIBLE: THE SECOND HAS NO LANGUAGE OR THE SAME
+	if !ok1 || !ok2 || (len(lang2) > 0 && !strings.EqualFold(lang1, lang2)) {
+		return nil
+	}
+
+	switch name {
+	case "STRSTARTS":
+		return booleanNode(strings.HasPrefix(s, sub))
+	case "STRENDS":
+		return booleanNode(strings.HasSuffix(s, sub))
+	case "CONTAINS":
+		return booleanNode(strings.Contains(s, sub))
+	}
+
+	i := strings.Index(s, sub)
+	switch {
+	case i < 0:
+		return stringNode("", "")
+	case name == "STRBEFORE":
+		if i == 0 && len(sub) < 1 {
+			return stringNode("", "")
+		}
+		return stringNode(s[:i], lang1)
+	}
+
+	return stringNode(s[i+len(sub):], lang1)
+}
+
+func encodeForURI(s string) string {
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAlpha(c) || isDigit(c) || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func concat(args []ld.Node) ld.Node {
+
+	var b strings.Builder
+	var language string
+
+	for i, arg := range args {
+		s, lang, ok := stringArg(arg)
+		if !ok {
+			return nil
+		}
+		// THE RESULT KEEPS A LANGUAGE SHARED BY ALL ARGUMENTS
+		if i == 0 {
+			language = lang
+		} else if lang != language {
+			language = ""
+		}
+		b.WriteString(s)
+	}
+
+	return stringNode(b.String(), language)
+}
+
+// regex evaluates REGEX and REPLACE
+func (e *evaluator) regex(name string, args []ld.Node) ld.Node {
+
+	s, lang, ok := stringArg(args[0])
+	pattern, _, ok2 := stringArg(args[1])
+	if !ok || !ok2 {
+		return nil
+	}
+
+	var replacement, flags string
+	i := 2
+	if name == "REPLACE" {
+		if replacement, _, ok = stringArg(args[2]); !ok {
+			return nil
+		}
+		i = 3
+	}
+	if len(args) > i {
+		if flags, _, ok = stringArg(args[i]); !ok {
+			return nil
+		}
+	}
+
+	re, err := e.compile(pattern, flags)
+	if err != nil {
+		return nil
+	}
+	if name == "REGEX" {
+		return booleanNode(re.MatchString(s))
+	}
+
+	return stringNode(re.ReplaceAllString(s, xpathReplacement(replacement)), lang)
+}
+
+// xpathReplacement converts an XPath replacement string, where $n
+// references a group and \ escapes $ and \, to a Go template
+func xpathReplacement(replacement string) string {
+
+	var b strings.Builder
+	for i := 0; i < len(replacement); i++ {
+		c := replacement[i]
+		switch {
+		case c == '\\' && i+1 < len(replacement):
+			i++
+			if replacement[i] == '$' {
+				b.WriteString("$$")
+			} else {
+				b.WriteByte(replacement[i])
+			}
+		case c == '$':
+			j := i + 1
+			for j < len(replacement) && isDigit(replacement[j]) {
+				j++
+			}
+			b.WriteString("${" + replacement[i+1:j] + "}")
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// compile compiles the XPath regular expression pattern with flags
+func (e *evaluator) compile(pattern string, flags string) (*regexp.Regexp, error) {
+
+	key := flags + "/" + pattern
+	if re, ok := e.regexps[key]; ok {
+		return re, nil
+	}
+
+	var goFlags string
+	for _, f := range flags {
+		switch f {
+		case 'i', 's', 'm':
+			goFlags += string(f)
+		case 'x':
+			// REMOVE WHITE SPACE FROM THE PATTERN
+			pattern = strings.Join(strings.Fields(pattern), "")
+		case 'q':
+			pattern = regexp.QuoteMeta(pattern)
+		default:
+			return nil, fmt.Errorf("unknown regular expression flag '%c'", f)
+		}
+	}
+	if len(goFlags) > 0 {
+		pattern = "(?" + goFlags + ")" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	e.regexps[key] = re
+
+	return re, nil
+}
+
+func rounding(name string, arg ld.Node) ld.Node {
+
+	n, ok := toNumeric(arg)
+	if !ok {
+		return nil
+	}
+	if n.kind == numericInteger {
+		if name == "ABS" && n.i < 0 {
+			n.i = -n.i
+		}
+		return n.node()
+	}
+
+	switch name {
+	case "ABS":
+		n.f = math.Abs(n.f)
+	case "ROUND":
+		// HALVES ROUND TOWARDS POSITIVE INFINITY
+		n.f = math.Floor(n.f + 0.5)
+	case "CEIL":
+		n.f = math.Ceil(n.f)
+	case "FLOOR":
+		n.f = math.Floor(n.f)
+	}
+
+	return n.node()
+}
+
+func dateTimePart(name string, arg ld.Node) ld.Node {
+
+	literal, ok := arg.(*ld.Literal)
+	if !ok || (literal.Datatype != xsdDateTime && literal.Datatype != xsdDate) {
+		return nil
+	}
+
+	var t time.Time
+	var err error
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02Z07:00", "2006-01-02"} {
+		if t, err = time.Parse(layout, literal.Value); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil
+	}
+
+	var part int
+	switch name {
+	case "YEAR":
+		part = t.Year()
+	case "MONTH":
+		part = int(t.Month())
+	case "DAY":
+		part = t.Day()
+	case "HOURS":
+		part = t.Hour()
+	case "MINUTES":
+		part = t.Minute()
+	default:
+		seconds := float64(t.Second()) + float64(t.Nanosecond())/1e9
+		return numeric{kind: numericDecimal, f: seconds}.node()
+	}
+
+	return numeric{kind: numericInteger, i: int64(part)}.node()
+}
+
+func digest(name string, arg ld.Node) ld.Node {
+
+	s, lang, ok := stringArg(arg)
+	if !ok || len(lang) > 0 {
+		return nil
+	}
+
+	var h hash.Hash
+	switch name {
+	case "MD5":
+		h = md5.New()
+	case "SHA1":
+		h = sha1.New()
+	case "SHA256":
+		h = sha256.New()
+	case "SHA384":
+		h = sha512.New384()
+	default:
+		h = sha512.New()
+	}
+	h.Write([]byte(s))
+
+	return stringNode(hex.EncodeToString(h.Sum(nil)), "")
+}
+
+// cast converts value to the datatype target
+func cast(value ld.Node, target string) ld.Node {
+
+	if ld.IsBlankNode(value) {
+		return nil
+	}
+	if ld.IsIRI(value) {
+		if target == rdf.XSDString {
+			return stringNode(value.GetValue(), "")
+		}
+		return nil
+	}
+
+	literal := value.(*ld.Literal)
+	lexical := strings.TrimSpace(literal.Value)
+	n, isNumeric := toNumeric(literal)
+
+	switch target {
+	case rdf.XSDString:
+		return stringNode(literal.Value, "")
+
+	case rdf.XSDBoolean:
+		if isNumeric {
+			v, _ := ebv(literal)
+			return booleanNode(v)
+		}
+		switch lexical {
+		case "true", "1":
+			return booleanNode(true)
+		case "false", "0":
+			return booleanNode(false)
+		}
+		return nil
+
+	case rdf.XSDInteger:
+		if isNumeric {
+			if n.kind != numericInteger {
+				if math.IsNaN(n.f) || math.IsInf(n.f, 0) {
+					return nil
+				}
+				n = numeric{kind: numericInteger, i: int64(math.Trunc(n.f))}
+			}
+			return n.node()
+		}
+		if v, ok := ebvBoolean(literal); ok {
+			return numeric{kind: numericInteger, i: v}.node()
+		}
+		return castLexical(lexical, rdf.XSDInteger)
+
+	case rdf.XSDDecimal, rdf.XSDDouble, xsdFloat:
+		kind := map[string]int{rdf.XSDDecimal: numericDecimal, rdf.XSDDouble: numericDouble, xsdFloat: numericFloat}[target]
+		if isNumeric {
+			if kind == numericDecimal && (math.IsNaN(n.f) || math.IsInf(n.f, 0)) {
+				return nil
+			}
+			return n.promote(kind).node()
+		}
+		if v, ok := ebvBoolean(literal); ok {
+			return numeric{kind: numericInteger, i: v}.promote(kind).node()
+		}
+		return castLexical(lexical, target)
+
+	case xsdDateTime:
+		if dt := datatype(literal); dt != rdf.XSDString && dt != xsdDateTime {
+			return nil
+		}
+		if _, err := time.Parse(time.RFC3339Nano, lexical); err != nil {
+			if _, err = time.Parse("2006-01-02T15:04:05.999999999", lexical); err != nil {
+				return nil
+			}
+		}
+		return ld.NewLiteral(lexical, xsdDateTime, "")
+	}
+
+	return nil
+}
+
+// ebvBoolean returns 1 or 0 for an xsd:boolean literal
+func ebvBoolean(literal *ld.Literal) (int64, bool) {
+
+	if literal.Datatype != rdf.XSDBoolean {
+		return 0, false
+	}
+	if v, _ := ebv(literal); v {
+		return 1, true
+	}
+
+	return 0, true
+}
+
+// castLexical returns the literal of the datatype target with the lexical form of
+// a string or nil if it is not a valid lexical form
+func castLexical(lexical string, target string) ld.Node {
+
+	n, ok := toNumeric(ld.NewLiteral(lexical, target, ""))
+	if !ok || (target == rdf.XSDDecimal && !decimalPattern.MatchString(lexical)) {
+		return nil
+	}
+
+	return n.node()
+}
+
+var decimalPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+func (x *aggregateExpr) eval(ctx *evalContext, b Binding) ld.Node {
+
+	var values []ld.Node
+	seen := make(map[string]bool)
+
+	for _, member := range ctx.group {
+		var value ld.Node
+		if x.arg == nil {
+			// COUNT(*) COUNTS SOLUTIONS
+			value = booleanNode(true)
+			if x.distinct {
+				value = stringNode(member.key(nil), "")
+			}
+		} else if value = x.arg.eval(&evalContext{e: ctx.e, graph: ctx.graph}, member); value == nil {
+			if x.name != "COUNT" && x.name != "SAMPLE" && x.name != "MIN" && x.name != "MAX" {
+				// AN ERROR IN AN ARGUMENT IS AN ERROR OF THE AGGREGATE
+				return nil
+			}
+			continue
+		}
+		if x.distinct {
+			if key := termKey(value); seen[key] {
+				continue
+			} else {
+				seen[key] = true
+			}
+		}
+		values = append(values, value)
+	}
+
+	switch x.name {
+	case "COUNT":
+		return numeric{kind: numericInteger, i: int64(len(values))}.node()
+
+	case "SUM", "AVG":
+		sum := numeric{kind: numericInteger}
+		for _, value := range values {
+			n, ok := toNumeric(value)
+			if !ok {
+				return nil
+			}
+			sum, _ = arithmetic("+", sum, n)
+		}
+		if x.name == "AVG" && len(values) > 0 {
+			avg, _ := arithmetic("/", sum, numeric{kind: numericInteger, i: int64(len(values))})
+			return avg.node()
+		}
+		return sum.node()
+
+	case "MIN", "MAX":
+		var result ld.Node
+		for _, value := range values {
+			c := orderCompare(value, result)
+			if result == nil || (x.name == "MIN" && c < 0) || (x.name == "MAX" && c > 0) {
+				result = value
+			}
+		}
+		return result
+
+	case "SAMPLE":
+		if len(values) > 0 {
+			return values[0]
+		}
+		return nil
+	}
+
+	// GROUP_CONCAT
+	parts := make([]string, len(values))
+	for i, value := range values {
+		s, _, ok := stringArg(value)
+		if !ok {
+			if !ld.IsLiteral(value) && !ld.IsIRI(value) {
+				return nil
+			}
+			s = value.GetValue()
+		}
+		parts[i] = s
+	}
+
+	return stringNode(strings.Join(parts, x.separator), "")
+}
@@ -0,0 +1,432 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package sparql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+// tokenKind is the kind of a lexical token of a query
+type tokenKind int
+
+const (
+	tokenEOF     tokenKind = iota
+	tokenIRI               // <iri>. text is the IRI
+	tokenPName             // prefix:local. text is the prefixed name
+	tokenBlank             // _:label. text is the label
+	tokenVar               // ?name or $name. text is the name
+	tokenString            // text is the unescaped string
+	tokenLangTag           // @lang. text is the language
+	tokenInteger           // text is the lexical form
+	tokenDecimal           // text is the lexical form
+	tokenDouble            // text is the lexical form
+	tokenWord              // keyword or function name. text as written
+	tokenPunct             // operator or punctuation
+)
+
+// token is a lexical token of a query
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // byte offset in the query
+}
+
+// is returns true if t is the punctuation or keyword s (case insensitive)
+func (t token) is(s string) bool {
+	return (t.kind == tokenPunct && t.text == s) || (t.kind == tokenWord && strings.EqualFold(t.text, s))
+}
+
+// punctuation and operators, longest first
+var punctuation = []string{"^^", "&&", "||", "!=", "<=", ">=", "{", "}", "(", ")", "[", "]", ".", ",", ";",
+	"*", "+", "-", "/", "|", "^", "!", "=", "<", ">", "?"}
+
+// lex splits query into tokens
+func lex(query string) ([]token, error) {
+
+	l := &lexer{input: query}
+	var tokens []token
+
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		if t.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func (l *lexer) errorf(pos int, format string, args ...interface{}) error {
+	return errors.InvalidValue.Newf("%s: "+format, append([]interface{}{position(l.input, pos)}, args...)...)
+}
+
+// position returns the line and column of the byte offset pos of query
+func position(query string, pos int) string {
+
+	line := 1 + strings.Count(query[:pos], "\n")
+	column := pos - strings.LastIndexByte(query[:pos], '\n')
+
+	return fmt.Sprintf("query:%d:%d", line, column)
+}
+
+// skip skips white space and comments
+func (l *lexer) skip() {
+
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+
+	l.skip()
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	rest := l.input[l.pos:]
+	c := rest[0]
+
+	switch {
+	case c == '<':
+		if end := iriRefEnd(rest); end > 0 {
+			l.pos += end + 1
+			iri, err := unescapeIRI(rest[1:end])
+			if err != nil {
+				return token{}, l.errorf(start, "%s", err)
+			}
+			return token{kind: tokenIRI, text: iri, pos: start}, nil
+		}
+
+	case c == '?' || c == '$':
+		if name := l.varName(l.pos + 1); len(name) > 0 {
+			l.pos += 1 + len(name)
+			return token{kind: tokenVar, text: name, pos: start}, nil
+		}
+
+	case c == '"' || c == '\'':
+		s, err := l.string()
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokenString, text: s, pos: start}, nil
+
+	case c == '@':
+		end := 1
+		for end < len(rest) && (isAlpha(rest[end]) || (end > 1 && (rest[end] == '-' || isDigit(rest[end])))) {
+			end++
+		}
+		if end == 1 {
+			return token{}, l.errorf(start, "expected a language tag")
+		}
+		l.pos += end
+		return token{kind: tokenLangTag, text: rest[1:end], pos: start}, nil
+
+	case c == '_' && strings.HasPrefix(rest, "_:"):
+		label := l.name(l.pos+2, true)
+		if len(label) < 1 {
+			return token{}, l.errorf(start, "expected a blank node label")
+		}
+		l.pos += 2 + len(label)
+		return token{kind: tokenBlank, text: label, pos: start}, nil
+
+	case isDigit(c) || (c == '.' && len(rest) > 1 && isDigit(rest[1])):
+		return l.number(), nil
+
+	case c == ':' || isNameStart(rest):
+		return l.word()
+	}
+
+	for _, p := range punctuation {
+		if strings.HasPrefix(rest, p) {
+			l.pos += len(p)
+			return token{kind: tokenPunct, text: p, pos: start}, nil
+		}
+	}
+
+	r, _ := utf8.DecodeRuneInString(rest)
+
+	return token{}, l.errorf(start, "unexpected character '%c'", r)
+}
+
+// iriRefEnd returns the index of the '>' closing the IRI reference s
+// starts with or -1 if s does not start with an IRI reference
+func iriRefEnd(s string) int {
+
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '>':
+			return i
+		case c <= ' ' || strings.IndexByte("<\"{}|^`", c) >= 0:
+			return -1
+		}
+	}
+
+	return -1
+}
+
+// unescapeIRI resolves the \u and \U escapes of an IRI reference
+func unescapeIRI(s string) (string, error) {
+
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		r, n, err := unicodeEscape(s[i:])
+		if err != nil {
+			return "", err
+		}
+		b.WriteRune(r)
+		i += n - 1
+	}
+
+	return b.String(), nil
+}
+
+// unicodeEscape decodes the \uXXXX or \UXXXXXXXX escape s starts with
+// returning the rune and the length of the escape
+func unicodeEscape(s string) (rune, int, error) {
+
+	n := 0
+	switch {
+	case strings.HasPrefix(s, `\u`):
+		n = 4
+	case strings.HasPrefix(s, `\U`):
+		n = 8
+	default:
+		return 0, 0, errors.InvalidValue.Newf("invalid escape sequence %.2s", s)
+	}
+	if len(s) < 2+n {
+		return 0, 0, errors.InvalidValue.Newf("invalid escape sequence %s", s)
+	}
+
+	var r rune
+	for _, c := range s[2 : 2+n] {
+		var d rune
+		switch {
+		case c >= '0' && c <= '9':
+			d = c - '0'
+		case c >= 'a' && c <= 'f':
+			d = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			d = c - 'A' + 10
+		default:
+			return 0, 0, errors.InvalidValue.Newf("invalid escape sequence %s", s[:2+n])
+		}
+		r = r*16 + d
+	}
+
+	return r, 2 + n, nil
+}
+
+// string reads a quoted string
+func (l *lexer) string() (string, error) {
+
+	start := l.pos
+	quote := l.input[l.pos : l.pos+1]
+	long := strings.HasPrefix(l.input[l.pos:], strings.Repeat(quote, 3))
+	if long {
+		quote = strings.Repeat(quote, 3)
+	}
+	l.pos += len(quote)
+
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.input) {
+			return "", l.errorf(start, "unterminated string")
+		}
+		if strings.HasPrefix(l.input[l.pos:], quote) {
+			l.pos += len(quote)
+			return b.String(), nil
+		}
+
+		c := l.input[l.pos]
+		switch {
+		case c == '\\':
+			if l.pos+1 >= len(l.input) {
+				return "", l.errorf(start, "unterminated string")
+			}
+			if e := strings.IndexByte(`tbnrf"'\`, l.input[l.pos+1]); e >= 0 {
+				b.WriteByte("\t\b\n\r\f\"'\\"[e])
+				l.pos += 2
+				continue
+			}
+			r, n, err := unicodeEscape(l.input[l.pos:])
+			if err != nil {
+				return "", l.errorf(l.pos, "%s", err)
+			}
+			b.WriteRune(r)
+			l.pos += n
+		case !long && (c == '\n' || c == '\r'):
+			return "", l.errorf(start, "unterminated string")
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+}
+
+// number reads an integer, decimal or double
+func (l *lexer) number() token {
+
+	start := l.pos
+	kind := tokenInteger
+
+	for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+		l.pos++
+	}
+	if l.pos+1 < len(l.input) && l.input[l.pos] == '.' && isDigit(l.input[l.pos+1]) {
+		kind = tokenDecimal
+		l.pos++
+		for l.pos < len(l.input) && isDigit(l.input[l.pos]) {
+			l.pos++
+		}
+	}
+	if l.pos < len(l.input) && (l.input[l.pos] == 'e' || l.input[l.pos] == 'E') {
+		end := l.pos + 1
+		if end < len(l.input) && (l.input[end] == '+' || l.input[end] == '-') {
+			end++
+		}
+		if end < len(l.input) && isDigit(l.input[end]) {
+			kind = tokenDouble
+			for l.pos = end; l.pos < len(l.input) && isDigit(l.input[l.pos]); l.pos++ {
+			}
+		}
+	}
+
+	return token{kind: kind, text: l.input[start:l.pos], pos: start}
+}
+
+// word reads a keyword, function name or prefixed name
+func (l *lexer) word() (token, error) {
+
+	start := l.pos
+	prefix := l.name(l.pos, false)
+	l.pos += len(prefix)
+
+	if l.pos >= len(l.input) || l.input[l.pos] != ':' {
+		return token{kind: tokenWord, text: prefix, pos: start}, nil
+	}
+	l.pos++
+
+	local := l.localName()
+	l.pos += len(local)
+
+	return token{kind: tokenPName, text: prefix + ":" + local, pos: start}, nil
+}
+
+// name returns the name (of a variable, prefix or blank node) starting
+// at pos. Dots are allowed within blank node labels and prefixes
+func (l *lexer) name(pos int, label bool) string {
+
+	end := pos
+	for end < len(l.input) {
+		r, n := utf8.DecodeRuneInString(l.input[end:])
+		switch {
+		case r == '_' || unicode.IsLetter(r) || (end > pos && (unicode.IsDigit(r) || r == '-' || r == 0xB7)):
+		case label && end == pos && unicode.IsDigit(r):
+		case r == '.' && end > pos && end+n < len(l.input) && l.input[end+n] != '.' && isNameRune(l.input[end+n:]):
+		default:
+			return l.input[pos:end]
+		}
+		end += n
+	}
+
+	return l.input[pos:end]
+}
+
+// varName returns the variable name starting at pos
+func (l *lexer) varName(pos int) string {
+
+	end := pos
+	for end < len(l.input) {
+		r, n := utf8.DecodeRuneInString(l.input[end:])
+		if !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || r == 0xB7) {
+			break
+		}
+		end += n
+	}
+
+	return l.input[pos:end]
+}
+
+// localName returns the local part of a prefixed name at the current
+// position. Escapes and percent encodings are kept as written
+func (l *lexer) localName() string {
+
+	end := l.pos
+	for end < len(l.input) {
+		r, n := utf8.DecodeRuneInString(l.input[end:])
+		switch {
+		case r == '_' || r == ':' || unicode.IsLetter(r) || unicode.IsDigit(r) || (end > l.pos && (r == '-' || r == 0xB7)):
+		case r == '%' && end+2 < len(l.input):
+			n = 3
+		case r == '\\' && end+1 < len(l.input):
+			n = 2
+		case r == '.' && end > l.pos && end+1 < len(l.input) && isNameRune(l.input[end+1:]):
+		default:
+			return l.input[l.pos:end]
+		}
+		end += n
+	}
+
+	return l.input[l.pos:end]
+}
+
+func isNameRune(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || r == '-' || r == ':' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isNameStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLetter(r)
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,1210 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package sparql
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/piprate/json-gold/ld"
+)
+
+// prefix of the names of the variables standing for blank nodes and
+// intermediate path nodes. It cannot start a variable name in a query
+const internalVariablePrefix = "."
+
+// Parse parses query
+func Parse(query string) (*Query, error) {
+
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, input: query, prefixes: make(map[string]string)}
+
+	q, err := p.query()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected '%s'", t.text)
+	}
+
+	return q, nil
+}
+
+type parser struct {
+	tokens   []token
+	pos      int
+	input    string
+	base     string
+	prefixes map[string]string
+	blanks   int  // COUNTER OF ANONYMOUS BLANK NODES
+	template bool // BLANK NODES ARE KEPT IN CONSTRUCT TEMPLATES
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return (&lexer{input: p.input}).errorf(t.pos, format, args...)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) token {
+
+	if p.pos+n < len(p.tokens) {
+		return p.tokens[p.pos+n]
+	}
+
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *parser) advance() token {
+
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// accept consumes the next token if it is the punctuation or keyword s
+func (p *parser) accept(s string) bool {
+
+	if p.peek().is(s) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(s string) error {
+
+	if t := p.peek(); !p.accept(s) {
+		if t.kind == tokenEOF {
+			return p.errorf(t, "expected '%s' but the query ended", s)
+		}
+		return p.errorf(t, "expected '%s' but found '%s'", s, t.text)
+	}
+
+	return nil
+}
+
+func (p *parser) query() (*Query, error) {
+
+	if err := p.prologue(); err != nil {
+		return nil, err
+	}
+
+	q := &Query{Prefixes: p.prefixes, limit: -1}
+
+	t := p.advance()
+	var err error
+
+	switch {
+	case t.is("SELECT"):
+		q.Form = Select
+		err = p.selectQuery(q)
+	case t.is("ASK"):
+		q.Form = Ask
+		err = p.whereClause(q, true)
+	case t.is("CONSTRUCT"):
+		q.Form = Construct
+		err = p.constructQuery(q)
+	case t.is("DESCRIBE"):
+		q.Form = Describe
+		err = p.describeQuery(q)
+	default:
+		return nil, p.errorf(t, "expected SELECT, ASK, CONSTRUCT or DESCRIBE")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.solutionModifier(q); err != nil {
+		return nil, err
+	}
+
+	if p.accept("VALUES") {
+		if q.values, err = p.dataBlock(); err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+func (p *parser) prologue() error {
+
+	for {
+		switch t := p.peek(); {
+		case t.is("BASE"):
+			p.advance()
+			iri := p.advance()
+			if iri.kind != tokenIRI {
+				return p.errorf(iri, "expected base IRI")
+			}
+			p.base = p.resolve(iri.text)
+		case t.is("PREFIX"):
+			p.advance()
+			name := p.advance()
+			iri := p.advance()
+			if name.kind != tokenPName || !strings.HasSuffix(name.text, ":") {
+				return p.errorf(name, "expected prefix name")
+			}
+			if iri.kind != tokenIRI {
+				return p.errorf(iri, "expected namespace IRI")
+			}
+			p.prefixes[strings.TrimSuffix(name.text, ":")] = p.resolve(iri.text)
+		default:
+			return nil
+		}
+	}
+}
+
+// resolve resolves iri against the base IRI
+func (p *parser) resolve(iri string) string {
+
+	if len(p.base) < 1 {
+		return iri
+	}
+	base, err := url.Parse(p.base)
+	if err != nil {
+		return iri
+	}
+	ref, err := url.Parse(iri)
+	if err != nil || ref.IsAbs() {
+		return iri
+	}
+
+	return base.ResolveReference(ref).String()
+}
+
+func (p *parser) selectQuery(q *Query) error {
+
+	if err := p.selectClause(q); err != nil {
+		return err
+	}
+
+	return p.whereClause(q, true)
+}
+
+func (p *parser) selectClause(q *Query) error {
+
+	if p.accept("DISTINCT") {
+		q.distinct = true
+	} else if p.accept("REDUCED") {
+		// REDUCED PERMITS BUT DOES NOT REQUIRE ELIMINATING DUPLICATES
+	}
+
+	if p.accept("*") {
+		q.star = true
+		return nil
+	}
+
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenVar:
+			p.advance()
+			q.projection = append(q.projection, projection{variable: t.text})
+		case t.is("("):
+			p.advance()
+			expr, err := p.expression()
+			if err != nil {
+				return err
+			}
+			if err = p.expect("AS"); err != nil {
+				return err
+			}
+			v := p.advance()
+			if v.kind != tokenVar {
+				return p.errorf(v, "expected variable")
+			}
+			if err = p.expect(")"); err != nil {
+				return err
+			}
+			q.projection = append(q.projection, projection{variable: v.text, expr: expr})
+		default:
+			if len(q.projection) < 1 {
+				return p.errorf(t, "expected variables or '*' to select")
+			}
+			return nil
+		}
+	}
+}
+
+// whereClause parses the dataset clauses and the WHERE clause. The
+// WHERE keyword is optional
+func (p *parser) whereClause(q *Query, required bool) error {
+
+	if t := p.peek(); t.is("FROM") {
+		return errors.NotImplemented.Newf("%s: FROM is not supported. the default graph is the grapp's default graph "+
+			"and named graphs are matched with GRAPH", position(p.input, t.pos))
+	}
+
+	hasWhere := p.accept("WHERE")
+	if !hasWhere && !p.peek().is("{") {
+		if required {
+			return p.errorf(p.peek(), "expected WHERE clause")
+		}
+		return nil
+	}
+
+	var err error
+	q.where, err = p.groupGraphPattern()
+
+	return err
+}
+
+func (p *parser) constructQuery(q *Query) error {
+
+	if p.peek().is("{") {
+		template, err := p.constructTemplate()
+		if err != nil {
+			return err
+		}
+		q.template = template
+		return p.whereClause(q, true)
+	}
+
+	// CONSTRUCT WHERE { TRIPLES }: THE PATTERN IS THE TEMPLATE
+	if err := p.expect("WHERE"); err != nil {
+		return err
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	var triples []triplePattern
+	if !p.peek().is("}") {
+		var err error
+		if triples, err = p.triplesBlock(false); err != nil {
+			return err
+		}
+	}
+	if err := p.expect("}"); err != nil {
+		return err
+	}
+	q.template = triples
+	q.where = &group{elements: []element{&basicPattern{triples: triples}}}
+
+	return nil
+}
+
+func (p *parser) constructTemplate() ([]triplePattern, error) {
+
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	p.template = true
+	defer func() { p.template = false }()
+
+	var triples []triplePattern
+	if !p.peek().is("}") {
+		var err error
+		if triples, err = p.triplesBlock(false); err != nil {
+			return nil, err
+		}
+	}
+
+	return triples, p.expect("}")
+}
+
+func (p *parser) describeQuery(q *Query) error {
+
+	if p.accept("*") {
+		q.star = true
+	} else {
+		for {
+			t := p.peek()
+			if t.kind != tokenVar && t.kind != tokenIRI && t.kind != tokenPName {
+				break
+			}
+			described, err := p.varOrTerm()
+			if err != nil {
+				return err
+			}
+			q.describe = append(q.describe, described)
+		}
+		if len(q.describe) < 1 {
+			return p.errorf(p.peek(), "expected variables or IRIs to describe")
+		}
+	}
+
+	return p.whereClause(q, false)
+}
+
+func (p *parser) solutionModifier(q *Query) error {
+
+	if p.accept("GROUP") {
+		if err := p.expect("BY"); err != nil {
+			return err
+		}
+		q.grouped = true
+		for {
+			t := p.peek()
+			switch {
+			case t.kind == tokenVar:
+				p.advance()
+				q.groupBy = append(q.groupBy, projection{variable: t.text, expr: &variableExpr{name: t.text}})
+			case t.is("("):
+				p.advance()
+				expr, err := p.expression()
+				if err != nil {
+					return err
+				}
+				condition := projection{expr: expr}
+				if p.accept("AS") {
+					v := p.advance()
+					if v.kind != tokenVar {
+						return p.errorf(v, "expected variable")
+					}
+					condition.variable = v.text
+				}
+				if err = p.expect(")"); err != nil {
+					return err
+				}
+				q.groupBy = append(q.groupBy, condition)
+			case t.kind == tokenWord || t.kind == tokenIRI || t.kind == tokenPName:
+				if !p.isCall() {
+					goto having
+				}
+				expr, err := p.primaryExpression()
+				if err != nil {
+					return err
+				}
+				q.groupBy = append(q.groupBy, projection{expr: expr})
+			default:
+				goto having
+			}
+		}
+	}
+
+having:
+	if len(q.groupBy) < 1 && q.grouped {
+		return p.errorf(p.peek(), "expected GROUP BY condition")
+	}
+
+	if p.accept("HAVING") {
+		q.grouped = true
+		for p.peek().is("(") || p.isCall() {
+			expr, err := p.constraint()
+			if err != nil {
+				return err
+			}
+			q.having = append(q.having, expr)
+		}
+		if len(q.having) < 1 {
+			return p.errorf(p.peek(), "expected HAVING condition")
+		}
+	}
+
+	if p.accept("ORDER") {
+		if err := p.expect("BY"); err != nil {
+			return err
+		}
+		for {
+			t := p.peek()
+			var condition orderCondition
+			switch {
+			case t.is("ASC") || t.is("DESC"):
+				p.advance()
+				if !p.peek().is("(") {
+					return p.errorf(p.peek(), "expected '(' after %s", t.text)
+				}
+				expr, err := p.constraint()
+				if err != nil {
+					return err
+				}
+				condition = orderCondition{expr: expr, descending: t.is("DESC")}
+			case t.kind == tokenVar:
+				p.advance()
+				condition = orderCondition{expr: &variableExpr{name: t.text}}
+			case t.is("(") || p.isCall():
+				expr, err := p.constraint()
+				if err != nil {
+					return err
+				}
+				condition = orderCondition{expr: expr}
+			default:
+				if len(q.orderBy) < 1 {
+					return p.errorf(t, "expected ORDER BY condition")
+				}
+				goto limit
+			}
+			q.orderBy = append(q.orderBy, condition)
+		}
+	}
+
+limit:
+	for i := 0; i < 2; i++ {
+		t := p.peek()
+		if !t.is("LIMIT") && !t.is("OFFSET") {
+			break
+		}
+		p.advance()
+		n := p.advance()
+		value, err := strconv.Atoi(n.text)
+		if n.kind != tokenInteger || err != nil {
+			return p.errorf(n, "expected integer after %s", t.text)
+		}
+		if t.is("LIMIT") {
+			q.limit = value
+		} else {
+			q.offset = value
+		}
+	}
+
+	if q.aggregatesUsed() {
+		q.grouped = true
+	}
+
+	return p.checkProjection(q)
+}
+
+// checkProjection verifies that grouped queries only project grouped
+// variables and aggregates and that projected variables are unique
+func (p *parser) checkProjection(q *Query) error {
+
+	seen := make(map[string]bool)
+	for _, proj := range q.projection {
+		if seen[proj.variable] {
+			return errors.InvalidValue.Newf("variable ?%s is projected more than once", proj.variable)
+		}
+		seen[proj.variable] = true
+	}
+
+	if !q.grouped {
+		return nil
+	}
+	if q.star {
+		return errors.InvalidValue.New("SELECT * cannot be used with GROUP BY or aggregates")
+	}
+
+	grouped := make(map[string]bool)
+	for _, condition := range q.groupBy {
+		if v, ok := condition.expr.(*variableExpr); ok && len(condition.variable) < 1 {
+			grouped[v.name] = true
+		}
+		if len(condition.variable) > 0 {
+			grouped[condition.variable] = true
+		}
+	}
+	for _, proj := range q.projection {
+		if proj.expr == nil && !grouped[proj.variable] {
+			return errors.InvalidValue.Newf("variable ?%s is neither grouped nor aggregated", proj.variable)
+		}
+		if proj.expr != nil {
+			if v := ungroupedVariable(proj.expr, grouped); len(v) > 0 {
+				return errors.InvalidValue.Newf("variable ?%s is neither grouped nor aggregated", v)
+			}
+		}
+		grouped[proj.variable] = true
+	}
+
+	return nil
+}
+
+func (p *parser) groupGraphPattern() (*group, error) {
+
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	if p.peek().is("SELECT") {
+		sub, err := p.subSelect()
+		if err != nil {
+			return nil, err
+		}
+		return &group{elements: []element{sub}}, p.expect("}")
+	}
+
+	g := &group{}
+
+	for {
+		t := p.peek()
+		switch {
+		case t.is("}"):
+			p.advance()
+			return g, nil
+
+		case t.kind == tokenEOF:
+			return nil, p.errorf(t, "expected '}' but the query ended")
+
+		case t.is("."):
+			p.advance()
+
+		case t.is("{"):
+			first, err := p.groupGraphPattern()
+			if err != nil {
+				return nil, err
+			}
+			groups := []*group{first}
+			for p.accept("UNION") {
+				next, err := p.groupGraphPattern()
+				if err != nil {
+					return nil, err
+				}
+				groups = append(groups, next)
+			}
+			if len(groups) == 1 {
+				g.elements = append(g.elements, first)
+			} else {
+				g.elements = append(g.elements, &unionPattern{groups: groups})
+			}
+
+		case t.is("OPTIONAL"):
+			p.advance()
+			optional, err := p.groupGraphPattern()
+			if err != nil {
+				return nil, err
+			}
+			g.elements = append(g.elements, &optionalPattern{group: optional})
+
+		case t.is("MINUS"):
+			p.advance()
+			minus, err := p.groupGraphPattern()
+			if err != nil {
+				return nil, err
+			}
+			g.elements = append(g.elements, &minusPattern{group: minus})
+
+		case t.is("GRAPH"):
+			p.advance()
+			name, err := p.varOrTerm()
+			if err != nil {
+				return nil, err
+			}
+			if !name.isVariable() && !ld.IsIRI(name.node) {
+				return nil, p.errorf(t, "expected graph IRI or variable")
+			}
+			inner, err := p.groupGraphPattern()
+			if err != nil {
+				return nil, err
+			}
+			g.elements = append(g.elements, &graphPattern{name: name, group: inner})
+
+		case t.is("FILTER"):
+			p.advance()
+			expr, err := p.constraint()
+			if err != nil {
+				return nil, err
+			}
+			g.filters = append(g.filters, expr)
+
+		case t.is("BIND"):
+			p.advance()
+			if err := p.expect("("); err != nil {
+				return nil, err
+			}
+			expr, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect("AS"); err != nil {
+				return nil, err
+			}
+			v := p.advance()
+			if v.kind != tokenVar {
+				return nil, p.errorf(v, "expected variable")
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+			g.elements = append(g.elements, &bindPattern{expr: expr, variable: v.text})
+
+		case t.is("VALUES"):
+			p.advance()
+			data, err := p.dataBlock()
+			if err != nil {
+				return nil, err
+			}
+			g.elements = append(g.elements, data)
+
+		case t.is("SERVICE"):
+			return nil, errors.NotImplemented.Newf("%s: SERVICE is not supported",
+				position(p.input, t.pos))
+
+		default:
+			triples, err := p.triplesBlock(true)
+			if err != nil {
+				return nil, err
+			}
+			// ADJACENT TRIPLES BLOCKS FORM ONE BASIC GRAPH PATTERN
+			if n := len(g.elements); n > 0 {
+				if bgp, ok := g.elements[n-1].(*basicPattern); ok {
+					bgp.triples = append(bgp.triples, triples...)
+					continue
+				}
+			}
+			g.elements = append(g.elements, &basicPattern{triples: triples})
+		}
+	}
+}
+
+func (p *parser) subSelect() (*subQuery, error) {
+
+	p.advance()
+	q := &Query{Form: Select, Prefixes: p.prefixes, limit: -1}
+
+	if err := p.selectClause(q); err != nil {
+		return nil, err
+	}
+	if err := p.whereClause(q, true); err != nil {
+		return nil, err
+	}
+	if err := p.solutionModifier(q); err != nil {
+		return nil, err
+	}
+	if p.accept("VALUES") {
+		var err error
+		if q.values, err = p.dataBlock(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &subQuery{query: q}, nil
+}
+
+// dataBlock parses the data of a VALUES clause
+func (p *parser) dataBlock() (*values, error) {
+
+	data := &values{}
+
+	if t := p.peek(); t.kind == tokenVar {
+		p.advance()
+		data.variables = []string{t.text}
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		for !p.accept("}") {
+			value, err := p.dataValue()
+			if err != nil {
+				return nil, err
+			}
+			data.rows = append(data.rows, []ld.Node{value})
+		}
+		return data, nil
+	}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for !p.accept(")") {
+		t := p.advance()
+		if t.kind != tokenVar {
+			return nil, p.errorf(t, "expected variable")
+		}
+		data.variables = append(data.variables, t.text)
+	}
+
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		var row []ld.Node
+		for !p.accept(")") {
+			value, err := p.dataValue()
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, value)
+		}
+		if len(row) != len(data.variables) {
+			return nil, p.errorf(p.peek(), "expected %d values in VALUES row", len(data.variables))
+		}
+		data.rows = append(data.rows, row)
+	}
+
+	return data, nil
+}
+
+// dataValue parses a value of a VALUES block. UNDEF is nil
+func (p *parser) dataValue() (ld.Node, error) {
+
+	if p.accept("UNDEF") {
+		return nil, nil
+	}
+
+	t := p.peek()
+	value, err := p.varOrTerm()
+	if err != nil {
+		return nil, err
+	}
+	if value.isVariable() {
+		return nil, p.errorf(t, "expected a constant value")
+	}
+
+	return value.node, nil
+}
+
+// triplesBlock parses triples separated by '.'. paths allows property
+// paths in the predicate position
+func (p *parser) triplesBlock(paths bool) ([]triplePattern, error) {
+
+	var triples []triplePattern
+
+	for {
+		t := p.peek()
+		if !p.startsTerm(t) {
+			if len(triples) < 1 {
+				return nil, p.errorf(t, "unexpected '%s'", t.text)
+			}
+			return triples, nil
+		}
+
+		var subject term
+		var err error
+		var propertyListRequired = true
+
+		switch {
+		case t.is("["):
+			if subject, triples, err = p.blankNodePropertyList(triples, paths); err != nil {
+				return nil, err
+			}
+			propertyListRequired = false
+		case t.is("("):
+			if subject, triples, err = p.collection(triples, paths); err != nil {
+				return nil, err
+			}
+			propertyListRequired = false
+		default:
+			if subject, err = p.varOrTerm(); err != nil {
+				return nil, err
+			}
+		}
+
+		if propertyListRequired || p.startsVerb(p.peek()) {
+			if triples, err = p.propertyList(subject, triples, paths); err != nil {
+				return nil, err
+			}
+		}
+
+		if !p.accept(".") {
+			return triples, nil
+		}
+	}
+}
+
+// startsTerm returns true if t can start a triple
+func (p *parser) startsTerm(t token) bool {
+
+	switch t.kind {
+	case tokenVar, tokenIRI, tokenPName, tokenBlank, tokenString, tokenInteger, tokenDecimal, tokenDouble:
+		return true
+	case tokenWord:
+		return t.is("true") || t.is("false")
+	}
+
+	return t.is("[") || t.is("(") || t.is("-") || t.is("+")
+}
+
+// startsVerb returns true if t can start a predicate
+func (p *parser) startsVerb(t token) bool {
+	return t.kind == tokenVar || t.kind == tokenIRI || t.kind == tokenPName || t.is("a") ||
+		t.is("^") || t.is("!") || t.is("(")
+}
+
+// propertyList parses the predicate-object list of subject
+func (p *parser) propertyList(subject term, triples []triplePattern, paths bool) ([]triplePattern, error) {
+
+	for {
+		t := p.peek()
+		if !p.startsVerb(t) {
+			return nil, p.errorf(t, "expected predicate")
+		}
+
+		var predicate term
+		var predicatePath path
+
+		if t.kind == tokenVar {
+			p.advance()
+			predicate = term{variable: t.text}
+		} else if paths {
+			parsed, err := p.path()
+			if err != nil {
+				return nil, err
+			}
+			if link, ok := parsed.(*linkPath); ok {
+				predicate = term{node: link.iri}
+			} else {
+				predicatePath = parsed
+			}
+		} else {
+			p.advance()
+			iri, err := p.iri(t)
+			if err != nil {
+				return nil, err
+			}
+			predicate = term{node: iri}
+		}
+
+		for {
+			var object term
+			var err error
+			switch o := p.peek(); {
+			case o.is("["):
+				object, triples, err = p.blankNodePropertyList(triples, paths)
+			case o.is("("):
+				object, triples, err = p.collection(triples, paths)
+			default:
+				object, err = p.varOrTerm()
+			}
+			if err != nil {
+				return nil, err
+			}
+			triples = append(triples, triplePattern{subject: subject, predicate: predicate, path: predicatePath, object: object})
+
+			if !p.accept(",") {
+				break
+			}
+		}
+
+		if !p.accept(";") {
+			return triples, nil
+		}
+		for p.accept(";") {
+		}
+		if !p.startsVerb(p.peek()) {
+			return triples, nil
+		}
+	}
+}
+
+// blankNode returns a new anonymous blank node: a blank node in a
+// template or an internal variable in a pattern
+func (p *parser) blankNode(label string) term {
+
+	if len(label) < 1 {
+		p.blanks++
+		label = fmt.Sprintf("anon%d", p.blanks)
+	} else {
+		label = "b_" + label
+	}
+
+	if p.template {
+		return term{node: ld.NewBlankNode("_:" + label)}
+	}
+
+	return term{variable: internalVariablePrefix + label}
+}
+
+func (p *parser) blankNodePropertyList(triples []triplePattern, paths bool) (term, []triplePattern, error) {
+
+	p.advance()
+	node := p.blankNode("")
+
+	if p.accept("]") {
+		return node, triples, nil
+	}
+
+	triples, err := p.propertyList(node, triples, paths)
+	if err != nil {
+		return term{}, nil, err
+	}
+
+	return node, triples, p.expect("]")
+}
+
+// collection parses an RDF collection adding its rdf:first and
+// rdf:rest triples
+func (p *parser) collection(triples []triplePattern, paths bool) (term, []triplePattern, error) {
+
+	p.advance()
+
+	var items []term
+	for !p.accept(")") {
+		var item term
+		var err error
+		switch t := p.peek(); {
+		case t.kind == tokenEOF:
+			return term{}, nil, p.errorf(t, "expected ')' but the query ended")
+		case t.is("["):
+			item, triples, err = p.blankNodePropertyList(triples, paths)
+		case t.is("("):
+			item, triples, err = p.collection(triples, paths)
+		default:
+			item, err = p.varOrTerm()
+		}
+		if err != nil {
+			return term{}, nil, err
+		}
+		items = append(items, item)
+	}
+
+	head := term{node: ld.NewIRI(rdf.RDFNil)}
+	for i := len(items) - 1; i >= 0; i-- {
+		node := p.blankNode("")
+		triples = append(triples,
+			triplePattern{subject: node, predicate: term{node: ld.NewIRI(rdf.RDFFirst)}, object: items[i]},
+			triplePattern{subject: node, predicate: term{node: ld.NewIRI(rdf.RDFRest)}, object: head})
+		head = node
+	}
+
+	return head, triples, nil
+}
+
+// varOrTerm parses a variable, IRI, blank node or literal
+func (p *parser) varOrTerm() (term, error) {
+
+	t := p.advance()
+
+	switch t.kind {
+	case tokenVar:
+		return term{variable: t.text}, nil
+	case tokenIRI, tokenPName:
+		iri, err := p.iri(t)
+		return term{node: iri}, err
+	case tokenBlank:
+		return p.blankNode(t.text), nil
+	case tokenString:
+		literal, err := p.literal(t)
+		return term{node: literal}, err
+	case tokenInteger, tokenDecimal, tokenDouble:
+		return term{node: numericLiteral(t, "")}, nil
+	case tokenPunct:
+		if (t.text == "-" || t.text == "+") && isNumberToken(p.peek()) {
+			return term{node: numericLiteral(p.advance(), t.text)}, nil
+		}
+		if t.text == "[" && p.accept("]") {
+			return p.blankNode(""), nil
+		}
+	case tokenWord:
+		if t.is("true") || t.is("false") {
+			return term{node: ld.NewLiteral(strings.ToLower(t.text), rdf.XSDBoolean, "")}, nil
+		}
+	case tokenEOF:
+		return term{}, p.errorf(t, "expected a term but the query ended")
+	}
+
+	return term{}, p.errorf(t, "expected a term but found '%s'", t.text)
+}
+
+func isNumberToken(t token) bool {
+	return t.kind == tokenInteger || t.kind == tokenDecimal || t.kind == tokenDouble
+}
+
+// numericLiteral returns the literal of a number token with sign
+func numericLiteral(t token, sign string) ld.Node {
+
+	if sign == "+" {
+		sign = ""
+	}
+
+	switch t.kind {
+	case tokenDecimal:
+		return ld.NewLiteral(sign+t.text, rdf.XSDDecimal, "")
+	case tokenDouble:
+		return ld.NewLiteral(sign+t.text, rdf.XSDDouble, "")
+	}
+
+	return ld.NewLiteral(sign+t.text, rdf.XSDInteger, "")
+}
+
+// literal parses the rest of a literal whose string is t
+func (p *parser) literal(t token) (ld.Node, error) {
+
+	if l := p.peek(); l.kind == tokenLangTag {
+		p.advance()
+		return ld.NewLiteral(t.text, ld.RDFLangString, strings.ToLower(l.text)), nil
+	}
+
+	if p.accept("^^") {
+		dt := p.advance()
+		if dt.kind != tokenIRI && dt.kind != tokenPName {
+			return nil, p.errorf(dt, "expected datatype IRI")
+		}
+		iri, err := p.iri(dt)
+		if err != nil {
+			return nil, err
+		}
+		return ld.NewLiteral(t.text, iri.GetValue(), ""), nil
+	}
+
+	return ld.NewLiteral(t.text, rdf.XSDString, ""), nil
+}
+
+// iri returns the IRI of an IRI reference or prefixed name token.
+// 'a' is rdf:type
+func (p *parser) iri(t token) (ld.Node, error) {
+
+	switch {
+	case t.kind == tokenIRI:
+		return ld.NewIRI(p.resolve(t.text)), nil
+	case t.is("a"):
+		return ld.NewIRI(rdf.RDFType), nil
+	case t.kind != tokenPName:
+		return nil, p.errorf(t, "expected IRI but found '%s'", t.text)
+	}
+
+	i := strings.IndexByte(t.text, ':')
+	ns, ok := p.prefixes[t.text[:i]]
+	if !ok {
+		return nil, p.errorf(t, "undeclared prefix '%s'", t.text[:i])
+	}
+
+	local := t.text[i+1:]
+	if strings.ContainsAny(local, `\`) {
+		var b strings.Builder
+		for j := 0; j < len(local); j++ {
+			if local[j] == '\\' && j+1 < len(local) {
+				j++
+			}
+			b.WriteByte(local[j])
+		}
+		local = b.String()
+	}
+
+	return ld.NewIRI(ns + local), nil
+}
+
+// path parses a property path
+func (p *parser) path() (path, error) {
+
+	var alternatives []path
+	for {
+		var sequence []path
+		for {
+			element, err := p.pathElementOrInverse()
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, element)
+			if !p.accept("/") {
+				break
+			}
+		}
+		if len(sequence) == 1 {
+			alternatives = append(alternatives, sequence[0])
+		} else {
+			alternatives = append(alternatives, &sequencePath{paths: sequence})
+		}
+		if !p.accept("|") {
+			break
+		}
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+
+	return &alternativePath{paths: alternatives}, nil
+}
+
+func (p *parser) pathElementOrInverse() (path, error) {
+
+	if p.accept("^") {
+		element, err := p.pathElement()
+		if err != nil {
+			return nil, err
+		}
+		return &inversePath{path: element}, nil
+	}
+
+	return p.pathElement()
+}
+
+func (p *parser) pathElement() (path, error) {
+
+	var primary path
+
+	switch t := p.advance(); {
+	case t.kind == tokenIRI || t.kind == tokenPName || t.is("a"):
+		iri, err := p.iri(t)
+		if err != nil {
+			return nil, err
+		}
+		primary = &linkPath{iri: iri}
+	case t.is("!"):
+		negated, err := p.negatedPropertySet()
+		if err != nil {
+			return nil, err
+		}
+		primary = negated
+	case t.is("("):
+		inner, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		primary = inner
+	default:
+		return nil, p.errorf(t, "expected property path but found '%s'", t.text)
+	}
+
+	switch {
+	case p.accept("*"):
+		return &repeatPath{path: primary, min: 0, max: -1}, nil
+	case p.accept("+"):
+		return &repeatPath{path: primary, min: 1, max: -1}, nil
+	case p.accept("?"):
+		return &repeatPath{path: primary, min: 0, max: 1}, nil
+	}
+
+	return primary, nil
+}
+
+func (p *parser) negatedPropertySet() (path, error) {
+
+	negated := &negatedPath{}
+
+	one := func() error {
+		inverse := p.accept("^")
+		t := p.advance()
+		if t.kind != tokenIRI && t.kind != tokenPName && !t.is("a") {
+			return p.errorf(t, "expected IRI in negated property set")
+		}
+		iri, err := p.iri(t)
+		if err != nil {
+			return err
+		}
+		if inverse {
+			negated.inverse = append(negated.inverse, iri.GetValue())
+		} else {
+			negated.forward = append(negated.forward, iri.GetValue())
+		}
+		return nil
+	}
+
+	if !p.accept("(") {
+		return negated, one()
+	}
+	if p.accept(")") {
+		return negated, nil
+	}
+	for {
+		if err := one(); err != nil {
+			return nil, err
+		}
+		if !p.accept("|") {
+			break
+		}
+	}
+
+	return negated, p.expect(")")
+}
@@ -0,0 +1,291 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package sparql
+
+import (
+	"io"
+
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	"github.com/piprate/json-gold/ld"
+)
+
+// path is a property path
+type path interface{}
+
+type (
+	// IRI
+	linkPath struct {
+		iri ld.Node
+	}
+	// ^path
+	inversePath struct {
+		path path
+	}
+	// path/path
+	sequencePath struct {
+		paths []path
+	}
+	// path|path
+	alternativePath struct {
+		paths []path
+	}
+	// path* path+ path?. max IS -1 IF UNBOUNDED
+	repeatPath struct {
+		path     path
+		min, max int
+	}
+	// !(iri|^iri)
+	negatedPath struct {
+		forward []string
+		inverse []string
+	}
+)
+
+// reverse returns the inverse of p
+func reverse(p path) path {
+
+	switch x := p.(type) {
+	case *linkPath:
+		return &inversePath{path: x}
+	case *inversePath:
+		return x.path
+	case *sequencePath:
+		reversed := make([]path, len(x.paths))
+		for i, step := range x.paths {
+			reversed[len(x.paths)-1-i] = reverse(step)
+		}
+		return &sequencePath{paths: reversed}
+	case *alternativePath:
+		reversed := make([]path, len(x.paths))
+		for i, alternative := range x.paths {
+			reversed[i] = reverse(alternative)
+		}
+		return &alternativePath{paths: reversed}
+	case *repeatPath:
+		return &repeatPath{path: reverse(x.path), min: x.min, max: x.max}
+	case *negatedPath:
+		return &negatedPath{forward: x.inverse, inverse: x.forward}
+	}
+
+	return p
+}
+
+// pathPairs returns the subject and object pairs connected by p in
+// graph. subject and object are nil if unbound
+func (e *evaluator) pathPairs(p path, graph string, subject ld.Node, object ld.Node) ([][2]ld.Node, error) {
+
+	var pairs [][2]ld.Node
+
+	switch {
+	case subject != nil:
+		targets, err := e.pathTargets(p, graph, subject)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			if object == nil || sameTerm(object, target) {
+				pairs = append(pairs, [2]ld.Node{subject, target})
+			}
+		}
+
+	case object != nil:
+		sources, err := e.pathTargets(reverse(p), graph, object)
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range sources {
+			pairs = append(pairs, [2]ld.Node{source, object})
+		}
+
+	default:
+		// BOTH ENDS UNBOUND: START FROM EVERY NODE OF THE GRAPH
+		nodes, err := e.graphNodes(graph)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			targets, err := e.pathTargets(p, graph, node)
+			if err != nil {
+				return nil, err
+			}
+			for _, target := range targets {
+				pairs = append(pairs, [2]ld.Node{node, target})
+			}
+		}
+	}
+
+	return pairs, nil
+}
+
+// graphNodes returns the distinct subjects and objects of graph
+func (e *evaluator) graphNodes(graph string) ([]ld.Node, error) {
+
+	var nodes []ld.Node
+	seen := make(map[string]bool)
+
+	err := e.match(quadstore.Pattern{Graph: graph}, func(quad *ld.Quad) {
+		for _, n := range []ld.Node{quad.Subject, quad.Object} {
+			if key := termKey(n); !seen[key] {
+				seen[key] = true
+				nodes = append(nodes, n)
+			}
+		}
+	})
+
+	return nodes, err
+}
+
+// match calls fn with the quads matching pattern
+func (e *evaluator) match(pattern quadstore.Pattern, fn func(quad *ld.Quad)) error {
+
+	it := e.store.Match(pattern)
+	defer it.Close()
+
+	for {
+		quad, err := it.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fn(quad)
+	}
+}
+
+// pathTargets returns the nodes reached from node by p in graph.
+// Repeated paths return distinct nodes
+func (e *evaluator) pathTargets(p path, graph string, node ld.Node) ([]ld.Node, error) {
+
+	var targets []ld.Node
+
+	switch x := p.(type) {
+	case *linkPath:
+		err := e.match(quadstore.Pattern{Subject: node, Predicate: x.iri, Graph: graph}, func(quad *ld.Quad) {
+			targets = append(targets, quad.Object)
+		})
+		return targets, err
+
+	case *inversePath:
+		if link, ok := x.path.(*linkPath); ok {
+			err := e.match(quadstore.Pattern{Predicate: link.iri, Object: node, Graph: graph}, func(quad *ld.Quad) {
+				targets = append(targets, quad.Subject)
+			})
+			return targets, err
+		}
+		return e.pathTargets(reverse(x.path), graph, node)
+
+	case *sequencePath:
+		targets = []ld.Node{node}
+		for _, step := range x.paths {
+			var next []ld.Node
+			for _, n := range targets {
+				reached, err := e.pathTargets(step, graph, n)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, reached...)
+			}
+			targets = next
+		}
+		return targets, nil
+
+	case *alternativePath:
+		for _, alternative := range x.paths {
+			reached, err := e.pathTargets(alternative, graph, node)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, reached...)
+		}
+		return targets, nil
+
+	case *repeatPath:
+		return e.repeatTargets(x, graph, node)
+
+	case *negatedPath:
+		excluded := func(iris []string, predicate ld.Node) bool {
+			for _, iri := range iris {
+				if iri == predicate.GetValue() {
+					return true
+				}
+			}
+			return false
+		}
+		// !(^p) ONLY TRAVERSES BACKWARDS
+		if len(x.forward) > 0 || len(x.inverse) < 1 {
+			err := e.match(quadstore.Pattern{Subject: node, Graph: graph}, func(quad *ld.Quad) {
+				if !excluded(x.forward, quad.Predicate) {
+					targets = append(targets, quad.Object)
+				}
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(x.inverse) > 0 {
+			err := e.match(quadstore.Pattern{Object: node, Graph: graph}, func(quad *ld.Quad) {
+				if !excluded(x.inverse, quad.Predicate) {
+					targets = append(targets, quad.Subject)
+				}
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return targets, nil
+	}
+
+	return nil, nil
+}
+
+// repeatTargets returns the distinct nodes reached from node by at
+// least min and at most max repetitions of the path of x
+func (e *evaluator) repeatTargets(x *repeatPath, graph string, node ld.Node) ([]ld.Node, error) {
+
+	var targets []ld.Node
+	reached := make(map[string]bool)
+
+	if x.min == 0 {
+		reached[termKey(node)] = true
+		targets = append(targets, node)
+	}
+
+	visited := map[string]bool{termKey(node): true}
+	frontier := []ld.Node{node}
+
+	for depth := 1; len(frontier) > 0 && (x.max < 0 || depth <= x.max); depth++ {
+		var next []ld.Node
+		for _, n := range frontier {
+			steps, err := e.pathTargets(x.path, graph, n)
+			if err != nil {
+				return nil, err
+			}
+			for _, step := range steps {
+				key := termKey(step)
+				if !reached[key] {
+					reached[key] = true
+					targets = append(targets, step)
+				}
+				if !visited[key] {
+					visited[key] = true
+					next = append(next, step)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	return targets, nil
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package sparql evaluates SPARQL 1.1 queries (https://www.w3.org/TR/sparql11-query/)
+// over a quad store. SELECT, ASK, CONSTRUCT and DESCRIBE queries are
+// supported with basic graph patterns, property paths, FILTER,
+// OPTIONAL, UNION, MINUS, GRAPH, BIND, VALUES, subqueries, grouping
+// and aggregates. The default graph of the query is the default graph
+// of the store and its named graphs are the store's named graphs
+package sparql
+
+import (
+	"github.com/piprate/json-gold/ld"
+)
+
+// Form is the form of a query
+type Form string
+
+const (
+	Select    Form = "SELECT"
+	Ask       Form = "ASK"
+	Construct Form = "CONSTRUCT"
+	Describe  Form = "DESCRIBE"
+)
+
+// Query is a parsed query
+type Query struct {
+	Form     Form
+	Prefixes map[string]string // prefix -> namespace IRI declared by the query
+
+	distinct   bool
+	star       bool // SELECT * OR DESCRIBE *
+	projection []projection
+	template   []triplePattern // CONSTRUCT TEMPLATE
+	describe   []term
+	where      *group
+	groupBy    []projection
+	having     []expression
+	orderBy    []orderCondition
+	limit      int // -1 IF UNLIMITED
+	offset     int
+	values     *values // TRAILING VALUES CLAUSE
+	grouped    bool    // GROUP BY OR AGGREGATES PRESENT
+}
+
+// projection is a projected (or grouped by) variable optionally bound
+// to an expression
+type projection struct {
+	variable string
+	expr     expression
+}
+
+// orderCondition is an ORDER BY condition
+type orderCondition struct {
+	expr       expression
+	descending bool
+}
+
+// term is a constant or a variable of a pattern
+type term struct {
+	node     ld.Node
+	variable string
+}
+
+func (t term) isVariable() bool {
+	return t.node == nil
+}
+
+// triplePattern is a triple pattern. path is set for property paths
+// other than a single IRI or variable predicate
+type triplePattern struct {
+	subject   term
+	predicate term
+	path      path
+	object    term
+}
+
+// group is a group graph pattern
+type group struct {
+	elements []element
+	filters  []expression
+}
+
+// element is an element of a group graph pattern
+type element interface{}
+
+type (
+	basicPattern struct {
+		triples []triplePattern
+	}
+	optionalPattern struct {
+		group *group
+	}
+	unionPattern struct {
+		groups []*group
+	}
+	minusPattern struct {
+		group *group
+	}
+	graphPattern struct {
+		name  term
+		group *group
+	}
+	bindPattern struct {
+		expr     expression
+		variable string
+	}
+	subQuery struct {
+		query *Query
+	}
+	values struct {
+		variables []string
+		rows      [][]ld.Node // NIL FOR UNDEF
+	}
+)
@@ -0,0 +1,280 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+package sparql
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	"github.com/datacequia/go-dogg3rz/rdf"
+	"github.com/piprate/json-gold/ld"
+)
+
+// Format is a serialization of query results
+type Format string
+
+const (
+	Table Format = "table"
+	JSON  Format = "json"
+	CSV   Format = "csv"
+	TSV   Format = "tsv"
+)
+
+// Formats returns the supported result serializations
+func Formats() []Format {
+	return []Format{Table, JSON, CSV, TSV}
+}
+
+// ParseFormat returns the result serialization named s
+func ParseFormat(s string) (Format, error) {
+
+	for _, f := range Formats() {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+
+	return "", errors.InvalidValue.Newf("%s: unknown result format. expected one of %v", s, Formats())
+}
+
+// Write serializes r to w in format f. Solutions are written as a
+// table, SPARQL JSON results, SPARQL CSV or SPARQL TSV results. Graphs
+// are written as Turtle (table), JSON-LD (json) or as rows of subject,
+// predicate and object (csv and tsv)
+func (r *Result) Write(w io.Writer, f Format) error {
+
+	if _, err := ParseFormat(string(f)); err != nil {
+		return err
+	}
+
+	switch r.Form {
+	case Ask:
+		if f == JSON {
+			return writeJSON(w, map[string]interface{}{"head": map[string]interface{}{}, "boolean": r.Boolean})
+		}
+		_, err := io.WriteString(w, strconv.FormatBool(r.Boolean)+"\n")
+		return err
+
+	case Construct, Describe:
+		return r.writeGraph(w, f)
+	}
+
+	switch f {
+	case JSON:
+		return r.writeJSON(w)
+	case CSV:
+		return r.writeCSV(w)
+	case TSV:
+		return r.writeTSV(w)
+	}
+
+	return r.writeTable(w)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// writeJSON writes the solutions as SPARQL 1.1 query results JSON
+func (r *Result) writeJSON(w io.Writer) error {
+
+	bindings := make([]map[string]interface{}, len(r.Solutions))
+	for i, solution := range r.Solutions {
+		bindings[i] = make(map[string]interface{})
+		for _, v := range r.Vars {
+			if value, ok := solution[v]; ok {
+				bindings[i][v] = jsonTerm(value)
+			}
+		}
+	}
+
+	return writeJSON(w, map[string]interface{}{
+		"head":    map[string]interface{}{"vars": r.Vars},
+		"results": map[string]interface{}{"bindings": bindings},
+	})
+}
+
+func jsonTerm(n ld.Node) map[string]string {
+
+	switch x := n.(type) {
+	case *ld.BlankNode:
+		return map[string]string{"type": "bnode", "value": strings.TrimPrefix(x.Attribute, "_:")}
+	case *ld.Literal:
+		term := map[string]string{"type": "literal", "value": x.Value}
+		if len(x.Language) > 0 {
+			term["xml:lang"] = x.Language
+		} else if len(x.Datatype) > 0 && x.Datatype != rdf.XSDString {
+			term["datatype"] = x.Datatype
+		}
+		return term
+	}
+
+	return map[string]string{"type": "uri", "value": n.GetValue()}
+}
+
+// writeCSV writes the solutions as SPARQL 1.1 query results CSV: the
+// lexical forms of literals, IRIs and blank node labels
+func (r *Result) writeCSV(w io.Writer) error {
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+
+	if err := cw.Write(r.Vars); err != nil {
+		return err
+	}
+	for _, solution := range r.Solutions {
+		record := make([]string, len(r.Vars))
+		for i, v := range r.Vars {
+			if value, ok := solution[v]; ok {
+				record[i] = value.GetValue()
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// writeTSV writes the solutions as SPARQL 1.1 query results TSV: terms
+// in their N-Triples form with numbers and booleans abbreviated
+func (r *Result) writeTSV(w io.Writer) error {
+
+	bw := bufio.NewWriter(w)
+
+	header := make([]string, len(r.Vars))
+	for i, v := range r.Vars {
+		header[i] = "?" + v
+	}
+	bw.WriteString(strings.Join(header, "\t") + "\n")
+
+	for _, solution := range r.Solutions {
+		fields := make([]string, len(r.Vars))
+		for i, v := range r.Vars {
+			if value, ok := solution[v]; ok {
+				fields[i] = rdf.TurtleTerm(value, nil)
+			}
+		}
+		bw.WriteString(strings.Join(fields, "\t") + "\n")
+	}
+
+	return bw.Flush()
+}
+
+// writeTable writes the solutions as a text table with terms
+// abbreviated by the prefixes of the query
+func (r *Result) writeTable(w io.Writer) error {
+
+	widths := make([]int, len(r.Vars))
+	header := make([]string, len(r.Vars))
+	for i, v := range r.Vars {
+		header[i] = "?" + v
+		widths[i] = utf8.RuneCountInString(header[i])
+	}
+
+	rows := make([][]string, len(r.Solutions))
+	for i, solution := range r.Solutions {
+		rows[i] = make([]string, len(r.Vars))
+		for j, v := range r.Vars {
+			if value, ok := solution[v]; ok {
+				// LINE BREAKS WOULD BREAK THE TABLE
+				rows[i][j] = strings.NewReplacer("\n", `\n`, "\r", `\r`).Replace(rdf.TurtleTerm(value, r.Prefixes))
+			}
+			if n := utf8.RuneCountInString(rows[i][j]); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+
+	rule := "+"
+	for _, width := range widths {
+		rule += strings.Repeat("-", width+2) + "+"
+	}
+	line := func(cells []string) {
+		bw.WriteString("|")
+		for i, cell := range cells {
+			bw.WriteString(" " + cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)) + " |")
+		}
+		bw.WriteString("\n")
+	}
+
+	bw.WriteString(rule + "\n")
+	line(header)
+	bw.WriteString(rule + "\n")
+	for _, row := range rows {
+		line(row)
+	}
+	if len(rows) > 0 {
+		bw.WriteString(rule + "\n")
+	}
+
+	return bw.Flush()
+}
+
+// writeGraph writes the graph of a CONSTRUCT or DESCRIBE query
+func (r *Result) writeGraph(w io.Writer, f Format) error {
+
+	switch f {
+	case Table:
+		return rdf.Write(w, r.Graph, rdf.Turtle, r.Prefixes)
+
+	case JSON:
+		options := ld.NewJsonLdOptions("")
+		expanded, err := ld.NewJsonLdApi().FromRDF(r.Graph, options)
+		if err != nil {
+			return err
+		}
+		var output interface{} = expanded
+		if len(r.Prefixes) > 0 {
+			context := make(map[string]interface{}, len(r.Prefixes))
+			for prefix, namespace := range r.Prefixes {
+				context[prefix] = namespace
+			}
+			compacted, err := ld.NewJsonLdProcessor().Compact(expanded, map[string]interface{}{"@context": context}, options)
+			if err != nil {
+				return errors.InvalidValue.Wrapf(err, "compaction failed")
+			}
+			output = compacted
+		}
+		return writeJSON(w, output)
+	}
+
+	// ROWS OF SUBJECT, PREDICATE AND OBJECT IN A STABLE ORDER
+	result := &Result{Form: Select, Vars: []string{"subject", "predicate", "object"}}
+	for _, quad := range r.Graph.Graphs[quadstore.DefaultGraph] {
+		result.Solutions = append(result.Solutions, Binding{"subject": quad.Subject, "predicate": quad.Predicate, "object": quad.Object})
+	}
+	sort.SliceStable(result.Solutions, func(i, j int) bool {
+		return result.Solutions[i].key(result.Vars) < result.Solutions[j].key(result.Vars)
+	})
+
+	return result.Write(w, f)
+}
@@ -0,0 +1,278 @@
+package sparql
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/quadstore"
+	"github.com/datacequia/go-dogg3rz/rdf"
+)
+
+const testData = `@prefix ex: <http://example.org/> .
+@prefix foaf: <http://xmlns.com/foaf/0.1/> .
+
+ex:alice a foaf:Person ; foaf:name "Alice" ; foaf:age 30 ; foaf:knows ex:bob .
+ex:bob a foaf:Person ; foaf:name "Bob"@en ; foaf:age 25 ; foaf:knows ex:carol .
+ex:carol a foaf:Person ; foaf:name "Carol" ; foaf:knows [ foaf:name "Dave" ] .
+
+ex:g1 {
+    ex:alice ex:likes ex:pizza .
+}
+
+ex:g2 {
+    ex:bob ex:likes ex:pasta , ex:pizza .
+}
+`
+
+const testPrefixes = "PREFIX ex: <http://example.org/>\nPREFIX foaf: <http://xmlns.com/foaf/0.1/>\n"
+
+func testStore(t *testing.T) quadstore.Store {
+
+	t.Helper()
+
+	dataset, err := rdf.Parse(strings.NewReader(testData), rdf.TriG, "", "test.trig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := quadstore.NewMemoryStore()
+	if err = quadstore.AddDataset(store, dataset); err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+// run evaluates query over the test store returning the result in format f
+func run(t *testing.T, store quadstore.Store, query string, f Format) (string, error) {
+
+	t.Helper()
+
+	q, err := Parse(testPrefixes + query)
+	if err != nil {
+		return "", err
+	}
+	result, err := q.Evaluate(store)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err = result.Write(&out, f); err != nil {
+		t.Fatal(err)
+	}
+
+	return out.String(), nil
+}
+
+func TestSelect(t *testing.T) {
+
+	store := testStore(t)
+
+	for _, test := range []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"bgp", `SELECT ?name WHERE { ?p a foaf:Person ; foaf:name ?name } ORDER BY ?name`,
+			"?name\n\"Alice\"\n\"Bob\"@en\n\"Carol\"\n"},
+		{"filter", `SELECT ?p WHERE { ?p foaf:age ?age FILTER(?age > 26) }`,
+			"?p\n<http://example.org/alice>\n"},
+		{"optional", `SELECT ?p ?age WHERE { ?p a foaf:Person OPTIONAL { ?p foaf:age ?age } } ORDER BY DESC(?p)`,
+			"?p\t?age\n<http://example.org/carol>\t\n<http://example.org/bob>\t25\n<http://example.org/alice>\t30\n"},
+		{"union", `SELECT ?x WHERE { { ex:alice foaf:knows ?x } UNION { ?x foaf:knows ex:alice } UNION { ?x foaf:age 25 } }`,
+			"?x\n<http://example.org/bob>\n<http://example.org/bob>\n"},
+		{"minus", `SELECT ?p WHERE { ?p a foaf:Person MINUS { ?p foaf:age ?age } }`,
+			"?p\n<http://example.org/carol>\n"},
+		{"not exists", `SELECT ?p WHERE { ?p a foaf:Person FILTER NOT EXISTS { ?p foaf:knows ex:bob } } ORDER BY ?p`,
+			"?p\n<http://example.org/bob>\n<http://example.org/carol>\n"},
+		{"blank node", `SELECT ?n WHERE { ex:carol foaf:knows [ foaf:name ?n ] }`,
+			"?n\n\"Dave\"\n"},
+		{"star", `SELECT * WHERE { ?p foaf:knows _:b . _:b foaf:name "Carol" }`,
+			"?p\n<http://example.org/bob>\n"},
+		{"sequence path", `SELECT ?n WHERE { ex:alice foaf:knows/foaf:knows/foaf:name ?n }`,
+			"?n\n\"Carol\"\n"},
+		{"plus path", `SELECT ?x WHERE { ex:alice foaf:knows+ ?x } ORDER BY ?x`,
+			"?x\n_:b0\n<http://example.org/bob>\n<http://example.org/carol>\n"},
+		{"star path with bound object", `SELECT ?x WHERE { ?x foaf:knows* ex:carol } ORDER BY ?x`,
+			"?x\n<http://example.org/alice>\n<http://example.org/bob>\n<http://example.org/carol>\n"},
+		{"inverse and alternative path", `SELECT ?x WHERE { ex:bob ^foaf:knows|foaf:knows ?x } ORDER BY ?x`,
+			"?x\n<http://example.org/alice>\n<http://example.org/carol>\n"},
+		{"negated path", `SELECT DISTINCT ?x WHERE { ex:alice !(foaf:knows|foaf:name|a) ?x }`,
+			"?x\n30\n"},
+		{"graph", `SELECT ?g ?food WHERE { GRAPH ?g { ex:bob ex:likes ?food } } ORDER BY ?food`,
+			"?g\t?food\n<http://example.org/g2>\t<http://example.org/pasta>\n<http://example.org/g2>\t<http://example.org/pizza>\n"},
+		{"graph iri", `SELECT ?who WHERE { GRAPH ex:g1 { ?who ex:likes ?food } }`,
+			"?who\n<http://example.org/alice>\n"},
+		{"default graph only", `SELECT ?who WHERE { ?who ex:likes ?food }`,
+			"?who\n"},
+		{"aggregates", `SELECT ?food (COUNT(?who) AS ?n) WHERE { GRAPH ?g { ?who ex:likes ?food } } GROUP BY ?food ORDER BY DESC(?n)`,
+			"?food\t?n\n<http://example.org/pizza>\t2\n<http://example.org/pasta>\t1\n"},
+		{"aggregates without grouping", `SELECT (SUM(?age) AS ?sum) (AVG(?age) AS ?avg) (MAX(?age) AS ?max) (COUNT(*) AS ?n) WHERE { ?p foaf:age ?age }`,
+			"?sum\t?avg\t?max\t?n\n55\t27.5\t30\t2\n"},
+		{"group concat and having", `SELECT ?who (GROUP_CONCAT(DISTINCT STRAFTER(STR(?g), "example.org/"); SEPARATOR=",") AS ?graphs)
+			WHERE { GRAPH ?g { ?who ex:likes ?food } } GROUP BY ?who HAVING (COUNT(?food) > 1)`,
+			"?who\t?graphs\n<http://example.org/bob>\t\"g2\"\n"},
+		{"bind and functions", `SELECT ?s WHERE { ?p foaf:name ?name BIND(CONCAT(UCASE(?name), "!") AS ?s) FILTER(LANG(?name) = "en") }`,
+			"?s\n\"BOB!\"\n"},
+		{"values", `SELECT ?p ?age WHERE { VALUES ?p { ex:alice ex:carol } ?p a foaf:Person OPTIONAL { ?p foaf:age ?age } } ORDER BY ?p`,
+			"?p\t?age\n<http://example.org/alice>\t30\n<http://example.org/carol>\t\n"},
+		{"subquery", `SELECT ?p ?max WHERE { ?p foaf:age ?max { SELECT (MAX(?a) AS ?max) WHERE { ?x foaf:age ?a } } }`,
+			"?p\t?max\n<http://example.org/alice>\t30\n"},
+		{"regex and in", `SELECT ?name WHERE { ?p foaf:name ?name FILTER(REGEX(?name, "^c", "i") || ?name IN ("Dave")) } ORDER BY ?name`,
+			"?name\n\"Carol\"\n\"Dave\"\n"},
+		{"limit offset", `SELECT ?p WHERE { ?p a foaf:Person } ORDER BY ?p LIMIT 1 OFFSET 1`,
+			"?p\n<http://example.org/bob>\n"},
+		{"expressions", `SELECT ?x WHERE { BIND((1 + 2) * 3 / 2 AS ?x) }`,
+			"?x\n4.5\n"},
+		{"casts", `SELECT ?x ?y WHERE { BIND(xsd:integer("12") + 1 AS ?x) BIND(STRDT("1", xsd:double) AS ?y) }`,
+			"?x\t?y\n13\t\"1\"^^<http://www.w3.org/2001/XMLSchema#double>\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			query := test.query
+			if strings.Contains(query, "xsd:") {
+				query = "PREFIX xsd: <http://www.w3.org/2001/XMLSchema#>\n" + query
+			}
+			out, err := run(t, store, query, TSV)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != test.expected {
+				t.Errorf("expected\n%s\ngot\n%s", test.expected, out)
+			}
+		})
+	}
+}
+
+func TestAskConstructDescribe(t *testing.T) {
+
+	store := testStore(t)
+
+	out, err := run(t, store, `ASK { ex:alice foaf:knows ex:bob }`, Table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "true\n" {
+		t.Errorf("expected true but got %s", out)
+	}
+
+	out, err = run(t, store, `ASK { ex:bob foaf:knows ex:alice }`, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"boolean": false`) {
+		t.Errorf("expected false boolean result but got %s", out)
+	}
+
+	out, err = run(t, store, `CONSTRUCT { ?b ex:knownBy ?a . ?a ex:friend [ ex:name ?n ] } WHERE { ?a foaf:knows ?b . ?b foaf:name ?n }`, TSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 knownBy TRIPLES, 3 friend TRIPLES AND 3 NAME TRIPLES OF FRESH BLANK NODES
+	if n := strings.Count(out, "\n") - 1; n != 9 {
+		t.Errorf("expected 9 constructed triples but got %d:\n%s", n, out)
+	}
+	if !strings.Contains(out, "<http://example.org/bob>\t<http://example.org/knownBy>\t<http://example.org/alice>\n") {
+		t.Errorf("expected bob known by alice in\n%s", out)
+	}
+
+	out, err = run(t, store, `CONSTRUCT WHERE { ex:alice foaf:name ?n }`, Table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `ex:alice foaf:name "Alice" .`) {
+		t.Errorf("expected Turtle with query prefixes but got\n%s", out)
+	}
+
+	out, err = run(t, store, `DESCRIBE ?p WHERE { ?p foaf:name "Carol" }`, TSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// CAROL'S 3 TRIPLES AND THOSE OF THE BLANK NODE SHE KNOWS
+	if n := strings.Count(out, "\n") - 1; n != 4 || !strings.Contains(out, `"Dave"`) {
+		t.Errorf("expected 4 triples describing carol but got\n%s", out)
+	}
+
+	out, err = run(t, store, `DESCRIBE ex:bob`, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ex:bob's STATEMENTS FROM ALL GRAPHS
+	if !strings.Contains(out, `"ex:pasta"`) || !strings.Contains(out, `"@context"`) {
+		t.Errorf("expected compacted JSON-LD describing bob but got\n%s", out)
+	}
+}
+
+func TestResultFormats(t *testing.T) {
+
+	store := testStore(t)
+	query := `SELECT ?p ?name WHERE { ?p foaf:name ?name FILTER(?p = ex:bob) }`
+
+	for _, test := range []struct {
+		format   Format
+		expected string
+	}{
+		{Table, "+--------+----------+\n| ?p     | ?name    |\n+--------+----------+\n| ex:bob | \"Bob\"@en |\n+--------+----------+\n"},
+		{CSV, "p,name\r\nhttp://example.org/bob,Bob\r\n"},
+		{TSV, "?p\t?name\n<http://example.org/bob>\t\"Bob\"@en\n"},
+		{JSON, `{
+  "head": {
+    "vars": [
+      "p",
+      "name"
+    ]
+  },
+  "results": {
+    "bindings": [
+      {
+        "name": {
+          "type": "literal",
+          "value": "Bob",
+          "xml:lang": "en"
+        },
+        "p": {
+          "type": "uri",
+          "value": "http://example.org/bob"
+        }
+      }
+    ]
+  }
+}
+`},
+	} {
+		out, err := run(t, store, query, test.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != test.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.format, test.expected, out)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); errors.GetType(err) != errors.InvalidValue {
+		t.Errorf("expected InvalidValue for an unknown format but got %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+
+	for _, test := range []struct {
+		query   string
+		errType errors.ErrorType
+		message string
+	}{
+		{`SELECT ?x WHERE { ?x ?p }`, errors.InvalidValue, "query:1:25"},
+		{`SELECT ?x WHERE { ?x undeclared:p ?o }`, errors.InvalidValue, "undeclared prefix"},
+		{`SELECT ?x (COUNT(?y) AS ?n) WHERE { ?x ?p ?y }`, errors.InvalidValue, "neither grouped nor aggregated"},
+		{`SELECT ?x FROM <http://example.org/g> WHERE { ?x ?p ?o }`, errors.NotImplemented, "FROM"},
+		{`SELECT ?x WHERE { ?x ?p "unterminated }`, errors.InvalidValue, "query:1:"},
+		{`SELECT ?x WHERE { FILTER(NOSUCH(?x)) }`, errors.InvalidValue, "unknown function"},
+		{`DELETE WHERE { ?x ?p ?o }`, errors.InvalidValue, "expected SELECT"},
+	} {
+		_, err := Parse(test.query)
+		if errors.GetType(err) != test.errType || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("%s: expected %v error containing '%s' but got %v", test.query, test.errType, test.message, err)
+		}
+	}
+}
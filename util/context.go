@@ -13,7 +13,10 @@
 
 package util
 
-import "context"
+import (
+	"context"
+	"strconv"
+)
 
 func ContextValueAsString(ctxt context.Context, key interface{}) (string, bool) {
 
@@ -44,3 +47,17 @@ func ContextValueAsStringOrDefault(ctxt context.Context, key interface{}, defaul
 	return defaultValue
 
 }
+
+// ContextValueAsBool returns true if the context value assigned to key
+// is a string that parses as a true boolean value (i.e. "true", "1")
+func ContextValueAsBool(ctxt context.Context, key interface{}) bool {
+
+	if s, ok := ContextValueAsString(ctxt, key); ok {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+
+	return false
+
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package vocab provides well-known JSON-LD contexts and vocabularies
+// that are embedded in the dogg3rz binary so that documents referencing
+// them can be processed without network access
+package vocab
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"github.com/datacequia/go-dogg3rz/impl/file"
+)
+
+//go:embed files/*.jsonld
+var bundledFiles embed.FS
+
+// Vocabulary is a well-known context/vocabulary bundled with dogg3rz
+type Vocabulary struct {
+	Name  string   // short name (i.e. prefix) used to refer to the vocabulary
+	Title string   // human readable title
+	IRIs  []string // context and namespace IRIs served by this vocabulary
+
+	ContextOnly bool // a context without vocabulary statements
+
+	// THE BUNDLED COPY IS THE UNMODIFIED DOCUMENT AT Source. NO Source
+	// MEANS IT WAS WRITTEN FOR DOGG3RZ AND IS NOT AN UPSTREAM COPY
+	Source    string // upstream URL of the bundled copy
+	Retrieved string // when and from where the bundled copy was obtained
+}
+
+var vocabularies = []Vocabulary{
+	{Name: "schemaorg", Title: "Schema.org",
+		Source:    "https://schema.org/version/latest/schemaorg-current-https.jsonld",
+		Retrieved: "2026-10-19, copied from the test files of dogg3rz (impl/file/grapp/testfiles)",
+		IRIs: []string{
+			"https://schema.org/version/latest/schemaorg-current-https.jsonld"}},
+	{Name: "schemaorg-context", Title: "Schema.org JSON-LD context", ContextOnly: true,
+		Source:    "https://schema.org/docs/jsonldcontext.json",
+		Retrieved: "2026-10-19, copied from the schema.org mirror in the test data of github.com/piprate/json-gold v0.5.0",
+		IRIs: []string{
+			"https://schema.org/", "http://schema.org/", "https://schema.org", "http://schema.org",
+			"https://schema.org/docs/jsonldcontext.jsonld", "http://schema.org/docs/jsonldcontext.jsonld",
+			"https://schema.org/docs/jsonldcontext.json", "http://schema.org/docs/jsonldcontext.json"}},
+	{Name: "rdf", Title: "The RDF Concepts Vocabulary (RDF)", IRIs: []string{
+		"http://www.w3.org/1999/02/22-rdf-syntax-ns#", "http://www.w3.org/1999/02/22-rdf-syntax-ns"}},
+	{Name: "rdfs", Title: "The RDF Schema vocabulary (RDFS)", IRIs: []string{
+		"http://www.w3.org/2000/01/rdf-schema#", "http://www.w3.org/2000/01/rdf-schema"}},
+	{Name: "xsd", Title: "XML Schema Definition Language (XSD) Datatypes", IRIs: []string{
+		"http://www.w3.org/2001/XMLSchema#", "http://www.w3.org/2001/XMLSchema"}},
+	{Name: "owl", Title: "The OWL 2 Schema vocabulary (OWL 2)", IRIs: []string{
+		"http://www.w3.org/2002/07/owl#", "http://www.w3.org/2002/07/owl"}},
+	{Name: "skos", Title: "SKOS Vocabulary", IRIs: []string{
+		"http://www.w3.org/2004/02/skos/core#", "http://www.w3.org/2004/02/skos/core"}},
+	{Name: "dcat", Title: "Data Catalog Vocabulary (DCAT)", IRIs: []string{
+		"http://www.w3.org/ns/dcat#", "http://www.w3.org/ns/dcat", "https://www.w3.org/ns/dcat"}},
+	{Name: "dcterms", Title: "DCMI Metadata Terms", IRIs: []string{
+		"http://purl.org/dc/terms/", "http://purl.org/dc/terms"}},
+	{Name: "prov", Title: "W3C PROVenance Interchange Ontology (PROV-O)", IRIs: []string{
+		"http://www.w3.org/ns/prov#", "http://www.w3.org/ns/prov", "http://www.w3.org/ns/prov-o"}},
+	{Name: "shacl", Title: "W3C Shapes Constraint Language (SHACL)", IRIs: []string{
+		"http://www.w3.org/ns/shacl#", "http://www.w3.org/ns/shacl"}},
+	{Name: "activitystreams", Title: "Activity Vocabulary (ActivityStreams 2.0)", IRIs: []string{
+		"https://www.w3.org/ns/activitystreams", "http://www.w3.org/ns/activitystreams",
+		"https://www.w3.org/ns/activitystreams#", "https://www.w3.org/ns/activitystreams.jsonld"}},
+	{Name: "did", Title: "Decentralized Identifiers (DIDs) v1.0", ContextOnly: true, IRIs: []string{
+		"https://www.w3.org/ns/did/v1", "https://w3id.org/did/v1"}},
+	{Name: "multikey", Title: "Multikey Verification Methods", ContextOnly: true, IRIs: []string{
+		"https://w3id.org/security/multikey/v1"}},
+}
+
+// List returns all bundled vocabularies
+func List() []Vocabulary {
+	return append([]Vocabulary(nil), vocabularies...)
+}
+
+// Get returns the bundled vocabulary with short name 'name'
+func Get(name string) (Vocabulary, bool) {
+
+	for _, v := range vocabularies {
+		if v.Name == name {
+			return v, true
+		}
+	}
+
+	return Vocabulary{}, false
+}
+
+// Lookup returns the bundled vocabulary that serves 'iri'
+func Lookup(iri string) (Vocabulary, bool) {
+
+	for _, v := range vocabularies {
+		for _, vocabIRI := range v.IRIs {
+			if iri == vocabIRI {
+				return v, true
+			}
+		}
+	}
+
+	return Vocabulary{}, false
+}
+
+// Bundled returns the content of the vocabulary embedded in the binary
+func (v Vocabulary) Bundled() []byte {
+
+	data, err := bundledFiles.ReadFile("files/" + v.Name + ".jsonld")
+	if err != nil {
+		// EVERY REGISTERED VOCABULARY IS EMBEDDED. PROGRAMMER ERROR
+		panic(fmt.Sprintf("bundled vocabulary %s not embedded: %s", v.Name, err))
+	}
+
+	return data
+}
+
+// OverridePath returns the path of the local copy of the vocabulary
+// in overrideDir that takes precedence over the bundled copy
+func (v Vocabulary) OverridePath(overrideDir string) string {
+	return filepath.Join(overrideDir, v.Name+".jsonld")
+}
+
+// Load returns the content of the vocabulary and where it was loaded
+// from. A local copy in overrideDir takes precedence over the bundled
+// copy. An empty overrideDir only considers the bundled copy
+func (v Vocabulary) Load(overrideDir string) ([]byte, string, error) {
+
+	if len(overrideDir) > 0 {
+		overridePath := v.OverridePath(overrideDir)
+		if file.FileExists(overridePath) {
+			data, err := os.ReadFile(overridePath)
+			return data, overridePath, err
+		}
+	}
+
+	return v.Bundled(), "bundled", nil
+}
+
+// Update refreshes the vocabulary from the local JSON-LD file at src
+// by copying it into overrideDir
+func (v Vocabulary) Update(overrideDir string, src string) error {
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	// MUST BE A JSON-LD DOCUMENT THAT DECLARES A CONTEXT
+	var doc map[string]interface{}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return errors.InvalidValue.Wrapf(err, "%s", src)
+	}
+	if _, ok := doc["@context"]; !ok {
+		return errors.InvalidValue.Newf("%s: not a JSON-LD context or vocabulary: missing @context", src)
+	}
+
+	if !file.DirExists(overrideDir) {
+		if err = os.MkdirAll(overrideDir, 0700); err != nil {
+			return err
+		}
+	}
+
+	_, err = file.WriteToFileAtomic(func() (io.Reader, error) { return bytes.NewReader(data), nil },
+		v.OverridePath(overrideDir))
+
+	return err
+}
+
+// Hash returns the hex encoded SHA-256 hash of data
+func Hash(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
@@ -0,0 +1,88 @@
+package vocab
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+func TestBundledVocabularies(t *testing.T) {
+
+	proc := ld.NewJsonLdProcessor()
+	options := ld.NewJsonLdOptions("")
+
+	for _, v := range List() {
+
+		var doc map[string]interface{}
+		if err := json.Unmarshal(v.Bundled(), &doc); err != nil {
+			t.Fatalf("%s: bundled vocabulary is not well-formed JSON: %s", v.Name, err)
+		}
+
+		expanded, err := proc.Expand(doc, options)
+		if err != nil {
+			t.Fatalf("%s: bundled vocabulary failed to expand: %s", v.Name, err)
+		}
+		if len(expanded) < 1 && !v.ContextOnly {
+			t.Errorf("%s: bundled vocabulary has no RDF statements", v.Name)
+		}
+
+		if len(v.Source) > 0 && len(v.Retrieved) < 1 {
+			t.Errorf("%s: expected retrieval of upstream copy to be recorded", v.Name)
+		}
+
+		for _, iri := range v.IRIs {
+			if found, ok := Lookup(iri); !ok || found.Name != v.Name {
+				t.Errorf("%s: expected Lookup(%s) to return vocabulary", v.Name, iri)
+			}
+		}
+	}
+
+	if _, ok := Lookup("https://vocab.example.org/"); ok {
+		t.Errorf("expected Lookup of unknown IRI to fail")
+	}
+
+}
+
+func TestVocabularyUpdate(t *testing.T) {
+
+	overrideDir := filepath.Join(t.TempDir(), "vocab")
+
+	skos, ok := Get("skos")
+	if !ok {
+		t.Fatal("skos vocabulary not bundled")
+	}
+
+	if _, source, err := skos.Load(overrideDir); err != nil || source != "bundled" {
+		t.Fatalf("expected bundled copy before update, got %s (%v)", source, err)
+	}
+
+	refreshed := filepath.Join(t.TempDir(), "skos.jsonld")
+	content := `{"@context": {"skos": "http://www.w3.org/2004/02/skos/core#"}, "@id": "skos:Concept", "@type": "skos:Class"}`
+	if err := os.WriteFile(refreshed, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := skos.Update(overrideDir, refreshed); err != nil {
+		t.Fatal(err)
+	}
+
+	data, source, err := skos.Load(overrideDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != skos.OverridePath(overrideDir) || string(data) != content {
+		t.Errorf("expected refreshed copy to take precedence, got source %s", source)
+	}
+
+	notJSONLD := filepath.Join(t.TempDir(), "not-jsonld.json")
+	if err := os.WriteFile(notJSONLD, []byte(`{"name": "no context"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := skos.Update(overrideDir, notJSONLD); err == nil {
+		t.Errorf("expected update from document without @context to fail")
+	}
+
+}
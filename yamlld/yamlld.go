@@ -0,0 +1,437 @@
+/*
+ * Copyright (c) 2019-2024 Datacequia LLC. All rights reserved.
+ *
+ * This program is licensed to you under the Apache License Version 2.0,
+ * and you may not use this file except in compliance with the Apache License Version 2.0.
+ * You may obtain a copy of the Apache License Version 2.0 at http://www.apache.org/licenses/LICENSE-2.0.
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the Apache License Version 2.0 is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the Apache License Version 2.0 for the specific language governing permissions and limitations there under.
+ */
+
+// package yamlld reads and writes YAML-LD documents: JSON-LD documents
+// serialized as YAML (https://www.w3.org/TR/yaml-ld/). A YAML-LD
+// document is a single YAML document restricted to the JSON data model:
+// mapping keys are strings and scalars resolve to strings, numbers,
+// booleans or null. Anchors, aliases and merge keys are expanded
+package yamlld
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// media type of YAML-LD documents
+const MediaType = "application/ld+yaml"
+
+// file extensions of YAML-LD documents
+var Extensions = []string{".yamlld", ".yaml-ld"}
+
+// IsYAMLLD returns true if name has a YAML-LD file extension
+func IsYAMLLD(name string) bool {
+
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Decode parses the YAML-LD document data read from src into the
+// values encoding/json produces. Errors are reported as
+// src:line:column. YAML syntax errors are reported as src:line only
+// because the YAML parser does not provide the column
+func Decode(data []byte, src string) (interface{}, error) {
+
+	v, err := decode(data, src)
+	if err != nil {
+		return nil, err
+	}
+
+	return plain(v), nil
+}
+
+// ToJSON converts the YAML-LD document data read from src to indented
+// JSON. The order of mapping keys is preserved
+func ToJSON(data []byte, src string) ([]byte, error) {
+
+	v, err := decode(data, src)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// FromJSON converts the JSON document data read from src to YAML-LD.
+// The order of object members is preserved
+func FromJSON(data []byte, src string) ([]byte, error) {
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	node, err := jsonNode(decoder)
+	if err != nil {
+		return nil, errors.InvalidValue.Wrapf(err, "%s: offset %d", src, decoder.InputOffset())
+	}
+	if _, err = decoder.Token(); err != io.EOF {
+		return nil, errors.InvalidValue.Newf("%s: offset %d: unexpected data after document", src, decoder.InputOffset())
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err = encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err = encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// object is a JSON object that keeps the order of its members
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *object) set(key string, value interface{}) {
+
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func marshal(v interface{}) ([]byte, error) {
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// plain replaces the ordered objects in v with maps
+func plain(v interface{}) interface{} {
+
+	switch value := v.(type) {
+	case *object:
+		m := make(map[string]interface{}, len(value.keys))
+		for _, key := range value.keys {
+			m[key] = plain(value.values[key])
+		}
+		return m
+	case []interface{}:
+		for i := range value {
+			value[i] = plain(value[i])
+		}
+	}
+
+	return v
+}
+
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+func decode(data []byte, src string) (interface{}, error) {
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	var doc yaml.Node
+	if err := decoder.Decode(&doc); err != nil {
+		if err == io.EOF {
+			return nil, errors.InvalidValue.Newf("%s: empty document", src)
+		}
+		msg := err.Error()
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			return nil, errors.InvalidValue.Newf("%s:%s: %s", src, m[1], strings.TrimPrefix(msg, m[0]))
+		}
+		return nil, errors.InvalidValue.Newf("%s: %s", src, strings.TrimPrefix(msg, "yaml: "))
+	}
+
+	var next yaml.Node
+	if err := decoder.Decode(&next); err != io.EOF {
+		line, column := next.Line, next.Column
+		if len(next.Content) > 0 {
+			line, column = next.Content[0].Line, next.Content[0].Column
+		}
+		return nil, errors.InvalidValue.Newf("%s:%d:%d: streams of more than one document are not supported",
+			src, line, column)
+	}
+
+	c := &converter{src: src, visiting: make(map[*yaml.Node]bool)}
+
+	return c.value(&doc)
+}
+
+type converter struct {
+	src      string
+	visiting map[*yaml.Node]bool // aliased nodes being converted
+}
+
+func (c *converter) errorf(node *yaml.Node, format string, args ...interface{}) error {
+	return errors.InvalidValue.Newf("%s:%d:%d: %s", c.src, node.Line, node.Column, fmt.Sprintf(format, args...))
+}
+
+// value converts node to its JSON value
+func (c *converter) value(node *yaml.Node) (interface{}, error) {
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) < 1 {
+			return nil, nil
+		}
+		return c.value(node.Content[0])
+
+	case yaml.AliasNode:
+		if c.visiting[node.Alias] {
+			return nil, c.errorf(node, "alias *%s refers to itself", node.Value)
+		}
+		c.visiting[node.Alias] = true
+		defer delete(c.visiting, node.Alias)
+		return c.value(node.Alias)
+
+	case yaml.SequenceNode:
+		array := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := c.value(item)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, v)
+		}
+		return array, nil
+
+	case yaml.MappingNode:
+		obj := &object{values: make(map[string]interface{})}
+		if err := c.members(node, obj, false); err != nil {
+			return nil, err
+		}
+		return obj, nil
+
+	case yaml.ScalarNode:
+		return c.scalar(node)
+	}
+
+	return nil, c.errorf(node, "unexpected YAML node")
+}
+
+// members adds the key value pairs of the mapping node to obj. Merged
+// mappings (<<) do not override keys of the mapping itself
+func (c *converter) members(node *yaml.Node, obj *object, merged bool) error {
+
+	var merges []*yaml.Node
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+		if keyNode.Kind == yaml.ScalarNode && keyNode.Tag == "!!merge" {
+			merges = append(merges, valueNode)
+			continue
+		}
+
+		key, err := c.key(keyNode)
+		if err != nil {
+			return err
+		}
+		if _, exists := obj.values[key]; exists {
+			if merged {
+				continue
+			}
+			return c.errorf(keyNode, "duplicate key %q", key)
+		}
+		v, err := c.value(valueNode)
+		if err != nil {
+			return err
+		}
+		obj.set(key, v)
+	}
+
+	for _, m := range merges {
+		if m.Kind == yaml.AliasNode {
+			m = m.Alias
+		}
+		sources := []*yaml.Node{m}
+		if m.Kind == yaml.SequenceNode {
+			sources = m.Content
+		}
+		for _, source := range sources {
+			if source.Kind == yaml.AliasNode {
+				source = source.Alias
+			}
+			if source.Kind != yaml.MappingNode {
+				return c.errorf(source, "merge key value must be a mapping or a sequence of mappings")
+			}
+			if err := c.members(source, obj, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// key returns the string of a mapping key
+func (c *converter) key(node *yaml.Node) (string, error) {
+
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind != yaml.ScalarNode {
+		return "", c.errorf(node, "mapping keys must be strings")
+	}
+	if tag := node.ShortTag(); tag != "!!str" {
+		return "", c.errorf(node, "mapping key %s must be a string, not %s", node.Value, strings.TrimPrefix(tag, "!!"))
+	}
+
+	return node.Value, nil
+}
+
+// scalar converts a scalar node. Scalars with tags outside of the
+// YAML core schema are strings
+func (c *converter) scalar(node *yaml.Node) (interface{}, error) {
+
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return nil, c.errorf(node, "invalid boolean %q", node.Value)
+		}
+		return b, nil
+
+	case "!!int":
+		var i int64
+		if err := node.Decode(&i); err != nil {
+			// BEYOND INT64. KEEP AS MUCH PRECISION AS encoding/json WOULD
+			f, ferr := strconv.ParseFloat(node.Value, 64)
+			if ferr != nil {
+				return nil, c.errorf(node, "invalid integer %q", node.Value)
+			}
+			return f, nil
+		}
+		return float64(i), nil
+
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return nil, c.errorf(node, "invalid number %q", node.Value)
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, c.errorf(node, "%s is not a JSON number", node.Value)
+		}
+		return f, nil
+	}
+
+	return node.Value, nil
+}
+
+// jsonNode reads the next JSON value from decoder as a YAML node
+func jsonNode(decoder *json.Decoder) (*yaml.Node, error) {
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := jsonNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)}, value)
+			}
+			_, err = decoder.Token()
+			return node, err
+		case '[':
+			node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for decoder.More() {
+				item, err := jsonNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, item)
+			}
+			_, err = decoder.Token()
+			return node, err
+		}
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(t.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(t)}, nil
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+
+	return nil, errors.UnexpectedType.Newf("unexpected JSON token %v", token)
+}
@@ -0,0 +1,131 @@
+package yamlld
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/datacequia/go-dogg3rz/errors"
+)
+
+const testYAMLLD = `
+"@context":
+  "@vocab": http://schema.org/
+  ex: http://example.org/
+"@id": ex:jane
+"@type": Person
+name: Jane Doe
+birthDate: 2001-02-03
+age: 23
+height: 1.72
+knows: &john
+  "@id": ex:john
+  name: "John Doe"
+colleague: *john
+defaults: &defaults
+  active: true
+  nickname: ~
+profile:
+  <<: *defaults
+  active: false
+description: |
+  two
+  lines
+`
+
+func TestDecode(t *testing.T) {
+
+	v, err := Decode([]byte(testYAMLLD), "jane.yamlld")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, ok := v.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected object, got %T", v)
+	}
+
+	for key, expected := range map[string]interface{}{
+		"@id":         "ex:jane",
+		"birthDate":   "2001-02-03",
+		"age":         23.0,
+		"height":      1.72,
+		"knows":       map[string]interface{}{"@id": "ex:john", "name": "John Doe"},
+		"colleague":   map[string]interface{}{"@id": "ex:john", "name": "John Doe"},
+		"profile":     map[string]interface{}{"active": false, "nickname": nil},
+		"description": "two\nlines\n",
+	} {
+		if !reflect.DeepEqual(doc[key], expected) {
+			t.Errorf("%s: expected %#v, got %#v", key, expected, doc[key])
+		}
+	}
+
+	for name, test := range map[string]struct {
+		doc      string
+		position string
+	}{
+		"syntax":       {"a: b\n c: d\n  - e", "test.yamlld:2: "},
+		"key":          {"a: 1\n? [b]\n: c\n", "test.yamlld:2:3: "},
+		"number key":   {"a: 1\n1: c\n", "test.yamlld:2:1: "},
+		"infinity":     {"a: .inf\n", "test.yamlld:1:4: "},
+		"duplicate":    {"a: 1\na: 2\n", "test.yamlld:2:1: "},
+		"two document": {"a: 1\n---\nb: 2\n", "test.yamlld:3:1: "},
+		"empty":        {"", "test.yamlld: "},
+	} {
+		_, err := Decode([]byte(test.doc), "test.yamlld")
+		if errors.GetType(err) != errors.InvalidValue || !strings.Contains(err.Error(), test.position) {
+			t.Errorf("%s: expected InvalidValue error at %s, got %v", name, test.position, err)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+
+	data := []byte(`{"@context": {"@vocab": "http://schema.org/"}, "@id": "http://example.org/jane", "name": "Jane", "age": 23, "height": 1.72, "zip": "01234", "flag": "true", "list": [1, null, false], "empty": {}}`)
+
+	yamlld, err := FromJSON(data, "jane.jsonld")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `'@context':
+  '@vocab': http://schema.org/
+'@id': http://example.org/jane
+name: Jane
+age: 23
+height: 1.72
+zip: "01234"
+flag: "true"
+list:
+  - 1
+  - null
+  - false
+empty: {}
+`
+	if string(yamlld) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, yamlld)
+	}
+
+	// ROUND TRIP KEEPS VALUES AND MEMBER ORDER
+	jsonld, err := ToJSON(yamlld, "jane.yamlld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact := strings.Join(strings.Fields(string(jsonld)), "")
+	if expected := strings.Join(strings.Fields(string(data)), ""); compact != expected {
+		t.Errorf("expected %s, got %s", expected, compact)
+	}
+
+	if _, err = FromJSON([]byte(`{"a": }`), "bad.jsonld"); errors.GetType(err) != errors.InvalidValue {
+		t.Errorf("expected InvalidValue error, got %v", err)
+	}
+}
+
+func TestIsYAMLLD(t *testing.T) {
+
+	for name, expected := range map[string]bool{"a.yamlld": true, "a.YAML-LD": true, "a.yaml": false, "a.jsonld": false} {
+		if IsYAMLLD(name) != expected {
+			t.Errorf("%s: expected %v", name, expected)
+		}
+	}
+}